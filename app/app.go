@@ -6,11 +6,64 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"tilank/clients/fcm"
 	"tilank/db"
+	"tilank/middleware"
 	"tilank/scheduler"
+	"tilank/service"
+	"tilank/utils/clientip"
+	"tilank/utils/crypt"
 	"tilank/utils/logger"
+	"tilank/utils/mjwt"
 )
 
 func RunApp() {
+	// memuat kunci jwt, menolak berjalan jika konfigurasi tidak aman
+	if err := mjwt.Init(); err != nil {
+		logger.Error("konfigurasi jwt tidak valid", err)
+		panic(err)
+	}
+
+	// memuat parameter hashing password
+	if err := crypt.Init(); err != nil {
+		logger.Error("konfigurasi argon2id tidak valid", err)
+		panic(err)
+	}
+
+	// memuat daftar reverse proxy terpercaya
+	if err := clientip.Init(); err != nil {
+		logger.Error("konfigurasi trusted proxy tidak valid", err)
+		panic(err)
+	}
+
+	// memuat aturan password
+	if err := service.LoadPasswordPolicy(); err != nil {
+		logger.Error("konfigurasi password policy tidak valid", err)
+		panic(err)
+	}
+
+	// memuat ambang batas score jpt
+	if err := service.LoadJptScorePolicy(); err != nil {
+		logger.Error("konfigurasi score jpt tidak valid", err)
+		panic(err)
+	}
+
+	// memuat SLA persetujuan pelanggaran
+	if err := service.LoadViolationSlaPolicy(); err != nil {
+		logger.Error("konfigurasi sla persetujuan pelanggaran tidak valid", err)
+		panic(err)
+	}
+
+	// memuat aturan pengarsipan draft pelanggaran default
+	if err := service.LoadDraftRetention(); err != nil {
+		logger.Error("konfigurasi retensi draft pelanggaran tidak valid", err)
+		panic(err)
+	}
+
+	// memuat jarak waktu pengingat blokir berakhir
+	if err := service.LoadBlockReminder(); err != nil {
+		logger.Error("konfigurasi pengingat blokir tidak valid", err)
+		panic(err)
+	}
+
 	// inisiasi database
 	client, ctx, cancel := db.Init()
 
@@ -19,6 +72,19 @@ func RunApp() {
 	}(client, ctx)
 	defer cancel()
 
+	// memuat daftar token yang dicabut
+	if err := tokenService.Reload(); err != nil {
+		logger.Error("gagal memuat daftar token yang dicabut", err)
+	}
+	middleware.SetRevocationChecker(tokenService)
+
+	// memuat matriks role-permission
+	if err := policyService.Reload(); err != nil {
+		logger.Error("gagal memuat role permission, menggunakan policy default", err)
+	}
+	middleware.SetPermissionChecker(policyService)
+	middleware.SetApiKeyAuthenticator(apiKeyService)
+
 	// inisasi firebase app
 	_ = fcm.Init()
 
@@ -26,7 +92,7 @@ func RunApp() {
 	mapUrls(app)
 
 	// menjalankan job scheduller
-	scheduler.RunScheduler(truckService)
+	scheduler.RunScheduler(truckService, driverService, violationService, jptService, webhookService, tokenService, policyService, userService)
 
 	if err := app.Listen(":3501"); err != nil {
 		logger.Error("error fiber listen", err)
@@ -2,13 +2,25 @@ package app
 
 import (
 	"tilank/clients/fcm"
+	"tilank/clients/oidc"
+	"tilank/clients/webhook"
+	"tilank/dao/apikeydao"
+	"tilank/dao/auditdao"
+	"tilank/dao/companydao"
+	"tilank/dao/driverdao"
 	"tilank/dao/jptdao"
+	"tilank/dao/passworddao"
+	"tilank/dao/policydao"
 	"tilank/dao/rulesdao"
+	"tilank/dao/ssodao"
+	"tilank/dao/tokendao"
 	"tilank/dao/truckdao"
 	"tilank/dao/userdao"
 	"tilank/dao/violationdao"
+	"tilank/dao/webhookdao"
 	"tilank/handler"
 	"tilank/service"
+	"tilank/stream"
 	"tilank/utils/crypt"
 	"tilank/utils/mjwt"
 )
@@ -23,16 +35,37 @@ var (
 	violationDao = violationdao.NewViolationDao()
 	jptDao       = jptdao.NewJptDao()
 	truckDao     = truckdao.NewTruckDao()
+	driverDao    = driverdao.NewDriverDao()
+	companyDao   = companydao.NewCompanyDao()
 	rulesDao     = rulesdao.NewRulesDao()
+	webhookDao   = webhookdao.NewWebhookDao()
+	tokenDao     = tokendao.NewTokenDao()
+	auditDao     = auditdao.NewAuditDao()
+	policyDao    = policydao.NewPolicyDao()
+	apiKeyDao    = apikeydao.NewApiKeyDao()
+	passwordDao  = passworddao.NewPasswordDao()
+	ssoDao       = ssodao.NewSsoDao()
 
 	// api client
-	fcmClient = fcm.NewFcmClient()
+	fcmClient     = fcm.NewFcmClient()
+	webhookClient = webhook.NewWebhookClient()
+	oidcClient    = oidc.NewOidcClient()
+
+	// event stream
+	eventHub = stream.NewHub()
 
 	// Service
-	userService      = service.NewUserService(userDao, cryptoUtils, jwt)
-	jptService       = service.NewJptService(jptDao)
-	violationService = service.NewViolationService(violationDao, truckDao, rulesDao, userDao, fcmClient)
-	truckService     = service.NewTruckService(truckDao)
+	tokenService     = service.NewTokenService(tokenDao)
+	auditService     = service.NewAuditService(auditDao)
+	policyService    = service.NewPolicyService(policyDao, auditService)
+	apiKeyService    = service.NewApiKeyService(apiKeyDao, auditService)
+	userService      = service.NewUserService(userDao, passwordDao, ssoDao, oidcClient, cryptoUtils, jwt, tokenService, auditService)
+	webhookService   = service.NewWebhookService(webhookDao, webhookClient)
+	jptService       = service.NewJptService(jptDao, truckDao, violationDao, webhookService)
+	violationService = service.NewViolationService(violationDao, truckDao, driverDao, jptDao, rulesDao, userDao, fcmClient, policyService, webhookService, eventHub)
+	truckService     = service.NewTruckService(truckDao, companyDao, jptDao, violationDao, userDao, fcmClient, auditService, webhookService, eventHub)
+	driverService    = service.NewDriverService(driverDao, webhookService, eventHub)
+	companyService   = service.NewCompanyService(companyDao, truckDao, violationDao)
 	rulesService     = service.NewRulesService(rulesDao)
 
 	// Controller or Handler
@@ -41,5 +74,13 @@ var (
 	violationHandler = handler.NewViolationHandler(violationService)
 	jptHandler       = handler.NewJptHandler(jptService)
 	truckHandler     = handler.NewTruckHandler(truckService)
+	driverHandler    = handler.NewDriverHandler(driverService)
+	companyHandler   = handler.NewCompanyHandler(companyService)
 	rulesHandler     = handler.NewRulesHandler(rulesService)
+	webhookHandler   = handler.NewWebhookHandler(webhookService)
+	streamHandler    = handler.NewStreamHandler(eventHub, userService, tokenService)
+	jwksHandler      = handler.NewJwksHandler(jwt)
+	policyHandler    = handler.NewPolicyHandler(policyService)
+	apiKeyHandler    = handler.NewApiKeyHandler(apiKeyService)
+	auditHandler     = handler.NewAuditHandler(auditService)
 )
@@ -13,14 +13,18 @@ func mapUrls(app *fiber.App) {
 	app.Use(logger.New())
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
-		AllowHeaders: "Content-Type, Accept, Authorization",
+		AllowHeaders: "Content-Type, Accept, Authorization, X-API-Key",
 	}))
 	app.Use(middleware.LimitRequest())
 
 	app.Static("/image/avatar", "./static/image/avatar")
 	app.Static("/image/violation", "./static/image/violation")
+	app.Static("/image/driver", "./static/image/driver")
 	app.Static("/pdf", "./static/pdf")
 
+	// JWKS
+	app.Get("/.well-known/jwks.json", jwksHandler.Get)
+
 	api := app.Group("/api/v1")
 
 	// PING
@@ -28,57 +32,146 @@ func mapUrls(app *fiber.App) {
 
 	// USER
 	api.Post("/login", userHandler.Login)
+	api.Post("/login/2fa", userHandler.LoginTwoFactor)
+	api.Post("/login/2fa/setup", userHandler.LoginSetupTwoFactor)
+	api.Post("/login/2fa/activate", userHandler.LoginActivateTwoFactor)
+	api.Post("/login/change-password", userHandler.LoginChangePassword)
+	api.Get("/sso/login", userHandler.SsoLogin)
+	api.Post("/sso/callback", userHandler.SsoCallback)
 	api.Post("/refresh", userHandler.RefreshToken)
+	api.Post("/forgot-password", userHandler.ForgotPassword)
+	api.Post("/reset-password", userHandler.ResetPasswordWithToken)
+	// Query [branch, role, name, page, limit]
 	api.Get("/users", middleware.NormalAuth(), userHandler.Find)
 	api.Get("/profile", middleware.NormalAuth(), userHandler.GetProfile)
 	api.Post("/avatar", middleware.NormalAuth(), userHandler.UploadImage)
 	api.Post("/change-password", middleware.FreshAuth(), userHandler.ChangePassword)
+	api.Post("/2fa/setup", middleware.FreshAuth(), userHandler.SetupTwoFactor)
+	api.Post("/2fa/activate", middleware.FreshAuth(), userHandler.ActivateTwoFactor)
 	api.Post("/update-fcm", middleware.NormalAuth(), userHandler.UpdateFcmToken)
-	api.Get("/logout", middleware.NormalAuth(), userHandler.DeleteFcmToken)
+	api.Get("/logout", middleware.NormalAuth(), userHandler.Logout)
+	api.Post("/logout", middleware.NormalAuth(), userHandler.Logout)
+	api.Post("/logout-all", middleware.NormalAuth(), userHandler.LogoutAll)
 
 	// USER ADMIN
 	apiAuthAdmin := app.Group("/api/v1/admin")
-	apiAuthAdmin.Use(middleware.NormalAuth(config.RoleAdmin))
+	apiAuthAdmin.Use(middleware.PermissionAuth(config.PermUserAdmin))
 	apiAuthAdmin.Post("/users", userHandler.Register)
+	// Query [branch, role, name, active, page, limit]
+	apiAuthAdmin.Get("/users", userHandler.FindAdmin)
 	apiAuthAdmin.Put("/users/:user_id", userHandler.Edit)
-	apiAuthAdmin.Delete("/users/:user_id", userHandler.Delete)
-	apiAuthAdmin.Get("/users/:user_id/reset-password", userHandler.ResetPassword)
+	// user tidak dihapus permanen, DELETE menonaktifkan user
+	apiAuthAdmin.Delete("/users/:user_id", userHandler.Deactivate)
+	apiAuthAdmin.Post("/users/:user_id/deactivate", userHandler.Deactivate)
+	apiAuthAdmin.Post("/users/:user_id/reactivate", userHandler.Reactivate)
+	// POST karena mengubah password dan mengembalikan password sementara pada body
+	apiAuthAdmin.Post("/users/:user_id/reset-password", userHandler.ResetPassword)
+	apiAuthAdmin.Post("/users/:user_id/revoke", userHandler.RevokeUser)
+	apiAuthAdmin.Post("/users/:user_id/unlock", userHandler.Unlock)
+	apiAuthAdmin.Post("/users/:user_id/reset-2fa", userHandler.ResetTwoFactor)
+
+	// PERMISSION ADMIN
+	apiAuthAdmin.Get("/permissions", policyHandler.Get)
+	apiAuthAdmin.Put("/permissions/:role", policyHandler.Edit)
+
+	// API KEY ADMIN
+	apiAuthAdmin.Post("/api-keys", apiKeyHandler.Insert)
+	apiAuthAdmin.Get("/api-keys", apiKeyHandler.Find)
+	apiAuthAdmin.Delete("/api-keys/:id", apiKeyHandler.Revoke)
+
+	// COMPANY ADMIN
+	apiAuthAdmin.Post("/company-migrate", companyHandler.Migrate)
+
+	// WEBHOOK ADMIN
+	apiAuthAdmin.Post("/webhooks", webhookHandler.Insert)
+	apiAuthAdmin.Get("/webhooks/:id", webhookHandler.Get)
+	apiAuthAdmin.Put("/webhooks/:id", webhookHandler.Edit)
+	apiAuthAdmin.Delete("/webhooks/:id", webhookHandler.Delete)
+	apiAuthAdmin.Get("/webhooks", webhookHandler.Find)
+	// Query [webhook, event, status, limit]
+	apiAuthAdmin.Get("/webhook-deliveries", webhookHandler.FindDelivery)
+	apiAuthAdmin.Post("/webhook-deliveries/:id/replay", webhookHandler.ReplayDelivery)
+
+	// AUDIT LOG ADMIN
+	// Query [user, action, outcome, start, end, limit]
+	apiAuthAdmin.Get("/audit-logs", auditHandler.Find)
+	apiAuthAdmin.Get("/audit-logs/export", auditHandler.Export)
 
 	// VIOLATION
-	api.Post("/violation", middleware.NormalAuth(), violationHandler.Insert)
-	api.Get("/violation/:id", middleware.NormalAuth(), violationHandler.Get)
+	api.Post("/violation", middleware.NormalOrApiKeyAuth(config.ScopeViolationWrite), violationHandler.Insert)
+	api.Get("/violation/:id", middleware.NormalOrApiKeyAuth(config.ScopeViolationRead), violationHandler.Get)
 	api.Put("/violation/:id", middleware.NormalAuth(), violationHandler.Edit)
 	api.Get("/violation-draft/:id", middleware.NormalAuth(), violationHandler.SendToDraft)
 	api.Get("/violation-confirm/:id", middleware.NormalAuth(), violationHandler.SendToConfirmation)
-	api.Get("/violation-approve/:id", middleware.NormalAuth(config.RoleHSSE), violationHandler.SendToApproved)
+	api.Get("/violation-approve/:id", middleware.PermissionAuth(config.PermViolationApprove), violationHandler.SendToApproved)
 	api.Delete("/violation/:id", middleware.NormalAuth(), violationHandler.Delete)
-	// Query [branch, lambung, nopol, state, limit, start, end]
-	api.Get("/violation", middleware.NormalAuth(), violationHandler.Find)
-	api.Post("/violation-upload-image/:id", middleware.NormalAuth(), violationHandler.UploadImage)
+	api.Post("/violation/:id/restore", middleware.NormalAuth(), violationHandler.Restore)
+	api.Get("/violation-retention", middleware.NormalAuth(), violationHandler.DraftRetention)
+	api.Put("/violation-retention", middleware.PermissionAuth(config.PermViolationRetention), violationHandler.EditDraftRetention)
+	// Query [branch, lambung, nopol, license, jpt_id, state, archived, limit, start, end]
+	api.Get("/violation", middleware.NormalOrApiKeyAuth(config.ScopeViolationRead), violationHandler.Find)
+	api.Post("/violation-upload-image/:id", middleware.NormalOrApiKeyAuth(config.ScopeViolationWrite), violationHandler.UploadImage)
 	api.Get("/violation-delete-image/:id/:image", middleware.NormalAuth(), violationHandler.DeleteImage)
-	api.Get("/violation-pdf/:id", middleware.NormalAuth(config.RoleHSSE), violationHandler.GeneratePDF)
+	api.Get("/violation-pdf/:id", middleware.PermissionAuth(config.PermViolationReport), violationHandler.GeneratePDF)
+
+	// STREAM server-sent events [violation.created, violation.state_changed, violation.escalated,
+	// violation.archived, violation.restored, truck.blocked, truck.unblocked, driver.blocked, driver.unblocked]
+	// Query [ticket] dari POST /events/ticket untuk EventSource yang tidak dapat mengirim header Authorization
+	api.Post("/events/ticket", middleware.NormalAuth(), streamHandler.Ticket)
+	api.Get("/events", middleware.StreamAuth(), streamHandler.Events)
 
 	// JPT
-	api.Post("/jpt", middleware.NormalAuth(config.RoleHSSE), jptHandler.Insert)
-	api.Get("/jpt/:id", middleware.NormalAuth(), jptHandler.Get)
-	api.Put("/jpt/:id", middleware.NormalAuth(config.RoleHSSE), jptHandler.Edit)
-	api.Delete("/jpt/:id", middleware.NormalAuth(config.RoleHSSE), jptHandler.Delete)
+	api.Post("/jpt", middleware.PermissionAuth(config.PermJptWrite), jptHandler.Insert)
+	api.Get("/jpt/:id", middleware.NormalOrApiKeyAuth(config.ScopeJptRead), jptHandler.Get)
+	api.Put("/jpt/:id", middleware.PermissionAuth(config.PermJptWrite), jptHandler.Edit)
+	api.Delete("/jpt/:id", middleware.PermissionAuth(config.PermJptWrite), jptHandler.Delete)
 	// Query [branch, name, active ]
-	api.Get("/jpt", middleware.NormalAuth(), jptHandler.Find)
+	api.Get("/jpt", middleware.NormalOrApiKeyAuth(config.ScopeJptRead), jptHandler.Find)
+	// Query [limit]
+	api.Get("/jpt/:id/score-history", middleware.NormalOrApiKeyAuth(config.ScopeJptRead), jptHandler.ScoreHistory)
+	// Query [branch]
+	api.Get("/jpt-leaderboard", middleware.NormalOrApiKeyAuth(config.ScopeJptRead), jptHandler.Leaderboard)
+	api.Post("/jpt-score/recalculate", middleware.PermissionAuth(config.PermJptWrite), jptHandler.RecalculateScore)
 
 	// TRUCK
-	api.Post("/truck", middleware.NormalAuth(config.RoleHSSE), truckHandler.Insert)
-	api.Get("/truck/:id", middleware.NormalAuth(), truckHandler.Get)
-	api.Get("/truck-lambung/:id", middleware.NormalAuth(), truckHandler.GetByNoLambung)
-	api.Put("/truck/:id", middleware.NormalAuth(config.RoleHSSE), truckHandler.Edit)
-	api.Delete("/truck/:id", middleware.NormalAuth(config.RoleHSSE), truckHandler.Delete)
-	//  Query [branch, identity, owner, active, block ]
-	api.Get("/truck", middleware.NormalAuth(), truckHandler.Find)
+	api.Post("/truck", middleware.PermissionAuth(config.PermTruckWrite), truckHandler.Insert)
+	api.Get("/truck/:id", middleware.NormalOrApiKeyAuth(config.ScopeTruckRead), truckHandler.Get)
+	api.Get("/truck/:id/timeline", middleware.NormalOrApiKeyAuth(config.ScopeTruckRead), truckHandler.Timeline)
+	api.Get("/truck-lambung/:id", middleware.NormalOrApiKeyAuth(config.ScopeTruckRead), truckHandler.GetByNoLambung)
+	api.Put("/truck/:id", middleware.PermissionAuth(config.PermTruckWrite), truckHandler.Edit)
+	api.Delete("/truck/:id", middleware.PermissionAuth(config.PermTruckWrite), truckHandler.Delete)
+	api.Post("/truck/:id/block", middleware.PermissionAuth(config.PermTruckBlock), truckHandler.Block)
+	api.Post("/truck/:id/unblock", middleware.PermissionAuth(config.PermTruckBlock), truckHandler.Unblock)
+	//  Query [branch, identity, owner, owner_id, jpt_id, active, block ]
+	api.Get("/truck", middleware.NormalOrApiKeyAuth(config.ScopeTruckRead), truckHandler.Find)
+
+	// COMPANY
+	api.Post("/company", middleware.PermissionAuth(config.PermTruckWrite), companyHandler.Insert)
+	api.Get("/company/:id", middleware.NormalOrApiKeyAuth(config.ScopeTruckRead), companyHandler.Get)
+	api.Put("/company/:id", middleware.PermissionAuth(config.PermTruckWrite), companyHandler.Edit)
+	api.Delete("/company/:id", middleware.PermissionAuth(config.PermTruckWrite), companyHandler.Delete)
+	api.Post("/company/:id/activate", middleware.PermissionAuth(config.PermTruckWrite), companyHandler.Activate)
+	api.Get("/company/:id/fleet", middleware.NormalOrApiKeyAuth(config.ScopeTruckRead), companyHandler.Fleet)
+	//  Query [start, end]
+	api.Get("/company/:id/violations", middleware.NormalOrApiKeyAuth(config.ScopeTruckRead), companyHandler.Violations)
+	//  Query [branch, name, active ]
+	api.Get("/company", middleware.NormalOrApiKeyAuth(config.ScopeTruckRead), companyHandler.Find)
+
+	// DRIVER
+	api.Post("/driver", middleware.PermissionAuth(config.PermDriverWrite), driverHandler.Insert)
+	api.Get("/driver/:id", middleware.NormalOrApiKeyAuth(config.ScopeDriverRead), driverHandler.Get)
+	api.Get("/driver-license/:id", middleware.NormalOrApiKeyAuth(config.ScopeDriverRead), driverHandler.GetByLicense)
+	api.Put("/driver/:id", middleware.PermissionAuth(config.PermDriverWrite), driverHandler.Edit)
+	api.Delete("/driver/:id", middleware.PermissionAuth(config.PermDriverWrite), driverHandler.Delete)
+	api.Post("/driver/:id/activate", middleware.PermissionAuth(config.PermDriverWrite), driverHandler.Activate)
+	api.Post("/driver-upload-image/:id", middleware.PermissionAuth(config.PermDriverWrite), driverHandler.UploadImage)
+	//  Query [branch, license, name, employer, active, block ]
+	api.Get("/driver", middleware.NormalOrApiKeyAuth(config.ScopeDriverRead), driverHandler.Find)
 
 	// RULES
-	api.Post("/rules", middleware.NormalAuth(config.RoleHSSE), rulesHandler.Insert)
+	api.Post("/rules", middleware.PermissionAuth(config.PermRulesWrite), rulesHandler.Insert)
 	api.Get("/rules/:id", middleware.NormalAuth(), rulesHandler.Get)
-	api.Put("/rules/:id", middleware.NormalAuth(config.RoleHSSE), rulesHandler.Edit)
-	api.Delete("/rules/:id", middleware.NormalAuth(config.RoleHSSE), rulesHandler.Delete)
+	api.Put("/rules/:id", middleware.PermissionAuth(config.PermRulesWrite), rulesHandler.Edit)
+	api.Delete("/rules/:id", middleware.PermissionAuth(config.PermRulesWrite), rulesHandler.Delete)
 	api.Get("/rules", middleware.NormalAuth(), rulesHandler.Find)
 }
@@ -0,0 +1,419 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"tilank/config"
+	"tilank/utils/mjwt"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	envIssuer        = "OIDC_ISSUER"
+	envClientID      = "OIDC_CLIENT_ID"
+	envClientSecret  = "OIDC_CLIENT_SECRET"
+	envRedirectURL   = "OIDC_REDIRECT_URL"
+	envScopes        = "OIDC_SCOPES"
+	envRolesClaim    = "OIDC_ROLES_CLAIM"
+	envBranchClaim   = "OIDC_BRANCH_CLAIM"
+	envBranchesClaim = "OIDC_BRANCHES_CLAIM"
+
+	requestTimeout = 10
+	// clockSkewSecond toleransi perbedaan jam dengan identity provider
+	clockSkewSecond = 60
+)
+
+var (
+	ErrDisabled     = errors.New("sso tidak dikonfigurasi")
+	errInvalidToken = errors.New("id token tidak valid")
+)
+
+// NewOidcClient membuat client dengan konfigurasi dari environment yang dibaca pada saat pertama digunakan
+func NewOidcClient() ClientAssumer {
+	return &oidcClient{
+		httpClient: &http.Client{Timeout: requestTimeout * time.Second},
+		loadConfig: ConfigFromEnv,
+	}
+}
+
+// NewClient membuat client dengan konfigurasi tertentu
+func NewClient(cfg Config) ClientAssumer {
+	return &oidcClient{
+		httpClient: &http.Client{Timeout: requestTimeout * time.Second},
+		loadConfig: func() Config { return cfg },
+	}
+}
+
+type ClientAssumer interface {
+	Enabled() bool
+	AuthCodeURL(state string, nonce string, codeChallenge string) (string, error)
+	Exchange(code string, codeVerifier string, nonce string) (*Identity, error)
+}
+
+type oidcClient struct {
+	httpClient *http.Client
+	loadConfig func() Config
+
+	mu        sync.Mutex
+	cfg       *Config
+	discovery *discoveryDocument
+	keys      map[string]interface{}
+}
+
+// ConfigFromEnv membaca konfigurasi identity provider dari environment
+func ConfigFromEnv() Config {
+	scopes := strings.Fields(config.EnvString(envScopes))
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+
+	return Config{
+		Issuer:        strings.TrimRight(config.EnvString(envIssuer), "/"),
+		ClientID:      config.EnvString(envClientID),
+		ClientSecret:  config.EnvString(envClientSecret),
+		RedirectURL:   config.EnvString(envRedirectURL),
+		Scopes:        scopes,
+		RolesClaim:    config.EnvString(envRolesClaim),
+		BranchClaim:   config.EnvString(envBranchClaim),
+		BranchesClaim: config.EnvString(envBranchesClaim),
+	}
+}
+
+// GenerateRandom membuat string acak base64url untuk state, nonce dan code verifier
+func GenerateRandom() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CodeChallenge menghitung code challenge PKCE metode S256 dari code verifier
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (o *oidcClient) config() *Config {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.cfg == nil {
+		cfg := o.loadConfig()
+		o.cfg = &cfg
+	}
+	return o.cfg
+}
+
+// Enabled return true jika issuer dan client id dikonfigurasi
+func (o *oidcClient) Enabled() bool {
+	cfg := o.config()
+	return cfg.Issuer != "" && cfg.ClientID != "" && cfg.RedirectURL != ""
+}
+
+// provider memuat discovery document, hasil disimpan setelah berhasil
+func (o *oidcClient) provider() (*discoveryDocument, error) {
+	if !o.Enabled() {
+		return nil, ErrDisabled
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.discovery != nil {
+		return o.discovery, nil
+	}
+
+	var doc discoveryDocument
+	if err := o.getJSON(o.cfg.Issuer+"/.well-known/openid-configuration", &doc); err != nil {
+		return nil, fmt.Errorf("gagal memuat discovery document: %w", err)
+	}
+	if strings.TrimRight(doc.Issuer, "/") != o.cfg.Issuer {
+		return nil, fmt.Errorf("issuer discovery document %s tidak sesuai", doc.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JwksURI == "" {
+		return nil, errors.New("discovery document tidak lengkap")
+	}
+
+	o.discovery = &doc
+	return o.discovery, nil
+}
+
+// AuthCodeURL membuat url halaman login identity provider dengan PKCE S256
+func (o *oidcClient) AuthCodeURL(state string, nonce string, codeChallenge string) (string, error) {
+	doc, err := o.provider()
+	if err != nil {
+		return "", err
+	}
+	cfg := o.config()
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", cfg.ClientID)
+	query.Set("redirect_uri", cfg.RedirectURL)
+	query.Set("scope", strings.Join(cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(doc.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return doc.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange menukar authorization code dengan id token lalu memverifikasi signature, issuer,
+// audience, masa berlaku dan nonce id token
+func (o *oidcClient) Exchange(code string, codeVerifier string, nonce string) (*Identity, error) {
+	doc, err := o.provider()
+	if err != nil {
+		return nil, err
+	}
+	cfg := o.config()
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", cfg.RedirectURL)
+	form.Set("client_id", cfg.ClientID)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequest(http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	}
+
+	res, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var tokenRes tokenResponse
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&tokenRes); err != nil {
+		return nil, fmt.Errorf("respon token endpoint tidak valid: %w", err)
+	}
+	if res.StatusCode != http.StatusOK || tokenRes.Error != "" {
+		return nil, fmt.Errorf("token endpoint menolak authorization code: %s %s", tokenRes.Error, tokenRes.ErrorDescription)
+	}
+	if tokenRes.IDToken == "" {
+		return nil, errors.New("respon token endpoint tidak berisi id_token")
+	}
+
+	claims, err := o.verifyIDToken(tokenRes.IDToken)
+	if err != nil {
+		return nil, err
+	}
+	if nonceClaim, _ := claims["nonce"].(string); nonceClaim == "" || nonceClaim != nonce {
+		return nil, fmt.Errorf("%w: nonce tidak sesuai", errInvalidToken)
+	}
+
+	return o.identity(claims), nil
+}
+
+func (o *oidcClient) verifyIDToken(rawToken string) (jwt.MapClaims, error) {
+	cfg := o.config()
+
+	parser := jwt.Parser{
+		ValidMethods:         []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()},
+		SkipClaimsValidation: true,
+	}
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return o.verifyKey(kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidToken, err.Error())
+	}
+
+	if iss, _ := claims["iss"].(string); strings.TrimRight(iss, "/") != cfg.Issuer {
+		return nil, fmt.Errorf("%w: issuer tidak sesuai", errInvalidToken)
+	}
+
+	audiences := stringSlice(claims["aud"])
+	if !containsString(audiences, cfg.ClientID) {
+		return nil, fmt.Errorf("%w: audience tidak sesuai", errInvalidToken)
+	}
+	if azp, ok := claims["azp"].(string); ok && azp != cfg.ClientID {
+		return nil, fmt.Errorf("%w: authorized party tidak sesuai", errInvalidToken)
+	}
+
+	now := time.Now().Unix()
+	exp, ok := claims["exp"].(float64)
+	if !ok || now > int64(exp)+clockSkewSecond {
+		return nil, fmt.Errorf("%w: token kadaluarsa", errInvalidToken)
+	}
+	if iat, ok := claims["iat"].(float64); ok && int64(iat) > now+clockSkewSecond {
+		return nil, fmt.Errorf("%w: iat tidak valid", errInvalidToken)
+	}
+
+	return claims, nil
+}
+
+// verifyKey mencari public key berdasarkan kid, jwks dimuat ulang jika kid belum dikenal (rotasi kunci)
+func (o *oidcClient) verifyKey(kid string) (interface{}, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if key, ok := o.keys[kid]; ok {
+		return key, nil
+	}
+
+	var set mjwt.JWKSet
+	if err := o.getJSON(o.discovery.JwksURI, &set); err != nil {
+		return nil, fmt.Errorf("gagal memuat jwks: %w", err)
+	}
+
+	keys := map[string]interface{}{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := parseJWK(jwk)
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	o.keys = keys
+
+	if key, ok := o.keys[kid]; ok {
+		return key, nil
+	}
+	// provider dengan satu kunci tanpa kid
+	if kid == "" && len(o.keys) == 1 {
+		for _, key := range o.keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("kunci %s tidak ditemukan pada jwks", kid)
+}
+
+func (o *oidcClient) identity(claims jwt.MapClaims) *Identity {
+	cfg := o.config()
+
+	identity := &Identity{}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	// email hanya dianggap terverifikasi jika identity provider mengirim claim email_verified bernilai true
+	identity.EmailVerified, _ = claims["email_verified"].(bool)
+
+	if cfg.RolesClaim != "" {
+		if value, ok := claims[cfg.RolesClaim]; ok {
+			identity.Roles = stringSlice(value)
+			identity.HasRoles = true
+		}
+	}
+	if cfg.BranchClaim != "" {
+		if value, ok := claims[cfg.BranchClaim].(string); ok && value != "" {
+			identity.Branch = value
+			identity.HasBranch = true
+		}
+	}
+	if cfg.BranchesClaim != "" {
+		if value, ok := claims[cfg.BranchesClaim]; ok {
+			identity.Branches = stringSlice(value)
+			identity.HasBranches = true
+		}
+	}
+
+	return identity
+}
+
+func (o *oidcClient) getJSON(target string, out interface{}) error {
+	res, err := o.httpClient.Get(target)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		_, _ = io.Copy(ioutil.Discard, res.Body)
+		return fmt.Errorf("%s merespon dengan status %d", target, res.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(out)
+}
+
+func parseJWK(jwk mjwt.JWK) (interface{}, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		if jwk.Crv != "P-256" {
+			return nil, fmt.Errorf("kurva %s tidak didukung", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	}
+	return nil, fmt.Errorf("tipe kunci %s tidak didukung", jwk.Kty)
+}
+
+// stringSlice membaca claim berupa string tunggal, string dipisah spasi/koma atau array string
+func stringSlice(value interface{}) []string {
+	var result []string
+	switch v := value.(type) {
+	case string:
+		result = strings.FieldsFunc(v, func(r rune) bool { return r == ' ' || r == ',' })
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				result = append(result, s)
+			}
+		}
+	}
+	return result
+}
+
+func containsString(list []string, target string) bool {
+	for _, item := range list {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"tilank/utils/mjwt"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockProvider identity provider lokal dengan discovery, jwks dan token endpoint.
+// code yang valid hanya "valid-code" dengan code_challenge yang dicatat pada saat login
+type mockProvider struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	kid       string
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	p := &mockProvider{key: key, kid: "kid-1"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(mjwt.JWKSet{Keys: []mjwt.JWK{{
+			Kty: "RSA",
+			Kid: p.kid,
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		user, pass, _ := r.BasicAuth()
+		if r.Form.Get("code") != "valid-code" || CodeChallenge(r.Form.Get("code_verifier")) != p.challenge ||
+			user != "tilank" || pass != "rahasia" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, p.claims)
+		token.Header["kid"] = p.kid
+		signed, _ := token.SignedString(p.key)
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "token_type": "Bearer"})
+	})
+	p.server = httptest.NewServer(mux)
+
+	p.claims = jwt.MapClaims{
+		"iss":            p.server.URL,
+		"aud":            "tilank",
+		"sub":            "user-1",
+		"email":          "budi@example.com",
+		"email_verified": true,
+		"nonce":          "nonce-1",
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(5 * time.Minute).Unix(),
+		"tilank_roles":   []string{"HSSE", "BASIC"},
+		"branch":         "BANJARMASIN",
+	}
+	return p
+}
+
+func (p *mockProvider) client() ClientAssumer {
+	return NewClient(Config{
+		Issuer:       p.server.URL,
+		ClientID:     "tilank",
+		ClientSecret: "rahasia",
+		RedirectURL:  "https://tilank.example.com/sso/callback",
+		Scopes:       []string{"openid", "email"},
+		RolesClaim:   "tilank_roles",
+		BranchClaim:  "branch",
+	})
+}
+
+func TestAuthCodeURLWithPKCE(t *testing.T) {
+	p := newMockProvider(t)
+	defer p.server.Close()
+
+	authURL, err := p.client().AuthCodeURL("state-1", "nonce-1", CodeChallenge("verifier"))
+	assert.Nil(t, err)
+
+	parsed, err := url.Parse(authURL)
+	assert.Nil(t, err)
+	assert.Equal(t, "/authorize", parsed.Path)
+	assert.Equal(t, "code", parsed.Query().Get("response_type"))
+	assert.Equal(t, "state-1", parsed.Query().Get("state"))
+	assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+	assert.Equal(t, CodeChallenge("verifier"), parsed.Query().Get("code_challenge"))
+}
+
+func TestExchangeSuccess(t *testing.T) {
+	p := newMockProvider(t)
+	defer p.server.Close()
+	p.challenge = CodeChallenge("verifier")
+
+	identity, err := p.client().Exchange("valid-code", "verifier", "nonce-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "user-1", identity.Subject)
+	assert.Equal(t, "budi@example.com", identity.Email)
+	assert.True(t, identity.EmailVerified)
+	assert.True(t, identity.HasRoles)
+	assert.Equal(t, []string{"HSSE", "BASIC"}, identity.Roles)
+	assert.True(t, identity.HasBranch)
+	assert.Equal(t, "BANJARMASIN", identity.Branch)
+	assert.False(t, identity.HasBranches)
+}
+
+func TestExchangeEmailVerifiedMissing(t *testing.T) {
+	p := newMockProvider(t)
+	defer p.server.Close()
+	p.challenge = CodeChallenge("verifier")
+	delete(p.claims, "email_verified")
+
+	identity, err := p.client().Exchange("valid-code", "verifier", "nonce-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "budi@example.com", identity.Email)
+	assert.False(t, identity.EmailVerified)
+}
+
+func TestExchangeRejected(t *testing.T) {
+	p := newMockProvider(t)
+	defer p.server.Close()
+	p.challenge = CodeChallenge("verifier")
+
+	// code verifier PKCE salah
+	_, err := p.client().Exchange("valid-code", "other-verifier", "nonce-1")
+	assert.NotNil(t, err)
+
+	// nonce tidak sesuai
+	_, err = p.client().Exchange("valid-code", "verifier", "nonce-2")
+	assert.NotNil(t, err)
+
+	// audience untuk client lain
+	p.claims["aud"] = []string{"other-client"}
+	_, err = p.client().Exchange("valid-code", "verifier", "nonce-1")
+	assert.NotNil(t, err)
+
+	// token kadaluarsa
+	p.claims["aud"] = "tilank"
+	p.claims["exp"] = time.Now().Add(-10 * time.Minute).Unix()
+	_, err = p.client().Exchange("valid-code", "verifier", "nonce-1")
+	assert.NotNil(t, err)
+}
+
+func TestExchangeKeyRotation(t *testing.T) {
+	p := newMockProvider(t)
+	defer p.server.Close()
+	p.challenge = CodeChallenge("verifier")
+	client := p.client()
+
+	_, err := client.Exchange("valid-code", "verifier", "nonce-1")
+	assert.Nil(t, err)
+
+	// provider merotasi kunci, jwks dimuat ulang untuk kid baru
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	p.key = newKey
+	p.kid = "kid-2"
+	_, err = client.Exchange("valid-code", "verifier", "nonce-1")
+	assert.Nil(t, err)
+}
+
+func TestDisabledWithoutIssuer(t *testing.T) {
+	client := NewClient(Config{})
+
+	assert.False(t, client.Enabled())
+	_, err := client.AuthCodeURL("state", "nonce", "challenge")
+	assert.Equal(t, ErrDisabled, err)
+}
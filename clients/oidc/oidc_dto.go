@@ -0,0 +1,43 @@
+package oidc
+
+// Config konfigurasi identity provider. RolesClaim, BranchClaim dan BranchesClaim adalah nama claim
+// pada id token yang disinkronkan ke user, kosong berarti tidak disinkronkan
+type Config struct {
+	Issuer        string
+	ClientID      string
+	ClientSecret  string
+	RedirectURL   string
+	Scopes        []string
+	RolesClaim    string
+	BranchClaim   string
+	BranchesClaim string
+}
+
+// Identity hasil verifikasi id token. HasRoles, HasBranch dan HasBranches bernilai true
+// jika claim terkait dikonfigurasi dan terdapat pada id token
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Roles         []string
+	HasRoles      bool
+	Branch        string
+	HasBranch     bool
+	Branches      []string
+	HasBranches   bool
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	TokenType        string `json:"token_type"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
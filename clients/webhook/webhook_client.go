@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	headerEvent     = "X-Tilank-Event"
+	headerDelivery  = "X-Tilank-Delivery"
+	headerTimestamp = "X-Tilank-Timestamp"
+	headerSignature = "X-Tilank-Signature"
+
+	requestTimeout = 10
+)
+
+func NewWebhookClient() ClientAssumer {
+	return &webhookClient{
+		httpClient: &http.Client{Timeout: requestTimeout * time.Second},
+	}
+}
+
+type webhookClient struct {
+	httpClient *http.Client
+}
+
+type ClientAssumer interface {
+	Send(payload Payload) (int, error)
+}
+
+// Send mengirimkan body ke url webhook dengan header signature HMAC-SHA256.
+// mengembalikan status code http, error jika gagal terhubung atau status bukan 2xx
+func (w *webhookClient) Send(payload Payload) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, payload.URL, bytes.NewReader(payload.Body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(headerEvent, payload.Event)
+	req.Header.Set(headerDelivery, payload.DeliveryID)
+	req.Header.Set(headerTimestamp, timestamp)
+	req.Header.Set(headerSignature, "sha256="+Sign(payload.Secret, timestamp, payload.Body))
+
+	res, err := w.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("webhook merespon dengan status %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
+
+// Sign menghasilkan hex HMAC-SHA256 dari "timestamp.body" menggunakan secret webhook.
+// penerima melakukan perhitungan yang sama untuk memverifikasi keaslian payload
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
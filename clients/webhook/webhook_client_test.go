@@ -0,0 +1,51 @@
+package webhook
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	signature := Sign("rahasia", "1600000000", []byte(`{"event":"truck.blocked"}`))
+
+	assert.Len(t, signature, 64)
+	assert.Equal(t, signature, Sign("rahasia", "1600000000", []byte(`{"event":"truck.blocked"}`)))
+	assert.NotEqual(t, signature, Sign("rahasia", "1600000001", []byte(`{"event":"truck.blocked"}`)))
+	assert.NotEqual(t, signature, Sign("lainnya", "1600000000", []byte(`{"event":"truck.blocked"}`)))
+}
+
+func TestSendSignedRequest(t *testing.T) {
+	body := []byte(`{"event":"violation.approved"}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expected := "sha256=" + Sign("rahasia", r.Header.Get(headerTimestamp), body)
+		assert.Equal(t, expected, r.Header.Get(headerSignature))
+		assert.Equal(t, "violation.approved", r.Header.Get(headerEvent))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	status, err := NewWebhookClient().Send(Payload{
+		DeliveryID: "1",
+		Event:      "violation.approved",
+		URL:        server.URL,
+		Secret:     "rahasia",
+		Body:       body,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, status)
+}
+
+func TestSendNon2xxIsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	status, err := NewWebhookClient().Send(Payload{URL: server.URL, Secret: "rahasia"})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusInternalServerError, status)
+}
@@ -0,0 +1,9 @@
+package webhook
+
+type Payload struct {
+	DeliveryID string
+	Event      string
+	URL        string
+	Secret     string
+	Body       []byte
+}
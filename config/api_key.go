@@ -0,0 +1,20 @@
+package config
+
+// Scope api key untuk client mesin (gate, kamera, integrasi)
+const (
+	ScopeViolationRead  = "violation:read"
+	ScopeViolationWrite = "violation:write"
+	ScopeTruckRead      = "truck:read"
+	ScopeDriverRead     = "driver:read"
+	ScopeJptRead        = "jpt:read"
+)
+
+func GetApiKeyScopesAvailable() []string {
+	return []string{
+		ScopeViolationRead,
+		ScopeViolationWrite,
+		ScopeTruckRead,
+		ScopeDriverRead,
+		ScopeJptRead,
+	}
+}
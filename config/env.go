@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Pembacaan environment variable digunakan oleh fungsi Load/Init pada package lain
+// yang dipanggil app.RunApp setelah main memuat .env
+
+// EnvString mendapatkan nilai environment tanpa spasi di awal dan akhir, kosong jika tidak diset
+func EnvString(key string) string {
+	return strings.TrimSpace(os.Getenv(key))
+}
+
+// EnvInt mendapatkan nilai environment berupa angka bulat min - max, def jika tidak diset
+func EnvInt(key string, def int, min int, max int) (int, error) {
+	raw := EnvString(key)
+	if raw == "" {
+		return def, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < min || value > max {
+		return def, fmt.Errorf("%s harus berupa angka %d - %d", key, min, max)
+	}
+	return value, nil
+}
+
+// EnvPositiveFloat mendapatkan nilai environment berupa angka desimal lebih dari 0, def jika tidak diset
+func EnvPositiveFloat(key string, def float64) (float64, error) {
+	raw := EnvString(key)
+	if raw == "" {
+		return def, nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || value <= 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return def, fmt.Errorf("%s harus berupa angka lebih dari 0", key)
+	}
+	return value, nil
+}
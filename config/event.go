@@ -0,0 +1,24 @@
+package config
+
+const (
+	EventViolationCreated      = "violation.created"
+	EventViolationStateChanged = "violation.state_changed"
+	EventViolationApproved     = "violation.approved"
+	EventViolationEscalated    = "violation.escalated"
+	EventViolationArchived     = "violation.archived"
+	EventViolationRestored     = "violation.restored"
+	EventTruckBlocked          = "truck.blocked"
+	EventTruckUnblocked        = "truck.unblocked"
+	EventTruckBlockExpiring    = "truck.block_expiring"
+	EventDriverBlocked         = "driver.blocked"
+	EventDriverUnblocked       = "driver.unblocked"
+	EventJptWarned             = "jpt.warned"
+	EventJptSuspended          = "jpt.suspended"
+	EventJptReinstated         = "jpt.reinstated"
+)
+
+func GetWebhookEventAvailable() []string {
+	return []string{EventViolationApproved, EventViolationEscalated, EventTruckBlocked, EventTruckUnblocked, EventTruckBlockExpiring,
+		EventDriverBlocked, EventDriverUnblocked,
+		EventJptWarned, EventJptSuspended, EventJptReinstated}
+}
@@ -0,0 +1,8 @@
+package config
+
+// Status JPT berdasarkan score pelanggaran per truck aktif
+const (
+	JptStatusNormal    = "NORMAL"
+	JptStatusWarning   = "WARNING"
+	JptStatusSuspended = "SUSPENDED"
+)
@@ -0,0 +1,15 @@
+package config
+
+import (
+	_ "embed" // daftar password bocor disertakan dalam binary
+	"strings"
+)
+
+//go:embed breached_passwords.txt
+var breachedPasswords string
+
+// GetBreachedPasswords daftar password umum yang pernah bocor, ditolak pada saat pembuatan
+// maupun penggantian password. daftar tambahan dapat dimuat dari file melalui PASSWORD_BREACHED_FILE
+func GetBreachedPasswords() []string {
+	return strings.Fields(breachedPasswords)
+}
@@ -0,0 +1,61 @@
+package config
+
+// Permission yang dicek oleh middleware, pemetaan role ke permission disimpan di database
+// dan dapat diubah oleh admin
+const (
+	PermViolationApprove   = "violation:approve"
+	PermViolationReport    = "violation:report"
+	PermViolationRetention = "violation:retention"
+	PermTruckWrite         = "truck:write"
+	PermTruckBlock         = "truck:block"
+	PermDriverWrite        = "driver:write"
+	PermRulesWrite         = "rules:write"
+	PermJptWrite           = "jpt:write"
+	PermUserAdmin          = "user:admin"
+)
+
+func GetPermissionsAvailable() []string {
+	return []string{
+		PermViolationApprove,
+		PermViolationReport,
+		PermViolationRetention,
+		PermTruckWrite,
+		PermTruckBlock,
+		PermDriverWrite,
+		PermRulesWrite,
+		PermJptWrite,
+		PermUserAdmin,
+	}
+}
+
+// GetPermissionsInitial permission yang tersedia sejak policy dapat disimpan, digunakan sebagai
+// known_permissions untuk policy tersimpan yang belum mencatatnya
+func GetPermissionsInitial() []string {
+	return []string{
+		PermViolationApprove,
+		PermViolationReport,
+		PermTruckWrite,
+		PermRulesWrite,
+		PermJptWrite,
+		PermUserAdmin,
+	}
+}
+
+// GetDefaultPolicy pemetaan role ke permission yang digunakan jika role belum memiliki policy tersimpan
+func GetDefaultPolicy() map[string][]string {
+	return map[string][]string{
+		RoleAdmin:    {PermUserAdmin},
+		RoleSEC:      {},
+		RoleRegional: {},
+		RoleHSSE: {
+			PermViolationApprove,
+			PermViolationReport,
+			PermViolationRetention,
+			PermTruckWrite,
+			PermTruckBlock,
+			PermDriverWrite,
+			PermRulesWrite,
+			PermJptWrite,
+		},
+	}
+}
@@ -4,8 +4,16 @@ const (
 	RoleAdmin = "ADMIN"
 	RoleSEC   = "SECURITY"
 	RoleHSSE  = "HSSE"
+	// RoleRegional dapat membaca data cabang lain yang terdaftar pada field branches user
+	RoleRegional = "REGIONAL"
 )
 
 func GetRolesAvailable() []string {
-	return []string{RoleAdmin, RoleSEC, RoleHSSE}
+	return []string{RoleAdmin, RoleSEC, RoleHSSE, RoleRegional}
+}
+
+// GetRolesRequireTwoFactor role yang wajib menggunakan two factor authentication (TOTP),
+// harus merupakan bagian dari GetRolesAvailable
+func GetRolesRequireTwoFactor() []string {
+	return []string{RoleAdmin, RoleHSSE}
 }
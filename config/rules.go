@@ -0,0 +1,12 @@
+package config
+
+// Subject rules pemblokiran, truck dan sopir memiliki skor dan sanksi masing-masing.
+// rules lama yang belum memiliki subject dianggap sebagai rules truck
+const (
+	RulesSubjectTruck  = "TRUCK"
+	RulesSubjectDriver = "DRIVER"
+)
+
+func GetRulesSubjectAvailable() []string {
+	return []string{RulesSubjectTruck, RulesSubjectDriver}
+}
@@ -0,0 +1,16 @@
+package config
+
+// Event riwayat truck yang disimpan pada koleksi truck_history
+const (
+	TruckHistoryCreate     = "CREATE"
+	TruckHistoryEdit       = "EDIT"
+	TruckHistoryDelete     = "DELETE"
+	TruckHistoryActivate   = "ACTIVATE"
+	TruckHistoryScore      = "SCORE"
+	TruckHistoryBlockStart = "BLOCK_START"
+	TruckHistoryBlockEnd   = "BLOCK_END"
+	TruckHistoryBlacklist  = "BLACKLIST"
+
+	// TruckTimelineViolation item timeline yang berasal dari dokumen pelanggaran
+	TruckTimelineViolation = "VIOLATION"
+)
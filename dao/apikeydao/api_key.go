@@ -0,0 +1,159 @@
+package apikeydao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"tilank/db"
+	"tilank/dto"
+	"tilank/utils/logger"
+	"tilank/utils/rest_err"
+	"time"
+)
+
+const (
+	connectTimeout   = 3
+	keyApiKeyColl    = "api_key"
+	keyApiKeyID      = "_id"
+	keyApiKeyHash    = "hash"
+	keyApiKeyLastAt  = "last_used_at"
+	keyApiKeyLastIP  = "last_used_ip"
+	keyApiKeyRevoked = "revoked"
+	keyApiKeyRevAt   = "revoked_at"
+	keyApiKeyRevBy   = "revoked_by"
+	keyApiKeyCreated = "created_at"
+)
+
+func NewApiKeyDao() ApiKeyDaoAssumer {
+	return &apiKeyDao{}
+}
+
+type apiKeyDao struct {
+}
+
+type ApiKeyDaoAssumer interface {
+	InsertApiKey(input dto.ApiKey) (*dto.ApiKey, resterr.APIError)
+	RevokeApiKey(apiKeyID primitive.ObjectID, revokedBy string, nowUnix int64) (*dto.ApiKey, resterr.APIError)
+	UpdateLastUsed(apiKeyID primitive.ObjectID, nowUnix int64, ip string) resterr.APIError
+
+	GetApiKeyByHash(hash string) (*dto.ApiKey, resterr.APIError)
+	FindApiKey() ([]dto.ApiKey, resterr.APIError)
+}
+
+func (a *apiKeyDao) InsertApiKey(input dto.ApiKey) (*dto.ApiKey, resterr.APIError) {
+	coll := db.DB.Collection(keyApiKeyColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	input.ID = primitive.NewObjectID()
+	input.Name = strings.ToUpper(input.Name)
+	input.Branch = strings.ToUpper(input.Branch)
+
+	if _, err := coll.InsertOne(ctx, input); err != nil {
+		logger.Error("Gagal menyimpan api key ke database (InsertApiKey)", err)
+		return nil, resterr.NewInternalServerError("Gagal menyimpan api key ke database", err)
+	}
+
+	return &input, nil
+}
+
+// RevokeApiKey menandai api key sebagai dicabut, dokumen tetap disimpan untuk keperluan audit
+func (a *apiKeyDao) RevokeApiKey(apiKeyID primitive.ObjectID, revokedBy string, nowUnix int64) (*dto.ApiKey, resterr.APIError) {
+	coll := db.DB.Collection(keyApiKeyColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate()
+	opts.SetReturnDocument(1)
+
+	filter := bson.M{
+		keyApiKeyID:      apiKeyID,
+		keyApiKeyRevoked: false,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyApiKeyRevoked: true,
+			keyApiKeyRevAt:   nowUnix,
+			keyApiKeyRevBy:   strings.ToUpper(revokedBy),
+		},
+	}
+
+	var apiKey dto.ApiKey
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&apiKey); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewNotFoundError(fmt.Sprintf("Api key dengan ID %s tidak ditemukan atau sudah dicabut", apiKeyID.Hex()))
+		}
+
+		logger.Error("Gagal mencabut api key (RevokeApiKey)", err)
+		return nil, resterr.NewInternalServerError("Gagal mencabut api key", err)
+	}
+
+	return &apiKey, nil
+}
+
+func (a *apiKeyDao) UpdateLastUsed(apiKeyID primitive.ObjectID, nowUnix int64, ip string) resterr.APIError {
+	coll := db.DB.Collection(keyApiKeyColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{keyApiKeyID: apiKeyID}
+	update := bson.M{
+		"$set": bson.M{
+			keyApiKeyLastAt: nowUnix,
+			keyApiKeyLastIP: ip,
+		},
+	}
+
+	if _, err := coll.UpdateOne(ctx, filter, update); err != nil {
+		logger.Error("Gagal menyimpan penggunaan api key (UpdateLastUsed)", err)
+		return resterr.NewInternalServerError("Gagal menyimpan penggunaan api key", err)
+	}
+
+	return nil
+}
+
+func (a *apiKeyDao) GetApiKeyByHash(hash string) (*dto.ApiKey, resterr.APIError) {
+	coll := db.DB.Collection(keyApiKeyColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	var apiKey dto.ApiKey
+	if err := coll.FindOne(ctx, bson.M{keyApiKeyHash: hash}).Decode(&apiKey); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewUnauthorizedError("Api key tidak valid")
+		}
+
+		logger.Error("Gagal mendapatkan api key dari database (GetApiKeyByHash)", err)
+		return nil, resterr.NewInternalServerError("Gagal mendapatkan api key dari database", err)
+	}
+
+	return &apiKey, nil
+}
+
+func (a *apiKeyDao) FindApiKey() ([]dto.ApiKey, resterr.APIError) {
+	coll := db.DB.Collection(keyApiKeyColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: keyApiKeyCreated, Value: -1}})
+
+	apiKeys := []dto.ApiKey{}
+	cursor, err := coll.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		logger.Error("Gagal mendapatkan api key dari database (FindApiKey)", err)
+		return nil, resterr.NewInternalServerError("Database error", err)
+	}
+
+	if err = cursor.All(ctx, &apiKeys); err != nil {
+		logger.Error("Gagal decode api key cursor ke objek slice (FindApiKey)", err)
+		return nil, resterr.NewInternalServerError("Database error", err)
+	}
+
+	return apiKeys, nil
+}
@@ -0,0 +1,105 @@
+package auditdao
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"tilank/db"
+	"tilank/dto"
+	"tilank/utils/logger"
+	"tilank/utils/rest_err"
+	"time"
+)
+
+const (
+	connectTimeout = 3
+	keyAuditColl   = "audit_log"
+
+	keyAuditTime    = "time"
+	keyAuditAction  = "action"
+	keyAuditActor   = "actor"
+	keyAuditTarget  = "target"
+	keyAuditOutcome = "outcome"
+)
+
+func NewAuditDao() AuditDaoAssumer {
+	return &auditDao{}
+}
+
+type auditDao struct {
+}
+
+type AuditDaoAssumer interface {
+	InsertAudit(input dto.AuditLog) resterr.APIError
+	FindAudit(filterA dto.FilterAudit) (dto.AuditLogList, resterr.APIError)
+}
+
+// InsertAudit menambahkan catatan audit, collection ini tidak memiliki fungsi edit maupun hapus
+func (a *auditDao) InsertAudit(input dto.AuditLog) resterr.APIError {
+	coll := db.DB.Collection(keyAuditColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	input.ID = primitive.NewObjectID()
+
+	if _, err := coll.InsertOne(ctx, input); err != nil {
+		logger.Error("Gagal menyimpan audit log ke database (InsertAudit)", err)
+		return resterr.NewInternalServerError("Gagal menyimpan audit log ke database", err)
+	}
+
+	return nil
+}
+
+// FindAudit menampilkan audit log terbaru sesuai filter
+func (a *auditDao) FindAudit(filterA dto.FilterAudit) (dto.AuditLogList, resterr.APIError) {
+	coll := db.DB.Collection(keyAuditColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if filterA.FilterUser != "" {
+		user := strings.ToUpper(filterA.FilterUser)
+		filter["$or"] = bson.A{
+			bson.M{keyAuditActor: user},
+			bson.M{keyAuditTarget: user},
+		}
+	}
+	if filterA.FilterAction != "" {
+		filter[keyAuditAction] = strings.ToUpper(filterA.FilterAction)
+	}
+	if filterA.FilterOutcome != "" {
+		filter[keyAuditOutcome] = strings.ToUpper(filterA.FilterOutcome)
+	}
+	timeFilter := bson.M{}
+	if filterA.FilterStart != 0 {
+		timeFilter["$gte"] = filterA.FilterStart
+	}
+	if filterA.FilterEnd != 0 {
+		timeFilter["$lte"] = filterA.FilterEnd
+	}
+	if len(timeFilter) != 0 {
+		filter[keyAuditTime] = timeFilter
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: keyAuditTime, Value: -1}})
+	opts.SetLimit(filterA.Limit)
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		logger.Error("Gagal mendapatkan audit log dari database (FindAudit)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.AuditLogList{}, apiErr
+	}
+
+	auditList := dto.AuditLogList{}
+	if err = cursor.All(ctx, &auditList); err != nil {
+		logger.Error("Gagal decode audit cursor ke objek slice (FindAudit)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.AuditLogList{}, apiErr
+	}
+
+	return auditList, nil
+}
@@ -0,0 +1,270 @@
+package companydao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"regexp"
+	"strings"
+	"tilank/db"
+	"tilank/dto"
+	"tilank/utils/logger"
+	"tilank/utils/rest_err"
+	"time"
+)
+
+const (
+	connectTimeout       = 3
+	keyCompanyCollection = "company"
+
+	keyCompanyID             = "_id"
+	keyCompanyUpdatedAt      = "updated_at"
+	keyCompanyUpdatedBy      = "updated_by"
+	keyCompanyUpdatedByID    = "updated_by_id"
+	keyCompanyBranch         = "branch"
+	keyCompanyName           = "name"
+	keyCompanyNormalizedName = "normalized_name"
+	keyCompanyAliases        = "aliases"
+	keyCompanyEmail          = "email"
+	keyCompanyHp             = "hp"
+	keyCompanyAddress        = "address"
+	keyCompanyContacts       = "contacts"
+	keyCompanyDeleted        = "deleted"
+)
+
+func NewCompanyDao() CompanyDaoAssumer {
+	return &companyDao{}
+}
+
+type companyDao struct {
+}
+
+type CompanyDaoAssumer interface {
+	InsertCompany(input dto.Company) (*string, resterr.APIError)
+	EditCompany(input dto.CompanyEdit) (*dto.Company, resterr.APIError)
+	DeleteCompany(input dto.FilterIDBranch, isSoftDelete bool) (*dto.Company, resterr.APIError)
+	AddAliases(companyID primitive.ObjectID, aliases []string) resterr.APIError
+
+	GetCompanyByID(companyID primitive.ObjectID, branchIfSpecific string) (*dto.Company, resterr.APIError)
+	GetCompanyByNormalizedName(normalizedName string, branch string) (*dto.Company, resterr.APIError)
+	FindCompany(filter dto.FilterCompany) (dto.CompanyResponseMinList, resterr.APIError)
+}
+
+func (c *companyDao) InsertCompany(input dto.Company) (*string, resterr.APIError) {
+	coll := db.DB.Collection(keyCompanyCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	input.Name = strings.ToUpper(strings.TrimSpace(input.Name))
+	input.Branch = strings.ToUpper(input.Branch)
+	input.Email = strings.ToLower(input.Email)
+
+	result, err := coll.InsertOne(ctx, input)
+	if err != nil {
+		apiErr := resterr.NewInternalServerError("Gagal menyimpan perusahaan ke database", err)
+		logger.Error("Gagal menyimpan perusahaan ke database, (InsertCompany)", err)
+		return nil, apiErr
+	}
+
+	insertID := result.InsertedID.(primitive.ObjectID).Hex()
+
+	return &insertID, nil
+}
+
+func (c *companyDao) EditCompany(input dto.CompanyEdit) (*dto.Company, resterr.APIError) {
+	coll := db.DB.Collection(keyCompanyCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	input.Name = strings.ToUpper(strings.TrimSpace(input.Name))
+	input.FilterBranch = strings.ToUpper(input.FilterBranch)
+	input.Email = strings.ToLower(input.Email)
+
+	opts := options.FindOneAndUpdate()
+	opts.SetReturnDocument(1)
+
+	filter := bson.M{
+		keyCompanyID:        input.ID,
+		keyCompanyBranch:    input.FilterBranch,
+		keyCompanyUpdatedAt: input.FilterTimestamp,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			keyCompanyUpdatedAt:      input.UpdatedAt,
+			keyCompanyUpdatedBy:      input.UpdatedBy,
+			keyCompanyUpdatedByID:    input.UpdatedByID,
+			keyCompanyName:           input.Name,
+			keyCompanyNormalizedName: input.NormalizedName,
+			keyCompanyEmail:          input.Email,
+			keyCompanyHp:             input.Hp,
+			keyCompanyAddress:        input.Address,
+			keyCompanyContacts:       input.Contacts,
+		},
+	}
+
+	var company dto.Company
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&company); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewBadRequestError("perusahaan tidak diupdate : validasi id timestamp")
+		}
+
+		logger.Error("Gagal mendapatkan perusahaan dari database (EditCompany)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mendapatkan perusahaan dari database", err)
+		return nil, apiErr
+	}
+
+	return &company, nil
+}
+
+func (c *companyDao) DeleteCompany(input dto.FilterIDBranch, isSoftDelete bool) (*dto.Company, resterr.APIError) {
+	coll := db.DB.Collection(keyCompanyCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate()
+	opts.SetReturnDocument(1)
+
+	filter := bson.M{
+		keyCompanyID:     input.FilterID,
+		keyCompanyBranch: input.FilterBranch,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			keyCompanyDeleted: isSoftDelete,
+		},
+	}
+
+	var company dto.Company
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&company); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewBadRequestError("Perusahaan tidak dihapus : validasi id branch status")
+		}
+
+		logger.Error("Gagal menghapus perusahaan dari database (DeleteCompany)", err)
+		apiErr := resterr.NewInternalServerError("Gagal menghapus perusahaan dari database", err)
+		return nil, apiErr
+	}
+
+	return &company, nil
+}
+
+// AddAliases menambahkan penulisan owner lama ke perusahaan tanpa duplikasi
+func (c *companyDao) AddAliases(companyID primitive.ObjectID, aliases []string) resterr.APIError {
+	coll := db.DB.Collection(keyCompanyCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{keyCompanyID: companyID}
+	update := bson.M{
+		"$addToSet": bson.M{
+			keyCompanyAliases: bson.M{"$each": aliases},
+		},
+	}
+
+	if _, err := coll.UpdateOne(ctx, filter, update); err != nil {
+		logger.Error("Gagal menambahkan alias perusahaan (AddAliases)", err)
+		return resterr.NewInternalServerError("Gagal menambahkan alias perusahaan", err)
+	}
+
+	return nil
+}
+
+func (c *companyDao) GetCompanyByID(companyID primitive.ObjectID, branchIfSpecific string) (*dto.Company, resterr.APIError) {
+	coll := db.DB.Collection(keyCompanyCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{keyCompanyID: companyID}
+	if branchIfSpecific != "" {
+		filter[keyCompanyBranch] = strings.ToUpper(branchIfSpecific)
+	}
+
+	var company dto.Company
+	if err := coll.FindOne(ctx, filter).Decode(&company); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			apiErr := resterr.NewNotFoundError(fmt.Sprintf("Perusahaan dengan ID %s tidak ditemukan", companyID.Hex()))
+			return nil, apiErr
+		}
+
+		logger.Error("gagal mendapatkan perusahaan dari database (GetCompanyByID)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mendapatkan perusahaan dari database", err)
+		return nil, apiErr
+	}
+
+	return &company, nil
+}
+
+// GetCompanyByNormalizedName mendapatkan perusahaan dengan nama yang sama setelah dinormalisasi
+func (c *companyDao) GetCompanyByNormalizedName(normalizedName string, branch string) (*dto.Company, resterr.APIError) {
+	coll := db.DB.Collection(keyCompanyCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyCompanyNormalizedName: normalizedName,
+		keyCompanyBranch:         strings.ToUpper(branch),
+	}
+
+	var company dto.Company
+	if err := coll.FindOne(ctx, filter).Decode(&company); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			apiErr := resterr.NewNotFoundError(fmt.Sprintf("Perusahaan %s tidak ditemukan", normalizedName))
+			return nil, apiErr
+		}
+
+		logger.Error("gagal mendapatkan perusahaan dari database (GetCompanyByNormalizedName)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mendapatkan perusahaan dari database", err)
+		return nil, apiErr
+	}
+
+	return &company, nil
+}
+
+func (c *companyDao) FindCompany(filterA dto.FilterCompany) (dto.CompanyResponseMinList, resterr.APIError) {
+	coll := db.DB.Collection(keyCompanyCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filterA.FilterBranch = strings.ToUpper(filterA.FilterBranch)
+	filterA.FilterName = strings.ToUpper(filterA.FilterName)
+
+	// filter
+	filter := bson.M{
+		keyCompanyDeleted: !filterA.Active,
+	}
+
+	// filter condition
+	if filterA.FilterBranch != "" {
+		filter[keyCompanyBranch] = filterA.FilterBranch
+	}
+	if filterA.FilterName != "" {
+		filter[keyCompanyName] = bson.M{
+			"$regex": fmt.Sprintf(".*%s", regexp.QuoteMeta(filterA.FilterName)),
+		}
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: keyCompanyName, Value: 1}})
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		logger.Error("Gagal mendapatkan daftar perusahaan dari database (FindCompany)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.CompanyResponseMinList{}, apiErr
+	}
+
+	companyList := dto.CompanyResponseMinList{}
+	if err = cursor.All(ctx, &companyList); err != nil {
+		logger.Error("Gagal decode companyList cursor ke objek slice (FindCompany)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.CompanyResponseMinList{}, apiErr
+	}
+
+	return companyList, nil
+}
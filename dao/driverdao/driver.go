@@ -0,0 +1,369 @@
+package driverdao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"regexp"
+	"strings"
+	"tilank/db"
+	"tilank/dto"
+	"tilank/utils/logger"
+	"tilank/utils/rest_err"
+	"time"
+)
+
+const (
+	connectTimeout      = 3
+	keyDriverCollection = "driver"
+
+	keyDriverID          = "_id"
+	keyDriverUpdatedAt   = "updated_at"
+	keyDriverUpdatedBy   = "updated_by"
+	keyDriverUpdatedByID = "updated_by_id"
+	keyDriverBranch      = "branch"
+	keyNoLicense         = "no_license"
+	keyName              = "name"
+	keyEmployer          = "employer"
+	keyHp                = "hp"
+	keyImage             = "image"
+	keyDeleted           = "deleted"
+	keyScore             = "score"
+	keyResetScoreDate    = "reset_score_date"
+	keyBlocked           = "blocked"
+	keyBlockStart        = "block_start"
+	keyBlockEnd          = "block_end"
+)
+
+func NewDriverDao() DriverDaoAssumer {
+	return &driverDao{}
+}
+
+type driverDao struct {
+}
+
+type DriverDaoAssumer interface {
+	InsertDriver(input dto.Driver) (*string, resterr.APIError)
+	EditDriver(input dto.DriverEdit) (*dto.Driver, resterr.APIError)
+	DeleteDriver(input dto.FilterIDBranch, isSoftDelete bool) (*dto.Driver, resterr.APIError)
+	ChangeScore(input dto.DriverScoreEdit) (*dto.Driver, resterr.APIError)
+	PutImage(driverID primitive.ObjectID, imagePath string, filterBranch string) (*dto.Driver, resterr.APIError)
+
+	GetDriverByID(driverID primitive.ObjectID, branchIfSpecific string) (*dto.Driver, resterr.APIError)
+	GetDriverByLicense(noLicense string, branch string) (*dto.Driver, resterr.APIError)
+	FindDriver(filter dto.FilterDriver) (dto.DriverResponseMinList, resterr.APIError)
+	ResetDriverBlock(driversID []primitive.ObjectID) (int64, resterr.APIError)
+}
+
+func (d *driverDao) InsertDriver(input dto.Driver) (*string, resterr.APIError) {
+	coll := db.DB.Collection(keyDriverCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	input.NoLicense = strings.ReplaceAll(strings.ToUpper(input.NoLicense), " ", "")
+	input.Name = strings.ToUpper(input.Name)
+	input.Employer = strings.ToUpper(input.Employer)
+	input.Branch = strings.ToUpper(input.Branch)
+
+	result, err := coll.InsertOne(ctx, input)
+	if err != nil {
+		apiErr := resterr.NewInternalServerError("Gagal menyimpan sopir ke database", err)
+		logger.Error("Gagal menyimpan sopir ke database, (InsertDriver)", err)
+		return nil, apiErr
+	}
+
+	insertID := result.InsertedID.(primitive.ObjectID).Hex()
+
+	return &insertID, nil
+}
+
+func (d *driverDao) EditDriver(input dto.DriverEdit) (*dto.Driver, resterr.APIError) {
+	coll := db.DB.Collection(keyDriverCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	input.NoLicense = strings.ReplaceAll(strings.ToUpper(input.NoLicense), " ", "")
+	input.Name = strings.ToUpper(input.Name)
+	input.Employer = strings.ToUpper(input.Employer)
+	input.FilterBranch = strings.ToUpper(input.FilterBranch)
+
+	opts := options.FindOneAndUpdate()
+	opts.SetReturnDocument(1)
+
+	filter := bson.M{
+		keyDriverID:        input.ID,
+		keyDriverBranch:    input.FilterBranch,
+		keyDriverUpdatedAt: input.FilterTimestamp,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			keyDriverUpdatedAt:   input.UpdatedAt,
+			keyDriverUpdatedBy:   input.UpdatedBy,
+			keyDriverUpdatedByID: input.UpdatedByID,
+			keyNoLicense:         input.NoLicense,
+			keyName:              input.Name,
+			keyEmployer:          input.Employer,
+			keyHp:                input.Hp,
+		},
+	}
+
+	var driver dto.Driver
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&driver); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewBadRequestError("sopir tidak diupdate : validasi id timestamp")
+		}
+
+		logger.Error("Gagal mendapatkan sopir dari database (EditDriver)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mendapatkan sopir dari database", err)
+		return nil, apiErr
+	}
+
+	return &driver, nil
+}
+
+func (d *driverDao) ChangeScore(input dto.DriverScoreEdit) (*dto.Driver, resterr.APIError) {
+	coll := db.DB.Collection(keyDriverCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate()
+	opts.SetReturnDocument(1)
+
+	filter := bson.M{
+		keyDriverID: input.ID,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			keyScore:          input.Score,
+			keyResetScoreDate: input.ResetScoreDate,
+			keyBlocked:        input.Blocked,
+			keyBlockStart:     input.BlockStart,
+			keyBlockEnd:       input.BlockEnd,
+		},
+	}
+
+	var driver dto.Driver
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&driver); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewBadRequestError("sopir tidak diupdate : sopir dengan id tersebut tidak ditemukan")
+		}
+
+		logger.Error("Gagal mendapatkan sopir dari database (ChangeScore)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mendapatkan sopir dari database", err)
+		return nil, apiErr
+	}
+
+	return &driver, nil
+}
+
+func (d *driverDao) DeleteDriver(input dto.FilterIDBranch, isSoftDelete bool) (*dto.Driver, resterr.APIError) {
+	coll := db.DB.Collection(keyDriverCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate()
+	opts.SetReturnDocument(1)
+
+	filter := bson.M{
+		keyDriverID:     input.FilterID,
+		keyDriverBranch: input.FilterBranch,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			keyDeleted: isSoftDelete,
+		},
+	}
+
+	var driver dto.Driver
+	err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&driver)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewBadRequestError("Sopir tidak dihapus : validasi id branch status")
+		}
+
+		logger.Error("Gagal menghapus sopir dari database (DeleteDriver)", err)
+		apiErr := resterr.NewInternalServerError("Gagal menghapus sopir dari database", err)
+		return nil, apiErr
+	}
+
+	return &driver, nil
+}
+
+// PutImage mengganti foto sopir dengan mengecek kesesuaian branch
+func (d *driverDao) PutImage(driverID primitive.ObjectID, imagePath string, filterBranch string) (*dto.Driver, resterr.APIError) {
+	coll := db.DB.Collection(keyDriverCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate()
+	opts.SetReturnDocument(1)
+
+	filter := bson.M{
+		keyDriverID:     driverID,
+		keyDriverBranch: strings.ToUpper(filterBranch),
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyImage: imagePath,
+		},
+	}
+
+	var driver dto.Driver
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&driver); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewBadRequestError(fmt.Sprintf("Foto sopir gagal diupload, sopir dengan id %s tidak ditemukan", driverID.Hex()))
+		}
+
+		logger.Error("Memasukkan path foto sopir ke db gagal, (PutImage)", err)
+		apiErr := resterr.NewInternalServerError("Memasukkan path foto sopir ke db gagal", err)
+		return nil, apiErr
+	}
+
+	return &driver, nil
+}
+
+func (d *driverDao) GetDriverByID(driverID primitive.ObjectID, branchIfSpecific string) (*dto.Driver, resterr.APIError) {
+	coll := db.DB.Collection(keyDriverCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{keyDriverID: driverID}
+	if branchIfSpecific != "" {
+		filter[keyDriverBranch] = strings.ToUpper(branchIfSpecific)
+	}
+
+	var driver dto.Driver
+	if err := coll.FindOne(ctx, filter).Decode(&driver); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			apiErr := resterr.NewNotFoundError(fmt.Sprintf("Sopir dengan ID %s tidak ditemukan", driverID.Hex()))
+			return nil, apiErr
+		}
+
+		logger.Error("gagal mendapatkan sopir dari database (GetDriverByID)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mendapatkan sopir dari database", err)
+		return nil, apiErr
+	}
+
+	return &driver, nil
+}
+
+// GetDriverByLicense berdasarkan nomor SIM
+func (d *driverDao) GetDriverByLicense(noLicense string, branch string) (*dto.Driver, resterr.APIError) {
+	coll := db.DB.Collection(keyDriverCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	noLicense = strings.ReplaceAll(strings.ToUpper(noLicense), " ", "")
+	branch = strings.ToUpper(branch)
+
+	filter := bson.M{keyNoLicense: noLicense, keyDriverBranch: branch}
+
+	var driver dto.Driver
+	if err := coll.FindOne(ctx, filter).Decode(&driver); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			apiErr := resterr.NewNotFoundError(fmt.Sprintf("Sopir dengan nomor SIM %s tidak ditemukan", noLicense))
+			return nil, apiErr
+		}
+
+		logger.Error("gagal mendapatkan sopir dari database (GetDriverByLicense)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mendapatkan sopir dari database", err)
+		return nil, apiErr
+	}
+
+	return &driver, nil
+}
+
+func (d *driverDao) FindDriver(filterA dto.FilterDriver) (dto.DriverResponseMinList, resterr.APIError) {
+	coll := db.DB.Collection(keyDriverCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filterA.FilterBranch = strings.ToUpper(filterA.FilterBranch)
+	filterA.FilterNoLicense = strings.ReplaceAll(strings.ToUpper(filterA.FilterNoLicense), " ", "")
+	filterA.FilterName = strings.ToUpper(filterA.FilterName)
+	filterA.FilterEmployer = strings.ToUpper(filterA.FilterEmployer)
+
+	// filter
+	filter := bson.M{
+		keyDeleted: !filterA.Active,
+	}
+
+	// filter condition
+	if filterA.FilterBranch != "" {
+		filter[keyDriverBranch] = filterA.FilterBranch
+	}
+	if filterA.FilterNoLicense != "" {
+		filter[keyNoLicense] = bson.M{
+			"$regex": fmt.Sprintf(".*%s", regexp.QuoteMeta(filterA.FilterNoLicense)),
+		}
+	}
+	if filterA.FilterName != "" {
+		filter[keyName] = bson.M{
+			"$regex": fmt.Sprintf(".*%s", regexp.QuoteMeta(filterA.FilterName)),
+		}
+	}
+	if filterA.FilterEmployer != "" {
+		filter[keyEmployer] = bson.M{
+			"$regex": fmt.Sprintf(".*%s", regexp.QuoteMeta(filterA.FilterEmployer)),
+		}
+	}
+	if filterA.Blocked {
+		filter[keyBlocked] = true
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: keyEmployer, Value: 1}, {Key: keyName, Value: 1}})
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		logger.Error("Gagal mendapatkan daftar sopir dari database (FindDriver)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.DriverResponseMinList{}, apiErr
+	}
+
+	driverList := dto.DriverResponseMinList{}
+	if err = cursor.All(ctx, &driverList); err != nil {
+		logger.Error("Gagal decode driverList cursor ke objek slice (FindDriver)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.DriverResponseMinList{}, apiErr
+	}
+
+	return driverList, nil
+}
+
+func (d *driverDao) ResetDriverBlock(driversID []primitive.ObjectID) (int64, resterr.APIError) {
+	coll := db.DB.Collection(keyDriverCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	nowUnix := time.Now().Unix()
+
+	filter := bson.M{
+		keyDriverID: bson.M{"$in": driversID},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			keyResetScoreDate: nowUnix,
+			keyBlocked:        false,
+			keyBlockStart:     0,
+			keyBlockEnd:       0,
+		},
+	}
+
+	result, err := coll.UpdateMany(ctx, filter, update)
+	if err != nil {
+		logger.Error("Gagal mengupdate sopir dari database (ResetDriverBlock)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mengupdate sopir dari database", err)
+		return 0, apiErr
+	}
+
+	return result.ModifiedCount, nil
+}
@@ -31,6 +31,17 @@ const (
 	keyJptHp          = "hp"
 	keyJptEmail       = "email"
 	keyJptDeleted     = "deleted"
+
+	keyJptScore           = "score"
+	keyJptViolationCount  = "violation_count"
+	keyJptTruckCount      = "truck_count"
+	keyJptStatus          = "status"
+	keyJptScoredAt        = "scored_at"
+	keyJptStatusChangedAt = "status_changed_at"
+
+	keyJptHistoryCollection = "jpt_score_history"
+	keyJptHistoryJptID      = "jpt_id"
+	keyJptHistoryPeriod     = "period"
 )
 
 func NewJptDao() JptDaoAssumer {
@@ -47,6 +58,11 @@ type JptDaoAssumer interface {
 
 	GetJptByID(jptID primitive.ObjectID, branchIfSpecific string) (*dto.Jpt, resterr.APIError)
 	FindJpt(filter dto.FilterJpt) (dto.JptResponseMinList, resterr.APIError)
+
+	ChangeScore(input dto.JptScoreEdit) (*dto.Jpt, resterr.APIError)
+	FindJptLeaderboard(branch string) (dto.JptResponseMinList, resterr.APIError)
+	UpsertScoreHistory(input dto.JptScoreHistory) resterr.APIError
+	FindScoreHistory(jptID string, limit int64) (dto.JptScoreHistoryList, resterr.APIError)
 }
 
 func (c *jptDao) InsertJpt(input dto.Jpt) (*string, resterr.APIError) {
@@ -215,3 +231,129 @@ func (c *jptDao) FindJpt(filterA dto.FilterJpt) (dto.JptResponseMinList, resterr
 
 	return jptList, nil
 }
+
+// ChangeScore menyimpan hasil perhitungan score dan status JPT
+func (c *jptDao) ChangeScore(input dto.JptScoreEdit) (*dto.Jpt, resterr.APIError) {
+	coll := db.DB.Collection(keyJptCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate()
+	opts.SetReturnDocument(1)
+
+	filter := bson.M{
+		keyJptID: input.ID,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			keyJptScore:           input.Score,
+			keyJptViolationCount:  input.ViolationCount,
+			keyJptTruckCount:      input.TruckCount,
+			keyJptStatus:          input.Status,
+			keyJptScoredAt:        input.ScoredAt,
+			keyJptStatusChangedAt: input.StatusChangedAt,
+		},
+	}
+
+	var jpt dto.Jpt
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&jpt); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewBadRequestError("score jpt tidak diupdate : validasi id")
+		}
+
+		logger.Error("Gagal mengupdate score jpt dari database (ChangeScore)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mengupdate score jpt dari database", err)
+		return nil, apiErr
+	}
+
+	return &jpt, nil
+}
+
+// FindJptLeaderboard menampilkan JPT aktif diurutkan dari score terbaik (terkecil)
+func (c *jptDao) FindJptLeaderboard(branch string) (dto.JptResponseMinList, resterr.APIError) {
+	coll := db.DB.Collection(keyJptCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyJptDeleted: false,
+	}
+	if branch != "" {
+		filter[keyJptBranch] = strings.ToUpper(branch)
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: keyJptScore, Value: 1}, {Key: keyJptViolationCount, Value: 1}, {Key: keyJptName, Value: 1}})
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		logger.Error("Gagal mendapatkan leaderboard jpt dari database (FindJptLeaderboard)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.JptResponseMinList{}, apiErr
+	}
+
+	jptList := dto.JptResponseMinList{}
+	if err = cursor.All(ctx, &jptList); err != nil {
+		logger.Error("Gagal decode jptList cursor ke objek slice (FindJptLeaderboard)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.JptResponseMinList{}, apiErr
+	}
+
+	return jptList, nil
+}
+
+// UpsertScoreHistory menyimpan score JPT pada periode bulan berjalan, menimpa perhitungan sebelumnya di bulan yang sama
+func (c *jptDao) UpsertScoreHistory(input dto.JptScoreHistory) resterr.APIError {
+	coll := db.DB.Collection(keyJptHistoryCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	input.Branch = strings.ToUpper(input.Branch)
+
+	filter := bson.M{
+		keyJptHistoryJptID:  input.JptID,
+		keyJptHistoryPeriod: input.Period,
+	}
+
+	opts := options.Replace()
+	opts.SetUpsert(true)
+
+	if _, err := coll.ReplaceOne(ctx, filter, input, opts); err != nil {
+		logger.Error("Gagal menyimpan riwayat score jpt ke database (UpsertScoreHistory)", err)
+		return resterr.NewInternalServerError("Gagal menyimpan riwayat score jpt ke database", err)
+	}
+
+	return nil
+}
+
+// FindScoreHistory menampilkan riwayat score bulanan JPT dari periode terbaru
+func (c *jptDao) FindScoreHistory(jptID string, limit int64) (dto.JptScoreHistoryList, resterr.APIError) {
+	coll := db.DB.Collection(keyJptHistoryCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyJptHistoryJptID: jptID,
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: keyJptHistoryPeriod, Value: -1}})
+	opts.SetLimit(limit)
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		logger.Error("Gagal mendapatkan riwayat score jpt dari database (FindScoreHistory)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.JptScoreHistoryList{}, apiErr
+	}
+
+	historyList := dto.JptScoreHistoryList{}
+	if err = cursor.All(ctx, &historyList); err != nil {
+		logger.Error("Gagal decode historyList cursor ke objek slice (FindScoreHistory)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.JptScoreHistoryList{}, apiErr
+	}
+
+	return historyList, nil
+}
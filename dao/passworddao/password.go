@@ -0,0 +1,151 @@
+package passworddao
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"tilank/db"
+	"tilank/dto"
+	"tilank/utils/logger"
+	"tilank/utils/rest_err"
+	"time"
+)
+
+const (
+	connectTimeout = 3
+
+	keyResetColl      = "password_reset"
+	keyResetID        = "_id"
+	keyResetUserID    = "user_id"
+	keyResetCreatedAt = "created_at"
+	keyResetExp       = "exp"
+	keyResetUsed      = "used"
+)
+
+func NewPasswordDao() PasswordDaoAssumer {
+	return &passwordDao{}
+}
+
+type passwordDao struct {
+}
+
+type PasswordDaoAssumer interface {
+	InsertResetToken(input dto.PasswordResetToken) resterr.APIError
+	GetResetToken(tokenHash string, nowUnix int64) (*dto.PasswordResetToken, resterr.APIError)
+	UseResetToken(tokenHash string, nowUnix int64) (*dto.PasswordResetToken, resterr.APIError)
+	GetLastResetToken(userID string) (*dto.PasswordResetToken, resterr.APIError)
+	DeleteResetTokenByUser(userID string) resterr.APIError
+}
+
+func (p *passwordDao) InsertResetToken(input dto.PasswordResetToken) resterr.APIError {
+	coll := db.DB.Collection(keyResetColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	input.UserID = strings.ToUpper(input.UserID)
+
+	if _, err := coll.InsertOne(ctx, input); err != nil {
+		logger.Error("Gagal menyimpan token reset password (InsertResetToken)", err)
+		return resterr.NewInternalServerError("Gagal menyimpan token reset password", err)
+	}
+
+	return nil
+}
+
+// UseResetToken menandai token sebagai terpakai secara atomik, gagal jika token
+// tidak ada, sudah digunakan atau sudah kadaluarsa
+// GetResetToken mendapatkan token yang belum digunakan dan belum kadaluarsa tanpa menandainya terpakai
+func (p *passwordDao) GetResetToken(tokenHash string, nowUnix int64) (*dto.PasswordResetToken, resterr.APIError) {
+	coll := db.DB.Collection(keyResetColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyResetID:   tokenHash,
+		keyResetUsed: false,
+		keyResetExp:  bson.M{"$gt": nowUnix},
+	}
+
+	var token dto.PasswordResetToken
+	if err := coll.FindOne(ctx, filter).Decode(&token); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewBadRequestError("Token reset password tidak valid atau sudah kadaluarsa")
+		}
+
+		logger.Error("Gagal mendapatkan token reset password (GetResetToken)", err)
+		return nil, resterr.NewInternalServerError("Gagal mendapatkan token reset password", err)
+	}
+
+	return &token, nil
+}
+
+func (p *passwordDao) UseResetToken(tokenHash string, nowUnix int64) (*dto.PasswordResetToken, resterr.APIError) {
+	coll := db.DB.Collection(keyResetColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate()
+	opts.SetReturnDocument(1)
+
+	filter := bson.M{
+		keyResetID:   tokenHash,
+		keyResetUsed: false,
+		keyResetExp:  bson.M{"$gt": nowUnix},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyResetUsed: true,
+		},
+	}
+
+	var token dto.PasswordResetToken
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&token); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewBadRequestError("Token reset password tidak valid atau sudah kadaluarsa")
+		}
+
+		logger.Error("Gagal menggunakan token reset password (UseResetToken)", err)
+		return nil, resterr.NewInternalServerError("Gagal menggunakan token reset password", err)
+	}
+
+	return &token, nil
+}
+
+// GetLastResetToken mendapatkan token terakhir milik user, nil jika belum pernah ada
+func (p *passwordDao) GetLastResetToken(userID string) (*dto.PasswordResetToken, resterr.APIError) {
+	coll := db.DB.Collection(keyResetColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.FindOne()
+	opts.SetSort(bson.D{{Key: keyResetCreatedAt, Value: -1}})
+
+	var token dto.PasswordResetToken
+	if err := coll.FindOne(ctx, bson.M{keyResetUserID: strings.ToUpper(userID)}, opts).Decode(&token); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+
+		logger.Error("Gagal mendapatkan token reset password (GetLastResetToken)", err)
+		return nil, resterr.NewInternalServerError("Gagal mendapatkan token reset password", err)
+	}
+
+	return &token, nil
+}
+
+// DeleteResetTokenByUser menghapus seluruh token milik user setelah password berhasil diganti
+func (p *passwordDao) DeleteResetTokenByUser(userID string) resterr.APIError {
+	coll := db.DB.Collection(keyResetColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	if _, err := coll.DeleteMany(ctx, bson.M{keyResetUserID: strings.ToUpper(userID)}); err != nil {
+		logger.Error("Gagal menghapus token reset password (DeleteResetTokenByUser)", err)
+		return resterr.NewInternalServerError("Gagal menghapus token reset password", err)
+	}
+
+	return nil
+}
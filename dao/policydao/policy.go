@@ -0,0 +1,95 @@
+package policydao
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"tilank/db"
+	"tilank/dto"
+	"tilank/utils/logger"
+	"tilank/utils/rest_err"
+	"time"
+)
+
+const (
+	connectTimeout = 3
+	keyPolicyColl  = "role_permission"
+
+	keyPolicyRole        = "_id"
+	keyPolicyPermissions = "permissions"
+	keyPolicyKnown       = "known_permissions"
+	keyPolicyUpdatedAt   = "updated_at"
+	keyPolicyUpdatedBy   = "updated_by"
+)
+
+func NewPolicyDao() PolicyDaoAssumer {
+	return &policyDao{}
+}
+
+type policyDao struct {
+}
+
+type PolicyDaoAssumer interface {
+	UpsertRolePermission(input dto.RolePermission) (*dto.RolePermission, resterr.APIError)
+	FindRolePermission() ([]dto.RolePermission, resterr.APIError)
+}
+
+// UpsertRolePermission mengganti seluruh permission role, membuat dokumen baru jika belum ada
+func (p *policyDao) UpsertRolePermission(input dto.RolePermission) (*dto.RolePermission, resterr.APIError) {
+	coll := db.DB.Collection(keyPolicyColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	input.Role = strings.ToUpper(input.Role)
+	input.UpdatedBy = strings.ToUpper(input.UpdatedBy)
+	if input.Permissions == nil {
+		input.Permissions = []string{}
+	}
+	if input.KnownPermissions == nil {
+		input.KnownPermissions = []string{}
+	}
+
+	opts := options.FindOneAndUpdate()
+	opts.SetUpsert(true)
+	opts.SetReturnDocument(1)
+
+	filter := bson.M{keyPolicyRole: input.Role}
+	update := bson.M{
+		"$set": bson.M{
+			keyPolicyPermissions: input.Permissions,
+			keyPolicyKnown:       input.KnownPermissions,
+			keyPolicyUpdatedAt:   input.UpdatedAt,
+			keyPolicyUpdatedBy:   input.UpdatedBy,
+		},
+	}
+
+	var result dto.RolePermission
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result); err != nil {
+		logger.Error("Gagal menyimpan role permission ke database (UpsertRolePermission)", err)
+		return nil, resterr.NewInternalServerError("Gagal menyimpan role permission ke database", err)
+	}
+
+	return &result, nil
+}
+
+// FindRolePermission mendapatkan seluruh policy yang tersimpan
+func (p *policyDao) FindRolePermission() ([]dto.RolePermission, resterr.APIError) {
+	coll := db.DB.Collection(keyPolicyColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	policy := []dto.RolePermission{}
+	cursor, err := coll.Find(ctx, bson.M{})
+	if err != nil {
+		logger.Error("Gagal mendapatkan role permission dari database (FindRolePermission)", err)
+		return nil, resterr.NewInternalServerError("Database error", err)
+	}
+
+	if err = cursor.All(ctx, &policy); err != nil {
+		logger.Error("Gagal decode role permission cursor ke objek slice (FindRolePermission)", err)
+		return nil, resterr.NewInternalServerError("Database error", err)
+	}
+
+	return policy, nil
+}
@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"strings"
+	"tilank/config"
 	"tilank/db"
 	"tilank/dto"
 	"tilank/utils/logger"
@@ -25,6 +26,7 @@ const (
 	keyRulesUpdatedBy   = "updated_by"
 	keyRulesUpdatedByID = "updated_by_id"
 	keyRulesBranch      = "branch"
+	keyRulesSubject     = "subject"
 	keyRulesScore       = "score"
 	keyRulesBlockTime   = "block_time"
 	keyRulesDescription = "description"
@@ -43,7 +45,7 @@ type RulesDaoAssumer interface {
 	DeleteRules(input dto.FilterIDBranch, isSoftDelete bool) (*dto.Rules, resterr.APIError)
 
 	GetRulesByID(rulesID primitive.ObjectID, branchIfSpecific string) (*dto.Rules, resterr.APIError)
-	GetRulesByScore(score int, branch string) (*dto.Rules, resterr.APIError)
+	GetRulesByScore(score int, branch string, subject string) (*dto.Rules, resterr.APIError)
 	FindRules() ([]dto.Rules, resterr.APIError)
 }
 
@@ -85,6 +87,7 @@ func (c *rulesDao) EditRules(input dto.RulesEdit) (*dto.Rules, resterr.APIError)
 			keyRulesUpdatedAt:   input.UpdatedAt,
 			keyRulesUpdatedBy:   input.UpdatedBy,
 			keyRulesUpdatedByID: input.UpdatedByID,
+			keyRulesSubject:     input.Subject,
 			keyRulesScore:       input.Score,
 			keyRulesBlockTime:   input.BlockTime,
 			keyRulesDescription: input.Description,
@@ -157,12 +160,18 @@ func (c *rulesDao) GetRulesByID(rulesID primitive.ObjectID, branchIfSpecific str
 	return &rules, nil
 }
 
-func (c *rulesDao) GetRulesByScore(score int, branch string) (*dto.Rules, resterr.APIError) {
+// GetRulesByScore mendapatkan rules sesuai subject, rules tanpa subject dianggap rules truck
+func (c *rulesDao) GetRulesByScore(score int, branch string, subject string) (*dto.Rules, resterr.APIError) {
 	coll := db.DB.Collection(keyRulesCollection)
 	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
 	defer cancel()
 
 	filter := bson.M{keyRulesScore: score, keyRulesBranch: strings.ToUpper(branch)}
+	if subject == config.RulesSubjectTruck {
+		filter[keyRulesSubject] = bson.M{"$in": bson.A{config.RulesSubjectTruck, "", nil}}
+	} else {
+		filter[keyRulesSubject] = subject
+	}
 
 	var rules dto.Rules
 	if err := coll.FindOne(ctx, filter).Decode(&rules); err != nil {
@@ -0,0 +1,86 @@
+package ssodao
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"tilank/db"
+	"tilank/dto"
+	"tilank/utils/logger"
+	"tilank/utils/rest_err"
+	"time"
+)
+
+const (
+	connectTimeout = 3
+
+	keySsoStateColl = "sso_state"
+	keySsoStateID   = "_id"
+	keySsoStateExp  = "exp"
+)
+
+func NewSsoDao() SsoDaoAssumer {
+	return &ssoDao{}
+}
+
+type ssoDao struct {
+}
+
+type SsoDaoAssumer interface {
+	InsertState(input dto.SsoState) resterr.APIError
+	UseState(stateHash string, nowUnix int64) (*dto.SsoState, resterr.APIError)
+	DeleteExpiredState(nowUnix int64) (int64, resterr.APIError)
+}
+
+func (s *ssoDao) InsertState(input dto.SsoState) resterr.APIError {
+	coll := db.DB.Collection(keySsoStateColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	if _, err := coll.InsertOne(ctx, input); err != nil {
+		logger.Error("Gagal menyimpan state sso (InsertState)", err)
+		return resterr.NewInternalServerError("Gagal menyimpan state sso", err)
+	}
+
+	return nil
+}
+
+// UseState mengambil sekaligus menghapus state secara atomik sehingga state hanya dapat digunakan sekali
+func (s *ssoDao) UseState(stateHash string, nowUnix int64) (*dto.SsoState, resterr.APIError) {
+	coll := db.DB.Collection(keySsoStateColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keySsoStateID:  stateHash,
+		keySsoStateExp: bson.M{"$gt": nowUnix},
+	}
+
+	var state dto.SsoState
+	if err := coll.FindOneAndDelete(ctx, filter).Decode(&state); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewBadRequestError("State sso tidak valid atau sudah kadaluarsa, silahkan login ulang")
+		}
+
+		logger.Error("Gagal menggunakan state sso (UseState)", err)
+		return nil, resterr.NewInternalServerError("Gagal menggunakan state sso", err)
+	}
+
+	return &state, nil
+}
+
+// DeleteExpiredState menghapus state login sso yang tidak pernah diselesaikan
+func (s *ssoDao) DeleteExpiredState(nowUnix int64) (int64, resterr.APIError) {
+	coll := db.DB.Collection(keySsoStateColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	result, err := coll.DeleteMany(ctx, bson.M{keySsoStateExp: bson.M{"$lte": nowUnix}})
+	if err != nil {
+		logger.Error("Gagal menghapus state sso kadaluarsa (DeleteExpiredState)", err)
+		return 0, resterr.NewInternalServerError("Gagal menghapus state sso", err)
+	}
+
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,301 @@
+package tokendao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"tilank/db"
+	"tilank/dto"
+	"tilank/utils/logger"
+	"tilank/utils/rest_err"
+	"time"
+)
+
+const (
+	connectTimeout           = 3
+	keyRevokedTokenColl      = "revoked_token"
+	keyRevokedUserColl       = "revoked_user"
+	keyRevokedID             = "_id"
+	keyRevokedTokenExp       = "exp"
+	keyRevokedUserNotBefore  = "not_before"
+	keyRevokedTokenIdentity  = "identity"
+	keyRevokedTokenRevokedAt = "revoked_at"
+
+	keyFamilyColl           = "token_family"
+	keyFamilyID             = "_id"
+	keyFamilyCurrentTokenID = "current_token_id"
+	keyFamilyUpdatedAt      = "updated_at"
+	keyFamilyExp            = "exp"
+	keyFamilyRevoked        = "revoked"
+	keyFamilyRevokedAt      = "revoked_at"
+)
+
+func NewTokenDao() TokenDaoAssumer {
+	return &tokenDao{}
+}
+
+type tokenDao struct {
+}
+
+type TokenDaoAssumer interface {
+	RevokeToken(input dto.RevokedToken) resterr.APIError
+	RevokeUser(input dto.RevokedUser) resterr.APIError
+
+	FindRevokedToken(nowUnix int64) ([]dto.RevokedToken, resterr.APIError)
+	FindRevokedUser(afterUnix int64) ([]dto.RevokedUser, resterr.APIError)
+	DeleteExpired(tokenExpBefore int64, userNotBefore int64) (int64, resterr.APIError)
+
+	InsertFamily(input dto.TokenFamily) resterr.APIError
+	RotateFamily(familyID string, oldTokenID string, newTokenID string, exp int64) (*dto.TokenFamily, resterr.APIError)
+	RevokeFamily(familyID string) (*dto.TokenFamily, resterr.APIError)
+	GetFamilyByID(familyID string) (*dto.TokenFamily, resterr.APIError)
+	FindRevokedFamily(nowUnix int64) ([]dto.TokenFamily, resterr.APIError)
+}
+
+// RevokeToken menyimpan jti yang dicabut, jti yang sama akan ditimpa
+func (t *tokenDao) RevokeToken(input dto.RevokedToken) resterr.APIError {
+	coll := db.DB.Collection(keyRevokedTokenColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	input.Identity = strings.ToUpper(input.Identity)
+
+	opts := options.Update()
+	opts.SetUpsert(true)
+
+	filter := bson.M{keyRevokedID: input.ID}
+	update := bson.M{
+		"$set": bson.M{
+			keyRevokedTokenIdentity:  input.Identity,
+			keyRevokedTokenRevokedAt: input.RevokedAt,
+			keyRevokedTokenExp:       input.Exp,
+		},
+	}
+
+	if _, err := coll.UpdateOne(ctx, filter, update, opts); err != nil {
+		logger.Error("Gagal menyimpan token yang dicabut ke database (RevokeToken)", err)
+		return resterr.NewInternalServerError("Gagal mencabut token", err)
+	}
+
+	return nil
+}
+
+// RevokeUser menyimpan batas waktu penerbitan token untuk user tertentu
+func (t *tokenDao) RevokeUser(input dto.RevokedUser) resterr.APIError {
+	coll := db.DB.Collection(keyRevokedUserColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.Update()
+	opts.SetUpsert(true)
+
+	filter := bson.M{keyRevokedID: strings.ToUpper(input.ID)}
+	update := bson.M{
+		"$set": bson.M{
+			keyRevokedUserNotBefore: input.NotBefore,
+		},
+	}
+
+	if _, err := coll.UpdateOne(ctx, filter, update, opts); err != nil {
+		logger.Error("Gagal menyimpan user yang dicabut tokennya ke database (RevokeUser)", err)
+		return resterr.NewInternalServerError("Gagal mencabut token user", err)
+	}
+
+	return nil
+}
+
+// FindRevokedToken mendapatkan jti yang dicabut dan belum kadaluarsa
+func (t *tokenDao) FindRevokedToken(nowUnix int64) ([]dto.RevokedToken, resterr.APIError) {
+	coll := db.DB.Collection(keyRevokedTokenColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	cursor, err := coll.Find(ctx, bson.M{keyRevokedTokenExp: bson.M{"$gte": nowUnix}})
+	if err != nil {
+		logger.Error("Gagal mendapatkan token yang dicabut dari database (FindRevokedToken)", err)
+		return []dto.RevokedToken{}, resterr.NewInternalServerError("Database error", err)
+	}
+
+	tokenList := []dto.RevokedToken{}
+	if err = cursor.All(ctx, &tokenList); err != nil {
+		logger.Error("Gagal decode tokenList cursor ke objek slice (FindRevokedToken)", err)
+		return []dto.RevokedToken{}, resterr.NewInternalServerError("Database error", err)
+	}
+
+	return tokenList, nil
+}
+
+// FindRevokedUser mendapatkan user dengan batas penerbitan token setelah afterUnix
+func (t *tokenDao) FindRevokedUser(afterUnix int64) ([]dto.RevokedUser, resterr.APIError) {
+	coll := db.DB.Collection(keyRevokedUserColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	cursor, err := coll.Find(ctx, bson.M{keyRevokedUserNotBefore: bson.M{"$gte": afterUnix}})
+	if err != nil {
+		logger.Error("Gagal mendapatkan user yang dicabut tokennya dari database (FindRevokedUser)", err)
+		return []dto.RevokedUser{}, resterr.NewInternalServerError("Database error", err)
+	}
+
+	userList := []dto.RevokedUser{}
+	if err = cursor.All(ctx, &userList); err != nil {
+		logger.Error("Gagal decode userList cursor ke objek slice (FindRevokedUser)", err)
+		return []dto.RevokedUser{}, resterr.NewInternalServerError("Database error", err)
+	}
+
+	return userList, nil
+}
+
+// DeleteExpired menghapus data pencabutan yang sudah tidak diperlukan karena token terkait pasti sudah kadaluarsa
+func (t *tokenDao) DeleteExpired(tokenExpBefore int64, userNotBefore int64) (int64, resterr.APIError) {
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	resultToken, err := db.DB.Collection(keyRevokedTokenColl).DeleteMany(ctx, bson.M{keyRevokedTokenExp: bson.M{"$lt": tokenExpBefore}})
+	if err != nil {
+		logger.Error("Gagal menghapus token yang dicabut (DeleteExpired)", err)
+		return 0, resterr.NewInternalServerError("Database error", err)
+	}
+
+	resultUser, err := db.DB.Collection(keyRevokedUserColl).DeleteMany(ctx, bson.M{keyRevokedUserNotBefore: bson.M{"$lt": userNotBefore}})
+	if err != nil {
+		logger.Error("Gagal menghapus user yang dicabut (DeleteExpired)", err)
+		return 0, resterr.NewInternalServerError("Database error", err)
+	}
+
+	resultFamily, err := db.DB.Collection(keyFamilyColl).DeleteMany(ctx, bson.M{keyFamilyExp: bson.M{"$lt": tokenExpBefore}})
+	if err != nil {
+		logger.Error("Gagal menghapus token family (DeleteExpired)", err)
+		return 0, resterr.NewInternalServerError("Database error", err)
+	}
+
+	return resultToken.DeletedCount + resultUser.DeletedCount + resultFamily.DeletedCount, nil
+}
+
+func (t *tokenDao) InsertFamily(input dto.TokenFamily) resterr.APIError {
+	coll := db.DB.Collection(keyFamilyColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	input.Identity = strings.ToUpper(input.Identity)
+
+	if _, err := coll.InsertOne(ctx, input); err != nil {
+		logger.Error("Gagal menyimpan token family ke database (InsertFamily)", err)
+		return resterr.NewInternalServerError("Gagal menyimpan sesi login", err)
+	}
+
+	return nil
+}
+
+// RotateFamily mengganti jti refresh token aktif secara atomik, hanya berhasil jika
+// oldTokenID masih merupakan token terakhir dan family belum dicabut.
+// mengembalikan not found jika syarat tersebut tidak terpenuhi
+func (t *tokenDao) RotateFamily(familyID string, oldTokenID string, newTokenID string, exp int64) (*dto.TokenFamily, resterr.APIError) {
+	coll := db.DB.Collection(keyFamilyColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate()
+	opts.SetReturnDocument(1)
+
+	filter := bson.M{
+		keyFamilyID:             familyID,
+		keyFamilyCurrentTokenID: oldTokenID,
+		keyFamilyRevoked:        false,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyFamilyCurrentTokenID: newTokenID,
+			keyFamilyUpdatedAt:      time.Now().Unix(),
+			keyFamilyExp:            exp,
+		},
+	}
+
+	var family dto.TokenFamily
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&family); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewNotFoundError(fmt.Sprintf("Token family %s dengan token tersebut tidak ditemukan", familyID))
+		}
+
+		logger.Error("Gagal merotasi token family (RotateFamily)", err)
+		return nil, resterr.NewInternalServerError("Gagal merotasi refresh token", err)
+	}
+
+	return &family, nil
+}
+
+func (t *tokenDao) RevokeFamily(familyID string) (*dto.TokenFamily, resterr.APIError) {
+	coll := db.DB.Collection(keyFamilyColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate()
+	opts.SetReturnDocument(1)
+
+	update := bson.M{
+		"$set": bson.M{
+			keyFamilyRevoked:   true,
+			keyFamilyRevokedAt: time.Now().Unix(),
+		},
+	}
+
+	var family dto.TokenFamily
+	if err := coll.FindOneAndUpdate(ctx, bson.M{keyFamilyID: familyID}, update, opts).Decode(&family); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewNotFoundError(fmt.Sprintf("Token family %s tidak ditemukan", familyID))
+		}
+
+		logger.Error("Gagal mencabut token family (RevokeFamily)", err)
+		return nil, resterr.NewInternalServerError("Gagal mencabut sesi login", err)
+	}
+
+	return &family, nil
+}
+
+func (t *tokenDao) GetFamilyByID(familyID string) (*dto.TokenFamily, resterr.APIError) {
+	coll := db.DB.Collection(keyFamilyColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	var family dto.TokenFamily
+	if err := coll.FindOne(ctx, bson.M{keyFamilyID: familyID}).Decode(&family); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewNotFoundError(fmt.Sprintf("Token family %s tidak ditemukan", familyID))
+		}
+
+		logger.Error("Gagal mendapatkan token family (GetFamilyByID)", err)
+		return nil, resterr.NewInternalServerError("Gagal mendapatkan sesi login", err)
+	}
+
+	return &family, nil
+}
+
+// FindRevokedFamily mendapatkan token family yang dicabut dan belum kadaluarsa
+func (t *tokenDao) FindRevokedFamily(nowUnix int64) ([]dto.TokenFamily, resterr.APIError) {
+	coll := db.DB.Collection(keyFamilyColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyFamilyRevoked: true,
+		keyFamilyExp:     bson.M{"$gte": nowUnix},
+	}
+
+	cursor, err := coll.Find(ctx, filter)
+	if err != nil {
+		logger.Error("Gagal mendapatkan token family yang dicabut (FindRevokedFamily)", err)
+		return []dto.TokenFamily{}, resterr.NewInternalServerError("Database error", err)
+	}
+
+	familyList := []dto.TokenFamily{}
+	if err = cursor.All(ctx, &familyList); err != nil {
+		logger.Error("Gagal decode familyList cursor ke objek slice (FindRevokedFamily)", err)
+		return []dto.TokenFamily{}, resterr.NewInternalServerError("Database error", err)
+	}
+
+	return familyList, nil
+}
@@ -29,6 +29,9 @@ const (
 	keyNoPol            = "no_pol"
 	keyMark             = "mark"
 	keyOwner            = "owner"
+	keyOwnerID          = "owner_id"
+	keyJptID            = "jpt_id"
+	keyJptName          = "jpt_name"
 	keyEmail            = "email"
 	keyHp               = "hp"
 	keyDeleted          = "deleted"
@@ -37,6 +40,13 @@ const (
 	keyBlocked          = "blocked"
 	keyBlockStart       = "block_start"
 	keyBlockEnd         = "block_end"
+	keyBlockPermanent   = "block_permanent"
+	keyBlockReason      = "block_reason"
+	keyBlockReminderAt  = "block_reminder_at"
+
+	keyTruckHistoryCollection = "truck_history"
+	keyTruckHistoryTruckID    = "truck_id"
+	keyTruckHistoryTime       = "time"
 )
 
 func NewTruckDao() TruckDaoAssumer {
@@ -51,11 +61,22 @@ type TruckDaoAssumer interface {
 	EditTruck(input dto.TruckEdit) (*dto.Truck, resterr.APIError)
 	DeleteTruck(input dto.FilterIDBranch, isSoftDelete bool) (*dto.Truck, resterr.APIError)
 	ChangeScore(input dto.TruckScoreEdit) (*dto.Truck, resterr.APIError)
+	ChangeBlock(input dto.TruckBlockEdit) (*dto.Truck, resterr.APIError)
+	SetBlockReminder(truckID primitive.ObjectID, remindedAt int64) resterr.APIError
 
 	GetTruckByID(truckID primitive.ObjectID, branchIfSpecific string) (*dto.Truck, resterr.APIError)
 	GetTruckByIdentity(noIdentity string, branch string) (*dto.Truck, resterr.APIError)
 	FindTruck(filter dto.FilterTruck) (dto.TruckResponseMinList, resterr.APIError)
 	ResetTruckBlock(trucksID []primitive.ObjectID) (int64, resterr.APIError)
+
+	FindTruckUnlinkedOwner() (dto.TruckResponseMinList, resterr.APIError)
+	SetOwner(trucksID []primitive.ObjectID, ownerID string, ownerName string) (int64, resterr.APIError)
+	UpdateOwnerName(ownerID string, ownerName string) (int64, resterr.APIError)
+	UpdateJptName(jptID string, jptName string) (int64, resterr.APIError)
+	CountActiveTruckByJpt() (map[string]int64, resterr.APIError)
+
+	InsertHistory(input dto.TruckHistory) resterr.APIError
+	FindHistory(truckID string, limit int64) (dto.TruckHistoryList, resterr.APIError)
 }
 
 func (c *truckDao) InsertTruck(input dto.Truck) (*string, resterr.APIError) {
@@ -66,6 +87,7 @@ func (c *truckDao) InsertTruck(input dto.Truck) (*string, resterr.APIError) {
 	input.NoIdentity = strings.ReplaceAll(strings.ToUpper(input.NoIdentity), " ", "")
 	input.NoPol = strings.ReplaceAll(strings.ToUpper(input.NoPol), " ", "")
 	input.Owner = strings.ToUpper(input.Owner)
+	input.JptName = strings.ToUpper(input.JptName)
 	input.Branch = strings.ToUpper(input.Branch)
 	input.Email = strings.ToLower(input.Email)
 
@@ -90,6 +112,7 @@ func (c *truckDao) EditTruck(input dto.TruckEdit) (*dto.Truck, resterr.APIError)
 	input.NoPol = strings.ReplaceAll(strings.ToUpper(input.NoPol), " ", "")
 	input.FilterBranch = strings.ToUpper(input.FilterBranch)
 	input.Owner = strings.ToUpper(input.Owner)
+	input.JptName = strings.ToUpper(input.JptName)
 	input.Email = strings.ToLower(input.Email)
 
 	opts := options.FindOneAndUpdate()
@@ -109,7 +132,10 @@ func (c *truckDao) EditTruck(input dto.TruckEdit) (*dto.Truck, resterr.APIError)
 			keyNoIdentity:       input.NoIdentity,
 			keyNoPol:            input.NoPol,
 			keyMark:             input.Mark,
+			keyOwnerID:          input.OwnerID,
 			keyOwner:            input.Owner,
+			keyJptID:            input.JptID,
+			keyJptName:          input.JptName,
 			keyEmail:            input.Email,
 			keyHp:               input.Hp,
 		},
@@ -148,6 +174,7 @@ func (c *truckDao) ChangeScore(input dto.TruckScoreEdit) (*dto.Truck, resterr.AP
 			keyBlocked:        input.Blocked,
 			keyBlockStart:     input.BlockStart,
 			keyBlockEnd:       input.BlockEnd,
+			keyBlockReason:    input.BlockReason,
 		},
 	}
 
@@ -165,6 +192,70 @@ func (c *truckDao) ChangeScore(input dto.TruckScoreEdit) (*dto.Truck, resterr.AP
 	return &truck, nil
 }
 
+// ChangeBlock menerapkan atau mencabut blokir manual, pencabutan blokir juga mengisi reset_score_date
+func (c *truckDao) ChangeBlock(input dto.TruckBlockEdit) (*dto.Truck, resterr.APIError) {
+	coll := db.DB.Collection(keyTruckCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate()
+	opts.SetReturnDocument(1)
+
+	filter := bson.M{
+		keyTruckID:     input.ID,
+		keyTruckBranch: strings.ToUpper(input.FilterBranch),
+		keyDeleted:     false,
+	}
+
+	set := bson.M{
+		keyBlocked:        input.Blocked,
+		keyBlockStart:     input.BlockStart,
+		keyBlockEnd:       input.BlockEnd,
+		keyBlockPermanent: input.BlockPermanent,
+		keyBlockReason:    input.BlockReason,
+	}
+	if !input.Blocked {
+		set[keyResetScoreDate] = time.Now().Unix()
+	}
+	update := bson.M{"$set": set}
+
+	var truck dto.Truck
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&truck); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewBadRequestError("blokir truck tidak diupdate : validasi id branch status")
+		}
+
+		logger.Error("Gagal mengupdate blokir truck dari database (ChangeBlock)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mengupdate truck dari database", err)
+		return nil, apiErr
+	}
+
+	return &truck, nil
+}
+
+// SetBlockReminder menandai pengingat blokir berakhir sudah dikirim untuk periode blokir saat ini
+func (c *truckDao) SetBlockReminder(truckID primitive.ObjectID, remindedAt int64) resterr.APIError {
+	coll := db.DB.Collection(keyTruckCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyTruckID: truckID,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyBlockReminderAt: remindedAt,
+		},
+	}
+
+	if _, err := coll.UpdateOne(ctx, filter, update); err != nil {
+		logger.Error("Gagal mengupdate pengingat blokir truck dari database (SetBlockReminder)", err)
+		return resterr.NewInternalServerError("Gagal mengupdate truck dari database", err)
+	}
+
+	return nil
+}
+
 func (c *truckDao) DeleteTruck(input dto.FilterIDBranch, isSoftDelete bool) (*dto.Truck, resterr.APIError) {
 	coll := db.DB.Collection(keyTruckCollection)
 	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
@@ -278,6 +369,12 @@ func (c *truckDao) FindTruck(filterA dto.FilterTruck) (dto.TruckResponseMinList,
 			"$regex": fmt.Sprintf(".*%s", filterA.FilterOwner),
 		}
 	}
+	if filterA.FilterOwnerID != "" {
+		filter[keyOwnerID] = filterA.FilterOwnerID
+	}
+	if filterA.FilterJptID != "" {
+		filter[keyJptID] = filterA.FilterJptID
+	}
 	if filterA.Blocked {
 		filter[keyBlocked] = true
 	}
@@ -309,9 +406,10 @@ func (c *truckDao) ResetTruckBlock(trucksID []primitive.ObjectID) (int64, rester
 
 	nowUnix := time.Now().Unix()
 
-	// Filter
+	// Filter, truck daftar hitam tidak pernah direset otomatis
 	filter := bson.M{
-		keyTruckID: bson.M{"$in": trucksID},
+		keyTruckID:        bson.M{"$in": trucksID},
+		keyBlockPermanent: bson.M{"$ne": true},
 	}
 
 	update := bson.M{
@@ -320,6 +418,7 @@ func (c *truckDao) ResetTruckBlock(trucksID []primitive.ObjectID) (int64, rester
 			keyBlocked:        false,
 			keyBlockStart:     0,
 			keyBlockEnd:       0,
+			keyBlockReason:    "",
 		},
 	}
 
@@ -335,3 +434,199 @@ func (c *truckDao) ResetTruckBlock(trucksID []primitive.ObjectID) (int64, rester
 
 	return result.ModifiedCount, nil
 }
+
+// FindTruckUnlinkedOwner mendapatkan seluruh truck (termasuk yang dihapus) yang belum terhubung ke perusahaan
+func (c *truckDao) FindTruckUnlinkedOwner() (dto.TruckResponseMinList, resterr.APIError) {
+	coll := db.DB.Collection(keyTruckCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyOwnerID: bson.M{"$in": bson.A{"", nil}},
+	}
+
+	cursor, err := coll.Find(ctx, filter)
+	if err != nil {
+		logger.Error("Gagal mendapatkan daftar truck dari database (FindTruckUnlinkedOwner)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.TruckResponseMinList{}, apiErr
+	}
+
+	truckList := dto.TruckResponseMinList{}
+	if err = cursor.All(ctx, &truckList); err != nil {
+		logger.Error("Gagal decode truckList cursor ke objek slice (FindTruckUnlinkedOwner)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.TruckResponseMinList{}, apiErr
+	}
+
+	return truckList, nil
+}
+
+// SetOwner menghubungkan truck ke perusahaan
+func (c *truckDao) SetOwner(trucksID []primitive.ObjectID, ownerID string, ownerName string) (int64, resterr.APIError) {
+	coll := db.DB.Collection(keyTruckCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyTruckID: bson.M{"$in": trucksID},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyOwnerID: ownerID,
+			keyOwner:   strings.ToUpper(ownerName),
+		},
+	}
+
+	result, err := coll.UpdateMany(ctx, filter, update)
+	if err != nil {
+		logger.Error("Gagal mengupdate owner truck dari database (SetOwner)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mengupdate truck dari database", err)
+		return 0, apiErr
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// UpdateOwnerName menyamakan nama owner seluruh truck milik perusahaan setelah nama perusahaan diubah
+func (c *truckDao) UpdateOwnerName(ownerID string, ownerName string) (int64, resterr.APIError) {
+	coll := db.DB.Collection(keyTruckCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyOwnerID: ownerID,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyOwner: strings.ToUpper(ownerName),
+		},
+	}
+
+	result, err := coll.UpdateMany(ctx, filter, update)
+	if err != nil {
+		logger.Error("Gagal mengupdate nama owner truck dari database (UpdateOwnerName)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mengupdate truck dari database", err)
+		return 0, apiErr
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// UpdateJptName menyamakan nama JPT seluruh truck yang terhubung setelah nama JPT diubah
+func (c *truckDao) UpdateJptName(jptID string, jptName string) (int64, resterr.APIError) {
+	coll := db.DB.Collection(keyTruckCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyJptID: jptID,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyJptName: strings.ToUpper(jptName),
+		},
+	}
+
+	result, err := coll.UpdateMany(ctx, filter, update)
+	if err != nil {
+		logger.Error("Gagal mengupdate nama jpt truck dari database (UpdateJptName)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mengupdate truck dari database", err)
+		return 0, apiErr
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// CountActiveTruckByJpt menghitung truck aktif per JPT, key map berupa jpt_id
+func (c *truckDao) CountActiveTruckByJpt() (map[string]int64, resterr.APIError) {
+	coll := db.DB.Collection(keyTruckCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{
+			keyJptID:   bson.M{"$nin": bson.A{"", nil}},
+			keyDeleted: false,
+		}},
+		bson.M{"$group": bson.M{
+			"_id":   "$" + keyJptID,
+			"count": bson.M{"$sum": 1},
+		}},
+	}
+
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.Error("Gagal menghitung truck dari database (CountActiveTruckByJpt)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return nil, apiErr
+	}
+
+	var counts []struct {
+		JptID string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	if err = cursor.All(ctx, &counts); err != nil {
+		logger.Error("Gagal decode hasil hitung truck (CountActiveTruckByJpt)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return nil, apiErr
+	}
+
+	result := make(map[string]int64, len(counts))
+	for _, count := range counts {
+		result[count.JptID] = count.Count
+	}
+
+	return result, nil
+}
+
+func (c *truckDao) InsertHistory(input dto.TruckHistory) resterr.APIError {
+	coll := db.DB.Collection(keyTruckHistoryCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	input.Branch = strings.ToUpper(input.Branch)
+
+	if _, err := coll.InsertOne(ctx, input); err != nil {
+		logger.Error("Gagal menyimpan riwayat truck ke database, (InsertHistory)", err)
+		return resterr.NewInternalServerError("Gagal menyimpan riwayat truck ke database", err)
+	}
+
+	return nil
+}
+
+// FindHistory menampilkan riwayat truck terbaru, diurutkan dari yang terlama
+func (c *truckDao) FindHistory(truckID string, limit int64) (dto.TruckHistoryList, resterr.APIError) {
+	coll := db.DB.Collection(keyTruckHistoryCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyTruckHistoryTruckID: truckID,
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: keyTruckHistoryTime, Value: -1}})
+	opts.SetLimit(limit)
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		logger.Error("Gagal mendapatkan riwayat truck dari database (FindHistory)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.TruckHistoryList{}, apiErr
+	}
+
+	historyList := dto.TruckHistoryList{}
+	if err = cursor.All(ctx, &historyList); err != nil {
+		logger.Error("Gagal decode historyList cursor ke objek slice (FindHistory)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.TruckHistoryList{}, apiErr
+	}
+
+	// urutkan kembali dari yang terlama
+	for i, k := 0, len(historyList)-1; i < k; i, k = i+1, k-1 {
+		historyList[i], historyList[k] = historyList[k], historyList[i]
+	}
+
+	return historyList, nil
+}
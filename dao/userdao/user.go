@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"net/http"
+	"regexp"
 	"strings"
 	"tilank/config"
 	"tilank/db"
@@ -28,9 +30,31 @@ const (
 	keyUserName      = "name"
 	keyUserRoles     = "roles"
 	keyUserBranch    = "branch"
+	keyUserBranches  = "branches"
 	keyUserAvatar    = "avatar"
 	keyUserFcmToken  = "fcm_token"
 	keyUserTimeStamp = "timestamp"
+
+	keyUserFailedLogin     = "failed_login"
+	keyUserLastFailedLogin = "last_failed_login"
+	keyUserLockedUntil     = "locked_until"
+
+	keyUserTwoFactorEnabled       = "two_factor_enabled"
+	keyUserTwoFactorSecret        = "two_factor_secret"
+	keyUserTwoFactorPendingSecret = "two_factor_pending_secret"
+	keyUserTwoFactorRecovery      = "two_factor_recovery"
+	keyUserTwoFactorLastStep      = "two_factor_last_step"
+
+	keyUserPasswordHistory    = "password_history"
+	keyUserPasswordChangedAt  = "password_changed_at"
+	keyUserMustChangePassword = "must_change_password"
+
+	keyUserDeactivated   = "deactivated"
+	keyUserDeactivatedAt = "deactivated_at"
+	keyUserDeactivatedBy = "deactivated_by"
+
+	keyUserSsoSubject   = "sso_subject"
+	keyUserLastSsoLogin = "last_sso_login"
 )
 
 func NewUserDao() UserDaoAssumer {
@@ -52,17 +76,21 @@ func (u *userDao) InsertUser(user dto.UserRequest) (*string, resterr.APIError) {
 	if user.Roles == nil {
 		user.Roles = []string{}
 	}
+	user.Branches = upperBranches(user.Branches)
 
-	//nolint:govet
 	insertDoc := bson.D{
-		{keyUserID, user.ID},
-		{keyUserName, user.Name},
-		{keyUserEmail, user.Email},
-		{keyUserRoles, user.Roles},
-		{keyUserBranch, user.Branch},
-		{keyUserAvatar, user.Avatar},
-		{keyUserHashPw, user.Password},
-		{keyUserTimeStamp, user.Timestamp},
+		{Key: keyUserID, Value: user.ID},
+		{Key: keyUserName, Value: user.Name},
+		{Key: keyUserEmail, Value: user.Email},
+		{Key: keyUserRoles, Value: user.Roles},
+		{Key: keyUserBranch, Value: user.Branch},
+		{Key: keyUserBranches, Value: user.Branches},
+		{Key: keyUserAvatar, Value: user.Avatar},
+		{Key: keyUserHashPw, Value: user.Password},
+		{Key: keyUserPasswordHistory, Value: []string{}},
+		{Key: keyUserPasswordChangedAt, Value: user.Timestamp},
+		{Key: keyUserMustChangePassword, Value: false},
+		{Key: keyUserTimeStamp, Value: user.Timestamp},
 	}
 
 	result, err := coll.InsertOne(ctx, insertDoc)
@@ -89,6 +117,7 @@ func (u *userDao) EditUser(userID string, userRequest dto.UserEditRequest) (*dto
 	if userRequest.Roles == nil {
 		userRequest.Roles = []string{}
 	}
+	userRequest.Branches = upperBranches(userRequest.Branches)
 
 	opts := options.FindOneAndUpdate()
 	opts.SetReturnDocument(1)
@@ -102,6 +131,7 @@ func (u *userDao) EditUser(userID string, userRequest dto.UserEditRequest) (*dto
 			keyUserName:      userRequest.Name,
 			keyUserRoles:     userRequest.Roles,
 			keyUserBranch:    userRequest.Branch,
+			keyUserBranches:  userRequest.Branches,
 			keyUserTimeStamp: time.Now().Unix(),
 		},
 	}
@@ -153,32 +183,48 @@ func (u *userDao) EditFcm(userID string, fcmToken string) (*dto.UserResponse, re
 	return &user, nil
 }
 
-// DeleteUser menghapus user
-func (u *userDao) DeleteUser(userID string) resterr.APIError {
+// SetUserActive menonaktifkan atau mengaktifkan kembali user tanpa menghapus dokumen
+// agar referensi user pada data lain (pembuat, penyetuju) tetap utuh
+func (u *userDao) SetUserActive(userID string, active bool, actor string) (*dto.UserResponse, resterr.APIError) {
 	coll := db.DB.Collection(keyUserColl)
 	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
 	defer cancel()
 
+	opts := options.FindOneAndUpdate()
+	opts.SetReturnDocument(1)
+	opts.SetProjection(bson.M{keyUserHashPw: 0, keyUserPasswordHistory: 0})
+
 	filter := bson.M{
 		keyUserID: strings.ToUpper(userID),
 	}
 
-	result, err := coll.DeleteOne(ctx, filter)
-	if err != nil {
+	set := bson.M{
+		keyUserDeactivated:   true,
+		keyUserDeactivatedAt: time.Now().Unix(),
+		keyUserDeactivatedBy: actor,
+		keyUserTimeStamp:     time.Now().Unix(),
+	}
+	if active {
+		set[keyUserDeactivated] = false
+		set[keyUserDeactivatedAt] = int64(0)
+		set[keyUserDeactivatedBy] = ""
+	}
+	update := bson.M{
+		"$set": set,
+	}
+
+	var user dto.UserResponse
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return resterr.NewBadRequestError("User gagal dihapus, dokumen tidak ditemukan")
+			return nil, resterr.NewNotFoundError(fmt.Sprintf("User dengan ID %s tidak ditemukan", userID))
 		}
 
-		logger.Error("Gagal menghapus user dari database", err)
-		apiErr := resterr.NewInternalServerError("Gagal mendapatkan user dari database", err)
-		return apiErr
-	}
-
-	if result.DeletedCount == 0 {
-		return resterr.NewBadRequestError("User gagal dihapus, dokumen tidak ditemukan")
+		logger.Error("Gagal mengubah status aktif user (SetUserActive)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mengubah status aktif user", err)
+		return nil, apiErr
 	}
 
-	return nil
+	return &user, nil
 }
 
 // PutAvatar hanya mengubah avatar berdasarkan filter email
@@ -214,8 +260,8 @@ func (u *userDao) PutAvatar(userID string, avatar string) (*dto.UserResponse, re
 	return &user, nil
 }
 
-// ChangePassword merubah hash_pw dengan password baru sesuai masukan
-func (u *userDao) ChangePassword(data dto.UserChangePasswordRequest) resterr.APIError {
+// ChangePassword merubah hash_pw dengan password baru beserta riwayat password dan flag wajib ganti password
+func (u *userDao) ChangePassword(data dto.UserPasswordChange) resterr.APIError {
 	coll := db.DB.Collection(keyUserColl)
 	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
 	defer cancel()
@@ -226,8 +272,11 @@ func (u *userDao) ChangePassword(data dto.UserChangePasswordRequest) resterr.API
 
 	update := bson.M{
 		"$set": bson.M{
-			keyUserHashPw:    data.NewPassword,
-			keyUserTimeStamp: time.Now().Unix(),
+			keyUserHashPw:             data.HashPw,
+			keyUserPasswordHistory:    data.History,
+			keyUserPasswordChangedAt:  data.ChangedAt,
+			keyUserMustChangePassword: data.MustChange,
+			keyUserTimeStamp:          time.Now().Unix(),
 		},
 	}
 
@@ -249,6 +298,320 @@ func (u *userDao) ChangePassword(data dto.UserChangePasswordRequest) resterr.API
 	return nil
 }
 
+// UpdatePasswordHash mengganti format hash password tanpa mengubah password, riwayat maupun waktu penggantian.
+// update hanya dilakukan jika hash belum berubah sejak dibaca
+func (u *userDao) UpdatePasswordHash(userID string, oldHash string, newHash string) resterr.APIError {
+	coll := db.DB.Collection(keyUserColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyUserID:     strings.ToUpper(userID),
+		keyUserHashPw: oldHash,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyUserHashPw: newHash,
+		},
+	}
+
+	if _, err := coll.UpdateOne(ctx, filter, update); err != nil {
+		logger.Error("Gagal memperbarui hash password (UpdatePasswordHash)", err)
+		return resterr.NewInternalServerError("Gagal memperbarui hash password", err)
+	}
+
+	return nil
+}
+
+// SyncSsoUser menyimpan subject identity provider dan menyinkronkan role serta cabang dari claim
+func (u *userDao) SyncSsoUser(userID string, data dto.UserSsoSync) (*dto.User, resterr.APIError) {
+	coll := db.DB.Collection(keyUserColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate()
+	opts.SetReturnDocument(1)
+
+	filter := bson.M{
+		keyUserID: strings.ToUpper(userID),
+	}
+
+	set := bson.M{
+		keyUserSsoSubject:   data.Subject,
+		keyUserLastSsoLogin: time.Now().Unix(),
+	}
+	if data.Roles != nil {
+		set[keyUserRoles] = data.Roles
+	}
+	if data.Branch != nil {
+		set[keyUserBranch] = strings.ToUpper(*data.Branch)
+	}
+	if data.Branches != nil {
+		set[keyUserBranches] = upperBranches(data.Branches)
+	}
+	update := bson.M{
+		"$set": set,
+	}
+
+	var user dto.User
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewNotFoundError(fmt.Sprintf("User dengan ID %s tidak ditemukan", userID))
+		}
+
+		logger.Error("Gagal menyinkronkan user sso (SyncSsoUser)", err)
+		apiErr := resterr.NewInternalServerError("Gagal menyinkronkan user sso", err)
+		return nil, apiErr
+	}
+
+	return &user, nil
+}
+
+// IncrementFailedLogin menambah jumlah kegagalan login secara atomik dan mengembalikan user terbaru
+func (u *userDao) IncrementFailedLogin(userID string, nowUnix int64) (*dto.User, resterr.APIError) {
+	coll := db.DB.Collection(keyUserColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate()
+	opts.SetReturnDocument(1)
+
+	filter := bson.M{
+		keyUserID: strings.ToUpper(userID),
+	}
+	update := bson.M{
+		"$inc": bson.M{
+			keyUserFailedLogin: 1,
+		},
+		"$set": bson.M{
+			keyUserLastFailedLogin: nowUnix,
+		},
+	}
+
+	var user dto.User
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewNotFoundError(fmt.Sprintf("User dengan ID %s tidak ditemukan", userID))
+		}
+
+		logger.Error("Gagal menyimpan kegagalan login ke database (IncrementFailedLogin)", err)
+		apiErr := resterr.NewInternalServerError("Gagal menyimpan kegagalan login", err)
+		return nil, apiErr
+	}
+
+	return &user, nil
+}
+
+// LockUser mengunci user hingga lockedUntil dan mereset jumlah kegagalan login
+// agar penundaan bertahap dimulai dari awal setelah kunci berakhir
+func (u *userDao) LockUser(userID string, lockedUntil int64) resterr.APIError {
+	coll := db.DB.Collection(keyUserColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyUserID: strings.ToUpper(userID),
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyUserFailedLogin: 0,
+			keyUserLockedUntil: lockedUntil,
+		},
+	}
+
+	if _, err := coll.UpdateOne(ctx, filter, update); err != nil {
+		logger.Error("Gagal mengunci user (LockUser)", err)
+		return resterr.NewInternalServerError("Gagal mengunci user", err)
+	}
+
+	return nil
+}
+
+// ResetLoginAttempt menghapus jumlah kegagalan login dan kunci user
+func (u *userDao) ResetLoginAttempt(userID string) (*dto.UserResponse, resterr.APIError) {
+	coll := db.DB.Collection(keyUserColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate()
+	opts.SetReturnDocument(1)
+	opts.SetProjection(bson.M{keyUserHashPw: 0, keyUserPasswordHistory: 0})
+
+	filter := bson.M{
+		keyUserID: strings.ToUpper(userID),
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyUserFailedLogin:     0,
+			keyUserLastFailedLogin: 0,
+			keyUserLockedUntil:     0,
+		},
+	}
+
+	var user dto.UserResponse
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewNotFoundError(fmt.Sprintf("User dengan ID %s tidak ditemukan", userID))
+		}
+
+		logger.Error("Gagal mereset kegagalan login (ResetLoginAttempt)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mereset kegagalan login", err)
+		return nil, apiErr
+	}
+
+	return &user, nil
+}
+
+// SetTwoFactorPending menyimpan secret TOTP yang belum diaktifkan, secret aktif tidak berubah
+// hingga kode dari secret baru berhasil diverifikasi
+func (u *userDao) SetTwoFactorPending(userID string, secret string) resterr.APIError {
+	coll := db.DB.Collection(keyUserColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyUserID: strings.ToUpper(userID),
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyUserTwoFactorPendingSecret: secret,
+		},
+	}
+
+	result, err := coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.Error("Gagal menyimpan secret two factor (SetTwoFactorPending)", err)
+		return resterr.NewInternalServerError("Gagal menyimpan secret two factor", err)
+	}
+	if result.MatchedCount == 0 {
+		return resterr.NewNotFoundError(fmt.Sprintf("User dengan ID %s tidak ditemukan", userID))
+	}
+
+	return nil
+}
+
+// EnableTwoFactor mengaktifkan secret pending sebagai secret TOTP beserta hash recovery code
+func (u *userDao) EnableTwoFactor(userID string, secret string, recoveryHashes []string, step int64) resterr.APIError {
+	coll := db.DB.Collection(keyUserColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyUserID:                     strings.ToUpper(userID),
+		keyUserTwoFactorPendingSecret: secret,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyUserTwoFactorEnabled:       true,
+			keyUserTwoFactorSecret:        secret,
+			keyUserTwoFactorPendingSecret: "",
+			keyUserTwoFactorRecovery:      recoveryHashes,
+			keyUserTwoFactorLastStep:      step,
+			keyUserTimeStamp:              time.Now().Unix(),
+		},
+	}
+
+	result, err := coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.Error("Gagal mengaktifkan two factor (EnableTwoFactor)", err)
+		return resterr.NewInternalServerError("Gagal mengaktifkan two factor", err)
+	}
+	if result.MatchedCount == 0 {
+		return resterr.NewBadRequestError("Aktivasi two factor gagal, lakukan setup ulang")
+	}
+
+	return nil
+}
+
+// DisableTwoFactor menghapus seluruh data two factor user
+func (u *userDao) DisableTwoFactor(userID string) resterr.APIError {
+	coll := db.DB.Collection(keyUserColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyUserID: strings.ToUpper(userID),
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyUserTwoFactorEnabled:       false,
+			keyUserTwoFactorSecret:        "",
+			keyUserTwoFactorPendingSecret: "",
+			keyUserTwoFactorRecovery:      []string{},
+			keyUserTwoFactorLastStep:      0,
+			keyUserTimeStamp:              time.Now().Unix(),
+		},
+	}
+
+	result, err := coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.Error("Gagal menonaktifkan two factor (DisableTwoFactor)", err)
+		return resterr.NewInternalServerError("Gagal menonaktifkan two factor", err)
+	}
+	if result.MatchedCount == 0 {
+		return resterr.NewNotFoundError(fmt.Sprintf("User dengan ID %s tidak ditemukan", userID))
+	}
+
+	return nil
+}
+
+// UseTwoFactorStep menandai periode TOTP sebagai terpakai, gagal jika periode yang sama
+// atau lebih lama sudah pernah digunakan sehingga kode tidak dapat dipakai ulang
+func (u *userDao) UseTwoFactorStep(userID string, step int64) resterr.APIError {
+	coll := db.DB.Collection(keyUserColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyUserID:                strings.ToUpper(userID),
+		keyUserTwoFactorLastStep: bson.M{"$lt": step},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyUserTwoFactorLastStep: step,
+		},
+	}
+
+	result, err := coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.Error("Gagal menyimpan penggunaan kode two factor (UseTwoFactorStep)", err)
+		return resterr.NewInternalServerError("Gagal menyimpan penggunaan kode two factor", err)
+	}
+	if result.MatchedCount == 0 {
+		return resterr.NewUnauthorizedError("Kode two factor sudah digunakan")
+	}
+
+	return nil
+}
+
+// UseRecoveryCode menghapus hash recovery code yang digunakan, gagal jika hash tidak dimiliki user
+func (u *userDao) UseRecoveryCode(userID string, recoveryHash string) resterr.APIError {
+	coll := db.DB.Collection(keyUserColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyUserID:                strings.ToUpper(userID),
+		keyUserTwoFactorRecovery: recoveryHash,
+	}
+	update := bson.M{
+		"$pull": bson.M{
+			keyUserTwoFactorRecovery: recoveryHash,
+		},
+	}
+
+	result, err := coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.Error("Gagal menggunakan recovery code (UseRecoveryCode)", err)
+		return resterr.NewInternalServerError("Gagal menggunakan recovery code", err)
+	}
+	if result.MatchedCount == 0 {
+		return resterr.NewUnauthorizedError("Kode two factor tidak valid")
+	}
+
+	return nil
+}
+
 // GetUser mendapatkan user dari database berdasarkan userID, jarang digunakan
 // pada case ini biasanya menggunakan email karena user yang digunakan adalah email
 func (u *userDao) GetUserByID(userID string) (*dto.UserResponse, resterr.APIError) {
@@ -258,7 +621,7 @@ func (u *userDao) GetUserByID(userID string) (*dto.UserResponse, resterr.APIErro
 
 	var user dto.UserResponse
 	opts := options.FindOne()
-	opts.SetProjection(bson.M{keyUserHashPw: 0})
+	opts.SetProjection(bson.M{keyUserHashPw: 0, keyUserPasswordHistory: 0})
 
 	if err := coll.FindOne(ctx, bson.M{keyUserID: strings.ToUpper(userID)}, opts).Decode(&user); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
@@ -298,16 +661,119 @@ func (u *userDao) GetUserByIDWithPassword(userID string) (*dto.User, resterr.API
 	return &user, nil
 }
 
-// FindUser mendapatkan daftar semua user dari database
-func (u *userDao) FindUser() (dto.UserResponseList, resterr.APIError) {
+// GetUserByEmail mendapatkan user lengkap berdasarkan email untuk keperluan login sso.
+// email yang terdaftar pada lebih dari satu user ditolak agar pemetaan tidak ambigu
+func (u *userDao) GetUserByEmail(email string) (*dto.User, resterr.APIError) {
+	coll := db.DB.Collection(keyUserColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.Find()
+	opts.SetLimit(2)
+	cursor, err := coll.Find(ctx, bson.M{keyUserEmail: strings.ToLower(strings.TrimSpace(email))}, opts)
+	if err != nil {
+		logger.Error("Gagal mendapatkan user dari database (GetUserByEmail)", err)
+		apiErr := resterr.NewInternalServerError("Error pada database", errors.New("database error"))
+		return nil, apiErr
+	}
+
+	var users []dto.User
+	if err := cursor.All(ctx, &users); err != nil {
+		logger.Error("Gagal decode user cursor ke objek slice (GetUserByEmail)", err)
+		apiErr := resterr.NewInternalServerError("Error pada database", errors.New("database error"))
+		return nil, apiErr
+	}
+
+	switch len(users) {
+	case 0:
+		return nil, resterr.NewUnauthorizedError("Akun sso tidak terdaftar pada aplikasi")
+	case 1:
+		return &users[0], nil
+	}
+	return nil, resterr.NewAPIError("Email terdaftar pada lebih dari satu user, hubungi admin", http.StatusConflict, "conflict", nil)
+}
+
+// FindUser mendapatkan daftar user sesuai filter per halaman beserta jumlah total user
+func (u *userDao) FindUser(filterA dto.FilterUser) (dto.UserResponseList, int64, resterr.APIError) {
+	coll := db.DB.Collection(keyUserColl)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filterA.FilterBranch = strings.ToUpper(filterA.FilterBranch)
+	filterA.FilterRole = strings.ToUpper(filterA.FilterRole)
+	filterA.FilterName = strings.ToUpper(filterA.FilterName)
+
+	// filter, user lama tidak memiliki field deactivated
+	filter := bson.M{
+		keyUserDeactivated: bson.M{"$ne": true},
+	}
+	if !filterA.Active {
+		filter[keyUserDeactivated] = true
+	}
+
+	// filter condition
+	if filterA.FilterBranch != "" {
+		filter[keyUserBranch] = filterA.FilterBranch
+	}
+	if filterA.FilterRole != "" {
+		filter[keyUserRoles] = filterA.FilterRole
+	}
+	if filterA.FilterName != "" {
+		filter[keyUserName] = bson.M{
+			"$regex": fmt.Sprintf(".*%s", regexp.QuoteMeta(filterA.FilterName)),
+		}
+	}
+
+	total, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		logger.Error("Gagal menghitung user dari database (FindUser)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.UserResponseList{}, 0, apiErr
+	}
+
+	users := dto.UserResponseList{}
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: keyUserID, Value: -1}})
+	opts.SetProjection(bson.M{keyUserHashPw: 0, keyUserPasswordHistory: 0})
+	opts.SetSkip((filterA.Page - 1) * filterA.Limit)
+	opts.SetLimit(filterA.Limit)
+	sortCursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		logger.Error("Gagal mendapatkan user dari database", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.UserResponseList{}, 0, apiErr
+	}
+
+	if err = sortCursor.All(ctx, &users); err != nil {
+		logger.Error("Gagal decode usersCursor ke objek slice", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.UserResponseList{}, 0, apiErr
+	}
+
+	return users, total, nil
+}
+
+func (u *userDao) FindUserHSSE(branch string) (dto.UserResponseList, resterr.APIError) {
+	return u.FindUserByRole(branch, config.RoleHSSE)
+}
+
+// FindUserByRole mendapatkan user aktif pada cabang yang memiliki role tertentu
+func (u *userDao) FindUserByRole(branch string, role string) (dto.UserResponseList, resterr.APIError) {
 	coll := db.DB.Collection(keyUserColl)
 	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
 	defer cancel()
 
+	// filter
+	filter := bson.M{
+		keyUserBranch:      strings.ToUpper(branch),
+		keyUserRoles:       strings.ToUpper(role),
+		keyUserDeactivated: bson.M{"$ne": true},
+	}
+
 	users := dto.UserResponseList{}
 	opts := options.Find()
-	opts.SetSort(bson.D{{keyUserID, -1}}) //nolint:govet
-	sortCursor, err := coll.Find(ctx, bson.M{}, opts)
+	opts.SetSort(bson.D{{Key: keyUserID, Value: -1}})
+	sortCursor, err := coll.Find(ctx, filter, opts)
 	if err != nil {
 		logger.Error("Gagal mendapatkan user dari database", err)
 		apiErr := resterr.NewInternalServerError("Database error", err)
@@ -323,21 +789,26 @@ func (u *userDao) FindUser() (dto.UserResponseList, resterr.APIError) {
 	return users, nil
 }
 
-// FindUser mendapatkan daftar semua user dari database
-func (u *userDao) FindUserHSSE(branch string) (dto.UserResponseList, resterr.APIError) {
+// FindUserCoveringBranch mendapatkan user aktif dengan role tertentu yang berada pada cabang
+// atau memiliki cabang tersebut pada field branches (misal user regional)
+func (u *userDao) FindUserCoveringBranch(branch string, role string) (dto.UserResponseList, resterr.APIError) {
 	coll := db.DB.Collection(keyUserColl)
 	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
 	defer cancel()
 
 	// filter
 	filter := bson.M{
-		keyUserBranch: strings.ToUpper(branch),
-		keyUserRoles:  config.RoleHSSE,
+		"$or": bson.A{
+			bson.M{keyUserBranch: strings.ToUpper(branch)},
+			bson.M{keyUserBranches: strings.ToUpper(branch)},
+		},
+		keyUserRoles:       strings.ToUpper(role),
+		keyUserDeactivated: bson.M{"$ne": true},
 	}
 
 	users := dto.UserResponseList{}
 	opts := options.Find()
-	opts.SetSort(bson.D{{keyUserID, -1}}) //nolint:govet
+	opts.SetSort(bson.D{{Key: keyUserID, Value: -1}})
 	sortCursor, err := coll.Find(ctx, filter, opts)
 	if err != nil {
 		logger.Error("Gagal mendapatkan user dari database", err)
@@ -378,3 +849,11 @@ func (u *userDao) CheckIDAvailable(userID string) (bool, resterr.APIError) {
 	apiErr := resterr.NewBadRequestError("ID tidak tersedia")
 	return false, apiErr
 }
+
+func upperBranches(branches []string) []string {
+	result := make([]string, len(branches))
+	for i, branch := range branches {
+		result[i] = strings.ToUpper(branch)
+	}
+	return result
+}
@@ -9,13 +9,26 @@ type UserDaoAssumer interface {
 	InsertUser(user dto.UserRequest) (*string, resterr.APIError)
 	EditUser(userID string, userRequest dto.UserEditRequest) (*dto.UserResponse, resterr.APIError)
 	EditFcm(userID string, fcmToken string) (*dto.UserResponse, resterr.APIError)
-	DeleteUser(userID string) resterr.APIError
+	SetUserActive(userID string, active bool, actor string) (*dto.UserResponse, resterr.APIError)
 	PutAvatar(userID string, avatar string) (*dto.UserResponse, resterr.APIError)
-	ChangePassword(data dto.UserChangePasswordRequest) resterr.APIError
+	ChangePassword(data dto.UserPasswordChange) resterr.APIError
+	UpdatePasswordHash(userID string, oldHash string, newHash string) resterr.APIError
+	IncrementFailedLogin(userID string, nowUnix int64) (*dto.User, resterr.APIError)
+	LockUser(userID string, lockedUntil int64) resterr.APIError
+	ResetLoginAttempt(userID string) (*dto.UserResponse, resterr.APIError)
+	SetTwoFactorPending(userID string, secret string) resterr.APIError
+	EnableTwoFactor(userID string, secret string, recoveryHashes []string, step int64) resterr.APIError
+	DisableTwoFactor(userID string) resterr.APIError
+	UseTwoFactorStep(userID string, step int64) resterr.APIError
+	UseRecoveryCode(userID string, recoveryHash string) resterr.APIError
+	SyncSsoUser(userID string, data dto.UserSsoSync) (*dto.User, resterr.APIError)
 
 	GetUserByID(userID string) (*dto.UserResponse, resterr.APIError)
 	GetUserByIDWithPassword(userID string) (*dto.User, resterr.APIError)
-	FindUser() (dto.UserResponseList, resterr.APIError)
+	GetUserByEmail(email string) (*dto.User, resterr.APIError)
+	FindUser(filterA dto.FilterUser) (dto.UserResponseList, int64, resterr.APIError)
 	FindUserHSSE(branch string) (dto.UserResponseList, resterr.APIError)
+	FindUserByRole(branch string, role string) (dto.UserResponseList, resterr.APIError)
+	FindUserCoveringBranch(branch string, role string) (dto.UserResponseList, resterr.APIError)
 	CheckIDAvailable(email string) (bool, resterr.APIError)
 }
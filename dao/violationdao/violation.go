@@ -22,6 +22,7 @@ const (
 	keyViolCollection = "violation"
 
 	keyViolID              = "_id"
+	keyViolCreatedAt       = "created_at"
 	keyViolUpdatedAt       = "updated_at"
 	keyViolUpdatedBy       = "updated_by"
 	keyViolUpdatedByID     = "updated_by_id"
@@ -35,11 +36,30 @@ const (
 	keyViolNoPol           = "no_pol"
 	keyViolMark            = "mark"
 	keyViolOwner           = "owner"
+	keyViolOwnerID         = "owner_id"
+	keyViolJptID           = "jpt_id"
+	keyViolJptName         = "jpt_name"
 	keyViolTypeViolation   = "type_violation"
 	keyViolDetailViolation = "detail_violation"
 	keyViolTimeViolation   = "time_violation"
 	keyViolLocation        = "location"
 	keyViolImages          = "images"
+	keyViolDriverID        = "driver_id"
+	keyViolNoLicense       = "no_license"
+	keyViolDriverName      = "driver_name"
+	keyViolDriverNViol     = "driver_n_viol"
+	keyViolSubmittedAt     = "submitted_at"
+	keyViolEscalationLevel = "escalation_level"
+	keyViolEscalatedAt     = "escalated_at"
+	keyViolDraftWarnedAt   = "draft_warned_at"
+	keyViolArchived        = "archived"
+	keyViolArchivedAt      = "archived_at"
+)
+
+// Pengelompokan yang tersedia untuk CountViolationByOwner
+const (
+	GroupByState = keyViolState
+	GroupByType  = keyViolTypeViolation
 )
 
 func NewViolationDao() ViolationDaoAssumer {
@@ -56,6 +76,17 @@ type ViolationDaoAssumer interface {
 	UploadImage(violationID primitive.ObjectID, imagePath string, filterBranch string) (*dto.Violation, resterr.APIError)
 	DeleteImage(violationID primitive.ObjectID, imagePath string, filterBranch string) (*dto.Violation, resterr.APIError)
 	ChangeStateViolation(input dto.ViolationConfirm) (*dto.Violation, resterr.APIError)
+	EscalateViolation(violationID primitive.ObjectID, level int, escalatedAt int64) (int64, resterr.APIError)
+	WarnDraft(violationID primitive.ObjectID, lastUpdatedAt int64, warnedAt int64) (int64, resterr.APIError)
+	ArchiveDraft(violationID primitive.ObjectID, lastUpdatedAt int64, archivedAt int64) (int64, resterr.APIError)
+	RestoreDraft(input dto.ViolationRestore) (*dto.Violation, resterr.APIError)
+	FindDraftUntouched(before int64) (dto.ViolationResponseMinList, resterr.APIError)
+	UpsertDraftRetention(input dto.DraftRetention) (*dto.DraftRetention, resterr.APIError)
+	FindDraftRetention() ([]dto.DraftRetention, resterr.APIError)
+
+	SetOwnerByIdentity(noIdentity string, branch string, ownerID string) (int64, resterr.APIError)
+	CountViolationByOwner(ownerID string, start int64, end int64, groupBy string) ([]dto.ViolationCount, resterr.APIError)
+	CountApprovedViolationByJpt(since int64) ([]dto.ViolationCount, resterr.APIError)
 
 	GetViolationByID(violationID primitive.ObjectID, branchIfSpecific string) (*dto.Violation, resterr.APIError)
 	FindViolation(filter dto.FilterViolation) (dto.ViolationResponseMinList, resterr.APIError)
@@ -100,6 +131,7 @@ func (c *violationDao) EditViolation(input dto.ViolationEdit) (*dto.Violation, r
 		keyViolID:        input.ID,
 		keyViolBranch:    input.FilterBranch,
 		keyViolUpdatedAt: input.FilterTimestamp,
+		keyViolArchived:  bson.M{"$ne": true},
 	}
 
 	update := bson.M{
@@ -111,18 +143,22 @@ func (c *violationDao) EditViolation(input dto.ViolationEdit) (*dto.Violation, r
 			keyViolNoIdentity:      input.NoIdentity,
 			keyViolNoPol:           input.NoPol,
 			keyViolMark:            input.Mark,
+			keyViolOwnerID:         input.OwnerID,
 			keyViolOwner:           input.Owner,
 			keyViolTypeViolation:   input.TypeViolation,
 			keyViolDetailViolation: input.DetailViolation,
 			keyViolTimeViolation:   input.TimeViolation,
 			keyViolLocation:        input.Location,
+			keyViolDriverID:        input.DriverID,
+			keyViolNoLicense:       input.NoLicense,
+			keyViolDriverName:      input.DriverName,
 		},
 	}
 
 	var violation dto.Violation
 	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&violation); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, resterr.NewBadRequestError("pelanggaran tidak diupdate : validasi id timestamp")
+			return nil, resterr.NewBadRequestError("pelanggaran tidak diupdate : validasi id timestamp atau draft diarsipkan")
 		}
 
 		logger.Error("Gagal mendapatkan violation dari database (EditViolation)", err)
@@ -146,20 +182,31 @@ func (c *violationDao) ChangeStateViolation(input dto.ViolationConfirm) (*dto.Vi
 		keyViolBranch: input.FilterBranch,
 	}
 
-	update := bson.M{
-		"$set": bson.M{
-			keyViolUpdatedAt:   input.UpdatedAt,
-			keyViolUpdatedBy:   input.UpdatedBy,
-			keyViolUpdatedByID: input.UpdatedByID,
+	set := bson.M{
+		keyViolUpdatedAt:   input.UpdatedAt,
+		keyViolUpdatedBy:   input.UpdatedBy,
+		keyViolUpdatedByID: input.UpdatedByID,
 
-			keyViolApprovedAt:   input.ApprovedAt,
-			keyViolApprovedBy:   input.ApprovedBy,
-			keyViolApprovedByID: input.ApprovedByID,
+		keyViolApprovedAt:   input.ApprovedAt,
+		keyViolApprovedBy:   input.ApprovedBy,
+		keyViolApprovedByID: input.ApprovedByID,
 
-			keyViolState: input.State,
-			keyViolNViol: input.NViol,
-		},
+		keyViolState:       input.State,
+		keyViolNViol:       input.NViol,
+		keyViolDriverNViol: input.DriverNViol,
+	}
+	// jpt yang bertanggung jawab dicatat saat pelanggaran disetujui
+	if input.State == enum.StApproved {
+		set[keyViolJptID] = input.JptID
+		set[keyViolJptName] = strings.ToUpper(input.JptName)
+	}
+	// pengajuan ulang memulai perhitungan SLA persetujuan dari awal
+	if input.State == enum.StNeedApprove {
+		set[keyViolSubmittedAt] = input.UpdatedAt
+		set[keyViolEscalationLevel] = 0
+		set[keyViolEscalatedAt] = int64(0)
 	}
+	update := bson.M{"$set": set}
 
 	var violation dto.Violation
 	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&violation); err != nil {
@@ -175,6 +222,38 @@ func (c *violationDao) ChangeStateViolation(input dto.ViolationConfirm) (*dto.Vi
 	return &violation, nil
 }
 
+// EscalateViolation menaikkan tingkat eskalasi pelanggaran yang masih menunggu persetujuan,
+// tingkat yang lebih rendah atau sama dengan yang tersimpan diabaikan. dokumen lama tanpa escalation_level
+// dianggap belum dieskalasi. mengembalikan jumlah dokumen yang cocok, 0 berarti tidak dieskalasi
+func (c *violationDao) EscalateViolation(violationID primitive.ObjectID, level int, escalatedAt int64) (int64, resterr.APIError) {
+	coll := db.DB.Collection(keyViolCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyViolID:    violationID,
+		keyViolState: enum.StNeedApprove,
+		"$or": bson.A{
+			bson.M{keyViolEscalationLevel: bson.M{"$exists": false}},
+			bson.M{keyViolEscalationLevel: bson.M{"$lt": level}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyViolEscalationLevel: level,
+			keyViolEscalatedAt:     escalatedAt,
+		},
+	}
+
+	result, err := coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.Error("Gagal mengupdate eskalasi violation dari database (EscalateViolation)", err)
+		return 0, resterr.NewInternalServerError("Gagal mengupdate pelanggaran dari database", err)
+	}
+
+	return result.MatchedCount, nil
+}
+
 func (c *violationDao) DeleteViolation(input dto.FilterIDBranch) (*dto.Violation, resterr.APIError) {
 	coll := db.DB.Collection(keyViolCollection)
 	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
@@ -335,18 +414,48 @@ func (c *violationDao) FindViolation(filterA dto.FilterViolation) (dto.Violation
 		filter[keyViolNoIdentity] = bson.M{
 			"$regex": fmt.Sprintf(".*%s", filterA.FilterNoIdentity),
 		}
+	} else if len(filterA.FilterNoIdentityPeriods) != 0 {
+		periods := make(bson.A, len(filterA.FilterNoIdentityPeriods))
+		for i, period := range filterA.FilterNoIdentityPeriods {
+			createdAt := bson.M{"$gte": period.Start}
+			if period.End != 0 {
+				createdAt["$lt"] = period.End
+			}
+			periods[i] = bson.M{
+				keyViolNoIdentity: strings.ToUpper(period.NoIdentity),
+				keyViolCreatedAt:  createdAt,
+			}
+		}
+		filter["$or"] = periods
 	}
 	if filterA.FilterNoPol != "" {
 		filter[keyViolNoPol] = bson.M{
 			"$regex": fmt.Sprintf(".*%s", filterA.FilterNoPol),
 		}
 	}
-	if filterA.FilterState != enum.StUndefined {
+	if filterA.FilterOwnerID != "" {
+		filter[keyViolOwnerID] = filterA.FilterOwnerID
+	}
+	if filterA.FilterJptID != "" {
+		filter[keyViolJptID] = filterA.FilterJptID
+	}
+	if filterA.FilterNoLicense != "" {
+		filter[keyViolNoLicense] = strings.ReplaceAll(strings.ToUpper(filterA.FilterNoLicense), " ", "")
+	}
+	if filterA.Submitted {
+		filter[keyViolState] = bson.M{"$gte": enum.StNeedApprove}
+	} else if filterA.FilterState != enum.StUndefined {
 		filter[keyViolState] = filterA.FilterState
 	}
 	if filterA.FilterStart != 0 && filterA.FilterEnd != 0 {
 		filter[keyViolTimeViolation] = bson.M{"$lte": filterA.FilterEnd, "$gte": filterA.FilterStart}
 	}
+	// dokumen lama tidak memiliki field archived
+	if filterA.Archived {
+		filter[keyViolArchived] = true
+	} else {
+		filter[keyViolArchived] = bson.M{"$ne": true}
+	}
 
 	opts := options.Find()
 	opts.SetSort(bson.D{{keyViolUpdatedAt, -1}}) //nolint:govet
@@ -368,3 +477,115 @@ func (c *violationDao) FindViolation(filterA dto.FilterViolation) (dto.Violation
 
 	return violationList, nil
 }
+
+// SetOwnerByIdentity menghubungkan pelanggaran lama milik truck ke perusahaan,
+// nama owner pada pelanggaran tidak diubah karena merupakan data saat pelanggaran dibuat
+func (c *violationDao) SetOwnerByIdentity(noIdentity string, branch string, ownerID string) (int64, resterr.APIError) {
+	coll := db.DB.Collection(keyViolCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyViolNoIdentity: strings.ToUpper(noIdentity),
+		keyViolBranch:     strings.ToUpper(branch),
+		keyViolOwnerID:    bson.M{"$in": bson.A{"", nil}},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyViolOwnerID: ownerID,
+		},
+	}
+
+	result, err := coll.UpdateMany(ctx, filter, update)
+	if err != nil {
+		logger.Error("Gagal mengupdate owner violation dari database (SetOwnerByIdentity)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mengupdate violation dari database", err)
+		return 0, apiErr
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// CountViolationByOwner menghitung pelanggaran perusahaan yang dikelompokkan berdasarkan field groupBy
+// (state atau type_violation), start dan end 0 berarti tidak dibatasi
+func (c *violationDao) CountViolationByOwner(ownerID string, start int64, end int64, groupBy string) ([]dto.ViolationCount, resterr.APIError) {
+	coll := db.DB.Collection(keyViolCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	if groupBy != GroupByState && groupBy != GroupByType {
+		return nil, resterr.NewBadRequestError(fmt.Sprintf("pengelompokan %s tidak tersedia", groupBy))
+	}
+
+	match := bson.M{keyViolOwnerID: ownerID}
+	timeFilter := bson.M{}
+	if start != 0 {
+		timeFilter["$gte"] = start
+	}
+	if end != 0 {
+		timeFilter["$lte"] = end
+	}
+	if len(timeFilter) != 0 {
+		match[keyViolTimeViolation] = timeFilter
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": match},
+		bson.M{"$group": bson.M{
+			"_id":   bson.M{"$toString": "$" + groupBy},
+			"count": bson.M{"$sum": 1},
+		}},
+		bson.M{"$sort": bson.M{"count": -1}},
+	}
+
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.Error("Gagal menghitung violation dari database (CountViolationByOwner)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return nil, apiErr
+	}
+
+	result := []dto.ViolationCount{}
+	if err = cursor.All(ctx, &result); err != nil {
+		logger.Error("Gagal decode hasil hitung violation (CountViolationByOwner)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return nil, apiErr
+	}
+
+	return result, nil
+}
+
+// CountApprovedViolationByJpt menghitung pelanggaran yang sudah disetujui sejak waktu since per JPT
+func (c *violationDao) CountApprovedViolationByJpt(since int64) ([]dto.ViolationCount, resterr.APIError) {
+	coll := db.DB.Collection(keyViolCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{
+			keyViolJptID:      bson.M{"$nin": bson.A{"", nil}},
+			keyViolState:      bson.M{"$gte": enum.StApproved},
+			keyViolApprovedAt: bson.M{"$gte": since},
+		}},
+		bson.M{"$group": bson.M{
+			"_id":   "$" + keyViolJptID,
+			"count": bson.M{"$sum": 1},
+		}},
+	}
+
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.Error("Gagal menghitung violation dari database (CountApprovedViolationByJpt)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return nil, apiErr
+	}
+
+	result := []dto.ViolationCount{}
+	if err = cursor.All(ctx, &result); err != nil {
+		logger.Error("Gagal decode hasil hitung violation (CountApprovedViolationByJpt)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return nil, apiErr
+	}
+
+	return result, nil
+}
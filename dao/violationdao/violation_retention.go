@@ -0,0 +1,203 @@
+package violationdao
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"tilank/db"
+	"tilank/dto"
+	"tilank/enum"
+	"tilank/utils/logger"
+	"tilank/utils/rest_err"
+	"time"
+)
+
+const (
+	keyRetentionCollection = "violation_draft_retention"
+
+	keyRetentionBranch    = "_id"
+	keyRetentionDay       = "retention_day"
+	keyRetentionWarning   = "warning_day"
+	keyRetentionUpdatedAt = "updated_at"
+	keyRetentionUpdatedBy = "updated_by"
+)
+
+// FindDraftUntouched mendapatkan draft seluruh cabang yang tidak diarsipkan dan tidak diubah sejak before
+func (c *violationDao) FindDraftUntouched(before int64) (dto.ViolationResponseMinList, resterr.APIError) {
+	coll := db.DB.Collection(keyViolCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyViolState:     enum.StDraft,
+		keyViolArchived:  bson.M{"$ne": true},
+		keyViolUpdatedAt: bson.M{"$lte": before},
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.D{{keyViolUpdatedAt, 1}}) //nolint:govet
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		logger.Error("Gagal mendapatkan draft pelanggaran dari database (FindDraftUntouched)", err)
+		return dto.ViolationResponseMinList{}, resterr.NewInternalServerError("Database error", err)
+	}
+
+	violationList := dto.ViolationResponseMinList{}
+	if err = cursor.All(ctx, &violationList); err != nil {
+		logger.Error("Gagal decode draft cursor ke objek slice (FindDraftUntouched)", err)
+		return dto.ViolationResponseMinList{}, resterr.NewInternalServerError("Database error", err)
+	}
+
+	return violationList, nil
+}
+
+// WarnDraft mencatat peringatan pengarsipan, draft yang sudah diubah setelah lastUpdatedAt tidak ditandai
+func (c *violationDao) WarnDraft(violationID primitive.ObjectID, lastUpdatedAt int64, warnedAt int64) (int64, resterr.APIError) {
+	coll := db.DB.Collection(keyViolCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyViolID:        violationID,
+		keyViolState:     enum.StDraft,
+		keyViolArchived:  bson.M{"$ne": true},
+		keyViolUpdatedAt: lastUpdatedAt,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyViolDraftWarnedAt: warnedAt,
+		},
+	}
+
+	result, err := coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.Error("Gagal mengupdate peringatan draft dari database (WarnDraft)", err)
+		return 0, resterr.NewInternalServerError("Gagal mengupdate pelanggaran dari database", err)
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// ArchiveDraft mengarsipkan draft, draft yang sudah diubah setelah lastUpdatedAt tidak diarsipkan
+func (c *violationDao) ArchiveDraft(violationID primitive.ObjectID, lastUpdatedAt int64, archivedAt int64) (int64, resterr.APIError) {
+	coll := db.DB.Collection(keyViolCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyViolID:        violationID,
+		keyViolState:     enum.StDraft,
+		keyViolArchived:  bson.M{"$ne": true},
+		keyViolUpdatedAt: lastUpdatedAt,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyViolArchived:   true,
+			keyViolArchivedAt: archivedAt,
+		},
+	}
+
+	result, err := coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.Error("Gagal mengarsipkan draft dari database (ArchiveDraft)", err)
+		return 0, resterr.NewInternalServerError("Gagal mengupdate pelanggaran dari database", err)
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// RestoreDraft memulihkan draft yang diarsipkan pada cabang user
+func (c *violationDao) RestoreDraft(input dto.ViolationRestore) (*dto.Violation, resterr.APIError) {
+	coll := db.DB.Collection(keyViolCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate()
+	opts.SetReturnDocument(1)
+
+	filter := bson.M{
+		keyViolID:       input.ID,
+		keyViolBranch:   input.FilterBranch,
+		keyViolArchived: true,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyViolUpdatedAt:   input.UpdatedAt,
+			keyViolUpdatedBy:   input.UpdatedBy,
+			keyViolUpdatedByID: input.UpdatedByID,
+
+			keyViolArchived:      false,
+			keyViolArchivedAt:    int64(0),
+			keyViolDraftWarnedAt: int64(0),
+		},
+	}
+
+	var violation dto.Violation
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&violation); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewBadRequestError("pelanggaran tidak dipulihkan : id salah atau draft tidak diarsipkan")
+		}
+
+		logger.Error("Gagal memulihkan violation dari database (RestoreDraft)", err)
+		return nil, resterr.NewInternalServerError("Gagal memulihkan pelanggaran dari database", err)
+	}
+
+	return &violation, nil
+}
+
+// UpsertDraftRetention menyimpan aturan pengarsipan draft cabang, membuat dokumen baru jika belum ada
+func (c *violationDao) UpsertDraftRetention(input dto.DraftRetention) (*dto.DraftRetention, resterr.APIError) {
+	coll := db.DB.Collection(keyRetentionCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	input.Branch = strings.ToUpper(input.Branch)
+
+	opts := options.FindOneAndUpdate()
+	opts.SetUpsert(true)
+	opts.SetReturnDocument(1)
+
+	filter := bson.M{keyRetentionBranch: input.Branch}
+	update := bson.M{
+		"$set": bson.M{
+			keyRetentionDay:       input.RetentionDay,
+			keyRetentionWarning:   input.WarningDay,
+			keyRetentionUpdatedAt: input.UpdatedAt,
+			keyRetentionUpdatedBy: input.UpdatedBy,
+		},
+	}
+
+	var result dto.DraftRetention
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result); err != nil {
+		logger.Error("Gagal menyimpan retensi draft ke database (UpsertDraftRetention)", err)
+		return nil, resterr.NewInternalServerError("Gagal menyimpan retensi draft ke database", err)
+	}
+
+	return &result, nil
+}
+
+// FindDraftRetention mendapatkan seluruh aturan pengarsipan draft yang tersimpan
+func (c *violationDao) FindDraftRetention() ([]dto.DraftRetention, resterr.APIError) {
+	coll := db.DB.Collection(keyRetentionCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	retentionList := []dto.DraftRetention{}
+	cursor, err := coll.Find(ctx, bson.M{})
+	if err != nil {
+		logger.Error("Gagal mendapatkan retensi draft dari database (FindDraftRetention)", err)
+		return nil, resterr.NewInternalServerError("Database error", err)
+	}
+
+	if err = cursor.All(ctx, &retentionList); err != nil {
+		logger.Error("Gagal decode retensi draft cursor ke objek slice (FindDraftRetention)", err)
+		return nil, resterr.NewInternalServerError("Database error", err)
+	}
+
+	return retentionList, nil
+}
@@ -0,0 +1,410 @@
+package webhookdao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"tilank/db"
+	"tilank/dto"
+	"tilank/utils/logger"
+	"tilank/utils/rest_err"
+	"time"
+)
+
+// Status log pengiriman webhook
+const (
+	StatusDeliveryPending = "pending"
+	StatusDeliverySuccess = "success"
+	StatusDeliveryFailed  = "failed"
+)
+
+const (
+	connectTimeout          = 3
+	keyWebhookCollection    = "webhook"
+	keyDeliveryCollection   = "webhook_delivery"
+	maxDeliveryFindDueLimit = 100
+
+	keyWebhookID          = "_id"
+	keyWebhookUpdatedAt   = "updated_at"
+	keyWebhookUpdatedBy   = "updated_by"
+	keyWebhookUpdatedByID = "updated_by_id"
+	keyWebhookName        = "name"
+	keyWebhookURL         = "url"
+	keyWebhookEvents      = "events"
+	keyWebhookBranch      = "branch"
+	keyWebhookActive      = "active"
+
+	keyDeliveryID             = "_id"
+	keyDeliveryCreatedAt      = "created_at"
+	keyDeliveryUpdatedAt      = "updated_at"
+	keyDeliveryWebhookID      = "webhook_id"
+	keyDeliveryEvent          = "event"
+	keyDeliveryStatus         = "status"
+	keyDeliveryAttempts       = "attempts"
+	keyDeliveryNextAttemptAt  = "next_attempt_at"
+	keyDeliveryLastStatusCode = "last_status_code"
+	keyDeliveryLastError      = "last_error"
+)
+
+func NewWebhookDao() WebhookDaoAssumer {
+	return &webhookDao{}
+}
+
+type webhookDao struct {
+}
+
+type WebhookDaoAssumer interface {
+	InsertWebhook(input dto.Webhook) (*string, resterr.APIError)
+	EditWebhook(input dto.WebhookEdit) (*dto.Webhook, resterr.APIError)
+	DeleteWebhook(webhookID primitive.ObjectID) resterr.APIError
+
+	GetWebhookByID(webhookID primitive.ObjectID) (*dto.Webhook, resterr.APIError)
+	FindWebhook() (dto.WebhookResponseMinList, resterr.APIError)
+	FindWebhookByEvent(event string, branch string) ([]dto.Webhook, resterr.APIError)
+
+	InsertDelivery(input dto.WebhookDelivery) (*string, resterr.APIError)
+	UpdateDeliveryAttempt(input dto.WebhookDeliveryAttempt) (*dto.WebhookDelivery, resterr.APIError)
+	GetDeliveryByID(deliveryID primitive.ObjectID) (*dto.WebhookDelivery, resterr.APIError)
+	FindDelivery(filter dto.FilterWebhookDelivery) (dto.WebhookDeliveryList, resterr.APIError)
+	FindDeliveryDue(nowUnix int64) (dto.WebhookDeliveryList, resterr.APIError)
+	ClaimDelivery(deliveryID primitive.ObjectID, nextAttemptAt int64, claimUntil int64) (int64, resterr.APIError)
+}
+
+func (c *webhookDao) InsertWebhook(input dto.Webhook) (*string, resterr.APIError) {
+	coll := db.DB.Collection(keyWebhookCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	input.Branch = strings.ToUpper(input.Branch)
+	if input.Events == nil {
+		input.Events = []string{}
+	}
+
+	result, err := coll.InsertOne(ctx, input)
+	if err != nil {
+		apiErr := resterr.NewInternalServerError("Gagal menyimpan webhook ke database", err)
+		logger.Error("Gagal menyimpan webhook ke database, (InsertWebhook)", err)
+		return nil, apiErr
+	}
+
+	insertID := result.InsertedID.(primitive.ObjectID).Hex()
+
+	return &insertID, nil
+}
+
+func (c *webhookDao) EditWebhook(input dto.WebhookEdit) (*dto.Webhook, resterr.APIError) {
+	coll := db.DB.Collection(keyWebhookCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	input.Branch = strings.ToUpper(input.Branch)
+	if input.Events == nil {
+		input.Events = []string{}
+	}
+
+	opts := options.FindOneAndUpdate()
+	opts.SetReturnDocument(1)
+
+	filter := bson.M{
+		keyWebhookID:        input.ID,
+		keyWebhookUpdatedAt: input.FilterTimestamp,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			keyWebhookUpdatedAt:   input.UpdatedAt,
+			keyWebhookUpdatedBy:   input.UpdatedBy,
+			keyWebhookUpdatedByID: input.UpdatedByID,
+			keyWebhookName:        input.Name,
+			keyWebhookURL:         input.URL,
+			keyWebhookEvents:      input.Events,
+			keyWebhookBranch:      input.Branch,
+			keyWebhookActive:      input.Active,
+		},
+	}
+
+	var webhook dto.Webhook
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&webhook); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewBadRequestError("webhook tidak diupdate : validasi id timestamp")
+		}
+
+		logger.Error("Gagal mendapatkan webhook dari database (EditWebhook)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mendapatkan webhook dari database", err)
+		return nil, apiErr
+	}
+
+	return &webhook, nil
+}
+
+func (c *webhookDao) DeleteWebhook(webhookID primitive.ObjectID) resterr.APIError {
+	coll := db.DB.Collection(keyWebhookCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	result, err := coll.DeleteOne(ctx, bson.M{keyWebhookID: webhookID})
+	if err != nil {
+		logger.Error("Gagal menghapus webhook dari database (DeleteWebhook)", err)
+		apiErr := resterr.NewInternalServerError("Gagal menghapus webhook dari database", err)
+		return apiErr
+	}
+
+	if result.DeletedCount == 0 {
+		return resterr.NewBadRequestError("Webhook gagal dihapus, dokumen tidak ditemukan")
+	}
+
+	return nil
+}
+
+func (c *webhookDao) GetWebhookByID(webhookID primitive.ObjectID) (*dto.Webhook, resterr.APIError) {
+	coll := db.DB.Collection(keyWebhookCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	var webhook dto.Webhook
+	if err := coll.FindOne(ctx, bson.M{keyWebhookID: webhookID}).Decode(&webhook); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			apiErr := resterr.NewNotFoundError(fmt.Sprintf("Webhook dengan ID %s tidak ditemukan", webhookID.Hex()))
+			return nil, apiErr
+		}
+
+		logger.Error("gagal mendapatkan webhook dari database (GetWebhookByID)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mendapatkan webhook dari database", err)
+		return nil, apiErr
+	}
+
+	return &webhook, nil
+}
+
+func (c *webhookDao) FindWebhook() (dto.WebhookResponseMinList, resterr.APIError) {
+	coll := db.DB.Collection(keyWebhookCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: keyWebhookName, Value: 1}})
+
+	cursor, err := coll.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		logger.Error("Gagal mendapatkan daftar webhook dari database (FindWebhook)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.WebhookResponseMinList{}, apiErr
+	}
+
+	webhookList := dto.WebhookResponseMinList{}
+	if err = cursor.All(ctx, &webhookList); err != nil {
+		logger.Error("Gagal decode webhookList cursor ke objek slice (FindWebhook)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.WebhookResponseMinList{}, apiErr
+	}
+
+	return webhookList, nil
+}
+
+// FindWebhookByEvent mendapatkan webhook aktif yang berlangganan event tertentu
+// webhook dengan branch kosong ikut disertakan karena menerima semua cabang
+func (c *webhookDao) FindWebhookByEvent(event string, branch string) ([]dto.Webhook, resterr.APIError) {
+	coll := db.DB.Collection(keyWebhookCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyWebhookActive: true,
+		keyWebhookEvents: event,
+		keyWebhookBranch: bson.M{"$in": []string{"", strings.ToUpper(branch)}},
+	}
+
+	cursor, err := coll.Find(ctx, filter)
+	if err != nil {
+		logger.Error("Gagal mendapatkan daftar webhook dari database (FindWebhookByEvent)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return []dto.Webhook{}, apiErr
+	}
+
+	webhookList := []dto.Webhook{}
+	if err = cursor.All(ctx, &webhookList); err != nil {
+		logger.Error("Gagal decode webhookList cursor ke objek slice (FindWebhookByEvent)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return []dto.Webhook{}, apiErr
+	}
+
+	return webhookList, nil
+}
+
+func (c *webhookDao) InsertDelivery(input dto.WebhookDelivery) (*string, resterr.APIError) {
+	coll := db.DB.Collection(keyDeliveryCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	result, err := coll.InsertOne(ctx, input)
+	if err != nil {
+		apiErr := resterr.NewInternalServerError("Gagal menyimpan log webhook ke database", err)
+		logger.Error("Gagal menyimpan log webhook ke database, (InsertDelivery)", err)
+		return nil, apiErr
+	}
+
+	insertID := result.InsertedID.(primitive.ObjectID).Hex()
+
+	return &insertID, nil
+}
+
+func (c *webhookDao) UpdateDeliveryAttempt(input dto.WebhookDeliveryAttempt) (*dto.WebhookDelivery, resterr.APIError) {
+	coll := db.DB.Collection(keyDeliveryCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate()
+	opts.SetReturnDocument(1)
+
+	filter := bson.M{
+		keyDeliveryID: input.ID,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			keyDeliveryUpdatedAt:      input.UpdatedAt,
+			keyDeliveryStatus:         input.Status,
+			keyDeliveryAttempts:       input.Attempts,
+			keyDeliveryNextAttemptAt:  input.NextAttemptAt,
+			keyDeliveryLastStatusCode: input.LastStatusCode,
+			keyDeliveryLastError:      input.LastError,
+		},
+	}
+
+	var delivery dto.WebhookDelivery
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, resterr.NewBadRequestError("log webhook tidak diupdate : log dengan id tersebut tidak ditemukan")
+		}
+
+		logger.Error("Gagal mengupdate log webhook dari database (UpdateDeliveryAttempt)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mengupdate log webhook dari database", err)
+		return nil, apiErr
+	}
+
+	return &delivery, nil
+}
+
+func (c *webhookDao) GetDeliveryByID(deliveryID primitive.ObjectID) (*dto.WebhookDelivery, resterr.APIError) {
+	coll := db.DB.Collection(keyDeliveryCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	var delivery dto.WebhookDelivery
+	if err := coll.FindOne(ctx, bson.M{keyDeliveryID: deliveryID}).Decode(&delivery); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			apiErr := resterr.NewNotFoundError(fmt.Sprintf("Log webhook dengan ID %s tidak ditemukan", deliveryID.Hex()))
+			return nil, apiErr
+		}
+
+		logger.Error("gagal mendapatkan log webhook dari database (GetDeliveryByID)", err)
+		apiErr := resterr.NewInternalServerError("Gagal mendapatkan log webhook dari database", err)
+		return nil, apiErr
+	}
+
+	return &delivery, nil
+}
+
+func (c *webhookDao) FindDelivery(filterA dto.FilterWebhookDelivery) (dto.WebhookDeliveryList, resterr.APIError) {
+	coll := db.DB.Collection(keyDeliveryCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	// filter
+	filter := bson.M{}
+
+	// filter condition
+	if !filterA.FilterWebhookID.IsZero() {
+		filter[keyDeliveryWebhookID] = filterA.FilterWebhookID
+	}
+	if filterA.FilterEvent != "" {
+		filter[keyDeliveryEvent] = filterA.FilterEvent
+	}
+	if filterA.FilterStatus != "" {
+		filter[keyDeliveryStatus] = filterA.FilterStatus
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: keyDeliveryCreatedAt, Value: -1}})
+	opts.SetLimit(filterA.Limit)
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		logger.Error("Gagal mendapatkan daftar log webhook dari database (FindDelivery)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.WebhookDeliveryList{}, apiErr
+	}
+
+	deliveryList := dto.WebhookDeliveryList{}
+	if err = cursor.All(ctx, &deliveryList); err != nil {
+		logger.Error("Gagal decode deliveryList cursor ke objek slice (FindDelivery)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.WebhookDeliveryList{}, apiErr
+	}
+
+	return deliveryList, nil
+}
+
+// FindDeliveryDue mendapatkan log webhook berstatus pending yang sudah waktunya dikirim ulang
+func (c *webhookDao) FindDeliveryDue(nowUnix int64) (dto.WebhookDeliveryList, resterr.APIError) {
+	coll := db.DB.Collection(keyDeliveryCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyDeliveryStatus:        StatusDeliveryPending,
+		keyDeliveryNextAttemptAt: bson.M{"$lte": nowUnix},
+	}
+
+	opts := options.Find()
+	opts.SetSort(bson.D{{Key: keyDeliveryNextAttemptAt, Value: 1}})
+	opts.SetLimit(maxDeliveryFindDueLimit)
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		logger.Error("Gagal mendapatkan daftar log webhook dari database (FindDeliveryDue)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.WebhookDeliveryList{}, apiErr
+	}
+
+	deliveryList := dto.WebhookDeliveryList{}
+	if err = cursor.All(ctx, &deliveryList); err != nil {
+		logger.Error("Gagal decode deliveryList cursor ke objek slice (FindDeliveryDue)", err)
+		apiErr := resterr.NewInternalServerError("Database error", err)
+		return dto.WebhookDeliveryList{}, apiErr
+	}
+
+	return deliveryList, nil
+}
+
+// ClaimDelivery menandai log pending sedang dikirim dengan memundurkan next_attempt_at ke claimUntil.
+// log yang sudah diklaim atau dikirim proses lain setelah nextAttemptAt dibaca tidak diklaim ulang
+func (c *webhookDao) ClaimDelivery(deliveryID primitive.ObjectID, nextAttemptAt int64, claimUntil int64) (int64, resterr.APIError) {
+	coll := db.DB.Collection(keyDeliveryCollection)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		keyDeliveryID:            deliveryID,
+		keyDeliveryStatus:        StatusDeliveryPending,
+		keyDeliveryNextAttemptAt: nextAttemptAt,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyDeliveryNextAttemptAt: claimUntil,
+		},
+	}
+
+	result, err := coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.Error("Gagal mengklaim log webhook dari database (ClaimDelivery)", err)
+		return 0, resterr.NewInternalServerError("Gagal mengupdate log webhook dari database", err)
+	}
+
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,36 @@
+package dto
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// ApiKey kredensial client mesin, key asli tidak disimpan melainkan hash sha256-nya
+type ApiKey struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	CreatedAt   int64              `json:"created_at" bson:"created_at"`
+	CreatedBy   string             `json:"created_by" bson:"created_by"`
+	CreatedByID string             `json:"created_by_id" bson:"created_by_id"`
+	Name        string             `json:"name" bson:"name"`
+	Prefix      string             `json:"prefix" bson:"prefix"`
+	Hash        string             `json:"-" bson:"hash"`
+	Scopes      []string           `json:"scopes" bson:"scopes"`
+	Branch      string             `json:"branch" bson:"branch"`
+	ExpiresAt   int64              `json:"expires_at" bson:"expires_at"`
+	LastUsedAt  int64              `json:"last_used_at" bson:"last_used_at"`
+	LastUsedIP  string             `json:"last_used_ip" bson:"last_used_ip"`
+	Revoked     bool               `json:"revoked" bson:"revoked"`
+	RevokedAt   int64              `json:"revoked_at" bson:"revoked_at"`
+	RevokedBy   string             `json:"revoked_by" bson:"revoked_by"`
+}
+
+// ApiKeyRequest input admin untuk membuat api key, ExpiresAt 0 berarti tidak kadaluarsa
+type ApiKeyRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	Branch    string   `json:"branch"`
+	ExpiresAt int64    `json:"expires_at"`
+}
+
+// ApiKeyCreateResponse Key hanya ditampilkan sekali saat pembuatan
+type ApiKeyCreateResponse struct {
+	ApiKey ApiKey `json:"api_key"`
+	Key    string `json:"key"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+func (a ApiKeyRequest) Validate() error {
+	if err := validation.ValidateStruct(&a,
+		validation.Field(&a.Name, validation.Required),
+		validation.Field(&a.Scopes, validation.Required),
+		validation.Field(&a.Branch, validation.Required),
+		validation.Field(&a.ExpiresAt, validation.Min(int64(0))),
+	); err != nil {
+		return err
+	}
+	return apiKeyScopeValidation(a.Scopes)
+}
@@ -0,0 +1,18 @@
+package dto
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// AuditLog catatan kejadian yang berkaitan dengan keamanan, hanya ditambahkan dan tidak pernah diubah
+type AuditLog struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Time      int64              `json:"time" bson:"time"`
+	Action    string             `json:"action" bson:"action"`
+	Actor     string             `json:"actor" bson:"actor"`
+	Target    string             `json:"target" bson:"target"`
+	IP        string             `json:"ip" bson:"ip"`
+	UserAgent string             `json:"user_agent" bson:"user_agent"`
+	Outcome   string             `json:"outcome" bson:"outcome"`
+	Detail    string             `json:"detail" bson:"detail"`
+}
+
+type AuditLogList []AuditLog
@@ -0,0 +1,116 @@
+package dto
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Company perusahaan pemilik truck (owner). NormalizedName digunakan untuk mencegah duplikasi
+// nama dengan penulisan berbeda, Aliases berisi penulisan owner lama yang digabungkan saat migrasi
+type Company struct {
+	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt   int64              `json:"created_at" bson:"created_at"`
+	CreatedBy   string             `json:"created_by" bson:"created_by"`
+	CreatedByID string             `json:"created_by_id" bson:"created_by_id"`
+	UpdatedAt   int64              `json:"updated_at" bson:"updated_at"`
+	UpdatedBy   string             `json:"updated_by" bson:"updated_by"`
+	UpdatedByID string             `json:"updated_by_id" bson:"updated_by_id"`
+
+	Branch         string           `json:"branch" bson:"branch"`
+	Name           string           `json:"name" bson:"name"`
+	NormalizedName string           `json:"normalized_name" bson:"normalized_name"`
+	Aliases        []string         `json:"aliases" bson:"aliases"`
+	Email          string           `json:"email" bson:"email"`
+	Hp             string           `json:"hp" bson:"hp"`
+	Address        string           `json:"address" bson:"address"`
+	Contacts       []CompanyContact `json:"contacts" bson:"contacts"`
+	Deleted        bool             `json:"deleted" bson:"deleted"`
+}
+
+// CompanyContact narahubung perusahaan
+type CompanyContact struct {
+	Name     string `json:"name" bson:"name"`
+	Position string `json:"position" bson:"position"`
+	Hp       string `json:"hp" bson:"hp"`
+	Email    string `json:"email" bson:"email"`
+}
+
+// CompanyRequest user input, id tidak diinput oleh user
+type CompanyRequest struct {
+	Name     string           `json:"name" bson:"name"`
+	Email    string           `json:"email" bson:"email"`
+	Hp       string           `json:"hp" bson:"hp"`
+	Address  string           `json:"address" bson:"address"`
+	Contacts []CompanyContact `json:"contacts" bson:"contacts"`
+}
+
+type CompanyEdit struct {
+	ID              primitive.ObjectID
+	FilterBranch    string
+	FilterTimestamp int64
+
+	UpdatedAt   int64
+	UpdatedBy   string
+	UpdatedByID string
+
+	Name           string
+	NormalizedName string
+	Email          string
+	Hp             string
+	Address        string
+	Contacts       []CompanyContact
+}
+
+// CompanyEditRequest user input
+type CompanyEditRequest struct {
+	FilterTimestamp int64 `json:"filter_timestamp"`
+
+	Name     string           `json:"name" bson:"name"`
+	Email    string           `json:"email" bson:"email"`
+	Hp       string           `json:"hp" bson:"hp"`
+	Address  string           `json:"address" bson:"address"`
+	Contacts []CompanyContact `json:"contacts" bson:"contacts"`
+}
+
+type CompanyResponseMinList []CompanyResponseMin
+
+type CompanyResponseMin struct {
+	ID     primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Branch string             `json:"branch" bson:"branch"`
+
+	Name    string `json:"name" bson:"name"`
+	Email   string `json:"email" bson:"email"`
+	Hp      string `json:"hp" bson:"hp"`
+	Address string `json:"address" bson:"address"`
+	Deleted bool   `json:"deleted" bson:"deleted"`
+}
+
+// CompanyFleetResponse armada perusahaan beserta rekap status truck
+type CompanyFleetResponse struct {
+	Company      Company              `json:"company"`
+	TruckTotal   int                  `json:"truck_total"`
+	TruckBlocked int                  `json:"truck_blocked"`
+	Trucks       TruckResponseMinList `json:"trucks"`
+}
+
+// CompanyViolationResponse rekap pelanggaran seluruh truck perusahaan
+type CompanyViolationResponse struct {
+	Company Company                  `json:"company"`
+	Start   int64                    `json:"start"`
+	End     int64                    `json:"end"`
+	Total   int64                    `json:"total"`
+	ByState []ViolationCount         `json:"by_state"`
+	ByType  []ViolationCount         `json:"by_type"`
+	Latest  ViolationResponseMinList `json:"latest"`
+}
+
+// ViolationCount jumlah pelanggaran per kelompok (state atau tipe pelanggaran)
+type ViolationCount struct {
+	Key   string `json:"key" bson:"_id"`
+	Count int64  `json:"count" bson:"count"`
+}
+
+// CompanyMigrationResponse hasil pengelompokan owner truck lama menjadi perusahaan
+type CompanyMigrationResponse struct {
+	CompanyCreated  int   `json:"company_created"`
+	CompanyMatched  int   `json:"company_matched"`
+	TruckLinked     int64 `json:"truck_linked"`
+	ViolationLinked int64 `json:"violation_linked"`
+}
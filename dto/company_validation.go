@@ -0,0 +1,39 @@
+package dto
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+func (c CompanyContact) Validate() error {
+	if err := validation.ValidateStruct(&c,
+		validation.Field(&c.Name, validation.Required),
+		validation.Field(&c.Email, is.Email),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c CompanyRequest) Validate() error {
+	if err := validation.ValidateStruct(&c,
+		validation.Field(&c.Name, validation.Required),
+		validation.Field(&c.Email, is.Email),
+		validation.Field(&c.Contacts),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c CompanyEditRequest) Validate() error {
+	if err := validation.ValidateStruct(&c,
+		validation.Field(&c.Name, validation.Required),
+		validation.Field(&c.Email, is.Email),
+		validation.Field(&c.Contacts),
+		validation.Field(&c.FilterTimestamp, validation.Required),
+	); err != nil {
+		return err
+	}
+	return nil
+}
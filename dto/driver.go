@@ -0,0 +1,90 @@
+package dto
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Driver struct penuh dari domain sopir, skor dan blokir sopir terpisah dari truck
+type Driver struct {
+	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt   int64              `json:"created_at" bson:"created_at"`
+	CreatedBy   string             `json:"created_by" bson:"created_by"`
+	CreatedByID string             `json:"created_by_id" bson:"created_by_id"`
+	UpdatedAt   int64              `json:"updated_at" bson:"updated_at"`
+	UpdatedBy   string             `json:"updated_by" bson:"updated_by"`
+	UpdatedByID string             `json:"updated_by_id" bson:"updated_by_id"`
+
+	Branch    string `json:"branch" bson:"branch"`
+	NoLicense string `json:"no_license" bson:"no_license"`
+	Name      string `json:"name" bson:"name"`
+	Employer  string `json:"employer" bson:"employer"`
+	Hp        string `json:"hp" bson:"hp"`
+	Image     string `json:"image" bson:"image"`
+	Deleted   bool   `json:"deleted" bson:"deleted"`
+
+	Score          int   `json:"score" bson:"score"`
+	ResetScoreDate int64 `json:"reset_score_date" bson:"reset_score_date"`
+	Blocked        bool  `json:"blocked" bson:"blocked"`
+	BlockStart     int64 `json:"block_start" bson:"block_start"`
+	BlockEnd       int64 `json:"block_end" bson:"block_end"`
+}
+
+type DriverScoreEdit struct {
+	ID             primitive.ObjectID
+	Score          int
+	ResetScoreDate int64
+	Blocked        bool
+	BlockStart     int64
+	BlockEnd       int64
+}
+
+// DriverRequest user input, id tidak diinput oleh user
+type DriverRequest struct {
+	NoLicense string `json:"no_license" bson:"no_license"`
+	Name      string `json:"name" bson:"name"`
+	Employer  string `json:"employer" bson:"employer"`
+	Hp        string `json:"hp" bson:"hp"`
+}
+
+type DriverEdit struct {
+	ID              primitive.ObjectID
+	FilterBranch    string
+	FilterTimestamp int64
+
+	UpdatedAt   int64
+	UpdatedBy   string
+	UpdatedByID string
+
+	NoLicense string
+	Name      string
+	Employer  string
+	Hp        string
+}
+
+// DriverEditRequest user input
+type DriverEditRequest struct {
+	FilterTimestamp int64 `json:"filter_timestamp"`
+
+	NoLicense string `json:"no_license" bson:"no_license"`
+	Name      string `json:"name" bson:"name"`
+	Employer  string `json:"employer" bson:"employer"`
+	Hp        string `json:"hp" bson:"hp"`
+}
+
+type DriverResponseMinList []DriverResponseMin
+
+type DriverResponseMin struct {
+	ID     primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Branch string             `json:"branch" bson:"branch"`
+
+	NoLicense string `json:"no_license" bson:"no_license"`
+	Name      string `json:"name" bson:"name"`
+	Employer  string `json:"employer" bson:"employer"`
+	Hp        string `json:"hp" bson:"hp"`
+	Image     string `json:"image" bson:"image"`
+	Deleted   bool   `json:"deleted" bson:"deleted"`
+
+	Score          int   `json:"score" bson:"score"`
+	ResetScoreDate int64 `json:"reset_score_date" bson:"reset_score_date"`
+	Blocked        bool  `json:"blocked" bson:"blocked"`
+	BlockStart     int64 `json:"block_start" bson:"block_start"`
+	BlockEnd       int64 `json:"block_end" bson:"block_end"`
+}
@@ -0,0 +1,28 @@
+package dto
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+func (d DriverRequest) Validate() error {
+	if err := validation.ValidateStruct(&d,
+		validation.Field(&d.NoLicense, validation.Required),
+		validation.Field(&d.Name, validation.Required),
+		validation.Field(&d.Employer, validation.Required),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (d DriverEditRequest) Validate() error {
+	if err := validation.ValidateStruct(&d,
+		validation.Field(&d.NoLicense, validation.Required),
+		validation.Field(&d.Name, validation.Required),
+		validation.Field(&d.Employer, validation.Required),
+		validation.Field(&d.FilterTimestamp, validation.Required),
+	); err != nil {
+		return err
+	}
+	return nil
+}
@@ -16,10 +16,28 @@ type FilterViolation struct {
 	FilterBranch     string
 	FilterNoIdentity string
 	FilterNoPol      string
+	FilterNoLicense  string
+	FilterOwnerID    string
+	FilterJptID      string
 	FilterState      enum.State
 	FilterStart      int64
 	FilterEnd        int64
-	Limit            int64
+
+	// FilterNoIdentityPeriods nomor lambung beserta rentang waktu pencatatannya, digunakan jika FilterNoIdentity kosong
+	FilterNoIdentityPeriods []NoIdentityPeriod
+	// Archived true menampilkan draft yang diarsipkan, false menampilkan pelanggaran yang tidak diarsipkan
+	Archived bool
+	// Submitted true hanya menampilkan pelanggaran yang sudah diajukan atau disetujui, FilterState diabaikan
+	Submitted bool
+	Limit     int64
+}
+
+// NoIdentityPeriod nomor lambung yang digunakan truck sejak Start sampai sebelum End (created_at pelanggaran),
+// End 0 berarti nomor lambung masih digunakan
+type NoIdentityPeriod struct {
+	NoIdentity string
+	Start      int64
+	End        int64
 }
 
 type FilterJpt struct {
@@ -32,6 +50,52 @@ type FilterTruck struct {
 	FilterBranch     string
 	FilterNoIdentity string
 	FilterOwner      string
+	FilterOwnerID    string
+	FilterJptID      string
 	Active           bool
 	Blocked          bool
 }
+
+type FilterDriver struct {
+	FilterBranch    string
+	FilterNoLicense string
+	FilterName      string
+	FilterEmployer  string
+	Active          bool
+	Blocked         bool
+}
+
+type FilterCompany struct {
+	FilterBranch string
+	FilterName   string
+	Active       bool
+}
+
+// FilterUser filter daftar user, Page dimulai dari 1
+// Active false menampilkan user yang dinonaktifkan
+type FilterUser struct {
+	FilterBranch string
+	FilterRole   string
+	FilterName   string
+	Active       bool
+	Page         int64
+	Limit        int64
+}
+
+type FilterWebhookDelivery struct {
+	FilterWebhookID primitive.ObjectID
+	FilterEvent     string
+	FilterStatus    string
+	Limit           int64
+}
+
+// FilterAudit filter audit log, FilterUser mencocokkan actor maupun target.
+// FilterStart dan FilterEnd dalam unix detik, 0 berarti tidak dibatasi
+type FilterAudit struct {
+	FilterUser    string
+	FilterAction  string
+	FilterOutcome string
+	FilterStart   int64
+	FilterEnd     int64
+	Limit         int64
+}
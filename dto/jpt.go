@@ -19,6 +19,15 @@ type Jpt struct {
 	Hp        string `json:"hp" bson:"hp"`
 	Email     string `json:"email" bson:"email"`
 	Deleted   bool   `json:"deleted" bson:"deleted"`
+
+	// Score jumlah pelanggaran yang disetujui per truck aktif pada periode berjalan, semakin kecil semakin baik.
+	// Status NORMAL, WARNING atau SUSPENDED ditentukan dari ambang batas score
+	Score           float64 `json:"score" bson:"score"`
+	ViolationCount  int64   `json:"violation_count" bson:"violation_count"`
+	TruckCount      int64   `json:"truck_count" bson:"truck_count"`
+	Status          string  `json:"status" bson:"status"`
+	ScoredAt        int64   `json:"scored_at" bson:"scored_at"`
+	StatusChangedAt int64   `json:"status_changed_at" bson:"status_changed_at"`
 }
 
 // JptRequest user input, id tidak diinput oleh user
@@ -71,4 +80,45 @@ type JptResponseMin struct {
 	Hp        string `json:"hp" bson:"hp"`
 	Email     string `json:"email" bson:"email"`
 	Deleted   bool   `json:"deleted" bson:"deleted"`
+
+	Score           float64 `json:"score" bson:"score"`
+	ViolationCount  int64   `json:"violation_count" bson:"violation_count"`
+	TruckCount      int64   `json:"truck_count" bson:"truck_count"`
+	Status          string  `json:"status" bson:"status"`
+	StatusChangedAt int64   `json:"status_changed_at" bson:"status_changed_at"`
+}
+
+type JptScoreEdit struct {
+	ID              primitive.ObjectID
+	Score           float64
+	ViolationCount  int64
+	TruckCount      int64
+	Status          string
+	ScoredAt        int64
+	StatusChangedAt int64
+}
+
+// JptScoreHistory score JPT per bulan, Period berformat YYYY-MM.
+// satu dokumen per JPT per bulan, diperbarui setiap perhitungan score pada bulan tersebut
+type JptScoreHistory struct {
+	ID             primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	JptID          string             `json:"jpt_id" bson:"jpt_id"`
+	Branch         string             `json:"branch" bson:"branch"`
+	Name           string             `json:"name" bson:"name"`
+	Period         string             `json:"period" bson:"period"`
+	Score          float64            `json:"score" bson:"score"`
+	ViolationCount int64              `json:"violation_count" bson:"violation_count"`
+	TruckCount     int64              `json:"truck_count" bson:"truck_count"`
+	Status         string             `json:"status" bson:"status"`
+	UpdatedAt      int64              `json:"updated_at" bson:"updated_at"`
+}
+
+type JptScoreHistoryList []JptScoreHistory
+
+// JptScoreRecalculateResponse ringkasan hasil perhitungan ulang score JPT
+type JptScoreRecalculateResponse struct {
+	JptScored     int `json:"jpt_scored"`
+	Warning       int `json:"warning"`
+	Suspended     int `json:"suspended"`
+	StatusChanged int `json:"status_changed"`
 }
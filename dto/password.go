@@ -0,0 +1,25 @@
+package dto
+
+// PasswordResetToken token lupa password, yang disimpan hanya hash sha256 dari token
+type PasswordResetToken struct {
+	ID        string `json:"id" bson:"_id"`
+	UserID    string `json:"user_id" bson:"user_id"`
+	CreatedAt int64  `json:"created_at" bson:"created_at"`
+	Exp       int64  `json:"exp" bson:"exp"`
+	Used      bool   `json:"used" bson:"used"`
+}
+
+// ForgotPasswordRequest input user yang lupa password
+type ForgotPasswordRequest struct {
+	ID        string `json:"id"`
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// ResetPasswordRequest input token dari email beserta password baru
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+	IP          string `json:"-"`
+	UserAgent   string `json:"-"`
+}
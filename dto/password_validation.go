@@ -0,0 +1,20 @@
+package dto
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// Validate input
+func (f ForgotPasswordRequest) Validate() error {
+	return validation.ValidateStruct(&f,
+		validation.Field(&f.ID, validation.Required),
+	)
+}
+
+// Validate input, aturan kekuatan password dicek di service
+func (r ResetPasswordRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Token, validation.Required),
+		validation.Field(&r.NewPassword, validation.Required),
+	)
+}
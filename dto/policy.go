@@ -0,0 +1,23 @@
+package dto
+
+// RolePermission daftar permission yang dimiliki sebuah role.
+// KnownPermissions permission yang tersedia saat policy disimpan, permission default yang ditambahkan
+// setelahnya diberikan otomatis ke role tersebut
+type RolePermission struct {
+	Role             string   `json:"role" bson:"_id"`
+	Permissions      []string `json:"permissions" bson:"permissions"`
+	KnownPermissions []string `json:"-" bson:"known_permissions"`
+	UpdatedAt        int64    `json:"updated_at" bson:"updated_at"`
+	UpdatedBy        string   `json:"updated_by" bson:"updated_by"`
+}
+
+// RolePermissionEditRequest input admin untuk mengganti seluruh permission sebuah role
+type RolePermissionEditRequest struct {
+	Permissions []string `json:"permissions"`
+}
+
+// PolicyResponse matriks role-permission beserta daftar permission yang tersedia
+type PolicyResponse struct {
+	Policy               []RolePermission `json:"policy"`
+	PermissionsAvailable []string         `json:"permissions_available"`
+}
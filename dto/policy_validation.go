@@ -0,0 +1,16 @@
+package dto
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// Validate input, permissions boleh kosong untuk mencabut seluruh permission role
+func (r RolePermissionEditRequest) Validate() error {
+	if err := validation.ValidateStruct(&r,
+		validation.Field(&r.Permissions, validation.NotNil),
+	); err != nil {
+		return err
+	}
+
+	return permissionValidation(r.Permissions)
+}
@@ -9,6 +9,7 @@ type Rules struct {
 	UpdatedBy   string             `json:"updated_by" bson:"updated_by"`
 	UpdatedByID string             `json:"updated_by_id" bson:"updated_by_id"`
 	Branch      string             `json:"branch" bson:"branch"`
+	Subject     string             `json:"subject" bson:"subject"`
 	Score       int                `json:"score" bson:"score"`
 	BlockTime   int64              `json:"block_time" bson:"block_time"`
 	Description string             `json:"description" bson:"description"`
@@ -21,12 +22,15 @@ type RulesEdit struct {
 	UpdatedAt       int64  `json:"updated_at" bson:"updated_at"`
 	UpdatedBy       string `json:"updated_by" bson:"updated_by"`
 	UpdatedByID     string `json:"updated_by_id" bson:"updated_by_id"`
+	Subject         string `json:"subject" bson:"subject"`
 	Score           int    `json:"score" bson:"score"`
 	BlockTime       int64  `json:"block_time" bson:"block_time"`
 	Description     string `json:"description" bson:"description"`
 }
 
+// RulesRequest Subject TRUCK atau DRIVER, kosong berarti TRUCK
 type RulesRequest struct {
+	Subject     string `json:"subject" bson:"subject"`
 	Score       int    `json:"score" bson:"score"`
 	BlockTime   int64  `json:"block_time" bson:"block_time"`
 	Description string `json:"description" bson:"description"`
@@ -35,6 +39,7 @@ type RulesRequest struct {
 type RulesEditRequest struct {
 	FilterTimestamp int64 `json:"filter_timestamp"`
 
+	Subject     string `json:"subject" bson:"subject"`
 	Score       int    `json:"score" bson:"score"`
 	BlockTime   int64  `json:"block_time" bson:"block_time"`
 	Description string `json:"description" bson:"description"`
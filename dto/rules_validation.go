@@ -12,6 +12,9 @@ func (r RulesRequest) Validate() error {
 	); err != nil {
 		return err
 	}
+	if err := rulesSubjectValidation(r.Subject); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -24,5 +27,8 @@ func (r RulesEditRequest) Validate() error {
 	); err != nil {
 		return err
 	}
+	if err := rulesSubjectValidation(r.Subject); err != nil {
+		return err
+	}
 	return nil
 }
@@ -0,0 +1,40 @@
+package dto
+
+// SsoState permintaan login SSO yang sedang berjalan, ID berisi hash sha256 dari state
+// CodeVerifier PKCE, Nonce dan hash sha256 dari binding cookie browser hanya disimpan di server
+type SsoState struct {
+	ID           string `json:"id" bson:"_id"`
+	Nonce        string `json:"-" bson:"nonce"`
+	CodeVerifier string `json:"-" bson:"code_verifier"`
+	BindingHash  string `json:"-" bson:"binding_hash"`
+	Limit        int    `json:"limit" bson:"limit"`
+	CreatedAt    int64  `json:"created_at" bson:"created_at"`
+	Exp          int64  `json:"exp" bson:"exp"`
+}
+
+// SsoLoginResponse url halaman login identity provider yang harus dibuka client,
+// Binding tidak dikirim pada body melainkan sebagai cookie HttpOnly
+type SsoLoginResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+	Expired          int64  `json:"expired"`
+	Binding          string `json:"-"`
+}
+
+// SsoCallbackRequest code dan state yang dikembalikan identity provider ke redirect url,
+// Binding diisi handler dari cookie browser
+type SsoCallbackRequest struct {
+	Code      string `json:"code"`
+	State     string `json:"state"`
+	Binding   string `json:"-"`
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// UserSsoSync data user yang disinkronkan dari claim identity provider, nil berarti tidak diubah
+type UserSsoSync struct {
+	Subject  string
+	Roles    []string
+	Branch   *string
+	Branches []string
+}
@@ -0,0 +1,13 @@
+package dto
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// Validate input
+func (s SsoCallbackRequest) Validate() error {
+	return validation.ValidateStruct(&s,
+		validation.Field(&s.Code, validation.Required),
+		validation.Field(&s.State, validation.Required),
+	)
+}
@@ -0,0 +1,40 @@
+package dto
+
+// RevokedToken token (jti) yang dicabut sebelum masa berlakunya habis
+type RevokedToken struct {
+	ID        string `json:"id" bson:"_id"`
+	Identity  string `json:"identity" bson:"identity"`
+	RevokedAt int64  `json:"revoked_at" bson:"revoked_at"`
+	Exp       int64  `json:"exp" bson:"exp"`
+}
+
+// RevokedUser semua token milik user yang diterbitkan sebelum NotBefore dianggap tidak berlaku
+type RevokedUser struct {
+	ID        string `json:"id" bson:"_id"`
+	NotBefore int64  `json:"not_before" bson:"not_before"`
+}
+
+// UserLogoutRequest refresh token bersifat opsional, jika dikirim akan ikut dicabut
+type UserLogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenFamily rangkaian refresh token hasil rotasi yang berasal dari satu kali login.
+// CurrentTokenID adalah jti refresh token terakhir yang boleh digunakan
+type TokenFamily struct {
+	ID             string `json:"id" bson:"_id"`
+	Identity       string `json:"identity" bson:"identity"`
+	CurrentTokenID string `json:"current_token_id" bson:"current_token_id"`
+	CreatedAt      int64  `json:"created_at" bson:"created_at"`
+	UpdatedAt      int64  `json:"updated_at" bson:"updated_at"`
+	Exp            int64  `json:"exp" bson:"exp"`
+	Revoked        bool   `json:"revoked" bson:"revoked"`
+	RevokedAt      int64  `json:"revoked_at" bson:"revoked_at"`
+}
+
+// StreamTicketResponse tiket untuk membuka koneksi server-sent events melalui query ticket,
+// tiket hanya berlaku sampai Expired namun koneksi yang terbuka mengikuti masa berlaku access token
+type StreamTicketResponse struct {
+	Ticket  string `json:"ticket"`
+	Expired int64  `json:"expired"`
+}
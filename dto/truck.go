@@ -16,7 +16,10 @@ type Truck struct {
 	NoIdentity string `json:"no_identity" bson:"no_identity"`
 	NoPol      string `json:"no_pol" bson:"no_pol"`
 	Mark       string `json:"mark" bson:"mark"`
+	OwnerID    string `json:"owner_id" bson:"owner_id"`
 	Owner      string `json:"owner" bson:"owner"`
+	JptID      string `json:"jpt_id" bson:"jpt_id"`
+	JptName    string `json:"jpt_name" bson:"jpt_name"`
 	Email      string `json:"email" bson:"email"`
 	Hp         string `json:"hp" bson:"hp"`
 	Deleted    bool   `json:"deleted" bson:"deleted"`
@@ -26,6 +29,40 @@ type Truck struct {
 	Blocked        bool  `json:"blocked" bson:"blocked"`
 	BlockStart     int64 `json:"block_start" bson:"block_start"`
 	BlockEnd       int64 `json:"block_end" bson:"block_end"`
+	// BlockPermanent truck masuk daftar hitam, BlockEnd 0 dan tidak direset otomatis
+	BlockPermanent bool   `json:"block_permanent" bson:"block_permanent"`
+	BlockReason    string `json:"block_reason" bson:"block_reason"`
+	// BlockReminderAt waktu pengingat blokir berakhir dikirim, lebih kecil dari BlockStart berarti belum dikirim
+	BlockReminderAt int64 `json:"block_reminder_at" bson:"block_reminder_at"`
+}
+
+// TruckBlockEdit blokir manual oleh HSSE, Blocked false berarti mencabut blokir
+type TruckBlockEdit struct {
+	ID             primitive.ObjectID
+	FilterBranch   string
+	Blocked        bool
+	BlockStart     int64
+	BlockEnd       int64
+	BlockPermanent bool
+	BlockReason    string
+}
+
+// TruckBlockRequest user input, BlockTime lama blokir dalam detik dan diabaikan jika Permanent
+type TruckBlockRequest struct {
+	Reason    string `json:"reason"`
+	BlockTime int64  `json:"block_time"`
+	Permanent bool   `json:"permanent"`
+
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// TruckUnblockRequest user input
+type TruckUnblockRequest struct {
+	Reason string `json:"reason"`
+
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
 type TruckScoreEdit struct {
@@ -35,14 +72,20 @@ type TruckScoreEdit struct {
 	Blocked        bool
 	BlockStart     int64
 	BlockEnd       int64
+	BlockReason    string
 }
 
-// TruckRequest user input, id tidak diinput oleh user
+// TruckRequest user input, id tidak diinput oleh user.
+// OwnerID id perusahaan pemilik, nama owner diambil dari data perusahaan.
+// Owner nama pemilik teks bebas untuk client lama, digunakan jika OwnerID kosong.
+// JptID opsional, id JPT yang bertanggung jawab atas truck
 type TruckRequest struct {
 	NoIdentity string `json:"no_identity" bson:"no_identity"`
 	NoPol      string `json:"no_pol" bson:"no_pol"`
 	Mark       string `json:"mark" bson:"mark"`
+	OwnerID    string `json:"owner_id" bson:"owner_id"`
 	Owner      string `json:"owner" bson:"owner"`
+	JptID      string `json:"jpt_id" bson:"jpt_id"`
 	Email      string `json:"email" bson:"email"`
 	Hp         string `json:"hp" bson:"hp"`
 }
@@ -59,19 +102,24 @@ type TruckEdit struct {
 	NoIdentity string `json:"no_identity" bson:"no_identity"`
 	NoPol      string `json:"no_pol" bson:"no_pol"`
 	Mark       string `json:"mark" bson:"mark"`
+	OwnerID    string `json:"owner_id" bson:"owner_id"`
 	Owner      string `json:"owner" bson:"owner"`
+	JptID      string `json:"jpt_id" bson:"jpt_id"`
+	JptName    string `json:"jpt_name" bson:"jpt_name"`
 	Email      string `json:"email" bson:"email"`
 	Hp         string `json:"hp" bson:"hp"`
 }
 
-// TruckEditRequest user input
+// TruckEditRequest user input, Owner digunakan jika OwnerID kosong
 type TruckEditRequest struct {
 	FilterTimestamp int64 `json:"filter_timestamp"`
 
 	NoIdentity string `json:"no_identity" bson:"no_identity"`
 	NoPol      string `json:"no_pol" bson:"no_pol"`
 	Mark       string `json:"mark" bson:"mark"`
+	OwnerID    string `json:"owner_id" bson:"owner_id"`
 	Owner      string `json:"owner" bson:"owner"`
+	JptID      string `json:"jpt_id" bson:"jpt_id"`
 	Email      string `json:"email" bson:"email"`
 	Hp         string `json:"hp" bson:"hp"`
 }
@@ -85,7 +133,10 @@ type TruckResponseMin struct {
 	NoIdentity string `json:"no_identity" bson:"no_identity"`
 	NoPol      string `json:"no_pol" bson:"no_pol"`
 	Mark       string `json:"mark" bson:"mark"`
+	OwnerID    string `json:"owner_id" bson:"owner_id"`
 	Owner      string `json:"owner" bson:"owner"`
+	JptID      string `json:"jpt_id" bson:"jpt_id"`
+	JptName    string `json:"jpt_name" bson:"jpt_name"`
 	Email      string `json:"email" bson:"email"`
 	Hp         string `json:"hp" bson:"hp"`
 	Deleted    bool   `json:"deleted" bson:"deleted"`
@@ -95,4 +146,9 @@ type TruckResponseMin struct {
 	Blocked        bool  `json:"blocked" bson:"blocked"`
 	BlockStart     int64 `json:"block_start" bson:"block_start"`
 	BlockEnd       int64 `json:"block_end" bson:"block_end"`
+	// BlockPermanent truck masuk daftar hitam, BlockEnd 0 dan tidak direset otomatis
+	BlockPermanent bool   `json:"block_permanent" bson:"block_permanent"`
+	BlockReason    string `json:"block_reason" bson:"block_reason"`
+	// BlockReminderAt waktu pengingat blokir berakhir dikirim, lebih kecil dari BlockStart berarti belum dikirim
+	BlockReminderAt int64 `json:"block_reminder_at" bson:"block_reminder_at"`
 }
@@ -0,0 +1,41 @@
+package dto
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// TruckHistory riwayat perubahan truck, tidak pernah ditimpa sehingga periode blokir lama tetap tersimpan.
+// Actor kosong untuk event yang dijalankan sistem (misal blokir berakhir oleh scheduler).
+// NoIdentityBefore diisi pada event EDIT yang mengubah nomor lambung
+type TruckHistory struct {
+	ID         primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	TruckID    string             `json:"truck_id" bson:"truck_id"`
+	Branch     string             `json:"branch" bson:"branch"`
+	NoIdentity string             `json:"no_identity" bson:"no_identity"`
+	Event      string             `json:"event" bson:"event"`
+	Time       int64              `json:"time" bson:"time"`
+	Actor      string             `json:"actor" bson:"actor"`
+	ActorID    string             `json:"actor_id" bson:"actor_id"`
+	Detail     string             `json:"detail" bson:"detail"`
+
+	ScoreBefore int    `json:"score_before" bson:"score_before"`
+	ScoreAfter  int    `json:"score_after" bson:"score_after"`
+	BlockStart  int64  `json:"block_start" bson:"block_start"`
+	BlockEnd    int64  `json:"block_end" bson:"block_end"`
+	ViolationID string `json:"violation_id" bson:"violation_id"`
+
+	NoIdentityBefore string `json:"no_identity_before,omitempty" bson:"no_identity_before,omitempty"`
+}
+
+type TruckHistoryList []TruckHistory
+
+// TruckTimelineItem satu kejadian pada timeline truck, berisi Violation atau History sesuai Event
+type TruckTimelineItem struct {
+	Time      int64                 `json:"time"`
+	Event     string                `json:"event"`
+	Violation *ViolationResponseMin `json:"violation,omitempty"`
+	History   *TruckHistory         `json:"history,omitempty"`
+}
+
+type TruckTimelineResponse struct {
+	Truck Truck               `json:"truck"`
+	Items []TruckTimelineItem `json:"items"`
+}
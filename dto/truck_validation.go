@@ -1,6 +1,8 @@
 package dto
 
 import (
+	"strings"
+
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
 )
@@ -9,7 +11,7 @@ func (t TruckRequest) Validate() error {
 	if err := validation.ValidateStruct(&t,
 		validation.Field(&t.NoIdentity, validation.Required),
 		validation.Field(&t.NoPol, validation.Required),
-		validation.Field(&t.Owner, validation.Required),
+		validation.Field(&t.OwnerID, validation.When(strings.TrimSpace(t.Owner) == "", validation.Required)),
 		validation.Field(&t.Email, validation.Required, is.Email),
 	); err != nil {
 		return err
@@ -21,7 +23,7 @@ func (t TruckEditRequest) Validate() error {
 	if err := validation.ValidateStruct(&t,
 		validation.Field(&t.NoIdentity, validation.Required),
 		validation.Field(&t.NoPol, validation.Required),
-		validation.Field(&t.Owner, validation.Required),
+		validation.Field(&t.OwnerID, validation.When(strings.TrimSpace(t.Owner) == "", validation.Required)),
 		validation.Field(&t.Email, validation.Required, is.Email),
 		validation.Field(&t.FilterTimestamp, validation.Required),
 	); err != nil {
@@ -29,3 +31,22 @@ func (t TruckEditRequest) Validate() error {
 	}
 	return nil
 }
+
+func (t TruckBlockRequest) Validate() error {
+	if err := validation.ValidateStruct(&t,
+		validation.Field(&t.Reason, validation.Required, validation.Length(5, 500)),
+		validation.Field(&t.BlockTime, validation.When(!t.Permanent, validation.Required, validation.Min(int64(60)))),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t TruckUnblockRequest) Validate() error {
+	if err := validation.ValidateStruct(&t,
+		validation.Field(&t.Reason, validation.Required, validation.Length(5, 500)),
+	); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package dto
+
+// TwoFactorTokenRequest token sementara yang didapat dari endpoint login
+type TwoFactorTokenRequest struct {
+	TwoFactorToken string `json:"two_factor_token"`
+}
+
+// TwoFactorVerifyRequest Code dapat berupa kode TOTP 6 digit atau recovery code
+type TwoFactorVerifyRequest struct {
+	TwoFactorToken string `json:"two_factor_token"`
+	Code           string `json:"code"`
+	Limit          int    `json:"limit"`
+	IP             string `json:"-"`
+	UserAgent      string `json:"-"`
+}
+
+// TwoFactorActivateRequest TwoFactorToken dan Limit hanya digunakan pada aktivasi saat login
+type TwoFactorActivateRequest struct {
+	TwoFactorToken string `json:"two_factor_token"`
+	Code           string `json:"code"`
+	Limit          int    `json:"limit"`
+	IP             string `json:"-"`
+	UserAgent      string `json:"-"`
+}
+
+// TwoFactorSetupResponse secret dan qr code untuk didaftarkan ke aplikasi authenticator
+type TwoFactorSetupResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+	QRCode string `json:"qr_code"`
+}
+
+// TwoFactorActivateResponse recovery code hanya ditampilkan sekali.
+// Login terisi jika aktivasi dilakukan pada saat login
+type TwoFactorActivateResponse struct {
+	RecoveryCodes []string           `json:"recovery_codes"`
+	Login         *UserLoginResponse `json:"login,omitempty"`
+}
@@ -6,11 +6,32 @@ type User struct {
 	Email     string   `json:"email" bson:"email"`
 	Name      string   `json:"name" bson:"name"`
 	Branch    string   `json:"branch" bson:"branch"`
+	Branches  []string `json:"branches" bson:"branches"`
 	Roles     []string `json:"roles" bson:"roles"`
 	Avatar    string   `json:"avatar" bson:"avatar"`
 	HashPw    string   `json:"hash_pw,omitempty" bson:"hash_pw,omitempty"`
 	FcmToken  string   `json:"fcm_token" bson:"fcm_token"`
 	Timestamp int64    `json:"timestamp" bson:"timestamp"`
+
+	FailedLogin     int   `json:"failed_login" bson:"failed_login"`
+	LastFailedLogin int64 `json:"last_failed_login" bson:"last_failed_login"`
+	LockedUntil     int64 `json:"locked_until" bson:"locked_until"`
+
+	TwoFactorEnabled       bool     `json:"two_factor_enabled" bson:"two_factor_enabled"`
+	TwoFactorSecret        string   `json:"-" bson:"two_factor_secret"`
+	TwoFactorPendingSecret string   `json:"-" bson:"two_factor_pending_secret"`
+	TwoFactorRecovery      []string `json:"-" bson:"two_factor_recovery"`
+	TwoFactorLastStep      int64    `json:"-" bson:"two_factor_last_step"`
+
+	PasswordHistory    []string `json:"-" bson:"password_history"`
+	PasswordChangedAt  int64    `json:"password_changed_at" bson:"password_changed_at"`
+	MustChangePassword bool     `json:"must_change_password" bson:"must_change_password"`
+
+	Deactivated   bool   `json:"deactivated" bson:"deactivated"`
+	DeactivatedAt int64  `json:"deactivated_at" bson:"deactivated_at"`
+	DeactivatedBy string `json:"deactivated_by" bson:"deactivated_by"`
+
+	SsoSubject string `json:"-" bson:"sso_subject"`
 }
 
 // UserResponseList tipe slice dari UserResponse
@@ -22,18 +43,39 @@ type UserResponse struct {
 	Email     string   `json:"email" bson:"email"`
 	Name      string   `json:"name" bson:"name"`
 	Branch    string   `json:"branch" bson:"branch"`
+	Branches  []string `json:"branches" bson:"branches"`
 	Roles     []string `json:"roles" bson:"roles"`
 	Avatar    string   `json:"avatar" bson:"avatar"`
 	FcmToken  string   `json:"fcm_token" bson:"fcm_token"`
 	Timestamp int64    `json:"timestamp" bson:"timestamp"`
+
+	FailedLogin        int   `json:"failed_login" bson:"failed_login"`
+	LockedUntil        int64 `json:"locked_until" bson:"locked_until"`
+	TwoFactorEnabled   bool  `json:"two_factor_enabled" bson:"two_factor_enabled"`
+	PasswordChangedAt  int64 `json:"password_changed_at" bson:"password_changed_at"`
+	MustChangePassword bool  `json:"must_change_password" bson:"must_change_password"`
+
+	Deactivated   bool   `json:"deactivated" bson:"deactivated"`
+	DeactivatedAt int64  `json:"deactivated_at" bson:"deactivated_at"`
+	DeactivatedBy string `json:"deactivated_by" bson:"deactivated_by"`
+}
+
+// UserPageResponse satu halaman daftar user beserta jumlah total user sesuai filter
+type UserPageResponse struct {
+	Users UserResponseList `json:"users"`
+	Total int64            `json:"total"`
+	Page  int64            `json:"page"`
+	Limit int64            `json:"limit"`
 }
 
 // UserRequest input JSON untuk keperluan register, timestamp dapat diabaikan
+// Branches cabang tambahan yang dapat dibaca, hanya berlaku untuk role REGIONAL
 type UserRequest struct {
 	ID        string   `json:"id" bson:"_id"`
 	Name      string   `json:"name" bson:"name"`
 	Email     string   `json:"email" bson:"email"`
 	Branch    string   `json:"branch" bson:"branch"`
+	Branches  []string `json:"branches" bson:"branches"`
 	Roles     []string `json:"roles" bson:"roles"`
 	Avatar    string   `json:"avatar" bson:"avatar"`
 	Password  string   `json:"password" bson:"password"`
@@ -49,15 +91,19 @@ type UserUpdateFcmRequest struct {
 type UserEditRequest struct {
 	Name            string   `json:"name" bson:"name"`
 	Branch          string   `json:"branch" bson:"branch"`
+	Branches        []string `json:"branches" bson:"branches"`
 	Roles           []string `json:"roles" bson:"roles"`
 	TimestampFilter int64    `json:"timestamp_filter" bson:"timestamp"`
 }
 
 // UserLoginRequest input JSON oleh client untuk keperluan login
+// IP dan UserAgent diisi oleh handler untuk keperluan audit
 type UserLoginRequest struct {
-	ID       string `json:"id" bson:"_id"`
-	Password string `json:"password" bson:"password"`
-	Limit    int    `json:"limit"`
+	ID        string `json:"id" bson:"_id"`
+	Password  string `json:"password" bson:"password"`
+	Limit     int    `json:"limit"`
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
 // UserChangePasswordRequest struck untuk keperluan change password dan reset password
@@ -68,27 +114,65 @@ type UserChangePasswordRequest struct {
 	NewPassword string `json:"new_password"`
 }
 
-// UserLoginResponse balikan user ketika sukses login dengan tambahan AccessToken
+// UserPasswordChange data penyimpanan password baru beserta riwayat hash password sebelumnya
+type UserPasswordChange struct {
+	ID         string
+	HashPw     string
+	History    []string
+	MustChange bool
+	ChangedAt  int64
+}
+
+// UserResetPasswordResponse password sementara hasil reset oleh admin, wajib diganti pada login berikutnya
+type UserResetPasswordResponse struct {
+	ID                string `json:"id"`
+	TemporaryPassword string `json:"temporary_password"`
+}
+
+// LoginChangePasswordRequest input penggantian password pada saat login menggunakan PasswordChangeToken
+type LoginChangePasswordRequest struct {
+	PasswordChangeToken string `json:"password_change_token"`
+	NewPassword         string `json:"new_password"`
+	Limit               int    `json:"limit"`
+	IP                  string `json:"-"`
+	UserAgent           string `json:"-"`
+}
+
+// UserLoginResponse balikan user ketika sukses login dengan tambahan AccessToken.
+// jika TwoFactorRequired atau TwoFactorSetupRequired bernilai true, AccessToken dan RefreshToken kosong
+// dan client harus melanjutkan login menggunakan TwoFactorToken.
+// jika PasswordChangeRequired bernilai true, client harus mengganti password menggunakan PasswordChangeToken
 type UserLoginResponse struct {
-	ID           string   `json:"id" bson:"_id"`
-	Email        string   `json:"email" bson:"email"`
-	Name         string   `json:"name" bson:"name"`
-	Branch       string   `json:"branch" bson:"branch"`
-	Roles        []string `json:"roles" bson:"roles"`
-	Avatar       string   `json:"avatar" bson:"avatar"`
-	AccessToken  string   `json:"access_token"`
-	RefreshToken string   `json:"refresh_token"`
-	Expired      int64    `json:"expired"`
+	ID                     string   `json:"id" bson:"_id"`
+	Email                  string   `json:"email" bson:"email"`
+	Name                   string   `json:"name" bson:"name"`
+	Branch                 string   `json:"branch" bson:"branch"`
+	Branches               []string `json:"branches" bson:"branches"`
+	Roles                  []string `json:"roles" bson:"roles"`
+	Avatar                 string   `json:"avatar" bson:"avatar"`
+	AccessToken            string   `json:"access_token"`
+	RefreshToken           string   `json:"refresh_token"`
+	Expired                int64    `json:"expired"`
+	TwoFactorRequired      bool     `json:"two_factor_required"`
+	TwoFactorSetupRequired bool     `json:"two_factor_setup_required"`
+	TwoFactorToken         string   `json:"two_factor_token,omitempty"`
+	PasswordChangeRequired bool     `json:"password_change_required"`
+	PasswordChangeToken    string   `json:"password_change_token,omitempty"`
 }
 
+// UserRefreshTokenRequest IP dan UserAgent diisi oleh handler untuk keperluan audit
 type UserRefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token"`
 	Limit        int    `json:"limit"`
+	IP           string `json:"-"`
+	UserAgent    string `json:"-"`
 }
 
 // UserRefreshTokenResponse mengembalikan token dengan claims yang
-// sama dengan token sebelumnya dengan expired yang baru
+// sama dengan token sebelumnya dengan expired yang baru.
+// RefreshToken wajib disimpan client karena refresh token lama tidak dapat digunakan lagi
 type UserRefreshTokenResponse struct {
-	AccessToken string `json:"access_token"`
-	Expired     int64  `json:"expired"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	Expired      int64  `json:"expired"`
 }
@@ -13,7 +13,7 @@ func (u UserRequest) Validate() error {
 		validation.Field(&u.Name, validation.Required),
 		validation.Field(&u.Branch, validation.Required),
 		validation.Field(&u.Roles, validation.Required),
-		validation.Field(&u.Password, validation.Required, validation.Length(3, 20)),
+		validation.Field(&u.Password, validation.Required),
 	); err != nil {
 		return err
 	}
@@ -45,15 +45,23 @@ func (u UserLoginRequest) Validate() error {
 	return validation.ValidateStruct(&u,
 		// validation.Field(&u.Email, validation.Required, is.Email),
 		validation.Field(&u.ID, validation.Required),
-		validation.Field(&u.Password, validation.Required, validation.Length(3, 20)),
+		validation.Field(&u.Password, validation.Required, validation.Length(3, 64)),
 	)
 }
 
 // Validate input
 func (u UserChangePasswordRequest) Validate() error {
 	return validation.ValidateStruct(&u,
-		validation.Field(&u.Password, validation.Required, validation.Length(3, 20)),
-		validation.Field(&u.NewPassword, validation.Required, validation.Length(3, 20)),
+		validation.Field(&u.Password, validation.Required),
+		validation.Field(&u.NewPassword, validation.Required),
+	)
+}
+
+// Validate input, aturan kekuatan password dicek di service
+func (l LoginChangePasswordRequest) Validate() error {
+	return validation.ValidateStruct(&l,
+		validation.Field(&l.PasswordChangeToken, validation.Required),
+		validation.Field(&l.NewPassword, validation.Required),
 	)
 }
 
@@ -70,3 +78,25 @@ func (u UserUpdateFcmRequest) Validate() error {
 		validation.Field(&u.FcmToken, validation.Required),
 	)
 }
+
+// Validate input
+func (t TwoFactorTokenRequest) Validate() error {
+	return validation.ValidateStruct(&t,
+		validation.Field(&t.TwoFactorToken, validation.Required),
+	)
+}
+
+// Validate input
+func (t TwoFactorVerifyRequest) Validate() error {
+	return validation.ValidateStruct(&t,
+		validation.Field(&t.TwoFactorToken, validation.Required),
+		validation.Field(&t.Code, validation.Required, validation.Length(6, 20)),
+	)
+}
+
+// Validate input
+func (t TwoFactorActivateRequest) Validate() error {
+	return validation.ValidateStruct(&t,
+		validation.Field(&t.Code, validation.Required, validation.Length(6, 6)),
+	)
+}
@@ -2,6 +2,7 @@ package dto
 
 import (
 	"fmt"
+	"strings"
 	"tilank/config"
 	"tilank/utils/sfunc"
 )
@@ -23,3 +24,31 @@ func typeViolationValidation(typeViolation string) error {
 
 	return nil
 }
+
+func webhookEventValidation(eventsIn []string) error {
+	if !sfunc.ValueInSliceIsAvailable(eventsIn, config.GetWebhookEventAvailable()) {
+		return fmt.Errorf("event yang dimasukkan tidak tersedia. gunakan %s", config.GetWebhookEventAvailable())
+	}
+	return nil
+}
+
+func permissionValidation(permissionsIn []string) error {
+	if !sfunc.ValueInSliceIsAvailable(permissionsIn, config.GetPermissionsAvailable()) {
+		return fmt.Errorf("permission yang dimasukkan tidak tersedia. gunakan %s", config.GetPermissionsAvailable())
+	}
+	return nil
+}
+
+func apiKeyScopeValidation(scopesIn []string) error {
+	if !sfunc.ValueInSliceIsAvailable(scopesIn, config.GetApiKeyScopesAvailable()) {
+		return fmt.Errorf("scope yang dimasukkan tidak tersedia. gunakan %s", config.GetApiKeyScopesAvailable())
+	}
+	return nil
+}
+
+func rulesSubjectValidation(subject string) error {
+	if subject != "" && !sfunc.InSlice(strings.ToUpper(subject), config.GetRulesSubjectAvailable()) {
+		return fmt.Errorf("subject yang dimasukkan tidak tersedia. gunakan %s", config.GetRulesSubjectAvailable())
+	}
+	return nil
+}
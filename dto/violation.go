@@ -24,12 +24,31 @@ type Violation struct {
 	NoIdentity      string     `json:"no_identity" bson:"no_identity"`
 	NoPol           string     `json:"no_pol" bson:"no_pol"`
 	Mark            string     `json:"mark" bson:"mark"`
+	OwnerID         string     `json:"owner_id" bson:"owner_id"`
 	Owner           string     `json:"owner" bson:"owner"`
 	TypeViolation   string     `json:"type_violation" bson:"type_violation"`
 	DetailViolation string     `json:"detail_violation" bson:"detail_violation"`
 	TimeViolation   int64      `json:"time_violation" bson:"time_violation"`
 	Location        string     `json:"location" bson:"location"`
 	Images          []string   `json:"images" bson:"images"`
+	// JptID dan JptName disalin dari truck saat pelanggaran disetujui
+	JptID   string `json:"jpt_id" bson:"jpt_id"`
+	JptName string `json:"jpt_name" bson:"jpt_name"`
+	// DriverID kosong jika pelanggaran tidak mencantumkan sopir
+	DriverID    string `json:"driver_id" bson:"driver_id"`
+	NoLicense   string `json:"no_license" bson:"no_license"`
+	DriverName  string `json:"driver_name" bson:"driver_name"`
+	DriverNViol int    `json:"driver_n_viol" bson:"driver_n_viol"`
+	// SubmittedAt waktu dokumen diajukan untuk persetujuan, EscalationLevel naik setiap kelipatan
+	// SLA persetujuan terlewati dan tetap disimpan setelah disetujui untuk laporan lama persetujuan
+	SubmittedAt     int64 `json:"submitted_at" bson:"submitted_at"`
+	EscalationLevel int   `json:"escalation_level" bson:"escalation_level"`
+	EscalatedAt     int64 `json:"escalated_at" bson:"escalated_at"`
+	// DraftWarnedAt waktu pembuat draft diperingatkan akan pengarsipan, Archived draft yang
+	// diarsipkan karena tidak diubah melewati masa retensi cabang dan tidak tampil pada pencarian
+	DraftWarnedAt int64 `json:"draft_warned_at" bson:"draft_warned_at"`
+	Archived      bool  `json:"archived" bson:"archived"`
+	ArchivedAt    int64 `json:"archived_at" bson:"archived_at"`
 }
 
 // ViolationRequest user input, id tidak diinput oleh user
@@ -42,6 +61,8 @@ type ViolationRequest struct {
 	DetailViolation string     `json:"detail_violation" bson:"detail_violation"`
 	TimeViolation   int64      `json:"time_violation" bson:"time_violation"`
 	Location        string     `json:"location" bson:"location"`
+	// NoLicense nomor SIM sopir, opsional
+	NoLicense string `json:"no_license" bson:"no_license"`
 }
 
 type ViolationEdit struct {
@@ -60,11 +81,16 @@ type ViolationEdit struct {
 	NoIdentity      string
 	NoPol           string
 	Mark            string
+	OwnerID         string
 	Owner           string
 	TypeViolation   string
 	DetailViolation string
 	TimeViolation   int64
 	Location        string
+
+	DriverID   string
+	NoLicense  string
+	DriverName string
 }
 
 type ViolationConfirm struct {
@@ -79,8 +105,24 @@ type ViolationConfirm struct {
 	ApprovedBy   string
 	ApprovedByID string
 
-	State enum.State
-	NViol int
+	State       enum.State
+	NViol       int
+	DriverNViol int
+
+	// JptID dan JptName hanya disimpan saat State Approved
+	JptID   string
+	JptName string
+}
+
+// ViolationRestore data pemulihan draft yang diarsipkan, waktu update diperbarui
+// sehingga masa retensi draft dihitung ulang
+type ViolationRestore struct {
+	ID           primitive.ObjectID
+	FilterBranch string
+
+	UpdatedAt   int64
+	UpdatedBy   string
+	UpdatedByID string
 }
 
 // ViolationEditRequest user input
@@ -94,26 +136,41 @@ type ViolationEditRequest struct {
 	DetailViolation string `json:"detail_violation" bson:"detail_violation"`
 	TimeViolation   int64  `json:"time_violation" bson:"time_violation"`
 	Location        string `json:"location" bson:"location"`
+	// NoLicense nomor SIM sopir, kosong berarti pelanggaran tidak mencantumkan sopir
+	NoLicense string `json:"no_license" bson:"no_license"`
 }
 
 type ViolationResponseMinList []ViolationResponseMin
 
 type ViolationResponseMin struct {
-	ID         primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	CreatedAt  int64              `json:"created_at" bson:"created_at"`
-	CreatedBy  string             `json:"created_by" bson:"created_by"`
-	ApprovedAt int64              `json:"approved_at" bson:"approved_at"`
-	ApprovedBy string             `json:"approved_by" bson:"approved_by"`
-	Branch     string             `json:"branch" bson:"branch"`
+	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt   int64              `json:"created_at" bson:"created_at"`
+	CreatedBy   string             `json:"created_by" bson:"created_by"`
+	CreatedByID string             `json:"created_by_id" bson:"created_by_id"`
+	UpdatedAt   int64              `json:"updated_at" bson:"updated_at"`
+	ApprovedAt  int64              `json:"approved_at" bson:"approved_at"`
+	ApprovedBy  string             `json:"approved_by" bson:"approved_by"`
+	Branch      string             `json:"branch" bson:"branch"`
 	// State 0 Draft, 1 Need Approve, 2 Approved, 3 sendToJPT
 	State           enum.State `json:"state" bson:"state"`
 	NViol           int        `json:"n_viol" bson:"n_viol"`
 	NoIdentity      string     `json:"no_identity" bson:"no_identity"`
 	NoPol           string     `json:"no_pol" bson:"no_pol"`
+	OwnerID         string     `json:"owner_id" bson:"owner_id"`
 	Owner           string     `json:"owner" bson:"owner"`
 	TypeViolation   string     `json:"type_violation" bson:"type_violation"`
 	DetailViolation string     `json:"detail_violation" bson:"detail_violation"`
 	TimeViolation   int64      `json:"time_violation" bson:"time_violation"`
 	Location        string     `json:"location" bson:"location"`
 	Images          []string   `json:"images" bson:"images"`
+	JptID           string     `json:"jpt_id" bson:"jpt_id"`
+	JptName         string     `json:"jpt_name" bson:"jpt_name"`
+	NoLicense       string     `json:"no_license" bson:"no_license"`
+	DriverName      string     `json:"driver_name" bson:"driver_name"`
+	SubmittedAt     int64      `json:"submitted_at" bson:"submitted_at"`
+	EscalationLevel int        `json:"escalation_level" bson:"escalation_level"`
+	EscalatedAt     int64      `json:"escalated_at" bson:"escalated_at"`
+	DraftWarnedAt   int64      `json:"draft_warned_at" bson:"draft_warned_at"`
+	Archived        bool       `json:"archived" bson:"archived"`
+	ArchivedAt      int64      `json:"archived_at" bson:"archived_at"`
 }
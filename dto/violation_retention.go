@@ -0,0 +1,26 @@
+package dto
+
+// DraftRetention aturan pengarsipan draft pelanggaran per cabang.
+// draft yang tidak diubah selama RetentionDay hari diarsipkan setelah pembuatnya diperingatkan
+// WarningDay hari sebelumnya, RetentionDay 0 berarti pengarsipan tidak aktif untuk cabang tersebut
+type DraftRetention struct {
+	Branch       string `json:"branch" bson:"_id"`
+	RetentionDay int    `json:"retention_day" bson:"retention_day"`
+	WarningDay   int    `json:"warning_day" bson:"warning_day"`
+	UpdatedAt    int64  `json:"updated_at" bson:"updated_at"`
+	UpdatedBy    string `json:"updated_by" bson:"updated_by"`
+	// Default true jika cabang belum memiliki aturan tersimpan dan menggunakan nilai dari environment
+	Default bool `json:"default" bson:"-"`
+}
+
+// DraftRetentionRequest input user untuk mengganti aturan pengarsipan draft cabangnya
+type DraftRetentionRequest struct {
+	RetentionDay int `json:"retention_day"`
+	WarningDay   int `json:"warning_day"`
+}
+
+// DraftArchiveResult hasil pengarsipan draft oleh scheduler
+type DraftArchiveResult struct {
+	Warned   int64 `json:"warned"`
+	Archived int64 `json:"archived"`
+}
@@ -36,3 +36,14 @@ func (c ViolationEditRequest) Validate() error {
 	}
 	return nil
 }
+
+func (d DraftRetentionRequest) Validate() error {
+	if err := validation.ValidateStruct(&d,
+		validation.Field(&d.RetentionDay, validation.Min(0), validation.Max(365)),
+		validation.Field(&d.WarningDay, validation.Min(0),
+			validation.When(d.RetentionDay > 0, validation.Max(d.RetentionDay-1)).Else(validation.Max(0))),
+	); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package dto
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Webhook struct penuh dari langganan webhook
+// Branch kosong berarti menerima event dari semua cabang
+type Webhook struct {
+	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt   int64              `json:"created_at" bson:"created_at"`
+	CreatedBy   string             `json:"created_by" bson:"created_by"`
+	CreatedByID string             `json:"created_by_id" bson:"created_by_id"`
+	UpdatedAt   int64              `json:"updated_at" bson:"updated_at"`
+	UpdatedBy   string             `json:"updated_by" bson:"updated_by"`
+	UpdatedByID string             `json:"updated_by_id" bson:"updated_by_id"`
+
+	Name   string   `json:"name" bson:"name"`
+	URL    string   `json:"url" bson:"url"`
+	Secret string   `json:"secret" bson:"secret"`
+	Events []string `json:"events" bson:"events"`
+	Branch string   `json:"branch" bson:"branch"`
+	Active bool     `json:"active" bson:"active"`
+}
+
+// WebhookRequest user input, secret akan digenerate jika kosong
+type WebhookRequest struct {
+	Name   string   `json:"name" bson:"name"`
+	URL    string   `json:"url" bson:"url"`
+	Secret string   `json:"secret" bson:"secret"`
+	Events []string `json:"events" bson:"events"`
+	Branch string   `json:"branch" bson:"branch"`
+}
+
+type WebhookEdit struct {
+	ID              primitive.ObjectID
+	FilterTimestamp int64
+
+	UpdatedAt   int64
+	UpdatedBy   string
+	UpdatedByID string
+
+	Name   string
+	URL    string
+	Events []string
+	Branch string
+	Active bool
+}
+
+// WebhookEditRequest user input, secret tidak dapat diubah melalui edit
+type WebhookEditRequest struct {
+	FilterTimestamp int64 `json:"filter_timestamp"`
+
+	Name   string   `json:"name" bson:"name"`
+	URL    string   `json:"url" bson:"url"`
+	Events []string `json:"events" bson:"events"`
+	Branch string   `json:"branch" bson:"branch"`
+	Active bool     `json:"active" bson:"active"`
+}
+
+// WebhookResponse detail webhook tanpa secret, secret hanya ditampilkan saat webhook dibuat
+type WebhookResponse struct {
+	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt   int64              `json:"created_at" bson:"created_at"`
+	CreatedBy   string             `json:"created_by" bson:"created_by"`
+	CreatedByID string             `json:"created_by_id" bson:"created_by_id"`
+	UpdatedAt   int64              `json:"updated_at" bson:"updated_at"`
+	UpdatedBy   string             `json:"updated_by" bson:"updated_by"`
+	UpdatedByID string             `json:"updated_by_id" bson:"updated_by_id"`
+
+	Name   string   `json:"name" bson:"name"`
+	URL    string   `json:"url" bson:"url"`
+	Events []string `json:"events" bson:"events"`
+	Branch string   `json:"branch" bson:"branch"`
+	Active bool     `json:"active" bson:"active"`
+}
+
+type WebhookResponseMinList []WebhookResponseMin
+
+// WebhookResponseMin tanpa secret
+type WebhookResponseMin struct {
+	ID        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UpdatedAt int64              `json:"updated_at" bson:"updated_at"`
+	Name      string             `json:"name" bson:"name"`
+	URL       string             `json:"url" bson:"url"`
+	Events    []string           `json:"events" bson:"events"`
+	Branch    string             `json:"branch" bson:"branch"`
+	Active    bool               `json:"active" bson:"active"`
+}
+
+// WebhookDelivery log pengiriman satu event ke satu webhook
+// Status pending, success, failed
+type WebhookDelivery struct {
+	ID        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	CreatedAt int64              `json:"created_at" bson:"created_at"`
+	UpdatedAt int64              `json:"updated_at" bson:"updated_at"`
+
+	WebhookID      primitive.ObjectID `json:"webhook_id" bson:"webhook_id"`
+	Event          string             `json:"event" bson:"event"`
+	Branch         string             `json:"branch" bson:"branch"`
+	URL            string             `json:"url" bson:"url"`
+	Payload        string             `json:"payload" bson:"payload"`
+	Status         string             `json:"status" bson:"status"`
+	Attempts       int                `json:"attempts" bson:"attempts"`
+	NextAttemptAt  int64              `json:"next_attempt_at" bson:"next_attempt_at"`
+	LastStatusCode int                `json:"last_status_code" bson:"last_status_code"`
+	LastError      string             `json:"last_error" bson:"last_error"`
+}
+
+type WebhookDeliveryAttempt struct {
+	ID             primitive.ObjectID
+	UpdatedAt      int64
+	Status         string
+	Attempts       int
+	NextAttemptAt  int64
+	LastStatusCode int
+	LastError      string
+}
+
+type WebhookDeliveryList []WebhookDelivery
+
+// WebhookEventPayload body yang dikirimkan ke url webhook
+type WebhookEventPayload struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	Branch    string      `json:"branch"`
+	Timestamp int64       `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
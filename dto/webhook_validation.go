@@ -0,0 +1,36 @@
+package dto
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+func (w WebhookRequest) Validate() error {
+	if err := validation.ValidateStruct(&w,
+		validation.Field(&w.Name, validation.Required),
+		validation.Field(&w.URL, validation.Required, is.URL),
+		validation.Field(&w.Secret, validation.Length(16, 128)),
+		validation.Field(&w.Events, validation.Required),
+	); err != nil {
+		return err
+	}
+	if err := webhookEventValidation(w.Events); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (w WebhookEditRequest) Validate() error {
+	if err := validation.ValidateStruct(&w,
+		validation.Field(&w.Name, validation.Required),
+		validation.Field(&w.URL, validation.Required, is.URL),
+		validation.Field(&w.Events, validation.Required),
+		validation.Field(&w.FilterTimestamp, validation.Required),
+	); err != nil {
+		return err
+	}
+	if err := webhookEventValidation(w.Events); err != nil {
+		return err
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ go 1.16
 
 require (
 	firebase.google.com/go/v4 v4.6.0
+	github.com/boombuler/barcode v1.0.0
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/go-co-op/gocron v1.6.2
 	github.com/go-ozzo/ozzo-validation/v4 v4.3.0
@@ -12,6 +13,7 @@ require (
 	github.com/joho/godotenv v1.3.0
 	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646
 	github.com/stretchr/testify v1.7.0
+	github.com/valyala/fasthttp v1.23.0
 	go.mongodb.org/mongo-driver v1.5.2
 	go.uber.org/zap v1.16.0
 	golang.org/x/crypto v0.0.0-20210220033148-5ea612d1eb83
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"tilank/dto"
+	"tilank/service"
+	"tilank/utils/logger"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+)
+
+func NewApiKeyHandler(apiKeyService *service.ApiKeyService) *apiKeyHandler {
+	return &apiKeyHandler{
+		service: apiKeyService,
+	}
+}
+
+type apiKeyHandler struct {
+	service *service.ApiKeyService
+}
+
+// Insert membuat api key, key hanya ditampilkan sekali pada response ini
+func (a *apiKeyHandler) Insert(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	var req dto.ApiKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | parse | %s", claims.Name, err.Error()))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | validate | %s", claims.Name, err.Error()))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	result, apiErr := a.service.CreateApiKey(*claims, req)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": result})
+}
+
+// Revoke mencabut api key
+func (a *apiKeyHandler) Revoke(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	id := c.Params("id")
+
+	result, apiErr := a.service.RevokeApiKey(*claims, id)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": result})
+}
+
+// Find menampilkan seluruh api key tanpa hash
+func (a *apiKeyHandler) Find(c *fiber.Ctx) error {
+	result, apiErr := a.service.FindApiKey()
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": result})
+}
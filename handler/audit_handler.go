@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"tilank/dto"
+	"tilank/service"
+	"tilank/utils/sfunc"
+	"time"
+)
+
+func NewAuditHandler(auditService *service.AuditService) *auditHandler {
+	return &auditHandler{
+		service: auditService,
+	}
+}
+
+type auditHandler struct {
+	service *service.AuditService
+}
+
+// Find menampilkan audit log
+// Query [user, action, outcome, start, end, limit]
+func (a *auditHandler) Find(c *fiber.Ctx) error {
+	auditList, apiErr := a.service.FindAudit(auditFilterFromQuery(c))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": auditList})
+}
+
+// Export mengunduh audit log dalam format csv dengan query yang sama seperti Find
+func (a *auditHandler) Export(c *fiber.Ctx) error {
+	content, apiErr := a.service.ExportCSV(auditFilterFromQuery(c))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"audit-log-%s.csv\"", time.Now().Format("20060102-150405")))
+	return c.Send(content)
+}
+
+func auditFilterFromQuery(c *fiber.Ctx) dto.FilterAudit {
+	return dto.FilterAudit{
+		FilterUser:    c.Query("user"),
+		FilterAction:  c.Query("action"),
+		FilterOutcome: c.Query("outcome"),
+		FilterStart:   int64(sfunc.StrToInt(c.Query("start"), 0)),
+		FilterEnd:     int64(sfunc.StrToInt(c.Query("end"), 0)),
+		Limit:         int64(sfunc.StrToInt(c.Query("limit"), 0)),
+	}
+}
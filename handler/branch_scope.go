@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+	"tilank/config"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+	"tilank/utils/sfunc"
+)
+
+// readableBranches cabang yang dapat dibaca user. user biasa hanya cabangnya sendiri,
+// user dengan role REGIONAL juga dapat membaca cabang pada claims Branches
+func readableBranches(claims *mjwt.CustomClaim) []string {
+	branches := []string{strings.ToUpper(claims.Branch)}
+	if sfunc.InSlice(config.RoleRegional, claims.Roles) {
+		for _, branch := range claims.Branches {
+			branches = append(branches, strings.ToUpper(branch))
+		}
+	}
+	return branches
+}
+
+// canReadBranch return true jika user dapat membaca data milik cabang branch
+func canReadBranch(claims *mjwt.CustomClaim, branch string) bool {
+	return sfunc.InSlice(strings.ToUpper(branch), readableBranches(claims))
+}
+
+// resolveBranch menentukan cabang untuk query list, kosong berarti cabang user sendiri.
+// cabang di luar cakupan user ditolak
+func resolveBranch(claims *mjwt.CustomClaim, queryBranch string) (string, resterr.APIError) {
+	branch := strings.ToUpper(queryBranch)
+	if branch == "" {
+		return strings.ToUpper(claims.Branch), nil
+	}
+	if !canReadBranch(claims, branch) {
+		return "", resterr.NewUnauthorizedError(fmt.Sprintf("Unauthorized, tidak memiliki akses ke cabang %s", branch))
+	}
+	return branch, nil
+}
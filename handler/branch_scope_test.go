@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"tilank/config"
+	"tilank/utils/mjwt"
+)
+
+var (
+	securityClaims = &mjwt.CustomClaim{
+		Identity: "SEC01",
+		Roles:    []string{config.RoleSEC},
+		Branch:   "BANJARMASIN",
+		Type:     mjwt.Access,
+	}
+	regionalClaims = &mjwt.CustomClaim{
+		Identity: "REG01",
+		Roles:    []string{config.RoleHSSE, config.RoleRegional},
+		Branch:   "BANJARMASIN",
+		Branches: []string{"SAMPIT", "kotabaru"},
+		Type:     mjwt.Access,
+	}
+	// branches tanpa role REGIONAL diabaikan
+	nonRegionalClaims = &mjwt.CustomClaim{
+		Identity: "HSSE01",
+		Roles:    []string{config.RoleHSSE},
+		Branch:   "BANJARMASIN",
+		Branches: []string{"SAMPIT"},
+		Type:     mjwt.Access,
+	}
+)
+
+// newTestApp membuat fiber app dengan claims yang sudah terpasang, menggantikan middleware auth
+func newTestApp(claims *mjwt.CustomClaim) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals(mjwt.CLAIMS, claims)
+		return c.Next()
+	})
+	return app
+}
+
+func doGet(t *testing.T, app *fiber.App, target string) (int, string) {
+	resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+	assert.Nil(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestResolveBranch(t *testing.T) {
+	branch, err := resolveBranch(securityClaims, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "BANJARMASIN", branch)
+
+	_, err = resolveBranch(securityClaims, "sampit")
+	assert.NotNil(t, err)
+
+	branch, err = resolveBranch(regionalClaims, "sampit")
+	assert.Nil(t, err)
+	assert.Equal(t, "SAMPIT", branch)
+
+	branch, err = resolveBranch(regionalClaims, "KOTABARU")
+	assert.Nil(t, err)
+	assert.Equal(t, "KOTABARU", branch)
+
+	_, err = resolveBranch(regionalClaims, "BATULICIN")
+	assert.NotNil(t, err)
+
+	_, err = resolveBranch(nonRegionalClaims, "SAMPIT")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"tilank/dto"
+	"tilank/service"
+	"tilank/utils/logger"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+	"tilank/utils/sfunc"
+)
+
+func NewCompanyHandler(companyService service.CompanyServiceAssumer) *companyHandler {
+	return &companyHandler{
+		service: companyService,
+	}
+}
+
+type companyHandler struct {
+	service service.CompanyServiceAssumer
+}
+
+func (ch *companyHandler) Insert(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	var req dto.CompanyRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | parse | %s", claims.Name, err.Error()))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | validate | %s", claims.Name, err.Error()))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	insertID, apiErr := ch.service.InsertCompany(*claims, req)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	res := fmt.Sprintf("Menambahkan perusahaan berhasil, ID: %s", *insertID)
+	return c.JSON(fiber.Map{"error": nil, "data": res})
+}
+
+func (ch *companyHandler) Edit(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	companyID := c.Params("id")
+
+	var req dto.CompanyEditRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | parse | %s", claims.Name, err.Error()))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | validate | %s", claims.Name, err.Error()))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	companyEdited, apiErr := ch.service.EditCompany(*claims, companyID, req)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+	return c.JSON(fiber.Map{"error": nil, "data": companyEdited})
+}
+
+func (ch *companyHandler) Delete(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	id := c.Params("id")
+
+	apiErr := ch.service.DeleteCompany(*claims, id)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": fmt.Sprintf("perusahaan %s berhasil dinonaktifkan", id)})
+}
+
+func (ch *companyHandler) Activate(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	id := c.Params("id")
+
+	apiErr := ch.service.ActivateCompany(*claims, id)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": fmt.Sprintf("perusahaan %s berhasil diaktifkan", id)})
+}
+
+// getScoped mendapatkan perusahaan dan memastikan user dapat membaca cabang perusahaan tersebut
+func (ch *companyHandler) getScoped(claims *mjwt.CustomClaim, companyID string) (*dto.Company, resterr.APIError) {
+	company, apiErr := ch.service.GetCompanyByID(companyID, "")
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if !canReadBranch(claims, company.Branch) {
+		return nil, resterr.NewNotFoundError(fmt.Sprintf("Perusahaan dengan ID %s tidak ditemukan", companyID))
+	}
+	return company, nil
+}
+
+// Get menampilkan companyDetail
+func (ch *companyHandler) Get(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	company, apiErr := ch.getScoped(claims, c.Params("id"))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": company})
+}
+
+// Find menampilkan list perusahaan
+// Query [branch, name, active]
+func (ch *companyHandler) Find(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	branch, apiErr := resolveBranch(claims, c.Query("branch"))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	companyList, apiErr := ch.service.FindCompany(dto.FilterCompany{
+		FilterBranch: branch,
+		FilterName:   c.Query("name"),
+		Active:       sfunc.StrToInt(c.Query("active"), 1) != 0,
+	})
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": companyList})
+}
+
+// Fleet menampilkan truck aktif milik perusahaan beserta jumlah truck yang terblokir
+func (ch *companyHandler) Fleet(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	company, apiErr := ch.getScoped(claims, c.Params("id"))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	fleet, apiErr := ch.service.GetFleet(*company)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": fleet})
+}
+
+// Violations menampilkan rekap pelanggaran seluruh truck perusahaan
+// Query [start, end]
+func (ch *companyHandler) Violations(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	company, apiErr := ch.getScoped(claims, c.Params("id"))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	start := sfunc.StrToInt(c.Query("start"), 0)
+	end := sfunc.StrToInt(c.Query("end"), 0)
+
+	summary, apiErr := ch.service.GetViolationSummary(*company, int64(start), int64(end))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": summary})
+}
+
+// Migrate menghubungkan owner truck lama (teks bebas) ke data perusahaan
+func (ch *companyHandler) Migrate(c *fiber.Ctx) error {
+	result, apiErr := ch.service.MigrateTruckOwner()
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": result})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": result})
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tilank/dto"
+	"tilank/service"
+	"tilank/utils/rest_err"
+)
+
+// fakeCompanyService hanya mengimplementasikan method yang digunakan pada pengujian
+type fakeCompanyService struct {
+	service.CompanyServiceAssumer
+	company    dto.Company
+	lastFilter dto.FilterCompany
+}
+
+func (f *fakeCompanyService) GetCompanyByID(_ string, _ string) (*dto.Company, resterr.APIError) {
+	company := f.company
+	return &company, nil
+}
+
+func (f *fakeCompanyService) FindCompany(filter dto.FilterCompany) (dto.CompanyResponseMinList, resterr.APIError) {
+	f.lastFilter = filter
+	return dto.CompanyResponseMinList{}, nil
+}
+
+func (f *fakeCompanyService) GetFleet(company dto.Company) (*dto.CompanyFleetResponse, resterr.APIError) {
+	return &dto.CompanyFleetResponse{Company: company}, nil
+}
+
+func TestCompanyHandler_Fleet_BranchScope(t *testing.T) {
+	fake := &fakeCompanyService{company: dto.Company{Branch: "KOTABARU"}}
+	handler := NewCompanyHandler(fake)
+
+	app := newTestApp(securityClaims)
+	app.Get("/company/:id/fleet", handler.Fleet)
+	status, _ := doGet(t, app, "/company/1/fleet")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	app = newTestApp(regionalClaims)
+	app.Get("/company/:id/fleet", handler.Fleet)
+	status, _ = doGet(t, app, "/company/1/fleet")
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestCompanyHandler_Find_Query(t *testing.T) {
+	fake := &fakeCompanyService{}
+	handler := NewCompanyHandler(fake)
+
+	app := newTestApp(securityClaims)
+	app.Get("/company", handler.Find)
+	status, _ := doGet(t, app, "/company?name=maju&active=0")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "BANJARMASIN", fake.lastFilter.FilterBranch)
+	assert.Equal(t, "maju", fake.lastFilter.FilterName)
+	assert.False(t, fake.lastFilter.Active)
+
+	status, _ = doGet(t, app, "/company?branch=KOTABARU")
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"strings"
+	"tilank/dto"
+	"tilank/service"
+	"tilank/utils/logger"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+	"tilank/utils/sfunc"
+	"time"
+)
+
+func NewDriverHandler(driverService service.DriverServiceAssumer) *driverHandler {
+	return &driverHandler{
+		service: driverService,
+	}
+}
+
+type driverHandler struct {
+	service service.DriverServiceAssumer
+}
+
+func (dh *driverHandler) Insert(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	var req dto.DriverRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | parse | %s", claims.Name, err.Error()))
+
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | validate | %s", claims.Name, err.Error()))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	insertID, apiErr := dh.service.InsertDriver(*claims, req)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	res := fmt.Sprintf("Menambahkan sopir berhasil, ID: %s", *insertID)
+	return c.JSON(fiber.Map{"error": nil, "data": res})
+}
+
+// Delete menonaktifkan sopir
+func (dh *driverHandler) Delete(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	id := c.Params("id")
+
+	apiErr := dh.service.DeleteDriver(*claims, id)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": fmt.Sprintf("sopir %s berhasil dinonaktifkan", id)})
+}
+
+// Activate mengaktifkan kembali sopir yang dinonaktifkan
+func (dh *driverHandler) Activate(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	id := c.Params("id")
+
+	apiErr := dh.service.ActivateDriver(*claims, id)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": fmt.Sprintf("sopir %s berhasil diaktifkan", id)})
+}
+
+func (dh *driverHandler) Edit(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	driverID := c.Params("id")
+
+	var req dto.DriverEditRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | parse | %s", claims.Name, err.Error()))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | validate | %s", claims.Name, err.Error()))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	driverEdited, apiErr := dh.service.EditDriver(*claims, driverID, req)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+	return c.JSON(fiber.Map{"error": nil, "data": driverEdited})
+}
+
+// UploadImage mengganti foto sopir menggunakan form "image"
+func (dh *driverHandler) UploadImage(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	id := c.Params("id")
+
+	// cek apakah ID sopir && branch ada
+	_, apiErr := dh.service.GetDriverByID(id, claims.Branch)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	randomName := fmt.Sprintf("%s%v", id, time.Now().Unix())
+	pathInDB, apiErr := saveImage(c, *claims, "driver", randomName, false)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	driverResult, apiErr := dh.service.PutImage(*claims, id, pathInDB)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": driverResult})
+}
+
+// Get menampilkan driverDetail
+func (dh *driverHandler) Get(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	driverID := c.Params("id")
+
+	driver, apiErr := dh.service.GetDriverByID(driverID, "")
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+	if !canReadBranch(claims, driver.Branch) {
+		apiErr := resterr.NewNotFoundError(fmt.Sprintf("Sopir dengan ID %s tidak ditemukan", driverID))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": driver})
+}
+
+// GetByLicense menampilkan driverDetail berdasarkan nomor SIM
+// Query [branch]
+func (dh *driverHandler) GetByLicense(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	noLicense := c.Params("id")
+
+	branch, apiErr := resolveBranch(claims, c.Query("branch"))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	driver, apiErr := dh.service.GetDriverByLicense(noLicense, branch)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": driver})
+}
+
+// Find menampilkan list sopir
+// Query [branch, license, name, employer, active, block ]
+func (dh *driverHandler) Find(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	branch, apiErr := resolveBranch(claims, c.Query("branch"))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	filterA := dto.FilterDriver{
+		FilterBranch:    branch,
+		FilterNoLicense: strings.ToUpper(c.Query("license")),
+		FilterName:      strings.ToUpper(c.Query("name")),
+		FilterEmployer:  strings.ToUpper(c.Query("employer")),
+		Active:          sfunc.StrToInt(c.Query("active"), 1) != 0,
+		Blocked:         sfunc.StrToInt(c.Query("block"), 0) == 1,
+	}
+
+	driverList, apiErr := dh.service.FindDriver(filterA)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": driverList})
+}
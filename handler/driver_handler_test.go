@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tilank/dto"
+	"tilank/service"
+	"tilank/utils/rest_err"
+)
+
+// fakeDriverService hanya mengimplementasikan method yang digunakan pada pengujian
+type fakeDriverService struct {
+	service.DriverServiceAssumer
+	driver     dto.Driver
+	lastFilter dto.FilterDriver
+}
+
+func (f *fakeDriverService) GetDriverByID(_ string, _ string) (*dto.Driver, resterr.APIError) {
+	driver := f.driver
+	return &driver, nil
+}
+
+func (f *fakeDriverService) FindDriver(filter dto.FilterDriver) (dto.DriverResponseMinList, resterr.APIError) {
+	f.lastFilter = filter
+	return dto.DriverResponseMinList{}, nil
+}
+
+func TestDriverHandler_Get_BranchScope(t *testing.T) {
+	fake := &fakeDriverService{driver: dto.Driver{Branch: "KOTABARU"}}
+	handler := NewDriverHandler(fake)
+
+	app := newTestApp(securityClaims)
+	app.Get("/driver/:id", handler.Get)
+	status, _ := doGet(t, app, "/driver/1")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	app = newTestApp(regionalClaims)
+	app.Get("/driver/:id", handler.Get)
+	status, _ = doGet(t, app, "/driver/1")
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestDriverHandler_Find_Query(t *testing.T) {
+	fake := &fakeDriverService{}
+	handler := NewDriverHandler(fake)
+
+	app := newTestApp(securityClaims)
+	app.Get("/driver", handler.Find)
+	status, _ := doGet(t, app, "/driver?license=b123&employer=maju&active=0&block=1")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "BANJARMASIN", fake.lastFilter.FilterBranch)
+	assert.Equal(t, "B123", fake.lastFilter.FilterNoLicense)
+	assert.Equal(t, "MAJU", fake.lastFilter.FilterEmployer)
+	assert.False(t, fake.lastFilter.Active)
+	assert.True(t, fake.lastFilter.Blocked)
+
+	status, _ = doGet(t, app, "/driver?branch=KOTABARU")
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
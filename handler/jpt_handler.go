@@ -12,14 +12,14 @@ import (
 	"tilank/utils/sfunc"
 )
 
-func NewJptHandler(jptService *service.JptService) *jptHandler {
+func NewJptHandler(jptService service.JptServiceAssumer) *jptHandler {
 	return &jptHandler{
 		service: jptService,
 	}
 }
 
 type jptHandler struct {
-	service *service.JptService
+	service service.JptServiceAssumer
 }
 
 func (vj *jptHandler) Insert(c *fiber.Ctx) error {
@@ -86,12 +86,17 @@ func (vj *jptHandler) Edit(c *fiber.Ctx) error {
 
 // Get menampilkan jptDetail
 func (vj *jptHandler) Get(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
 	jptID := c.Params("id")
 
 	jpt, apiErr := vj.service.GetJptByID(jptID, "")
 	if apiErr != nil {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
 	}
+	if !canReadBranch(claims, jpt.Branch) {
+		apiErr := resterr.NewNotFoundError(fmt.Sprintf("Jpt dengan ID %s tidak ditemukan", jptID))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
 
 	return c.JSON(fiber.Map{"error": nil, "data": jpt})
 }
@@ -99,14 +104,14 @@ func (vj *jptHandler) Get(c *fiber.Ctx) error {
 // Find menampilkan list jpt
 // Query [branch, name, active ]
 func (vj *jptHandler) Find(c *fiber.Ctx) error {
-	branch := strings.ToUpper(c.Query("branch"))
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	branch, apiErr := resolveBranch(claims, c.Query("branch"))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
 	name := strings.ToUpper(c.Query("name"))
 	tempActive := sfunc.StrToInt(c.Query("active"), 1)
 
-	if branch == "" {
-		branch = c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim).Branch
-	}
-
 	active := true
 	if tempActive == 0 {
 		active = false
@@ -125,3 +130,54 @@ func (vj *jptHandler) Find(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{"error": nil, "data": jptList})
 }
+
+// Leaderboard menampilkan JPT aktif diurutkan dari score terbaik
+// Query [branch]
+func (vj *jptHandler) Leaderboard(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	branch, apiErr := resolveBranch(claims, c.Query("branch"))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	jptList, apiErr := vj.service.FindLeaderboard(branch)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": jptList})
+}
+
+// ScoreHistory menampilkan riwayat score bulanan JPT
+// Query [limit]
+func (vj *jptHandler) ScoreHistory(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	jptID := c.Params("id")
+
+	jpt, apiErr := vj.service.GetJptByID(jptID, "")
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+	if !canReadBranch(claims, jpt.Branch) {
+		apiErr := resterr.NewNotFoundError(fmt.Sprintf("Jpt dengan ID %s tidak ditemukan", jptID))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	limit := sfunc.StrToInt(c.Query("limit"), 0)
+	historyList, apiErr := vj.service.GetScoreHistory(*jpt, int64(limit))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": historyList})
+}
+
+// RecalculateScore menghitung ulang score seluruh JPT tanpa menunggu jadwal harian
+func (vj *jptHandler) RecalculateScore(c *fiber.Ctx) error {
+	result, apiErr := vj.service.RecalculateScore()
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": result})
+}
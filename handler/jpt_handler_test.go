@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tilank/dto"
+	"tilank/service"
+	"tilank/utils/rest_err"
+)
+
+// fakeJptService hanya mengimplementasikan method yang digunakan pada pengujian
+type fakeJptService struct {
+	service.JptServiceAssumer
+	jpt        dto.Jpt
+	lastFilter dto.FilterJpt
+}
+
+func (f *fakeJptService) GetJptByID(_ string, _ string) (*dto.Jpt, resterr.APIError) {
+	jpt := f.jpt
+	return &jpt, nil
+}
+
+func (f *fakeJptService) FindJpt(filter dto.FilterJpt) (dto.JptResponseMinList, resterr.APIError) {
+	f.lastFilter = filter
+	return dto.JptResponseMinList{}, nil
+}
+
+func TestJptHandler_Get_BranchScope(t *testing.T) {
+	fake := &fakeJptService{jpt: dto.Jpt{Branch: "SAMPIT"}}
+	handler := NewJptHandler(fake)
+
+	app := newTestApp(securityClaims)
+	app.Get("/jpt/:id", handler.Get)
+	status, _ := doGet(t, app, "/jpt/1")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	fake.jpt.Branch = "BANJARMASIN"
+	status, _ = doGet(t, app, "/jpt/1")
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestJptHandler_Find_BranchScope(t *testing.T) {
+	fake := &fakeJptService{}
+	handler := NewJptHandler(fake)
+
+	app := newTestApp(securityClaims)
+	app.Get("/jpt", handler.Find)
+	status, _ := doGet(t, app, "/jpt")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "BANJARMASIN", fake.lastFilter.FilterBranch)
+
+	status, _ = doGet(t, app, "/jpt?branch=SAMPIT")
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	app = newTestApp(regionalClaims)
+	app.Get("/jpt", handler.Find)
+	status, _ = doGet(t, app, "/jpt?branch=SAMPIT")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "SAMPIT", fake.lastFilter.FilterBranch)
+}
+
+func (f *fakeJptService) FindLeaderboard(branch string) (dto.JptResponseMinList, resterr.APIError) {
+	f.lastFilter = dto.FilterJpt{FilterBranch: branch}
+	return dto.JptResponseMinList{}, nil
+}
+
+func (f *fakeJptService) GetScoreHistory(_ dto.Jpt, _ int64) (dto.JptScoreHistoryList, resterr.APIError) {
+	return dto.JptScoreHistoryList{}, nil
+}
+
+func TestJptHandler_Leaderboard_BranchScope(t *testing.T) {
+	fake := &fakeJptService{}
+	handler := NewJptHandler(fake)
+
+	app := newTestApp(securityClaims)
+	app.Get("/jpt-leaderboard", handler.Leaderboard)
+	status, _ := doGet(t, app, "/jpt-leaderboard")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "BANJARMASIN", fake.lastFilter.FilterBranch)
+
+	status, _ = doGet(t, app, "/jpt-leaderboard?branch=SAMPIT")
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestJptHandler_ScoreHistory_BranchScope(t *testing.T) {
+	fake := &fakeJptService{jpt: dto.Jpt{Branch: "SAMPIT"}}
+	handler := NewJptHandler(fake)
+
+	app := newTestApp(securityClaims)
+	app.Get("/jpt/:id/score-history", handler.ScoreHistory)
+	status, _ := doGet(t, app, "/jpt/1/score-history")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	app = newTestApp(regionalClaims)
+	app.Get("/jpt/:id/score-history", handler.ScoreHistory)
+	status, _ = doGet(t, app, "/jpt/1/score-history")
+	assert.Equal(t, http.StatusOK, status)
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"tilank/utils/mjwt"
+)
+
+func NewJwksHandler(jwt mjwt.JWTAssumer) *jwksHandler {
+	return &jwksHandler{
+		jwt: jwt,
+	}
+}
+
+type jwksHandler struct {
+	jwt mjwt.JWTAssumer
+}
+
+// Get mengembalikan JSON Web Key Set agar service lain dapat memverifikasi token tanpa berbagi secret
+func (j *jwksHandler) Get(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(j.jwt.JWKS())
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"tilank/dto"
+	"tilank/service"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+)
+
+func NewPolicyHandler(policyService *service.PolicyService) *policyHandler {
+	return &policyHandler{
+		service: policyService,
+	}
+}
+
+type policyHandler struct {
+	service *service.PolicyService
+}
+
+// Get menampilkan matriks role-permission
+func (p *policyHandler) Get(c *fiber.Ctx) error {
+	policy, apiErr := p.service.GetPolicy()
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": policy})
+}
+
+// Edit mengganti seluruh permission role
+func (p *policyHandler) Edit(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	role := c.Params("role")
+
+	var req dto.RolePermissionEditRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	result, apiErr := p.service.EditRolePermission(claims.Identity, role, req)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": result})
+}
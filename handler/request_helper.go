@@ -0,0 +1,11 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"tilank/utils/clientip"
+)
+
+// clientIP mengembalikan ip client asli, X-Forwarded-For hanya dipercaya dari reverse proxy terdaftar
+func clientIP(c *fiber.Ctx) string {
+	return clientip.FromCtx(c)
+}
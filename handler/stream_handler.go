@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+	"strings"
+	"tilank/service"
+	"tilank/stream"
+	"tilank/utils/logger"
+	"tilank/utils/mjwt"
+	"time"
+)
+
+// streamKeepAlive interval komentar ping agar koneksi tidak diputus proxy
+const streamKeepAlive = 20 * time.Second
+
+// revocationChecker mengecek token yang sudah dicabut, dipenuhi oleh service.TokenService
+type revocationChecker interface {
+	IsRevoked(claims mjwt.CustomClaim) bool
+}
+
+func NewStreamHandler(eventHub stream.HubAssumer, userService service.UserServiceAssumer, revocation revocationChecker) *streamHandler {
+	return &streamHandler{
+		hub:        eventHub,
+		service:    userService,
+		revocation: revocation,
+	}
+}
+
+type streamHandler struct {
+	hub        stream.HubAssumer
+	service    service.UserServiceAssumer
+	revocation revocationChecker
+}
+
+// Ticket menerbitkan tiket singkat untuk membuka koneksi Events melalui query ticket
+func (sh *streamHandler) Ticket(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	ticket, apiErr := sh.service.StreamTicket(*claims)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": ticket})
+}
+
+// sessionActive return false jika token koneksi sudah kadaluarsa atau dicabut (logout, nonaktif, reset password)
+func (sh *streamHandler) sessionActive(claims *mjwt.CustomClaim, now int64) bool {
+	if claims.Exp <= now {
+		return false
+	}
+	return sh.revocation == nil || !sh.revocation.IsRevoked(*claims)
+}
+
+// Events membuka koneksi server-sent events yang mengirimkan event violation, truck dan sopir
+// dari seluruh cabang yang dapat dibaca user. token dicek ulang setiap ping, koneksi ditutup
+// dengan event session.closed jika token kadaluarsa atau dicabut
+func (sh *streamHandler) Events(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	branches := readableBranches(claims)
+	events, unsubscribe := sh.hub.Subscribe(branches...)
+	logger.Info(fmt.Sprintf("u: %s | stream | terhubung ke cabang %s", claims.Name, strings.Join(branches, ", ")))
+
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		ticker := time.NewTicker(streamKeepAlive)
+		defer ticker.Stop()
+
+		_, _ = fmt.Fprint(w, ": connected\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				body, err := json.Marshal(event)
+				if err != nil {
+					logger.Error("gagal membuat payload stream", err)
+					continue
+				}
+				_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Name, body)
+			case <-ticker.C:
+				if !sh.sessionActive(claims, time.Now().Unix()) {
+					_, _ = fmt.Fprint(w, "event: session.closed\ndata: {}\n\n")
+					_ = w.Flush()
+					logger.Info(fmt.Sprintf("u: %s | stream | token tidak berlaku, koneksi ditutup", claims.Name))
+					return
+				}
+				_, _ = fmt.Fprint(w, ": ping\n\n")
+			}
+
+			// flush gagal berarti client sudah menutup koneksi
+			if err := w.Flush(); err != nil {
+				logger.Info(fmt.Sprintf("u: %s | stream | koneksi ditutup", claims.Name))
+				return
+			}
+		}
+	}))
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"tilank/stream"
+	"tilank/utils/mjwt"
+)
+
+// fakeHub mencatat cabang subscriber, channel langsung ditutup sehingga stream selesai
+type fakeHub struct {
+	stream.HubAssumer
+	branches []string
+}
+
+func (f *fakeHub) Subscribe(branches ...string) (<-chan stream.Event, func()) {
+	f.branches = branches
+	events := make(chan stream.Event)
+	close(events)
+	return events, func() {}
+}
+
+type fakeRevocation struct {
+	revoked bool
+}
+
+func (f fakeRevocation) IsRevoked(_ mjwt.CustomClaim) bool {
+	return f.revoked
+}
+
+func TestStreamEventsSubscribeReadableBranches(t *testing.T) {
+	hub := &fakeHub{}
+	sh := NewStreamHandler(hub, nil, fakeRevocation{})
+
+	app := newTestApp(regionalClaims)
+	app.Get("/events", sh.Events)
+
+	status, body := doGet(t, app, "/events")
+	assert.Equal(t, 200, status)
+	assert.Contains(t, body, ": connected")
+	assert.Equal(t, []string{"BANJARMASIN", "SAMPIT", "KOTABARU"}, hub.branches)
+}
+
+func TestStreamSessionActive(t *testing.T) {
+	now := time.Now().Unix()
+	claims := &mjwt.CustomClaim{Identity: "hsse", Exp: now + 60}
+
+	assert.True(t, NewStreamHandler(&fakeHub{}, nil, fakeRevocation{}).sessionActive(claims, now))
+	assert.False(t, NewStreamHandler(&fakeHub{}, nil, fakeRevocation{revoked: true}).sessionActive(claims, now))
+	assert.False(t, NewStreamHandler(&fakeHub{}, nil, fakeRevocation{}).sessionActive(claims, now+60))
+}
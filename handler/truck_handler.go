@@ -12,14 +12,14 @@ import (
 	"tilank/utils/sfunc"
 )
 
-func NewTruckHandler(truckService *service.TruckService) *truckHandler {
+func NewTruckHandler(truckService service.TruckServiceAssumer) *truckHandler {
 	return &truckHandler{
 		service: truckService,
 	}
 }
 
 type truckHandler struct {
-	service *service.TruckService
+	service service.TruckServiceAssumer
 }
 
 func (th *truckHandler) Insert(c *fiber.Ctx) error {
@@ -86,22 +86,55 @@ func (th *truckHandler) Edit(c *fiber.Ctx) error {
 
 // Get menampilkan truckDetail
 func (th *truckHandler) Get(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
 	truckID := c.Params("id")
 
 	truck, apiErr := th.service.GetTruckByID(truckID, "")
 	if apiErr != nil {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
 	}
+	if !canReadBranch(claims, truck.Branch) {
+		apiErr := resterr.NewNotFoundError(fmt.Sprintf("Truck dengan ID %s tidak ditemukan", truckID))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
 
 	return c.JSON(fiber.Map{"error": nil, "data": truck})
 }
 
+// Timeline menampilkan pelanggaran, perubahan skor, periode blokir dan perubahan data truck secara kronologis
+func (th *truckHandler) Timeline(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	truckID := c.Params("id")
+
+	truck, apiErr := th.service.GetTruckByID(truckID, "")
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+	if !canReadBranch(claims, truck.Branch) {
+		apiErr := resterr.NewNotFoundError(fmt.Sprintf("Truck dengan ID %s tidak ditemukan", truckID))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	timeline, apiErr := th.service.GetTimeline(*truck)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": timeline})
+}
+
 // GetByNopol menampilkan truckDetail berdasarkan nopol
+// Query [branch]
 func (th *truckHandler) GetByNoLambung(c *fiber.Ctx) error {
 	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
 	noLambung := c.Params("id")
 
-	truck, apiErr := th.service.GetTruckByNoLambung(noLambung, claims.Branch)
+	branch, apiErr := resolveBranch(claims, c.Query("branch"))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	truck, apiErr := th.service.GetTruckByNoLambung(noLambung, branch)
 	if apiErr != nil {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
 	}
@@ -110,18 +143,20 @@ func (th *truckHandler) GetByNoLambung(c *fiber.Ctx) error {
 }
 
 // Find menampilkan list truck
-// Query [branch, identity, owner, active, block ]
+// Query [branch, identity, owner, owner_id, jpt_id, active, block ]
 func (th *truckHandler) Find(c *fiber.Ctx) error {
-	branch := strings.ToUpper(c.Query("branch"))
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	branch, apiErr := resolveBranch(claims, c.Query("branch"))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
 	noIdentity := strings.ToUpper(c.Query("identity"))
 	owner := strings.ToUpper(c.Query("owner"))
+	ownerID := c.Query("owner_id")
+	jptID := c.Query("jpt_id")
 	tempActive := sfunc.StrToInt(c.Query("active"), 1)
 	tempBlocked := sfunc.StrToInt(c.Query("block"), 0)
 
-	if branch == "" {
-		branch = c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim).Branch
-	}
-
 	active := true
 	if tempActive == 0 {
 		active = false
@@ -136,6 +171,8 @@ func (th *truckHandler) Find(c *fiber.Ctx) error {
 		FilterBranch:     branch,
 		FilterNoIdentity: noIdentity,
 		FilterOwner:      owner,
+		FilterOwnerID:    ownerID,
+		FilterJptID:      jptID,
 		Active:           active,
 		Blocked:          blocked,
 	}
@@ -147,3 +184,57 @@ func (th *truckHandler) Find(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{"error": nil, "data": truckList})
 }
+
+// Block memblokir truck secara manual dengan durasi atau permanen, alasan wajib diisi
+func (th *truckHandler) Block(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	truckID := c.Params("id")
+
+	var req dto.TruckBlockRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | parse | %s", claims.Name, err.Error()))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | validate | %s", claims.Name, err.Error()))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+	req.IP = clientIP(c)
+	req.UserAgent = c.Get(fiber.HeaderUserAgent)
+
+	truckBlocked, apiErr := th.service.BlockTruck(*claims, truckID, req)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+	return c.JSON(fiber.Map{"error": nil, "data": truckBlocked})
+}
+
+// Unblock mencabut blokir truck sebelum waktunya, alasan wajib diisi
+func (th *truckHandler) Unblock(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	truckID := c.Params("id")
+
+	var req dto.TruckUnblockRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | parse | %s", claims.Name, err.Error()))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | validate | %s", claims.Name, err.Error()))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+	req.IP = clientIP(c)
+	req.UserAgent = c.Get(fiber.HeaderUserAgent)
+
+	truckUnblocked, apiErr := th.service.UnblockTruck(*claims, truckID, req)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+	return c.JSON(fiber.Map{"error": nil, "data": truckUnblocked})
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tilank/dto"
+	"tilank/service"
+	"tilank/utils/rest_err"
+)
+
+// fakeTruckService hanya mengimplementasikan method yang digunakan pada pengujian
+type fakeTruckService struct {
+	service.TruckServiceAssumer
+	truck         dto.Truck
+	lastFilter    dto.FilterTruck
+	lastNoLambung string
+	lastBranch    string
+}
+
+func (f *fakeTruckService) GetTruckByID(_ string, _ string) (*dto.Truck, resterr.APIError) {
+	truck := f.truck
+	return &truck, nil
+}
+
+func (f *fakeTruckService) GetTruckByNoLambung(noLambung string, branch string) (*dto.Truck, resterr.APIError) {
+	f.lastNoLambung = noLambung
+	f.lastBranch = branch
+	truck := f.truck
+	return &truck, nil
+}
+
+func (f *fakeTruckService) FindTruck(filter dto.FilterTruck) (dto.TruckResponseMinList, resterr.APIError) {
+	f.lastFilter = filter
+	return dto.TruckResponseMinList{}, nil
+}
+
+func (f *fakeTruckService) GetTimeline(truck dto.Truck) (*dto.TruckTimelineResponse, resterr.APIError) {
+	return &dto.TruckTimelineResponse{Truck: truck, Items: []dto.TruckTimelineItem{}}, nil
+}
+
+func TestTruckHandler_Get_BranchScope(t *testing.T) {
+	fake := &fakeTruckService{truck: dto.Truck{Branch: "KOTABARU"}}
+	handler := NewTruckHandler(fake)
+
+	app := newTestApp(securityClaims)
+	app.Get("/truck/:id", handler.Get)
+	status, _ := doGet(t, app, "/truck/1")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	app = newTestApp(regionalClaims)
+	app.Get("/truck/:id", handler.Get)
+	status, _ = doGet(t, app, "/truck/1")
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestTruckHandler_GetByNoLambung_BranchScope(t *testing.T) {
+	fake := &fakeTruckService{}
+	handler := NewTruckHandler(fake)
+
+	app := newTestApp(securityClaims)
+	app.Get("/truck-lambung/:id", handler.GetByNoLambung)
+	status, _ := doGet(t, app, "/truck-lambung/A01")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "BANJARMASIN", fake.lastBranch)
+
+	status, _ = doGet(t, app, "/truck-lambung/A01?branch=SAMPIT")
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	app = newTestApp(regionalClaims)
+	app.Get("/truck-lambung/:id", handler.GetByNoLambung)
+	status, _ = doGet(t, app, "/truck-lambung/A01?branch=SAMPIT")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "SAMPIT", fake.lastBranch)
+}
+
+func TestTruckHandler_Find_BranchScope(t *testing.T) {
+	fake := &fakeTruckService{}
+	handler := NewTruckHandler(fake)
+
+	app := newTestApp(securityClaims)
+	app.Get("/truck", handler.Find)
+	status, _ := doGet(t, app, "/truck")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "BANJARMASIN", fake.lastFilter.FilterBranch)
+
+	status, _ = doGet(t, app, "/truck?branch=KOTABARU")
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	app = newTestApp(regionalClaims)
+	app.Get("/truck", handler.Find)
+	status, _ = doGet(t, app, "/truck?branch=KOTABARU")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "KOTABARU", fake.lastFilter.FilterBranch)
+}
+
+func TestTruckHandler_Timeline_BranchScope(t *testing.T) {
+	fake := &fakeTruckService{truck: dto.Truck{Branch: "KOTABARU"}}
+	handler := NewTruckHandler(fake)
+
+	app := newTestApp(securityClaims)
+	app.Get("/truck/:id/timeline", handler.Timeline)
+	status, _ := doGet(t, app, "/truck/1/timeline")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	app = newTestApp(regionalClaims)
+	app.Get("/truck/:id/timeline", handler.Timeline)
+	status, _ = doGet(t, app, "/truck/1/timeline")
+	assert.Equal(t, http.StatusOK, status)
+}
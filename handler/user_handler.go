@@ -8,6 +8,7 @@ import (
 	"tilank/utils/logger"
 	"tilank/utils/mjwt"
 	"tilank/utils/rest_err"
+	"tilank/utils/sfunc"
 	"time"
 )
 
@@ -58,7 +59,8 @@ func (usr *userHandler) Register(c *fiber.Ctx) error {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
 	}
 
-	insertID, apiErr := usr.service.InsertUser(user)
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	insertID, apiErr := usr.service.InsertUser(claims.Identity, user)
 	if apiErr != nil {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
 	}
@@ -67,9 +69,47 @@ func (usr *userHandler) Register(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"error": nil, "data": res})
 }
 
-// Find menampilkan list user
+// Find menampilkan list user aktif per halaman pada cabang yang dapat dibaca user
+// Query [branch, role, name, page, limit]
 func (usr *userHandler) Find(c *fiber.Ctx) error {
-	userList, apiErr := usr.service.FindUsers()
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	branch, apiErr := resolveBranch(claims, c.Query("branch"))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	filterA := dto.FilterUser{
+		FilterBranch: branch,
+		FilterRole:   c.Query("role"),
+		FilterName:   c.Query("name"),
+		Active:       true,
+		Page:         int64(sfunc.StrToInt(c.Query("page"), 1)),
+		Limit:        int64(sfunc.StrToInt(c.Query("limit"), 50)),
+	}
+
+	userList, apiErr := usr.service.FindUsers(filterA)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": userList})
+}
+
+// FindAdmin menampilkan list user seluruh cabang per halaman termasuk user nonaktif, khusus admin
+// Query [branch, role, name, active, page, limit]
+func (usr *userHandler) FindAdmin(c *fiber.Ctx) error {
+	active := sfunc.StrToInt(c.Query("active"), 1) != 0
+
+	filterA := dto.FilterUser{
+		FilterBranch: c.Query("branch"),
+		FilterRole:   c.Query("role"),
+		FilterName:   c.Query("name"),
+		Active:       active,
+		Page:         int64(sfunc.StrToInt(c.Query("page"), 1)),
+		Limit:        int64(sfunc.StrToInt(c.Query("limit"), 50)),
+	}
+
+	userList, apiErr := usr.service.FindUsers(filterA)
 	if apiErr != nil {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
 	}
@@ -92,7 +132,8 @@ func (usr *userHandler) Edit(c *fiber.Ctx) error {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
 	}
 
-	userEdited, apiErr := usr.service.EditUser(userID, user)
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	userEdited, apiErr := usr.service.EditUser(claims.Identity, userID, user)
 	if apiErr != nil {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
 	}
@@ -123,34 +164,90 @@ func (usr *userHandler) UpdateFcmToken(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"error": nil, "data": userEdited})
 }
 
-// DeleteFcmToken menghapus FCM token
-func (usr *userHandler) DeleteFcmToken(c *fiber.Ctx) error {
+// Logout mencabut token yang sedang digunakan dan menghapus FCM token
+// body refresh_token opsional, jika dikirim refresh token ikut dicabut
+func (usr *userHandler) Logout(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	var payload dto.UserLogoutRequest
+	if len(c.Body()) != 0 {
+		if err := c.BodyParser(&payload); err != nil {
+			apiErr := resterr.NewBadRequestError(err.Error())
+			logger.Info(fmt.Sprintf("u: %s | parse | %s", claims.Name, err.Error()))
+			return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+		}
+	}
+
+	apiErr := usr.service.Logout(*claims, payload.RefreshToken)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": "logout berhasil"})
+}
+
+// LogoutAll mencabut seluruh sesi milik user yang sedang login di semua perangkat
+func (usr *userHandler) LogoutAll(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	apiErr := usr.service.RevokeAllTokens(claims.Identity, claims.Identity)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": "logout dari semua perangkat berhasil"})
+}
+
+// RevokeUser mencabut seluruh sesi user tertentu oleh admin
+func (usr *userHandler) RevokeUser(c *fiber.Ctx) error {
 	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	userID := c.Params("user_id")
 
-	_, apiErr := usr.service.EditFcm(claims.Identity, "")
+	apiErr := usr.service.RevokeAllTokens(claims.Identity, userID)
 	if apiErr != nil {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
 	}
 
-	return c.JSON(fiber.Map{"error": nil, "data": "fcm di hapus"})
+	return c.JSON(fiber.Map{"error": nil, "data": fmt.Sprintf("seluruh sesi user %s berhasil dicabut", userID)})
 }
 
-// Delete menghapus user, idealnya melalui middleware is_admin
-func (usr *userHandler) Delete(c *fiber.Ctx) error {
+// Unlock membuka kunci user yang terkunci karena terlalu banyak percobaan login
+func (usr *userHandler) Unlock(c *fiber.Ctx) error {
 	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
-	userIDParams := c.Params("user_id")
+	userID := c.Params("user_id")
 
-	if claims.Identity == userIDParams {
-		apiErr := resterr.NewBadRequestError("Tidak dapat menghapus akun terkait (diri sendiri)!")
+	user, apiErr := usr.service.UnlockUser(claims.Identity, userID)
+	if apiErr != nil {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
 	}
 
-	apiErr := usr.service.DeleteUser(userIDParams)
+	return c.JSON(fiber.Map{"error": nil, "data": user})
+}
+
+// Deactivate menonaktifkan user, user tidak dapat login namun data dan riwayatnya tetap disimpan
+func (usr *userHandler) Deactivate(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	userID := c.Params("user_id")
+
+	user, apiErr := usr.service.DeactivateUser(claims.Identity, userID)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": user})
+}
+
+// Reactivate mengaktifkan kembali user yang dinonaktifkan
+func (usr *userHandler) Reactivate(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	userID := c.Params("user_id")
+
+	user, apiErr := usr.service.ReactivateUser(claims.Identity, userID)
 	if apiErr != nil {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
 	}
 
-	return c.JSON(fiber.Map{"error": nil, "data": fmt.Sprintf("user %s berhasil dihapus", userIDParams)})
+	return c.JSON(fiber.Map{"error": nil, "data": user})
 }
 
 // ChangePassword mengganti password pada user sendiri
@@ -179,21 +276,92 @@ func (usr *userHandler) ChangePassword(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"error": apiErr, "data": "Password berhasil diubah!"})
 }
 
-// ResetPassword mengganti password oleh admin pada user tertentu
+// ResetPassword mengganti password oleh admin pada user tertentu dengan password sementara
+// yang wajib diganti user pada login berikutnya
 func (usr *userHandler) ResetPassword(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
 	userID := c.Params("user_id")
 
-	data := dto.UserChangePasswordRequest{
-		ID:          userID,
-		NewPassword: "Password",
+	response, apiErr := usr.service.ResetPassword(claims.Identity, userID)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	// password sementara tidak boleh disimpan cache
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(fiber.Map{"error": nil, "data": response})
+}
+
+// LoginChangePassword mengganti password yang wajib diganti pada saat login
+func (usr *userHandler) LoginChangePassword(c *fiber.Ctx) error {
+	var payload dto.LoginChangePasswordRequest
+	if err := c.BodyParser(&payload); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := payload.Validate(); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	payload.IP = clientIP(c)
+	payload.UserAgent = c.Get(fiber.HeaderUserAgent)
+
+	response, apiErr := usr.service.LoginChangePassword(payload)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": response})
+}
+
+// ForgotPassword mengirim token reset password ke email user
+func (usr *userHandler) ForgotPassword(c *fiber.Ctx) error {
+	var payload dto.ForgotPasswordRequest
+	if err := c.BodyParser(&payload); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := payload.Validate(); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
 	}
 
-	apiErr := usr.service.ResetPassword(data)
+	payload.IP = clientIP(c)
+	payload.UserAgent = c.Get(fiber.HeaderUserAgent)
+
+	apiErr := usr.service.ForgotPassword(payload)
 	if apiErr != nil {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
 	}
 
-	return c.JSON(fiber.Map{"error": nil, "data": fmt.Sprintf("Password user %s berhasil di reset!", c.Params("user_id"))})
+	return c.JSON(fiber.Map{"error": nil, "data": "Jika ID terdaftar, token reset password telah dikirim ke email user"})
+}
+
+// ResetPasswordWithToken mengganti password menggunakan token dari email
+func (usr *userHandler) ResetPasswordWithToken(c *fiber.Ctx) error {
+	var payload dto.ResetPasswordRequest
+	if err := c.BodyParser(&payload); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := payload.Validate(); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	payload.IP = clientIP(c)
+	payload.UserAgent = c.Get(fiber.HeaderUserAgent)
+
+	apiErr := usr.service.ResetPasswordWithToken(payload)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": "Password berhasil diganti, silahkan login ulang"})
 }
 
 // Login login
@@ -211,6 +379,9 @@ func (usr *userHandler) Login(c *fiber.Ctx) error {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
 	}
 
+	login.IP = clientIP(c)
+	login.UserAgent = c.Get(fiber.HeaderUserAgent)
+
 	response, apiErr := usr.service.Login(login)
 	if apiErr != nil {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
@@ -219,6 +390,184 @@ func (usr *userHandler) Login(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"error": nil, "data": response})
 }
 
+// SsoLogin memulai login sso, client membuka authorization_url pada browser
+// Query [limit]
+func (usr *userHandler) SsoLogin(c *fiber.Ctx) error {
+	limit := sfunc.StrToInt(c.Query("limit"), 0)
+
+	response, apiErr := usr.service.SsoLogin(limit)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	c.Cookie(ssoBindingCookie(response.Binding, time.Unix(response.Expired, 0)))
+	return c.JSON(fiber.Map{"error": nil, "data": response})
+}
+
+const ssoCookieName = "tilank_sso"
+
+// ssoBindingCookie cookie yang mengikat state login sso dengan browser yang memulai login
+func ssoBindingCookie(value string, expires time.Time) *fiber.Cookie {
+	return &fiber.Cookie{
+		Name:     ssoCookieName,
+		Value:    value,
+		Path:     "/api/v1/sso",
+		Expires:  expires,
+		Secure:   true,
+		HTTPOnly: true,
+		SameSite: "Lax",
+	}
+}
+
+// SsoCallback menukar code dan state dari identity provider dengan token aplikasi
+func (usr *userHandler) SsoCallback(c *fiber.Ctx) error {
+	var payload dto.SsoCallbackRequest
+	if err := c.BodyParser(&payload); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := payload.Validate(); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	payload.IP = clientIP(c)
+	payload.UserAgent = c.Get(fiber.HeaderUserAgent)
+	payload.Binding = c.Cookies(ssoCookieName)
+
+	// cookie binding hanya berlaku untuk satu kali callback
+	c.Cookie(ssoBindingCookie("", time.Unix(0, 0)))
+
+	response, apiErr := usr.service.SsoCallback(payload)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": response})
+}
+
+// LoginTwoFactor langkah kedua login menggunakan kode TOTP atau recovery code
+func (usr *userHandler) LoginTwoFactor(c *fiber.Ctx) error {
+	var payload dto.TwoFactorVerifyRequest
+	if err := c.BodyParser(&payload); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := payload.Validate(); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	payload.IP = clientIP(c)
+	payload.UserAgent = c.Get(fiber.HeaderUserAgent)
+
+	response, apiErr := usr.service.VerifyTwoFactor(payload)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": response})
+}
+
+// LoginSetupTwoFactor setup authenticator pada saat login untuk user yang diwajibkan two factor
+func (usr *userHandler) LoginSetupTwoFactor(c *fiber.Ctx) error {
+	var payload dto.TwoFactorTokenRequest
+	if err := c.BodyParser(&payload); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := payload.Validate(); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	response, apiErr := usr.service.LoginSetupTwoFactor(payload)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": response})
+}
+
+// LoginActivateTwoFactor aktivasi authenticator pada saat login, mengembalikan recovery code dan token
+func (usr *userHandler) LoginActivateTwoFactor(c *fiber.Ctx) error {
+	var payload dto.TwoFactorActivateRequest
+	if err := c.BodyParser(&payload); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := payload.Validate(); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+	if payload.TwoFactorToken == "" {
+		apiErr := resterr.NewBadRequestError("two_factor_token: cannot be blank.")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	payload.IP = clientIP(c)
+	payload.UserAgent = c.Get(fiber.HeaderUserAgent)
+
+	response, apiErr := usr.service.LoginActivateTwoFactor(payload)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": response})
+}
+
+// SetupTwoFactor setup authenticator oleh user yang sedang login
+func (usr *userHandler) SetupTwoFactor(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	response, apiErr := usr.service.SetupTwoFactor(claims.Identity)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": response})
+}
+
+// ActivateTwoFactor aktivasi authenticator oleh user yang sedang login
+func (usr *userHandler) ActivateTwoFactor(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	var payload dto.TwoFactorActivateRequest
+	if err := c.BodyParser(&payload); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := payload.Validate(); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	recoveryCodes, apiErr := usr.service.ActivateTwoFactor(claims.Identity, payload.Code)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": dto.TwoFactorActivateResponse{RecoveryCodes: recoveryCodes}})
+}
+
+// ResetTwoFactor menghapus two factor user oleh admin
+func (usr *userHandler) ResetTwoFactor(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	userID := c.Params("user_id")
+
+	apiErr := usr.service.ResetTwoFactor(claims.Identity, userID)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": fmt.Sprintf("two factor user %s berhasil direset", userID)})
+}
+
 // RefreshToken
 func (usr *userHandler) RefreshToken(c *fiber.Ctx) error {
 	var payload dto.UserRefreshTokenRequest
@@ -234,6 +583,9 @@ func (usr *userHandler) RefreshToken(c *fiber.Ctx) error {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
 	}
 
+	payload.IP = clientIP(c)
+	payload.UserAgent = c.Get(fiber.HeaderUserAgent)
+
 	response, apiErr := usr.service.Refresh(payload)
 	if apiErr != nil {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tilank/dto"
+	"tilank/service"
+	"tilank/utils/rest_err"
+)
+
+// fakeUserService hanya mengimplementasikan method yang digunakan pada pengujian
+type fakeUserService struct {
+	service.UserServiceAssumer
+	lastFilter *dto.FilterUser
+}
+
+func (f *fakeUserService) FindUsers(filter dto.FilterUser) (*dto.UserPageResponse, resterr.APIError) {
+	f.lastFilter = &filter
+	return &dto.UserPageResponse{}, nil
+}
+
+func TestUserHandler_Find_BranchScope(t *testing.T) {
+	fake := &fakeUserService{}
+	handler := NewUserHandler(fake)
+
+	app := newTestApp(securityClaims)
+	app.Get("/users", handler.Find)
+
+	status, _ := doGet(t, app, "/users?active=0")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "BANJARMASIN", fake.lastFilter.FilterBranch)
+	assert.True(t, fake.lastFilter.Active, "user nonaktif hanya dapat dilihat admin")
+
+	fake.lastFilter = nil
+	status, _ = doGet(t, app, "/users?branch=SAMPIT")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Nil(t, fake.lastFilter, "service tidak boleh dipanggil")
+}
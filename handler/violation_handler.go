@@ -14,14 +14,14 @@ import (
 	"time"
 )
 
-func NewViolationHandler(violationService *service.ViolationService) *violationHandler {
+func NewViolationHandler(violationService service.ViolationServiceAssumer) *violationHandler {
 	return &violationHandler{
 		service: violationService,
 	}
 }
 
 type violationHandler struct {
-	service *service.ViolationService
+	service service.ViolationServiceAssumer
 }
 
 func (vh *violationHandler) Insert(c *fiber.Ctx) error {
@@ -179,38 +179,49 @@ func (vh *violationHandler) DeleteImage(c *fiber.Ctx) error {
 
 // Get menampilkan violationDetail
 func (vh *violationHandler) Get(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
 	violationID := c.Params("id")
 
 	violation, apiErr := vh.service.GetViolationByID(violationID, "")
 	if apiErr != nil {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
 	}
+	if !canReadBranch(claims, violation.Branch) {
+		apiErr := resterr.NewNotFoundError(fmt.Sprintf("Pelanggaran dengan ID %s tidak ditemukan", violationID))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
 
 	return c.JSON(fiber.Map{"error": nil, "data": violation})
 }
 
-// Find menampilkan list violation
-// Query [branch, lambung, nopol, state, limit, start, end]
+// Find menampilkan list violation, draft yang diarsipkan hanya tampil dengan archived=1
+// Query [branch, lambung, nopol, license, jpt_id, state, archived, limit, start, end]
 func (vh *violationHandler) Find(c *fiber.Ctx) error {
-	branch := strings.ToUpper(c.Query("branch"))
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	branch, apiErr := resolveBranch(claims, c.Query("branch"))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
 	lambung := strings.ToUpper(c.Query("lambung"))
 	noPol := c.Query("nopol")
+	noLicense := c.Query("license")
+	jptID := c.Query("jpt_id")
 	state := sfunc.StrToInt(c.Query("state"), -1)
 	limit := sfunc.StrToInt(c.Query("limit"), 100)
 	start := sfunc.StrToInt(c.Query("start"), 0)
 	end := sfunc.StrToInt(c.Query("end"), 0)
-
-	if branch == "" {
-		branch = c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim).Branch
-	}
+	archived := sfunc.StrToInt(c.Query("archived"), 0) != 0
 
 	filterA := dto.FilterViolation{
 		FilterBranch:     branch,
 		FilterNoIdentity: lambung,
 		FilterNoPol:      noPol,
+		FilterNoLicense:  noLicense,
+		FilterJptID:      jptID,
 		FilterState:      enum.IntToState(state),
 		FilterStart:      int64(start),
 		FilterEnd:        int64(end),
+		Archived:         archived,
 		Limit:            int64(limit),
 	}
 
@@ -224,8 +235,18 @@ func (vh *violationHandler) Find(c *fiber.Ctx) error {
 
 // GeneratePDF membuat pdf
 func (vh *violationHandler) GeneratePDF(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
 	violationID := c.Params("id")
 
+	existing, apiErr := vh.service.GetViolationByID(violationID, "")
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+	if !canReadBranch(claims, existing.Branch) {
+		apiErr := resterr.NewNotFoundError(fmt.Sprintf("Pelanggaran dengan ID %s tidak ditemukan", violationID))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
 	violation, apiErr := vh.service.GeneratePDFViolation(violationID)
 	if apiErr != nil {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
@@ -233,3 +254,58 @@ func (vh *violationHandler) GeneratePDF(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{"error": nil, "data": violation})
 }
+
+// Restore memulihkan draft yang diarsipkan
+func (vh *violationHandler) Restore(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	violationID := c.Params("id")
+
+	violation, apiErr := vh.service.RestoreViolation(*claims, violationID)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": violation})
+}
+
+// DraftRetention menampilkan aturan pengarsipan draft cabang
+// Query [branch]
+func (vh *violationHandler) DraftRetention(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	branch, apiErr := resolveBranch(claims, c.Query("branch"))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	retention, apiErr := vh.service.GetDraftRetention(branch)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": retention})
+}
+
+// EditDraftRetention mengganti aturan pengarsipan draft cabang user
+func (vh *violationHandler) EditDraftRetention(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	var req dto.DraftRetentionRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | parse | %s", claims.Name, err.Error()))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | validate | %s", claims.Name, err.Error()))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	retention, apiErr := vh.service.EditDraftRetention(*claims, req)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": retention})
+}
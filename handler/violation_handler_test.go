@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tilank/dto"
+	"tilank/service"
+	"tilank/utils/rest_err"
+)
+
+// fakeViolationService hanya mengimplementasikan method yang digunakan pada pengujian
+type fakeViolationService struct {
+	service.ViolationServiceAssumer
+	violation    dto.Violation
+	lastFilter   dto.FilterViolation
+	lastBranch   string
+	pdfGenerated bool
+}
+
+func (f *fakeViolationService) GetViolationByID(_ string, _ string) (*dto.Violation, resterr.APIError) {
+	violation := f.violation
+	return &violation, nil
+}
+
+func (f *fakeViolationService) FindViolation(filter dto.FilterViolation) (dto.ViolationResponseMinList, resterr.APIError) {
+	f.lastFilter = filter
+	return dto.ViolationResponseMinList{}, nil
+}
+
+func (f *fakeViolationService) GetDraftRetention(branch string) (*dto.DraftRetention, resterr.APIError) {
+	f.lastBranch = branch
+	return &dto.DraftRetention{Branch: branch}, nil
+}
+
+func (f *fakeViolationService) GeneratePDFViolation(_ string) (*dto.Violation, resterr.APIError) {
+	f.pdfGenerated = true
+	violation := f.violation
+	return &violation, nil
+}
+
+func TestViolationHandler_Get_BranchScope(t *testing.T) {
+	fake := &fakeViolationService{violation: dto.Violation{Branch: "SAMPIT"}}
+	handler := NewViolationHandler(fake)
+
+	app := newTestApp(securityClaims)
+	app.Get("/violation/:id", handler.Get)
+	status, _ := doGet(t, app, "/violation/1")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	app = newTestApp(regionalClaims)
+	app.Get("/violation/:id", handler.Get)
+	status, _ = doGet(t, app, "/violation/1")
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestViolationHandler_Find_BranchScope(t *testing.T) {
+	fake := &fakeViolationService{}
+	handler := NewViolationHandler(fake)
+
+	app := newTestApp(securityClaims)
+	app.Get("/violation", handler.Find)
+
+	status, _ := doGet(t, app, "/violation")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "BANJARMASIN", fake.lastFilter.FilterBranch)
+
+	fake.lastFilter = dto.FilterViolation{}
+	status, _ = doGet(t, app, "/violation?branch=SAMPIT")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Empty(t, fake.lastFilter.FilterBranch, "service tidak boleh dipanggil")
+
+	app = newTestApp(regionalClaims)
+	app.Get("/violation", handler.Find)
+	status, _ = doGet(t, app, "/violation?branch=sampit")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "SAMPIT", fake.lastFilter.FilterBranch)
+}
+
+func TestViolationHandler_Find_Jpt(t *testing.T) {
+	fake := &fakeViolationService{}
+	handler := NewViolationHandler(fake)
+
+	app := newTestApp(securityClaims)
+	app.Get("/violation", handler.Find)
+
+	status, _ := doGet(t, app, "/violation?jpt_id=60a1b2c3d4e5f60718293a4b")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "60a1b2c3d4e5f60718293a4b", fake.lastFilter.FilterJptID)
+}
+
+func TestViolationHandler_GeneratePDF_BranchScope(t *testing.T) {
+	fake := &fakeViolationService{violation: dto.Violation{Branch: "SAMPIT"}}
+	handler := NewViolationHandler(fake)
+
+	app := newTestApp(nonRegionalClaims)
+	app.Get("/violation-pdf/:id", handler.GeneratePDF)
+	status, _ := doGet(t, app, "/violation-pdf/1")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.False(t, fake.pdfGenerated)
+
+	app = newTestApp(regionalClaims)
+	app.Get("/violation-pdf/:id", handler.GeneratePDF)
+	status, _ = doGet(t, app, "/violation-pdf/1")
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, fake.pdfGenerated)
+}
+
+func TestViolationHandler_Find_Archived(t *testing.T) {
+	fake := &fakeViolationService{}
+	handler := NewViolationHandler(fake)
+
+	app := newTestApp(securityClaims)
+	app.Get("/violation", handler.Find)
+
+	status, _ := doGet(t, app, "/violation")
+	assert.Equal(t, http.StatusOK, status)
+	assert.False(t, fake.lastFilter.Archived)
+
+	status, _ = doGet(t, app, "/violation?archived=1")
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, fake.lastFilter.Archived)
+}
+
+func TestViolationHandler_DraftRetention_BranchScope(t *testing.T) {
+	fake := &fakeViolationService{}
+	handler := NewViolationHandler(fake)
+
+	app := newTestApp(securityClaims)
+	app.Get("/violation-retention", handler.DraftRetention)
+	status, _ := doGet(t, app, "/violation-retention")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "BANJARMASIN", fake.lastBranch)
+
+	fake.lastBranch = ""
+	status, _ = doGet(t, app, "/violation-retention?branch=SAMPIT")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Empty(t, fake.lastBranch, "service tidak boleh dipanggil")
+
+	app = newTestApp(regionalClaims)
+	app.Get("/violation-retention", handler.DraftRetention)
+	status, _ = doGet(t, app, "/violation-retention?branch=sampit")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "SAMPIT", fake.lastBranch)
+}
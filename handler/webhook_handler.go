@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"tilank/dto"
+	"tilank/service"
+	"tilank/utils/logger"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+	"tilank/utils/sfunc"
+)
+
+func NewWebhookHandler(webhookService *service.WebhookService) *webhookHandler {
+	return &webhookHandler{
+		service: webhookService,
+	}
+}
+
+type webhookHandler struct {
+	service *service.WebhookService
+}
+
+// Insert menambahkan langganan webhook, secret hanya ditampilkan pada response ini
+func (wh *webhookHandler) Insert(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	var req dto.WebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | parse | %s", claims.Name, err.Error()))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | validate | %s", claims.Name, err.Error()))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	webhookCreated, apiErr := wh.service.InsertWebhook(*claims, req)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": webhookCreated})
+}
+
+func (wh *webhookHandler) Edit(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	webhookID := c.Params("id")
+
+	var req dto.WebhookEditRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | parse | %s", claims.Name, err.Error()))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := resterr.NewBadRequestError(err.Error())
+		logger.Info(fmt.Sprintf("u: %s | validate | %s", claims.Name, err.Error()))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	webhookEdited, apiErr := wh.service.EditWebhook(*claims, webhookID, req)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+	return c.JSON(fiber.Map{"error": nil, "data": webhookEdited})
+}
+
+func (wh *webhookHandler) Delete(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	apiErr := wh.service.DeleteWebhook(id)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": fmt.Sprintf("webhook %s berhasil dihapus", id)})
+}
+
+// Get menampilkan webhookDetail tanpa secret
+func (wh *webhookHandler) Get(c *fiber.Ctx) error {
+	webhookID := c.Params("id")
+
+	webhookSubs, apiErr := wh.service.GetWebhookByID(webhookID)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": webhookSubs})
+}
+
+// Find menampilkan list webhook
+func (wh *webhookHandler) Find(c *fiber.Ctx) error {
+	webhookList, apiErr := wh.service.FindWebhook()
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": webhookList})
+}
+
+// FindDelivery menampilkan log pengiriman webhook
+// Query [webhook, event, status, limit]
+func (wh *webhookHandler) FindDelivery(c *fiber.Ctx) error {
+	webhookID := c.Query("webhook")
+	event := c.Query("event")
+	status := c.Query("status")
+	limit := sfunc.StrToInt(c.Query("limit"), 100)
+
+	filterA := dto.FilterWebhookDelivery{
+		FilterEvent:  event,
+		FilterStatus: status,
+		Limit:        int64(limit),
+	}
+
+	if webhookID != "" {
+		oid, errT := primitive.ObjectIDFromHex(webhookID)
+		if errT != nil {
+			apiErr := resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
+			return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+		}
+		filterA.FilterWebhookID = oid
+	}
+
+	deliveryList, apiErr := wh.service.FindDelivery(filterA)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": deliveryList})
+}
+
+// ReplayDelivery mengirim ulang payload dari log pengiriman
+func (wh *webhookHandler) ReplayDelivery(c *fiber.Ctx) error {
+	deliveryID := c.Params("id")
+
+	delivery, apiErr := wh.service.ReplayDelivery(deliveryID)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": delivery})
+}
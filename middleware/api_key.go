@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"tilank/utils/clientip"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+	"tilank/utils/sfunc"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+var apiKeyAuthenticator ApiKeyAuthenticator
+
+// ApiKeyAuthenticator memvalidasi api key dan mengembalikan claims beserta scope-nya
+type ApiKeyAuthenticator interface {
+	Authenticate(key string, ip string) (*mjwt.CustomClaim, []string, resterr.APIError)
+}
+
+// SetApiKeyAuthenticator dipanggil sekali pada saat aplikasi dijalankan
+func SetApiKeyAuthenticator(authenticator ApiKeyAuthenticator) {
+	apiKeyAuthenticator = authenticator
+}
+
+// NormalOrApiKeyAuth menerima api key dengan scope yang diminta melalui header X-API-Key,
+// jika header tidak ada maka berperilaku sama dengan NormalAuth(rolesReq...)
+func NormalOrApiKeyAuth(scope string, rolesReq ...string) fiber.Handler {
+	normalAuth := NormalAuth(rolesReq...)
+
+	return func(c *fiber.Ctx) error {
+		key := c.Get(apiKeyHeader)
+		if key == "" {
+			return normalAuth(c)
+		}
+
+		if apiKeyAuthenticator == nil {
+			apiErr := resterr.NewUnauthorizedError("Api key tidak didukung")
+			return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+		}
+
+		claims, scopes, apiErr := apiKeyAuthenticator.Authenticate(key, clientip.FromCtx(c))
+		if apiErr != nil {
+			return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+		}
+
+		if !sfunc.InSlice(scope, scopes) {
+			apiErr := resterr.NewUnauthorizedError(fmt.Sprintf("Unauthorized, api key memerlukan scope %s", scope))
+			return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+		}
+
+		c.Locals(mjwt.CLAIMS, claims)
+		return c.Next()
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+)
+
+type fakeApiKeyAuthenticator struct{}
+
+func (f fakeApiKeyAuthenticator) Authenticate(key string, _ string) (*mjwt.CustomClaim, []string, resterr.APIError) {
+	if key != "tlk_valid" {
+		return nil, nil, resterr.NewUnauthorizedError("Api key tidak valid")
+	}
+	return &mjwt.CustomClaim{Identity: "APIKEY:1", Branch: "BANJARMASIN", Type: mjwt.Access}, []string{"truck:read"}, nil
+}
+
+func TestNormalOrApiKeyAuth(t *testing.T) {
+	SetApiKeyAuthenticator(fakeApiKeyAuthenticator{})
+	defer SetApiKeyAuthenticator(nil)
+
+	app := fiber.New()
+	app.Get("/truck", NormalOrApiKeyAuth("truck:read"), func(c *fiber.Ctx) error {
+		return c.SendString(c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim).Branch)
+	})
+	app.Post("/violation", NormalOrApiKeyAuth("violation:write"), func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+
+	cases := []struct {
+		method string
+		target string
+		key    string
+		status int
+	}{
+		{"GET", "/truck", "tlk_valid", http.StatusOK},
+		{"GET", "/truck", "tlk_invalid", http.StatusUnauthorized},
+		{"POST", "/violation", "tlk_valid", http.StatusUnauthorized},
+		// tanpa api key kembali ke NormalAuth yang memerlukan bearer token
+		{"GET", "/truck", "", http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.target, nil)
+		if tc.key != "" {
+			req.Header.Set(apiKeyHeader, tc.key)
+		}
+		resp, err := app.Test(req)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.status, resp.StatusCode, tc.method+" "+tc.target+" "+tc.key)
+	}
+}
@@ -10,12 +10,35 @@ import (
 )
 
 var (
-	jwt = mjwt.NewJwt()
+	jwt               = mjwt.NewJwt()
+	revocationChecker RevocationChecker
+	permissionChecker PermissionChecker
 )
 
+// RevocationChecker mengecek apakah token sudah dicabut (logout, hapus user, dll)
+type RevocationChecker interface {
+	IsRevoked(claims mjwt.CustomClaim) bool
+}
+
+// SetRevocationChecker dipanggil sekali pada saat aplikasi dijalankan
+func SetRevocationChecker(checker RevocationChecker) {
+	revocationChecker = checker
+}
+
+// PermissionChecker mengecek apakah salah satu role memiliki salah satu permission
+type PermissionChecker interface {
+	HasAnyPermission(roles []string, permissions []string) bool
+}
+
+// SetPermissionChecker dipanggil sekali pada saat aplikasi dijalankan
+func SetPermissionChecker(checker PermissionChecker) {
+	permissionChecker = checker
+}
+
 const (
 	headerKey = "Authorization"
 	bearerKey = "Bearer"
+	ticketKey = "ticket"
 )
 
 func NormalAuth(rolesReq ...string) fiber.Handler {
@@ -30,6 +53,77 @@ func NormalAuth(rolesReq ...string) fiber.Handler {
 	}
 }
 
+// PermissionAuth memvalidasi token dan memastikan user memiliki salah satu dari permission yang diminta
+func PermissionAuth(permissionsReq ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authHeader := c.Get(headerKey)
+		claims, err := authMustHaveRoleValidator(authHeader, false, nil)
+		if err != nil {
+			return c.Status(err.Status()).JSON(fiber.Map{"error": err, "data": nil})
+		}
+
+		if err := permissionValidator(claims, permissionsReq); err != nil {
+			return c.Status(err.Status()).JSON(fiber.Map{"error": err, "data": nil})
+		}
+
+		c.Locals(mjwt.CLAIMS, claims)
+		return c.Next()
+	}
+}
+
+func permissionValidator(claims *mjwt.CustomClaim, permissionsRequired []string) resterr.APIError {
+	if len(permissionsRequired) == 0 {
+		return nil
+	}
+	if permissionChecker == nil || !permissionChecker.HasAnyPermission(claims.Roles, permissionsRequired) {
+		return resterr.NewUnauthorizedError(fmt.Sprintf("Unauthorized, memerlukan permission %s", strings.Join(permissionsRequired, " atau ")))
+	}
+	return nil
+}
+
+// StreamAuth memvalidasi access token pada header atau tiket stream pada query ticket.
+// EventSource pada browser tidak dapat mengirim header Authorization sehingga dashboard menggunakan tiket
+func StreamAuth() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ticket := c.Query(ticketKey)
+		if ticket == "" {
+			return NormalAuth()(c)
+		}
+
+		claims, err := streamTicketValidator(ticket)
+		if err != nil {
+			return c.Status(err.Status()).JSON(fiber.Map{"error": err, "data": nil})
+		}
+		c.Locals(mjwt.CLAIMS, claims)
+		return c.Next()
+	}
+}
+
+// streamTicketValidator memvalidasi tiket stream, Exp claims diganti masa berlaku access token asal
+// sehingga koneksi ditutup bersamaan dengan berakhirnya access token
+func streamTicketValidator(ticket string) (*mjwt.CustomClaim, resterr.APIError) {
+	token, apiErr := jwt.ValidateToken(ticket)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	claims, apiErr := jwt.ReadToken(token)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if claims.Type != mjwt.StreamTicket {
+		return nil, resterr.NewUnauthorizedError("Unauthorized, memerlukan tiket stream")
+	}
+
+	if revocationChecker != nil && revocationChecker.IsRevoked(*claims) {
+		return nil, resterr.NewUnauthorizedError("Token sudah tidak berlaku, silahkan login ulang")
+	}
+
+	claims.Exp = claims.AccessExp
+	return claims, nil
+}
+
 func FreshAuth(rolesReq ...string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get(headerKey)
@@ -65,6 +159,17 @@ func authMustHaveRoleValidator(authHeader string, mustFresh bool, rolesRequired
 		return nil, apiErr
 	}
 
+	// refresh token dan token two factor tidak dapat digunakan untuk mengakses endpoint
+	if claims.Type != mjwt.Access {
+		apiErr := resterr.NewUnauthorizedError("Unauthorized, memerlukan access token")
+		return nil, apiErr
+	}
+
+	if revocationChecker != nil && revocationChecker.IsRevoked(*claims) {
+		apiErr := resterr.NewUnauthorizedError("Token sudah tidak berlaku, silahkan login ulang")
+		return nil, apiErr
+	}
+
 	if mustFresh {
 		if !claims.Fresh {
 			apiErr := resterr.NewUnauthorizedError("Memerlukan token yang baru untuk mengakses halaman ini")
@@ -72,13 +177,15 @@ func authMustHaveRoleValidator(authHeader string, mustFresh bool, rolesRequired
 		}
 	}
 
+	// cukup memiliki salah satu dari role yang diminta
 	if len(rolesRequired) != 0 {
 		for _, roleReq := range rolesRequired {
-			if !sfunc.InSlice(roleReq, claims.Roles) {
-				apiErr := resterr.NewUnauthorizedError(fmt.Sprintf("Unauthorized, memerlukan hak akses %s", roleReq))
-				return nil, apiErr
+			if sfunc.InSlice(roleReq, claims.Roles) {
+				return claims, nil
 			}
 		}
+		apiErr := resterr.NewUnauthorizedError(fmt.Sprintf("Unauthorized, memerlukan hak akses %s", strings.Join(rolesRequired, " atau ")))
+		return nil, apiErr
 	}
 	return claims, nil
 }
\ No newline at end of file
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"tilank/utils/mjwt"
+)
+
+type fakeRevocationChecker struct {
+	revokedTokenID string
+}
+
+func (f fakeRevocationChecker) IsRevoked(claims mjwt.CustomClaim) bool {
+	return claims.TokenID == f.revokedTokenID
+}
+
+func TestStreamAuth(t *testing.T) {
+	SetRevocationChecker(fakeRevocationChecker{revokedTokenID: "revoked"})
+	defer SetRevocationChecker(nil)
+
+	accessExp := time.Now().Add(30 * time.Minute).Unix()
+	newToken := func(tokenID string, tokenType int) string {
+		token, err := jwt.GenerateToken(mjwt.CustomClaim{
+			TokenID:     tokenID,
+			Identity:    "hsse",
+			Name:        "HSSE",
+			Roles:       []string{},
+			Branch:      "BANJARMASIN",
+			ExtraMinute: 1,
+			Type:        tokenType,
+			AccessExp:   accessExp,
+		})
+		assert.Nil(t, err)
+		return token
+	}
+
+	app := fiber.New()
+	app.Get("/events", StreamAuth(), func(c *fiber.Ctx) error {
+		claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+		if claims.Exp != accessExp {
+			return c.SendStatus(http.StatusInternalServerError)
+		}
+		return c.SendStatus(http.StatusOK)
+	})
+
+	cases := []struct {
+		name   string
+		ticket string
+		status int
+	}{
+		{"tiket valid", newToken("ticket", mjwt.StreamTicket), http.StatusOK},
+		{"access token pada query ditolak", newToken("access", mjwt.Access), http.StatusUnauthorized},
+		{"tiket dari token yang dicabut", newToken("revoked", mjwt.StreamTicket), http.StatusUnauthorized},
+		// tanpa tiket kembali ke NormalAuth yang memerlukan bearer token
+		{"tanpa tiket", "", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest("GET", "/events?ticket="+tc.ticket, nil)
+		resp, err := app.Test(req)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.status, resp.StatusCode, tc.name)
+	}
+}
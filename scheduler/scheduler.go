@@ -10,6 +10,13 @@ import (
 
 func RunScheduler(
 	truckService *service.TruckService,
+	driverService *service.DriverService,
+	violationService *service.ViolationService,
+	jptService *service.JptService,
+	webhookService *service.WebhookService,
+	tokenService *service.TokenService,
+	policyService *service.PolicyService,
+	userService service.UserServiceAssumer,
 ) {
 	witaTimeZone, err := time.LoadLocation("Asia/Makassar")
 	if err != nil {
@@ -26,6 +33,112 @@ func RunScheduler(
 		if truckAffected != 0 {
 			logger.Info(fmt.Sprintf("Reset blokir truck diterapkan ke %d truck", truckAffected))
 		}
+
+		truckReminded, err := truckService.RemindBlockExpiring()
+		if err != nil {
+			logger.Error("Pengingat blokir truck error", err)
+		}
+		if truckReminded != 0 {
+			logger.Info(fmt.Sprintf("Pengingat blokir berakhir dikirim ke %d truck", truckReminded))
+		}
+
+		driverAffected, err := driverService.ResetBlockedDriver()
+		if err != nil {
+			logger.Error("Reset blokir sopir error", err)
+		}
+		if driverAffected != 0 {
+			logger.Info(fmt.Sprintf("Reset blokir sopir diterapkan ke %d sopir", driverAffected))
+		}
+	})
+
+	// eskalasi pelanggaran yang melewati sla persetujuan
+	_, _ = s.Every(1).Hours().Do(func() {
+		escalated, err := violationService.EscalatePendingViolation()
+		if err != nil {
+			logger.Error("Eskalasi pelanggaran error", err)
+		}
+		if escalated != 0 {
+			logger.Info(fmt.Sprintf("Eskalasi persetujuan diterapkan ke %d pelanggaran", escalated))
+		}
+	})
+
+	// ringkasan harian pelanggaran yang menunggu persetujuan
+	_, _ = s.Every(1).Day().At("07:00").Do(func() {
+		branchSent, err := violationService.SendPendingDigest()
+		if err != nil {
+			logger.Error("Ringkasan pelanggaran menunggu persetujuan error", err)
+			return
+		}
+		logger.Info(fmt.Sprintf("Ringkasan pelanggaran menunggu persetujuan dikirim ke %d cabang", branchSent))
+	})
+
+	// peringatan dan pengarsipan draft pelanggaran yang tidak diubah
+	_, _ = s.Every(1).Day().At("01:00").Do(func() {
+		result, err := violationService.ArchiveStaleDraft()
+		if err != nil {
+			logger.Error("Pengarsipan draft pelanggaran error", err)
+			return
+		}
+		logger.Info(fmt.Sprintf("Pengarsipan draft pelanggaran: %d diperingatkan, %d diarsipkan",
+			result.Warned, result.Archived))
+	})
+
+	// hitung ulang score jpt dan riwayat bulanan
+	_, _ = s.Every(1).Day().At("02:00").Do(func() {
+		result, err := jptService.RecalculateScore()
+		if err != nil {
+			logger.Error("Perhitungan score jpt error", err)
+			return
+		}
+		logger.Info(fmt.Sprintf("Perhitungan score jpt diterapkan ke %d jpt, %d perubahan status",
+			result.JptScored, result.StatusChanged))
+	})
+
+	// run pengiriman ulang webhook yang gagal, tidak dijalankan bersamaan dengan proses sebelumnya yang belum selesai
+	_, _ = s.Every(1).Minutes().SingletonMode().Do(func() {
+		retried, err := webhookService.RetryDueDeliveries()
+		if err != nil {
+			logger.Error("Pengiriman ulang webhook error", err)
+		}
+		if retried != 0 {
+			logger.Info(fmt.Sprintf("Pengiriman ulang webhook dijalankan untuk %d log", retried))
+		}
+	})
+
+	// sinkronisasi daftar token yang dicabut antar instance
+	_, _ = s.Every(1).Minutes().Do(func() {
+		if err := tokenService.Reload(); err != nil {
+			logger.Error("Sinkronisasi token yang dicabut error", err)
+		}
+	})
+
+	// sinkronisasi matriks role-permission antar instance
+	_, _ = s.Every(1).Minutes().Do(func() {
+		if err := policyService.Reload(); err != nil {
+			logger.Error("Sinkronisasi role permission error", err)
+		}
+	})
+
+	// hapus data pencabutan token yang sudah kadaluarsa
+	_, _ = s.Every(1).Day().At("01:00").Do(func() {
+		deleted, err := tokenService.CleanupExpired()
+		if err != nil {
+			logger.Error("Pembersihan token yang dicabut error", err)
+		}
+		if deleted != 0 {
+			logger.Info(fmt.Sprintf("Pembersihan token yang dicabut menghapus %d data", deleted))
+		}
+	})
+
+	// hapus state login sso yang tidak diselesaikan
+	_, _ = s.Every(1).Hours().Do(func() {
+		deleted, err := userService.CleanupSsoState()
+		if err != nil {
+			logger.Error("Pembersihan state sso error", err)
+		}
+		if deleted != 0 {
+			logger.Info(fmt.Sprintf("Pembersihan state sso menghapus %d data", deleted))
+		}
 	})
 
 	s.StartAsync()
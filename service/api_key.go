@@ -0,0 +1,156 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"tilank/dao/apikeydao"
+	"tilank/dto"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+	"tilank/utils/sfunc"
+	"time"
+)
+
+const (
+	// apiKeyPrefix penanda agar api key mudah dikenali jika bocor di log atau repository
+	apiKeyPrefix = "tlk_"
+	// apiKeyPrefixLength jumlah karakter awal key yang disimpan untuk identifikasi
+	apiKeyPrefixLength = 12
+	// apiKeyLastUsedInterval interval minimum (detik) pembaruan last_used_at agar tidak menulis setiap request
+	apiKeyLastUsedInterval = 60
+	// ApiKeyIdentityPrefix awalan claims Identity untuk request yang menggunakan api key
+	ApiKeyIdentityPrefix = "APIKEY:"
+)
+
+func NewApiKeyService(apiKeyDao apikeydao.ApiKeyDaoAssumer, audit *AuditService) *ApiKeyService {
+	return &ApiKeyService{
+		dao:   apiKeyDao,
+		audit: audit,
+	}
+}
+
+type ApiKeyService struct {
+	dao   apikeydao.ApiKeyDaoAssumer
+	audit *AuditService
+}
+
+// CreateApiKey membuat api key baru, key asli hanya dikembalikan sekali
+func (a *ApiKeyService) CreateApiKey(user mjwt.CustomClaim, input dto.ApiKeyRequest) (*dto.ApiKeyCreateResponse, resterr.APIError) {
+	timeNow := time.Now().Unix()
+	if input.ExpiresAt != 0 && input.ExpiresAt <= timeNow {
+		return nil, resterr.NewBadRequestError("expires_at harus lebih besar dari waktu sekarang")
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, resterr.NewInternalServerError("gagal membuat api key", err)
+	}
+	key := apiKeyPrefix + hex.EncodeToString(b)
+
+	apiKey, apiErr := a.dao.InsertApiKey(dto.ApiKey{
+		CreatedAt:   timeNow,
+		CreatedBy:   user.Name,
+		CreatedByID: user.Identity,
+		Name:        input.Name,
+		Prefix:      key[:apiKeyPrefixLength],
+		Hash:        hashApiKey(key),
+		Scopes:      sfunc.Unique(input.Scopes),
+		Branch:      input.Branch,
+		ExpiresAt:   input.ExpiresAt,
+	})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	a.audit.Record(dto.AuditLog{
+		Action:  AuditApiKeyCreate,
+		Actor:   user.Identity,
+		Target:  ApiKeyIdentityPrefix + apiKey.ID.Hex(),
+		Outcome: AuditSuccess,
+		Detail:  fmt.Sprintf("%s cabang %s scope %s", apiKey.Name, apiKey.Branch, strings.Join(apiKey.Scopes, ",")),
+	})
+
+	return &dto.ApiKeyCreateResponse{
+		ApiKey: *apiKey,
+		Key:    key,
+	}, nil
+}
+
+// RevokeApiKey mencabut api key, request berikutnya dengan key tersebut akan ditolak
+func (a *ApiKeyService) RevokeApiKey(user mjwt.CustomClaim, apiKeyID string) (*dto.ApiKey, resterr.APIError) {
+	oid, errT := primitive.ObjectIDFromHex(apiKeyID)
+	if errT != nil {
+		return nil, resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
+	}
+
+	apiKey, apiErr := a.dao.RevokeApiKey(oid, user.Identity, time.Now().Unix())
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	a.audit.Record(dto.AuditLog{
+		Action:  AuditApiKeyRevoke,
+		Actor:   user.Identity,
+		Target:  ApiKeyIdentityPrefix + apiKey.ID.Hex(),
+		Outcome: AuditSuccess,
+		Detail:  apiKey.Name,
+	})
+
+	return apiKey, nil
+}
+
+func (a *ApiKeyService) FindApiKey() ([]dto.ApiKey, resterr.APIError) {
+	return a.dao.FindApiKey()
+}
+
+// Authenticate memvalidasi api key dan mengembalikan claims pengganti token jwt.
+// claims bertipe access tanpa role, akses dibatasi oleh scope dan cabang api key
+func (a *ApiKeyService) Authenticate(key string, ip string) (*mjwt.CustomClaim, []string, resterr.APIError) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, nil, resterr.NewUnauthorizedError("Api key tidak valid")
+	}
+
+	apiKey, apiErr := a.dao.GetApiKeyByHash(hashApiKey(key))
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	timeNow := time.Now().Unix()
+	if apiKey.Revoked {
+		return nil, nil, resterr.NewUnauthorizedError("Api key sudah dicabut")
+	}
+	if apiKey.ExpiresAt != 0 && apiKey.ExpiresAt <= timeNow {
+		return nil, nil, resterr.NewUnauthorizedError("Api key sudah kadaluarsa")
+	}
+
+	if timeNow-apiKey.LastUsedAt >= apiKeyLastUsedInterval || apiKey.LastUsedIP != ip {
+		_ = a.dao.UpdateLastUsed(apiKey.ID, timeNow, ip)
+	}
+
+	claims := mjwt.CustomClaim{
+		Identity: ApiKeyIdentityPrefix + apiKey.ID.Hex(),
+		Name:     apiKey.Name,
+		Roles:    []string{},
+		Branch:   apiKey.Branch,
+		Exp:      apiKey.ExpiresAt,
+		Type:     mjwt.Access,
+	}
+
+	return &claims, apiKey.Scopes, nil
+}
+
+// hashApiKey api key bernilai acak 256 bit sehingga cukup menggunakan sha256
+func hashApiKey(key string) string {
+	return sha256Hex(key)
+}
+
+// sha256Hex digunakan untuk menyimpan nilai acak (api key, recovery code, token reset)
+// yang tidak memerlukan hash lambat seperti password
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"tilank/dao/auditdao"
+	"tilank/dto"
+	"tilank/utils/rest_err"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeAuditDao struct {
+	auditdao.AuditDaoAssumer
+	auditList dto.AuditLogList
+	filter    dto.FilterAudit
+	inserted  []dto.AuditLog
+}
+
+func (f *fakeAuditDao) InsertAudit(input dto.AuditLog) resterr.APIError {
+	f.inserted = append(f.inserted, input)
+	return nil
+}
+
+func (f *fakeAuditDao) FindAudit(filter dto.FilterAudit) (dto.AuditLogList, resterr.APIError) {
+	f.filter = filter
+	return f.auditList, nil
+}
+
+func TestCsvSafe(t *testing.T) {
+	cases := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"kosong", "", ""},
+		{"teks biasa", "Mozilla/5.0", "Mozilla/5.0"},
+		{"sama dengan", "=HYPERLINK(\"x\")", "'=HYPERLINK(\"x\")"},
+		{"plus", "+62811", "'+62811"},
+		{"minus", "-1+2", "'-1+2"},
+		{"at", "@SUM(A1)", "'@SUM(A1)"},
+		{"tab", "\t=1+2", "'\t=1+2"},
+		{"carriage return", "\r=1+2", "'\r=1+2"},
+		{"formula di tengah", "a=1", "a=1"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, csvSafe(c.value))
+		})
+	}
+}
+
+func TestExportCSVEscapeAllColumn(t *testing.T) {
+	id := primitive.NewObjectID()
+	dao := &fakeAuditDao{auditList: dto.AuditLogList{{
+		ID:        id,
+		Time:      0,
+		Action:    AuditLoginFailed,
+		Actor:     "=CMD()",
+		Target:    "+TARGET",
+		IP:        "@10.0.0.1",
+		UserAgent: "-agent",
+		Outcome:   AuditDenied,
+		Detail:    "\tdetail",
+	}}}
+
+	out, apiErr := NewAuditService(dao).ExportCSV(dto.FilterAudit{})
+	assert.Nil(t, apiErr)
+	assert.Equal(t, int64(auditExportMaxLimit), dao.filter.Limit)
+
+	records, err := csv.NewReader(strings.NewReader(string(out))).ReadAll()
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, []string{"id", "time", "action", "actor", "target", "ip", "user_agent", "outcome", "detail"}, records[0])
+	assert.Equal(t, []string{
+		id.Hex(), "1970-01-01T00:00:00Z", AuditLoginFailed, "'=CMD()", "'+TARGET", "'@10.0.0.1", "'-agent", AuditDenied, "'\tdetail",
+	}, records[1])
+}
+
+func TestExportCSVInvalidRange(t *testing.T) {
+	_, apiErr := NewAuditService(&fakeAuditDao{}).ExportCSV(dto.FilterAudit{FilterStart: 10, FilterEnd: 5})
+	assert.NotNil(t, apiErr)
+	assert.Equal(t, 400, apiErr.Status())
+}
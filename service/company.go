@@ -0,0 +1,378 @@
+package service
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"strings"
+	"tilank/dao/companydao"
+	"tilank/dao/truckdao"
+	"tilank/dao/violationdao"
+	"tilank/dto"
+	"tilank/utils/logger"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+	"tilank/utils/sfunc"
+	"time"
+)
+
+const (
+	// companyLatestViolation jumlah pelanggaran terbaru yang ditampilkan pada rekap perusahaan
+	companyLatestViolation = 20
+)
+
+// CompanyServiceAssumer dipenuhi oleh *CompanyService, digunakan handler agar dapat diganti saat pengujian
+type CompanyServiceAssumer interface {
+	InsertCompany(user mjwt.CustomClaim, input dto.CompanyRequest) (*string, resterr.APIError)
+	EditCompany(user mjwt.CustomClaim, companyID string, input dto.CompanyEditRequest) (*dto.Company, resterr.APIError)
+	DeleteCompany(user mjwt.CustomClaim, id string) resterr.APIError
+	ActivateCompany(user mjwt.CustomClaim, id string) resterr.APIError
+	GetCompanyByID(companyID string, branchIfSpecific string) (*dto.Company, resterr.APIError)
+	FindCompany(filter dto.FilterCompany) (dto.CompanyResponseMinList, resterr.APIError)
+	GetFleet(company dto.Company) (*dto.CompanyFleetResponse, resterr.APIError)
+	GetViolationSummary(company dto.Company, start int64, end int64) (*dto.CompanyViolationResponse, resterr.APIError)
+	MigrateTruckOwner() (*dto.CompanyMigrationResponse, resterr.APIError)
+}
+
+func NewCompanyService(companyDao companydao.CompanyDaoAssumer,
+	truckDao truckdao.TruckDaoAssumer,
+	violationDao violationdao.ViolationDaoAssumer) *CompanyService {
+	return &CompanyService{
+		daoC: companyDao,
+		tDao: truckDao,
+		vDao: violationDao,
+	}
+}
+
+type CompanyService struct {
+	daoC companydao.CompanyDaoAssumer
+	tDao truckdao.TruckDaoAssumer
+	vDao violationdao.ViolationDaoAssumer
+}
+
+func (c *CompanyService) InsertCompany(user mjwt.CustomClaim, input dto.CompanyRequest) (*string, resterr.APIError) {
+	normalizedName := sfunc.NormalizeCompanyName(input.Name)
+	if normalizedName == "" {
+		return nil, resterr.NewBadRequestError("Nama perusahaan tidak valid")
+	}
+
+	companyExisting, _ := c.daoC.GetCompanyByNormalizedName(normalizedName, user.Branch)
+	if companyExisting != nil {
+		return nil, resterr.NewBadRequestError(fmt.Sprintf("Perusahaan %s sudah terdaftar! ", companyExisting.Name))
+	}
+
+	if input.Contacts == nil {
+		input.Contacts = []dto.CompanyContact{}
+	}
+
+	// Filling data
+	timeNow := time.Now().Unix()
+	data := dto.Company{
+		ID:             primitive.NewObjectID(),
+		CreatedAt:      timeNow,
+		CreatedBy:      user.Name,
+		CreatedByID:    user.Identity,
+		UpdatedAt:      timeNow,
+		UpdatedBy:      user.Name,
+		UpdatedByID:    user.Identity,
+		Branch:         user.Branch,
+		Name:           input.Name,
+		NormalizedName: normalizedName,
+		Aliases:        []string{},
+		Email:          input.Email,
+		Hp:             input.Hp,
+		Address:        input.Address,
+		Contacts:       input.Contacts,
+		Deleted:        false,
+	}
+
+	// DB
+	insertedID, err := c.daoC.InsertCompany(data)
+	if err != nil {
+		return nil, resterr.NewBadRequestError(err.Message())
+	}
+
+	return insertedID, nil
+}
+
+// EditCompany mengubah perusahaan, perubahan nama diterapkan juga ke nama owner truck
+func (c *CompanyService) EditCompany(user mjwt.CustomClaim, companyID string, input dto.CompanyEditRequest) (*dto.Company, resterr.APIError) {
+	oid, errT := primitive.ObjectIDFromHex(companyID)
+	if errT != nil {
+		return nil, resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
+	}
+
+	normalizedName := sfunc.NormalizeCompanyName(input.Name)
+	if normalizedName == "" {
+		return nil, resterr.NewBadRequestError("Nama perusahaan tidak valid")
+	}
+	companyExisting, _ := c.daoC.GetCompanyByNormalizedName(normalizedName, user.Branch)
+	if companyExisting != nil && companyExisting.ID != oid {
+		return nil, resterr.NewBadRequestError(fmt.Sprintf("Perusahaan %s sudah terdaftar! ", companyExisting.Name))
+	}
+
+	if input.Contacts == nil {
+		input.Contacts = []dto.CompanyContact{}
+	}
+
+	// Filling data
+	timeNow := time.Now().Unix()
+	data := dto.CompanyEdit{
+		ID:              oid,
+		FilterBranch:    user.Branch,
+		FilterTimestamp: input.FilterTimestamp,
+		UpdatedAt:       timeNow,
+		UpdatedBy:       user.Name,
+		UpdatedByID:     user.Identity,
+		Name:            input.Name,
+		NormalizedName:  normalizedName,
+		Email:           input.Email,
+		Hp:              input.Hp,
+		Address:         input.Address,
+		Contacts:        input.Contacts,
+	}
+
+	// DB
+	companyEdited, err := c.daoC.EditCompany(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := c.tDao.UpdateOwnerName(companyEdited.ID.Hex(), companyEdited.Name); err != nil {
+		logger.Error(fmt.Sprintf("gagal menyamakan nama owner truck perusahaan %s (EditCompany)", companyID), err)
+	}
+
+	return companyEdited, nil
+}
+
+// DeleteCompany menonaktifkan perusahaan, truck yang sudah terhubung tidak diubah
+func (c *CompanyService) DeleteCompany(user mjwt.CustomClaim, id string) resterr.APIError {
+	oid, errT := primitive.ObjectIDFromHex(id)
+	if errT != nil {
+		return resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
+	}
+	// DB
+	_, err := c.daoC.DeleteCompany(dto.FilterIDBranch{
+		FilterID:     oid,
+		FilterBranch: user.Branch,
+	}, true)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *CompanyService) ActivateCompany(user mjwt.CustomClaim, id string) resterr.APIError {
+	oid, errT := primitive.ObjectIDFromHex(id)
+	if errT != nil {
+		return resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
+	}
+	// DB
+	_, err := c.daoC.DeleteCompany(dto.FilterIDBranch{
+		FilterID:     oid,
+		FilterBranch: user.Branch,
+	}, false)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *CompanyService) GetCompanyByID(companyID string, branchIfSpecific string) (*dto.Company, resterr.APIError) {
+	oid, errT := primitive.ObjectIDFromHex(companyID)
+	if errT != nil {
+		return nil, resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
+	}
+
+	company, err := c.daoC.GetCompanyByID(oid, branchIfSpecific)
+	if err != nil {
+		return nil, err
+	}
+
+	return company, nil
+}
+
+func (c *CompanyService) FindCompany(filter dto.FilterCompany) (dto.CompanyResponseMinList, resterr.APIError) {
+	companyList, err := c.daoC.FindCompany(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return companyList, nil
+}
+
+// GetFleet menampilkan truck aktif milik perusahaan
+func (c *CompanyService) GetFleet(company dto.Company) (*dto.CompanyFleetResponse, resterr.APIError) {
+	truckList, err := c.tDao.FindTruck(dto.FilterTruck{
+		FilterBranch:  company.Branch,
+		FilterOwnerID: company.ID.Hex(),
+		Active:        true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	blocked := 0
+	for _, truck := range truckList {
+		if truck.Blocked {
+			blocked++
+		}
+	}
+
+	return &dto.CompanyFleetResponse{
+		Company:      company,
+		TruckTotal:   len(truckList),
+		TruckBlocked: blocked,
+		Trucks:       truckList,
+	}, nil
+}
+
+// GetViolationSummary merekap pelanggaran seluruh truck perusahaan berdasarkan waktu pelanggaran
+func (c *CompanyService) GetViolationSummary(company dto.Company, start int64, end int64) (*dto.CompanyViolationResponse, resterr.APIError) {
+	if start != 0 && end != 0 && start > end {
+		return nil, resterr.NewBadRequestError("start tidak boleh lebih besar dari end")
+	}
+
+	ownerID := company.ID.Hex()
+	byState, err := c.vDao.CountViolationByOwner(ownerID, start, end, violationdao.GroupByState)
+	if err != nil {
+		return nil, err
+	}
+	byType, err := c.vDao.CountViolationByOwner(ownerID, start, end, violationdao.GroupByType)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, count := range byState {
+		total += count.Count
+	}
+
+	latest, err := c.vDao.FindViolation(dto.FilterViolation{
+		FilterBranch:  company.Branch,
+		FilterOwnerID: ownerID,
+		FilterState:   -1,
+		FilterStart:   start,
+		FilterEnd:     end,
+		Limit:         companyLatestViolation,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.CompanyViolationResponse{
+		Company: company,
+		Start:   start,
+		End:     end,
+		Total:   total,
+		ByState: byState,
+		ByType:  byType,
+		Latest:  latest,
+	}, nil
+}
+
+// MigrateTruckOwner mengelompokkan owner truck lama (teks bebas) berdasarkan nama yang dinormalisasi
+// per cabang, membuat perusahaan yang belum ada lalu menghubungkan truck dan pelanggarannya.
+// aman dijalankan berulang kali karena hanya memproses truck yang belum memiliki owner_id
+func (c *CompanyService) MigrateTruckOwner() (*dto.CompanyMigrationResponse, resterr.APIError) {
+	truckList, err := c.tDao.FindTruckUnlinkedOwner()
+	if err != nil {
+		return nil, err
+	}
+
+	type ownerCluster struct {
+		branch     string
+		normalized string
+		variants   map[string]int
+		trucks     dto.TruckResponseMinList
+	}
+
+	clusters := map[string]*ownerCluster{}
+	var keys []string
+	for _, truck := range truckList {
+		normalized := sfunc.NormalizeCompanyName(truck.Owner)
+		if normalized == "" {
+			continue
+		}
+		key := truck.Branch + "|" + normalized
+		cluster, ok := clusters[key]
+		if !ok {
+			cluster = &ownerCluster{branch: truck.Branch, normalized: normalized, variants: map[string]int{}}
+			clusters[key] = cluster
+			keys = append(keys, key)
+		}
+		cluster.variants[strings.TrimSpace(strings.ToUpper(truck.Owner))]++
+		cluster.trucks = append(cluster.trucks, truck)
+	}
+	sort.Strings(keys)
+
+	result := dto.CompanyMigrationResponse{}
+	timeNow := time.Now().Unix()
+	for _, key := range keys {
+		cluster := clusters[key]
+
+		var aliases []string
+		for variant := range cluster.variants {
+			aliases = append(aliases, variant)
+		}
+		// penulisan yang paling sering digunakan menjadi nama perusahaan
+		sort.Slice(aliases, func(i, j int) bool {
+			if cluster.variants[aliases[i]] != cluster.variants[aliases[j]] {
+				return cluster.variants[aliases[i]] > cluster.variants[aliases[j]]
+			}
+			return aliases[i] < aliases[j]
+		})
+
+		company, _ := c.daoC.GetCompanyByNormalizedName(cluster.normalized, cluster.branch)
+		if company != nil {
+			if err := c.daoC.AddAliases(company.ID, aliases); err != nil {
+				return &result, err
+			}
+			result.CompanyMatched++
+		} else {
+			first := cluster.trucks[0]
+			company = &dto.Company{
+				ID:             primitive.NewObjectID(),
+				CreatedAt:      timeNow,
+				CreatedBy:      "MIGRASI",
+				UpdatedAt:      timeNow,
+				UpdatedBy:      "MIGRASI",
+				Branch:         cluster.branch,
+				Name:           aliases[0],
+				NormalizedName: cluster.normalized,
+				Aliases:        aliases,
+				Email:          first.Email,
+				Hp:             first.Hp,
+				Contacts:       []dto.CompanyContact{},
+			}
+			if _, err := c.daoC.InsertCompany(*company); err != nil {
+				return &result, err
+			}
+			company.Name = strings.ToUpper(company.Name)
+			result.CompanyCreated++
+		}
+
+		ownerID := company.ID.Hex()
+		truckIDs := make([]primitive.ObjectID, 0, len(cluster.trucks))
+		for _, truck := range cluster.trucks {
+			truckIDs = append(truckIDs, truck.ID)
+		}
+		linked, err := c.tDao.SetOwner(truckIDs, ownerID, company.Name)
+		if err != nil {
+			return &result, err
+		}
+		result.TruckLinked += linked
+
+		for _, truck := range cluster.trucks {
+			violationLinked, err := c.vDao.SetOwnerByIdentity(truck.NoIdentity, truck.Branch, ownerID)
+			if err != nil {
+				return &result, err
+			}
+			result.ViolationLinked += violationLinked
+		}
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"tilank/config"
+	"tilank/dao/driverdao"
+	"tilank/dto"
+	"tilank/stream"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+	"time"
+)
+
+// DriverServiceAssumer dipenuhi oleh *DriverService, digunakan handler agar dapat diganti saat pengujian
+type DriverServiceAssumer interface {
+	InsertDriver(user mjwt.CustomClaim, input dto.DriverRequest) (*string, resterr.APIError)
+	EditDriver(user mjwt.CustomClaim, driverID string, input dto.DriverEditRequest) (*dto.Driver, resterr.APIError)
+	DeleteDriver(user mjwt.CustomClaim, id string) resterr.APIError
+	ActivateDriver(user mjwt.CustomClaim, id string) resterr.APIError
+	PutImage(user mjwt.CustomClaim, id string, imagePath string) (*dto.Driver, resterr.APIError)
+	GetDriverByID(driverID string, branchIfSpecific string) (*dto.Driver, resterr.APIError)
+	GetDriverByLicense(noLicense string, branch string) (*dto.Driver, resterr.APIError)
+	FindDriver(filter dto.FilterDriver) (dto.DriverResponseMinList, resterr.APIError)
+}
+
+func NewDriverService(driverDao driverdao.DriverDaoAssumer, webhookService *WebhookService, eventHub stream.HubAssumer) *DriverService {
+	return &DriverService{
+		daoC:    driverDao,
+		webhook: webhookService,
+		hub:     eventHub,
+	}
+}
+
+type DriverService struct {
+	daoC    driverdao.DriverDaoAssumer
+	webhook *WebhookService
+	hub     stream.HubAssumer
+}
+
+func (d *DriverService) InsertDriver(user mjwt.CustomClaim, input dto.DriverRequest) (*string, resterr.APIError) {
+	idGenerated := primitive.NewObjectID()
+
+	driverExisting, _ := d.daoC.GetDriverByLicense(input.NoLicense, user.Branch)
+	if driverExisting != nil {
+		return nil, resterr.NewBadRequestError("Nomor SIM sudah terdaftar! ")
+	}
+
+	// Filling data
+	timeNow := time.Now().Unix()
+	data := dto.Driver{
+		ID:          idGenerated,
+		CreatedAt:   timeNow,
+		CreatedBy:   user.Name,
+		CreatedByID: user.Identity,
+		UpdatedAt:   timeNow,
+		UpdatedBy:   user.Name,
+		UpdatedByID: user.Identity,
+		Branch:      user.Branch,
+		NoLicense:   input.NoLicense,
+		Name:        input.Name,
+		Employer:    input.Employer,
+		Hp:          input.Hp,
+		Image:       "",
+		Deleted:     false,
+		Score:       0,
+		Blocked:     false,
+	}
+
+	// DB
+	insertedID, err := d.daoC.InsertDriver(data)
+	if err != nil {
+		return nil, resterr.NewBadRequestError(err.Message())
+	}
+
+	return insertedID, nil
+}
+
+func (d *DriverService) EditDriver(user mjwt.CustomClaim, driverID string, input dto.DriverEditRequest) (*dto.Driver, resterr.APIError) {
+	oid, errT := primitive.ObjectIDFromHex(driverID)
+	if errT != nil {
+		return nil, resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
+	}
+
+	// nomor SIM baru tidak boleh dimiliki sopir lain
+	driverExisting, _ := d.daoC.GetDriverByLicense(input.NoLicense, user.Branch)
+	if driverExisting != nil && driverExisting.ID != oid {
+		return nil, resterr.NewBadRequestError("Nomor SIM sudah terdaftar! ")
+	}
+
+	// Filling data
+	timeNow := time.Now().Unix()
+	data := dto.DriverEdit{
+		ID:              oid,
+		FilterBranch:    user.Branch,
+		FilterTimestamp: input.FilterTimestamp,
+		UpdatedAt:       timeNow,
+		UpdatedBy:       user.Name,
+		UpdatedByID:     user.Identity,
+		NoLicense:       input.NoLicense,
+		Name:            input.Name,
+		Employer:        input.Employer,
+		Hp:              input.Hp,
+	}
+
+	// DB
+	driverEdited, err := d.daoC.EditDriver(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return driverEdited, nil
+}
+
+// DeleteDriver menonaktifkan sopir, riwayat pelanggaran tetap tersimpan
+func (d *DriverService) DeleteDriver(user mjwt.CustomClaim, id string) resterr.APIError {
+	oid, errT := primitive.ObjectIDFromHex(id)
+	if errT != nil {
+		return resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
+	}
+	// DB
+	_, err := d.daoC.DeleteDriver(dto.FilterIDBranch{
+		FilterID:     oid,
+		FilterBranch: user.Branch,
+	}, true)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (d *DriverService) ActivateDriver(user mjwt.CustomClaim, id string) resterr.APIError {
+	oid, errT := primitive.ObjectIDFromHex(id)
+	if errT != nil {
+		return resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
+	}
+	// DB
+	_, err := d.daoC.DeleteDriver(dto.FilterIDBranch{
+		FilterID:     oid,
+		FilterBranch: user.Branch,
+	}, false)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// PutImage memasukkan lokasi foto sopir ke database dengan mengecek kesesuaian branch
+func (d *DriverService) PutImage(user mjwt.CustomClaim, id string, imagePath string) (*dto.Driver, resterr.APIError) {
+	oid, errT := primitive.ObjectIDFromHex(id)
+	if errT != nil {
+		return nil, resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
+	}
+
+	driver, err := d.daoC.PutImage(oid, imagePath, user.Branch)
+	if err != nil {
+		return nil, err
+	}
+	return driver, nil
+}
+
+func (d *DriverService) GetDriverByID(driverID string, branchIfSpecific string) (*dto.Driver, resterr.APIError) {
+	oid, errT := primitive.ObjectIDFromHex(driverID)
+	if errT != nil {
+		return nil, resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
+	}
+
+	driver, err := d.daoC.GetDriverByID(oid, branchIfSpecific)
+	if err != nil {
+		return nil, err
+	}
+
+	return driver, nil
+}
+
+func (d *DriverService) GetDriverByLicense(noLicense string, branch string) (*dto.Driver, resterr.APIError) {
+	driver, err := d.daoC.GetDriverByLicense(noLicense, branch)
+	if err != nil {
+		return nil, err
+	}
+	return driver, nil
+}
+
+func (d *DriverService) FindDriver(filter dto.FilterDriver) (dto.DriverResponseMinList, resterr.APIError) {
+	driverList, err := d.daoC.FindDriver(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return driverList, nil
+}
+
+// ResetBlockedDriver membuka blokir sopir yang masa blokirnya sudah berakhir
+func (d *DriverService) ResetBlockedDriver() (int64, resterr.APIError) {
+	driverList, err := d.daoC.FindDriver(dto.FilterDriver{
+		Blocked: true,
+		Active:  true,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	nowUnix := time.Now().Unix()
+
+	var driverIDMustReset []primitive.ObjectID
+	var driverReset dto.DriverResponseMinList
+
+	for _, driver := range driverList {
+		if driver.BlockEnd <= nowUnix {
+			driverIDMustReset = append(driverIDMustReset, driver.ID)
+			driverReset = append(driverReset, driver)
+		}
+	}
+
+	var updated int64
+	if len(driverIDMustReset) != 0 {
+		updated, err = d.daoC.ResetDriverBlock(driverIDMustReset)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, driver := range driverReset {
+			driver.Blocked = false
+			driver.BlockStart = 0
+			driver.BlockEnd = 0
+			d.webhook.Emit(config.EventDriverUnblocked, driver.Branch, driver)
+			d.hub.Publish(config.EventDriverUnblocked, driver.Branch, driver)
+		}
+	}
+
+	return updated, nil
+}
@@ -1,22 +1,49 @@
 package service
 
 import (
+	"fmt"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"tilank/config"
 	"tilank/dao/jptdao"
+	"tilank/dao/truckdao"
+	"tilank/dao/violationdao"
 	"tilank/dto"
+	"tilank/utils/logger"
 	"tilank/utils/mjwt"
 	"tilank/utils/rest_err"
 	"time"
 )
 
-func NewJptService(jptDao jptdao.JptDaoAssumer) *JptService {
+// JptServiceAssumer dipenuhi oleh *JptService, digunakan handler agar dapat diganti saat pengujian
+type JptServiceAssumer interface {
+	InsertJpt(user mjwt.CustomClaim, input dto.JptRequest) (*string, resterr.APIError)
+	EditJpt(user mjwt.CustomClaim, jptID string, input dto.JptEditRequest) (*dto.Jpt, resterr.APIError)
+	DeleteJpt(user mjwt.CustomClaim, id string) resterr.APIError
+	ActivateJpt(user mjwt.CustomClaim, id string) resterr.APIError
+	GetJptByID(jptID string, branchIfSpecific string) (*dto.Jpt, resterr.APIError)
+	FindJpt(filter dto.FilterJpt) (dto.JptResponseMinList, resterr.APIError)
+	RecalculateScore() (*dto.JptScoreRecalculateResponse, resterr.APIError)
+	FindLeaderboard(branch string) (dto.JptResponseMinList, resterr.APIError)
+	GetScoreHistory(jpt dto.Jpt, limit int64) (dto.JptScoreHistoryList, resterr.APIError)
+}
+
+func NewJptService(jptDao jptdao.JptDaoAssumer,
+	truckDao truckdao.TruckDaoAssumer,
+	violationDao violationdao.ViolationDaoAssumer,
+	webhookService *WebhookService) *JptService {
 	return &JptService{
-		daoC: jptDao,
+		daoC:    jptDao,
+		tDao:    truckDao,
+		vDao:    violationDao,
+		webhook: webhookService,
 	}
 }
 
 type JptService struct {
-	daoC jptdao.JptDaoAssumer
+	daoC    jptdao.JptDaoAssumer
+	tDao    truckdao.TruckDaoAssumer
+	vDao    violationdao.ViolationDaoAssumer
+	webhook *WebhookService
 }
 
 func (j *JptService) InsertJpt(user mjwt.CustomClaim, input dto.JptRequest) (*string, resterr.APIError) {
@@ -39,6 +66,7 @@ func (j *JptService) InsertJpt(user mjwt.CustomClaim, input dto.JptRequest) (*st
 		Hp:          input.Hp,
 		Email:       input.Email,
 		Deleted:     false,
+		Status:      config.JptStatusNormal,
 	}
 
 	// DB
@@ -77,6 +105,10 @@ func (j *JptService) EditJpt(user mjwt.CustomClaim, jptID string, input dto.JptE
 		return nil, err
 	}
 
+	if _, err := j.tDao.UpdateJptName(jptEdited.ID.Hex(), jptEdited.Name); err != nil {
+		logger.Error(fmt.Sprintf("gagal menyamakan nama jpt truck %s (EditJpt)", jptID), err)
+	}
+
 	return jptEdited, nil
 }
 
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"tilank/config"
+	"tilank/dto"
+	"tilank/utils/logger"
+	"tilank/utils/rest_err"
+	"time"
+)
+
+const (
+	envJptScoreWindowDay = "JPT_SCORE_WINDOW_DAYS"
+	envJptWarningScore   = "JPT_WARNING_SCORE"
+	envJptSuspendScore   = "JPT_SUSPEND_SCORE"
+
+	// jptScoreHistoryLimit jumlah bulan riwayat score yang ditampilkan secara default
+	jptScoreHistoryLimit = 12
+)
+
+// JptScorePolicy aturan perhitungan score JPT.
+// WindowDay panjang periode berjalan dalam hari, score adalah jumlah pelanggaran yang disetujui
+// dalam periode tersebut dibagi jumlah truck aktif JPT. JPT dengan score >= WarningScore mendapat
+// peringatan dan score >= SuspendScore disuspend sehingga tidak dapat dihubungkan ke truck baru
+type JptScorePolicy struct {
+	WindowDay    int
+	WarningScore float64
+	SuspendScore float64
+}
+
+var jptScorePolicy = defaultJptScorePolicy()
+
+func defaultJptScorePolicy() JptScorePolicy {
+	return JptScorePolicy{
+		WindowDay:    90,
+		WarningScore: 0.5,
+		SuspendScore: 1,
+	}
+}
+
+// LoadJptScorePolicy memuat ambang batas score JPT dari environment
+func LoadJptScorePolicy() error {
+	policy := defaultJptScorePolicy()
+
+	var err error
+	if policy.WindowDay, err = config.EnvInt(envJptScoreWindowDay, policy.WindowDay, 1, 3650); err != nil {
+		return err
+	}
+	if policy.WarningScore, err = config.EnvPositiveFloat(envJptWarningScore, policy.WarningScore); err != nil {
+		return err
+	}
+	if policy.SuspendScore, err = config.EnvPositiveFloat(envJptSuspendScore, policy.SuspendScore); err != nil {
+		return err
+	}
+	if policy.WarningScore > policy.SuspendScore {
+		return fmt.Errorf("%s tidak boleh lebih besar dari %s", envJptWarningScore, envJptSuspendScore)
+	}
+
+	jptScorePolicy = policy
+	return nil
+}
+
+// Status menentukan status JPT berdasarkan score
+func (p JptScorePolicy) Status(score float64) string {
+	switch {
+	case score >= p.SuspendScore:
+		return config.JptStatusSuspended
+	case score >= p.WarningScore:
+		return config.JptStatusWarning
+	default:
+		return config.JptStatusNormal
+	}
+}
+
+// Score pelanggaran per truck aktif dibulatkan 2 desimal,
+// JPT tanpa truck aktif dihitung seolah memiliki satu truck
+func (p JptScorePolicy) Score(violationCount int64, truckCount int64) float64 {
+	if truckCount < 1 {
+		truckCount = 1
+	}
+	return math.Round(float64(violationCount)/float64(truckCount)*100) / 100
+}
+
+// RecalculateScore menghitung ulang score seluruh JPT aktif, menyimpan riwayat bulan berjalan
+// dan mengirim webhook jika status JPT berubah
+func (j *JptService) RecalculateScore() (*dto.JptScoreRecalculateResponse, resterr.APIError) {
+	now := time.Now()
+	since := now.AddDate(0, 0, -jptScorePolicy.WindowDay).Unix()
+
+	jptList, err := j.daoC.FindJpt(dto.FilterJpt{Active: true})
+	if err != nil {
+		return nil, err
+	}
+	violationCounts, err := j.vDao.CountApprovedViolationByJpt(since)
+	if err != nil {
+		return nil, err
+	}
+	truckCounts, err := j.tDao.CountActiveTruckByJpt()
+	if err != nil {
+		return nil, err
+	}
+
+	violationByJpt := make(map[string]int64, len(violationCounts))
+	for _, count := range violationCounts {
+		violationByJpt[count.Key] = count.Count
+	}
+
+	result := dto.JptScoreRecalculateResponse{}
+	period := now.Format("2006-01")
+	for _, jpt := range jptList {
+		jptID := jpt.ID.Hex()
+		violationCount := violationByJpt[jptID]
+		truckCount := truckCounts[jptID]
+		score := jptScorePolicy.Score(violationCount, truckCount)
+		status := jptScorePolicy.Status(score)
+
+		// jpt lama yang belum pernah dihitung dianggap berstatus normal
+		previousStatus := jpt.Status
+		if previousStatus == "" {
+			previousStatus = config.JptStatusNormal
+		}
+		statusChangedAt := jpt.StatusChangedAt
+		if status != previousStatus {
+			statusChangedAt = now.Unix()
+		}
+
+		jptUpdated, err := j.daoC.ChangeScore(dto.JptScoreEdit{
+			ID:              jpt.ID,
+			Score:           score,
+			ViolationCount:  violationCount,
+			TruckCount:      truckCount,
+			Status:          status,
+			ScoredAt:        now.Unix(),
+			StatusChangedAt: statusChangedAt,
+		})
+		if err != nil {
+			logger.Error(fmt.Sprintf("gagal menyimpan score jpt %s (RecalculateScore)", jptID), err)
+			continue
+		}
+		result.JptScored++
+
+		if err := j.daoC.UpsertScoreHistory(dto.JptScoreHistory{
+			JptID:          jptID,
+			Branch:         jptUpdated.Branch,
+			Name:           jptUpdated.Name,
+			Period:         period,
+			Score:          score,
+			ViolationCount: violationCount,
+			TruckCount:     truckCount,
+			Status:         status,
+			UpdatedAt:      now.Unix(),
+		}); err != nil {
+			logger.Error(fmt.Sprintf("gagal menyimpan riwayat score jpt %s (RecalculateScore)", jptID), err)
+		}
+
+		switch status {
+		case config.JptStatusWarning:
+			result.Warning++
+		case config.JptStatusSuspended:
+			result.Suspended++
+		}
+
+		if status != previousStatus {
+			result.StatusChanged++
+			event := config.EventJptReinstated
+			switch status {
+			case config.JptStatusWarning:
+				event = config.EventJptWarned
+			case config.JptStatusSuspended:
+				event = config.EventJptSuspended
+			}
+			j.webhook.Emit(event, jptUpdated.Branch, jptUpdated)
+		}
+	}
+
+	return &result, nil
+}
+
+// FindLeaderboard menampilkan JPT aktif dari score terbaik, branch kosong untuk seluruh cabang
+func (j *JptService) FindLeaderboard(branch string) (dto.JptResponseMinList, resterr.APIError) {
+	return j.daoC.FindJptLeaderboard(branch)
+}
+
+// GetScoreHistory menampilkan riwayat score bulanan JPT
+func (j *JptService) GetScoreHistory(jpt dto.Jpt, limit int64) (dto.JptScoreHistoryList, resterr.APIError) {
+	if limit < 1 {
+		limit = jptScoreHistoryLimit
+	}
+	return j.daoC.FindScoreHistory(jpt.ID.Hex(), limit)
+}
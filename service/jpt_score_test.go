@@ -0,0 +1,77 @@
+package service
+
+import (
+	"os"
+	"testing"
+	"tilank/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJptScorePolicyStatus(t *testing.T) {
+	policy := JptScorePolicy{WindowDay: 90, WarningScore: 0.5, SuspendScore: 1}
+	cases := []struct {
+		score float64
+		want  string
+	}{
+		{0, config.JptStatusNormal},
+		{0.49, config.JptStatusNormal},
+		{0.5, config.JptStatusWarning},
+		{0.99, config.JptStatusWarning},
+		{1, config.JptStatusSuspended},
+		{3.5, config.JptStatusSuspended},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, policy.Status(c.score), c.score)
+	}
+}
+
+func TestJptScorePolicyScore(t *testing.T) {
+	policy := defaultJptScorePolicy()
+	cases := []struct {
+		violation int64
+		truck     int64
+		want      float64
+	}{
+		{0, 0, 0},
+		{3, 0, 3},
+		{1, 2, 0.5},
+		{1, 3, 0.33},
+		{2, 3, 0.67},
+		{5, 5, 1},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, policy.Score(c.violation, c.truck), c)
+	}
+
+	// score tepat di ambang batas menghasilkan status ambang tersebut
+	assert.Equal(t, config.JptStatusWarning, policy.Status(policy.Score(1, 2)))
+	assert.Equal(t, config.JptStatusNormal, policy.Status(policy.Score(1, 3)))
+	assert.Equal(t, config.JptStatusSuspended, policy.Status(policy.Score(5, 5)))
+}
+
+func TestLoadJptScorePolicy(t *testing.T) {
+	defer func() { jptScorePolicy = defaultJptScorePolicy() }()
+	keys := []string{envJptScoreWindowDay, envJptWarningScore, envJptSuspendScore}
+	setEnv := func(values ...string) {
+		for i, key := range keys {
+			_ = os.Setenv(key, values[i])
+		}
+	}
+	defer setEnv("", "", "")
+
+	setEnv("30", "0.2", "0.8")
+	assert.Nil(t, LoadJptScorePolicy())
+	assert.Equal(t, JptScorePolicy{WindowDay: 30, WarningScore: 0.2, SuspendScore: 0.8}, jptScorePolicy)
+
+	for _, values := range [][]string{
+		{"0", "", ""},
+		{"abc", "", ""},
+		{"", "-1", ""},
+		{"", "", "NaN"},
+		{"", "2", "1"},
+	} {
+		setEnv(values...)
+		assert.NotNil(t, LoadJptScorePolicy(), values)
+	}
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"tilank/config"
+	"tilank/dto"
+	"tilank/utils/rest_err"
+	"time"
+	"unicode"
+)
+
+const (
+	envPasswordMinLength    = "PASSWORD_MIN_LENGTH"
+	envPasswordMinClasses   = "PASSWORD_MIN_CLASSES"
+	envPasswordHistory      = "PASSWORD_HISTORY"
+	envPasswordMaxAgeDay    = "PASSWORD_MAX_AGE_DAYS"
+	envPasswordBreachedFile = "PASSWORD_BREACHED_FILE"
+
+	passwordMaxLength = 64
+	// temporaryPasswordLength panjang password sementara hasil reset oleh admin
+	temporaryPasswordLength = 12
+)
+
+// PasswordPolicy aturan password yang berlaku untuk seluruh user.
+// MinClasses jumlah minimal jenis karakter (huruf besar, huruf kecil, angka, simbol),
+// History jumlah password terakhir yang tidak boleh digunakan ulang,
+// MaxAgeDay umur maksimal password sebelum wajib diganti, 0 berarti tidak kadaluarsa
+type PasswordPolicy struct {
+	MinLength  int
+	MinClasses int
+	History    int
+	MaxAgeDay  int
+	breached   map[string]bool
+}
+
+var passwordPolicy = defaultPasswordPolicy()
+
+func defaultPasswordPolicy() PasswordPolicy {
+	policy := PasswordPolicy{
+		MinLength:  8,
+		MinClasses: 3,
+		History:    5,
+		MaxAgeDay:  0,
+		breached:   map[string]bool{},
+	}
+	for _, p := range config.GetBreachedPasswords() {
+		policy.breached[strings.ToLower(p)] = true
+	}
+	return policy
+}
+
+// LoadPasswordPolicy memuat aturan password dari environment
+func LoadPasswordPolicy() error {
+	policy := defaultPasswordPolicy()
+
+	var err error
+	if policy.MinLength, err = config.EnvInt(envPasswordMinLength, policy.MinLength, 6, passwordMaxLength); err != nil {
+		return err
+	}
+	if policy.MinClasses, err = config.EnvInt(envPasswordMinClasses, policy.MinClasses, 1, 4); err != nil {
+		return err
+	}
+	if policy.History, err = config.EnvInt(envPasswordHistory, policy.History, 0, 24); err != nil {
+		return err
+	}
+	if policy.MaxAgeDay, err = config.EnvInt(envPasswordMaxAgeDay, policy.MaxAgeDay, 0, 3650); err != nil {
+		return err
+	}
+
+	if path := config.EnvString(envPasswordBreachedFile); path != "" {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("gagal membaca %s: %w", envPasswordBreachedFile, err)
+		}
+		for _, p := range strings.Fields(string(content)) {
+			policy.breached[strings.ToLower(p)] = true
+		}
+	}
+
+	passwordPolicy = policy
+	return nil
+}
+
+// Validate memastikan password memenuhi panjang, kompleksitas dan tidak termasuk daftar password bocor
+func (p PasswordPolicy) Validate(password string) resterr.APIError {
+	var problems []string
+
+	length := len([]rune(password))
+	if length < p.MinLength {
+		problems = append(problems, fmt.Sprintf("minimal %d karakter", p.MinLength))
+	}
+	if length > passwordMaxLength {
+		problems = append(problems, fmt.Sprintf("maksimal %d karakter", passwordMaxLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasSymbol = true
+		}
+	}
+	classes := 0
+	for _, ok := range []bool{hasUpper, hasLower, hasDigit, hasSymbol} {
+		if ok {
+			classes++
+		}
+	}
+	if classes < p.MinClasses {
+		problems = append(problems, fmt.Sprintf("mengandung minimal %d dari huruf besar, huruf kecil, angka dan simbol", p.MinClasses))
+	}
+
+	if len(problems) != 0 {
+		return resterr.NewBadRequestError("Password harus " + strings.Join(problems, ", "))
+	}
+
+	if p.breached[strings.ToLower(password)] {
+		return resterr.NewBadRequestError("Password terlalu umum dan pernah bocor, gunakan password lain")
+	}
+
+	return nil
+}
+
+// IsExpired return true jika password sudah melewati umur maksimal.
+// user lama yang belum memiliki password_changed_at menggunakan timestamp user
+func (p PasswordPolicy) IsExpired(user *dto.User, now int64) bool {
+	if p.MaxAgeDay == 0 {
+		return false
+	}
+	changedAt := user.PasswordChangedAt
+	if changedAt == 0 {
+		changedAt = user.Timestamp
+	}
+	return now-changedAt > int64(p.MaxAgeDay)*24*60*60
+}
+
+// generateTemporaryPassword membuat password acak yang memenuhi seluruh jenis karakter
+func generateTemporaryPassword() (string, error) {
+	charsets := []string{
+		"ABCDEFGHJKLMNPQRSTUVWXYZ",
+		"abcdefghijkmnopqrstuvwxyz",
+		"23456789",
+		"!@#$%*?",
+	}
+	all := strings.Join(charsets, "")
+
+	pick := func(chars string) (byte, error) {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+		if err != nil {
+			return 0, err
+		}
+		return chars[n.Int64()], nil
+	}
+
+	result := make([]byte, temporaryPasswordLength)
+	for i := range result {
+		chars := all
+		if i < len(charsets) {
+			chars = charsets[i]
+		}
+		c, err := pick(chars)
+		if err != nil {
+			return "", err
+		}
+		result[i] = c
+	}
+
+	// acak posisi agar karakter wajib tidak selalu berada di depan
+	for i := len(result) - 1; i > 0; i-- {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		j := n.Int64()
+		result[i], result[j] = result[j], result[i]
+	}
+
+	return string(result), nil
+}
+
+// passwordHistoryLimit jumlah password terakhir yang ditolak, password saat ini selalu ditolak
+func passwordHistoryLimit() int {
+	if passwordPolicy.History < 1 {
+		return 1
+	}
+	return passwordPolicy.History
+}
+
+// previousPasswordHashes hash password saat ini beserta riwayat yang tidak boleh digunakan ulang
+func previousPasswordHashes(user *dto.User) []string {
+	limit := passwordHistoryLimit()
+	previous := append([]string{user.HashPw}, user.PasswordHistory...)
+	if len(previous) > limit {
+		previous = previous[:limit]
+	}
+	return previous
+}
+
+// checkNewPassword memvalidasi password baru terhadap policy dan riwayat password user tanpa menyimpannya.
+// mustChange true digunakan untuk password sementara yang wajib diganti pada login berikutnya
+func (u *userService) checkNewPassword(user *dto.User, newPassword string, mustChange bool) resterr.APIError {
+	if !mustChange {
+		if apiErr := passwordPolicy.Validate(newPassword); apiErr != nil {
+			return apiErr
+		}
+	}
+
+	limit := passwordHistoryLimit()
+	for _, hash := range previousPasswordHashes(user) {
+		if hash != "" && u.crypto.IsPWAndHashPWMatch(newPassword, hash) {
+			if limit == 1 {
+				return resterr.NewBadRequestError("Password tidak boleh sama dengan sebelumnya")
+			}
+			return resterr.NewBadRequestError(fmt.Sprintf("Password tidak boleh sama dengan %d password terakhir", limit))
+		}
+	}
+	return nil
+}
+
+// setPassword memvalidasi password baru terhadap policy dan riwayat password user lalu menyimpannya
+func (u *userService) setPassword(user *dto.User, newPassword string, mustChange bool) resterr.APIError {
+	if apiErr := u.checkNewPassword(user, newPassword, mustChange); apiErr != nil {
+		return apiErr
+	}
+	return u.storePassword(user, newPassword, mustChange)
+}
+
+// storePassword menyimpan password baru yang sudah divalidasi checkNewPassword
+func (u *userService) storePassword(user *dto.User, newPassword string, mustChange bool) resterr.APIError {
+	newPasswordHash, apiErr := u.crypto.GenerateHash(newPassword)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	// riwayat menyimpan hash sebelumnya, hash aktif disimpan di hash_pw
+	history := previousPasswordHashes(user)
+	if limit := passwordHistoryLimit(); len(history) > limit-1 {
+		history = history[:limit-1]
+	}
+
+	return u.dao.ChangePassword(dto.UserPasswordChange{
+		ID:         user.ID,
+		HashPw:     newPasswordHash,
+		History:    history,
+		MustChange: mustChange,
+		ChangedAt:  time.Now().Unix(),
+	})
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tilank/dto"
+)
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := defaultPasswordPolicy()
+
+	tests := []struct {
+		name     string
+		password string
+		valid    bool
+	}{
+		{"memenuhi seluruh aturan", "Tilank#2024", true},
+		{"tepat panjang minimal", "Abcdef1!", true},
+		{"tiga jenis karakter cukup", "Abcdefg12", true},
+		{"terlalu pendek", "Ab1!", false},
+		{"terlalu panjang", "Aa1!" + strings.Repeat("a", passwordMaxLength), false},
+		{"hanya dua jenis karakter", "abcdefgh12", false},
+		{"password bocor tidak peka huruf besar", "P@ssw0rd", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			apiErr := policy.Validate(tc.password)
+			if tc.valid {
+				assert.Nil(t, apiErr)
+				return
+			}
+			if assert.NotNil(t, apiErr) {
+				assert.Equal(t, http.StatusBadRequest, apiErr.Status())
+			}
+		})
+	}
+}
+
+func TestPasswordPolicy_IsExpired(t *testing.T) {
+	const day = int64(24 * 60 * 60)
+	now := int64(1700000000)
+
+	tests := []struct {
+		name      string
+		maxAgeDay int
+		user      dto.User
+		expired   bool
+	}{
+		{"tanpa umur maksimal", 0, dto.User{PasswordChangedAt: now - 1000*day}, false},
+		{"belum melewati umur maksimal", 90, dto.User{PasswordChangedAt: now - 89*day}, false},
+		{"tepat umur maksimal", 90, dto.User{PasswordChangedAt: now - 90*day}, false},
+		{"melewati umur maksimal", 90, dto.User{PasswordChangedAt: now - 90*day - 1}, true},
+		{"user lama menggunakan timestamp", 90, dto.User{Timestamp: now - 91*day}, true},
+		{"password_changed_at diutamakan", 90, dto.User{Timestamp: now - 91*day, PasswordChangedAt: now - day}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := defaultPasswordPolicy()
+			policy.MaxAgeDay = tc.maxAgeDay
+			user := tc.user
+			assert.Equal(t, tc.expired, policy.IsExpired(&user, now))
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"tilank/config"
+	"tilank/dao/policydao"
+	"tilank/dto"
+	"tilank/utils/rest_err"
+	"tilank/utils/sfunc"
+	"time"
+)
+
+func NewPolicyService(policyDao policydao.PolicyDaoAssumer, audit *AuditService) *PolicyService {
+	return &PolicyService{
+		dao:    policyDao,
+		audit:  audit,
+		policy: config.GetDefaultPolicy(),
+	}
+}
+
+// PolicyService menyimpan matriks role-permission di memory agar middleware tidak memerlukan
+// query database pada setiap request. data disinkronkan ulang oleh scheduler
+type PolicyService struct {
+	dao   policydao.PolicyDaoAssumer
+	audit *AuditService
+
+	mu     sync.RWMutex
+	policy map[string][]string // role -> permissions
+}
+
+// HasAnyPermission return true jika salah satu role memiliki salah satu permission yang diminta
+func (p *PolicyService) HasAnyPermission(roles []string, permissions []string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, role := range roles {
+		for _, permission := range permissions {
+			if sfunc.InSlice(permission, p.policy[role]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mergeDefaultPermission menambahkan permission default role yang belum dikenal saat policy disimpan,
+// permission yang sengaja dicabut admin tidak ditambahkan kembali
+func mergeDefaultPermission(stored dto.RolePermission, defaultPolicy map[string][]string) []string {
+	known := stored.KnownPermissions
+	if len(known) == 0 {
+		known = config.GetPermissionsInitial()
+	}
+
+	permissions := append([]string{}, stored.Permissions...)
+	for _, permission := range defaultPolicy[stored.Role] {
+		if !sfunc.InSlice(permission, known) && !sfunc.InSlice(permission, permissions) {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions
+}
+
+// Reload memuat ulang policy dari database, role tanpa policy tersimpan menggunakan policy default
+// dan policy tersimpan mendapatkan permission default yang ditambahkan setelah policy disimpan
+func (p *PolicyService) Reload() resterr.APIError {
+	stored, err := p.dao.FindRolePermission()
+	if err != nil {
+		return err
+	}
+
+	policy := config.GetDefaultPolicy()
+	for _, rolePermission := range stored {
+		policy[rolePermission.Role] = mergeDefaultPermission(rolePermission, config.GetDefaultPolicy())
+	}
+
+	p.mu.Lock()
+	p.policy = policy
+	p.mu.Unlock()
+
+	return nil
+}
+
+// GetPolicy mengembalikan matriks role-permission yang berlaku, role tanpa policy tersimpan
+// ditampilkan dengan policy default
+func (p *PolicyService) GetPolicy() (*dto.PolicyResponse, resterr.APIError) {
+	stored, err := p.dao.FindRolePermission()
+	if err != nil {
+		return nil, err
+	}
+
+	storedMap := map[string]dto.RolePermission{}
+	for _, rolePermission := range stored {
+		rolePermission.Permissions = mergeDefaultPermission(rolePermission, config.GetDefaultPolicy())
+		storedMap[rolePermission.Role] = rolePermission
+	}
+	for role, permissions := range config.GetDefaultPolicy() {
+		if _, ok := storedMap[role]; !ok {
+			storedMap[role] = dto.RolePermission{Role: role, Permissions: permissions}
+		}
+	}
+
+	policy := make([]dto.RolePermission, 0, len(storedMap))
+	for _, rolePermission := range storedMap {
+		policy = append(policy, rolePermission)
+	}
+	sort.Slice(policy, func(i, j int) bool {
+		return policy[i].Role < policy[j].Role
+	})
+
+	return &dto.PolicyResponse{
+		Policy:               policy,
+		PermissionsAvailable: config.GetPermissionsAvailable(),
+	}, nil
+}
+
+// EditRolePermission mengganti seluruh permission sebuah role.
+// role ADMIN tidak dapat kehilangan user:admin agar admin tidak mengunci dirinya sendiri
+func (p *PolicyService) EditRolePermission(actor string, role string, input dto.RolePermissionEditRequest) (*dto.RolePermission, resterr.APIError) {
+	role = strings.ToUpper(role)
+	if !sfunc.InSlice(role, config.GetRolesAvailable()) {
+		return nil, resterr.NewBadRequestError(fmt.Sprintf("role tidak tersedia. gunakan %s", config.GetRolesAvailable()))
+	}
+	if role == config.RoleAdmin && !sfunc.InSlice(config.PermUserAdmin, input.Permissions) {
+		return nil, resterr.NewBadRequestError(fmt.Sprintf("role %s wajib memiliki permission %s", config.RoleAdmin, config.PermUserAdmin))
+	}
+
+	result, err := p.dao.UpsertRolePermission(dto.RolePermission{
+		Role:             role,
+		Permissions:      sfunc.Unique(input.Permissions),
+		KnownPermissions: config.GetPermissionsAvailable(),
+		UpdatedAt:        time.Now().Unix(),
+		UpdatedBy:        actor,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.policy[result.Role] = result.Permissions
+	p.mu.Unlock()
+
+	p.audit.Record(dto.AuditLog{
+		Action:  AuditPolicyEdit,
+		Actor:   actor,
+		Target:  role,
+		Outcome: AuditSuccess,
+		Detail:  strings.Join(result.Permissions, ","),
+	})
+
+	return result, nil
+}
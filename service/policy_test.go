@@ -0,0 +1,75 @@
+package service
+
+import (
+	"testing"
+	"tilank/config"
+	"tilank/dao/policydao"
+	"tilank/dto"
+	"tilank/utils/rest_err"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePolicyDao struct {
+	policydao.PolicyDaoAssumer
+	stored   []dto.RolePermission
+	upserted *dto.RolePermission
+}
+
+func (f *fakePolicyDao) FindRolePermission() ([]dto.RolePermission, resterr.APIError) {
+	return f.stored, nil
+}
+
+func (f *fakePolicyDao) UpsertRolePermission(input dto.RolePermission) (*dto.RolePermission, resterr.APIError) {
+	f.upserted = &input
+	return &input, nil
+}
+
+func TestMergeDefaultPermission(t *testing.T) {
+	defaultPolicy := map[string][]string{
+		config.RoleHSSE: {config.PermViolationApprove, config.PermTruckWrite, config.PermTruckBlock, config.PermDriverWrite},
+	}
+	cases := []struct {
+		name   string
+		stored dto.RolePermission
+		want   []string
+	}{
+		{"policy lama mendapat permission baru",
+			dto.RolePermission{Role: config.RoleHSSE, Permissions: []string{config.PermViolationApprove}},
+			[]string{config.PermViolationApprove, config.PermTruckBlock, config.PermDriverWrite}},
+		{"permission lama yang dicabut tidak ditambahkan",
+			dto.RolePermission{Role: config.RoleHSSE, Permissions: []string{}},
+			[]string{config.PermTruckBlock, config.PermDriverWrite}},
+		{"permission baru yang dicabut setelah dikenal tidak ditambahkan",
+			dto.RolePermission{Role: config.RoleHSSE, Permissions: []string{config.PermTruckWrite}, KnownPermissions: config.GetPermissionsAvailable()},
+			[]string{config.PermTruckWrite}},
+		{"role tanpa permission default",
+			dto.RolePermission{Role: config.RoleSEC, Permissions: []string{config.PermViolationReport}},
+			[]string{config.PermViolationReport}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, mergeDefaultPermission(c.stored, defaultPolicy))
+		})
+	}
+}
+
+func TestPolicyReloadMergeDefault(t *testing.T) {
+	policyDao := &fakePolicyDao{stored: []dto.RolePermission{
+		{Role: config.RoleHSSE, Permissions: []string{config.PermViolationApprove, config.PermTruckWrite}},
+	}}
+	p := NewPolicyService(policyDao, NewAuditService(&fakeAuditDao{}))
+
+	assert.Nil(t, p.Reload())
+	assert.True(t, p.HasAnyPermission([]string{config.RoleHSSE}, []string{config.PermTruckBlock}))
+	assert.True(t, p.HasAnyPermission([]string{config.RoleHSSE}, []string{config.PermViolationRetention}))
+	assert.False(t, p.HasAnyPermission([]string{config.RoleHSSE}, []string{config.PermRulesWrite}))
+
+	// policy yang disimpan admin mencatat seluruh permission yang sudah dikenal
+	_, apiErr := p.EditRolePermission("admin", config.RoleHSSE, dto.RolePermissionEditRequest{
+		Permissions: []string{config.PermViolationApprove},
+	})
+	assert.Nil(t, apiErr)
+	assert.Equal(t, config.GetPermissionsAvailable(), policyDao.upserted.KnownPermissions)
+	assert.False(t, p.HasAnyPermission([]string{config.RoleHSSE}, []string{config.PermTruckBlock}))
+}
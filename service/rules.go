@@ -2,6 +2,8 @@ package service
 
 import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"tilank/config"
 	"tilank/dao/rulesdao"
 	"tilank/dto"
 	"tilank/utils/mjwt"
@@ -21,9 +23,10 @@ type RulesService struct {
 
 func (j *RulesService) InsertRules(user mjwt.CustomClaim, input dto.RulesRequest) (*string, resterr.APIError) {
 	idGenerated := primitive.NewObjectID()
+	subject := rulesSubject(input.Subject)
 
 	// DB 2
-	rules, _ := j.daoC.GetRulesByScore(input.Score, user.Branch)
+	rules, _ := j.daoC.GetRulesByScore(input.Score, user.Branch, subject)
 	if rules != nil {
 		return nil, resterr.NewBadRequestError("Score tersebut sudah ada, silahkan lakukan perubahan di menu edit!")
 	}
@@ -36,6 +39,7 @@ func (j *RulesService) InsertRules(user mjwt.CustomClaim, input dto.RulesRequest
 		UpdatedBy:   user.Name,
 		UpdatedByID: user.Identity,
 		Branch:      user.Branch,
+		Subject:     subject,
 		Score:       input.Score,
 		BlockTime:   input.BlockTime,
 		Description: input.Description,
@@ -64,6 +68,7 @@ func (j *RulesService) EditRules(user mjwt.CustomClaim, rulesID string, input dt
 		UpdatedAt:       timeNow,
 		UpdatedBy:       user.Name,
 		UpdatedByID:     user.Identity,
+		Subject:         rulesSubject(input.Subject),
 		Score:           input.Score,
 		BlockTime:       input.BlockTime,
 		Description:     input.Description,
@@ -118,3 +123,12 @@ func (j *RulesService) FindRules() ([]dto.Rules, resterr.APIError) {
 
 	return rulesList, nil
 }
+
+// rulesSubject subject kosong dianggap rules truck
+func rulesSubject(subject string) string {
+	subject = strings.ToUpper(subject)
+	if subject == "" {
+		return config.RulesSubjectTruck
+	}
+	return subject
+}
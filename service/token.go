@@ -0,0 +1,229 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"tilank/dao/tokendao"
+	"tilank/dto"
+	"tilank/utils/logger"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+	"time"
+)
+
+const (
+	// refreshTokenMinute masa berlaku refresh token dalam menit (90 hari)
+	refreshTokenMinute = 60 * 24 * 90
+	// maxTokenLifetime masa berlaku token terpanjang dalam detik,
+	// pencabutan user yang lebih tua dari ini sudah tidak berpengaruh
+	maxTokenLifetime = refreshTokenMinute * 60
+)
+
+func NewTokenService(tokenDao tokendao.TokenDaoAssumer) *TokenService {
+	return &TokenService{
+		dao:             tokenDao,
+		revokedTokens:   map[string]int64{},
+		revokedUsers:    map[string]int64{},
+		revokedFamilies: map[string]int64{},
+	}
+}
+
+// TokenService menyimpan daftar pencabutan token di memory agar pengecekan di middleware
+// tidak memerlukan query database pada setiap request. data disinkronkan ulang oleh scheduler
+type TokenService struct {
+	dao tokendao.TokenDaoAssumer
+
+	mu              sync.RWMutex
+	revokedTokens   map[string]int64 // jti -> exp
+	revokedUsers    map[string]int64 // user id -> not before
+	revokedFamilies map[string]int64 // family id -> exp
+}
+
+// IsRevoked return true jika jti atau family token dicabut, atau token diterbitkan sebelum pencabutan user
+func (t *TokenService) IsRevoked(claims mjwt.CustomClaim) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if claims.TokenID != "" {
+		if _, ok := t.revokedTokens[claims.TokenID]; ok {
+			return true
+		}
+	}
+
+	if claims.FamilyID != "" {
+		if _, ok := t.revokedFamilies[claims.FamilyID]; ok {
+			return true
+		}
+	}
+
+	if notBefore, ok := t.revokedUsers[strings.ToUpper(claims.Identity)]; ok {
+		if claims.IssuedAt < notBefore {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RevokeToken mencabut satu token berdasarkan jti
+func (t *TokenService) RevokeToken(claims mjwt.CustomClaim) resterr.APIError {
+	if claims.TokenID == "" {
+		// token lama tanpa jti hanya dapat dicabut melalui RevokeUser
+		return nil
+	}
+
+	err := t.dao.RevokeToken(dto.RevokedToken{
+		ID:        claims.TokenID,
+		Identity:  claims.Identity,
+		RevokedAt: time.Now().Unix(),
+		Exp:       claims.Exp,
+	})
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.revokedTokens[claims.TokenID] = claims.Exp
+	t.mu.Unlock()
+
+	return nil
+}
+
+// RevokeUser mencabut seluruh token milik user yang diterbitkan sebelum saat ini
+func (t *TokenService) RevokeUser(userID string) resterr.APIError {
+	userID = strings.ToUpper(userID)
+	notBefore := time.Now().Unix()
+
+	err := t.dao.RevokeUser(dto.RevokedUser{
+		ID:        userID,
+		NotBefore: notBefore,
+	})
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.revokedUsers[userID] = notBefore
+	t.mu.Unlock()
+
+	return nil
+}
+
+// Reload memuat ulang daftar pencabutan dari database
+func (t *TokenService) Reload() resterr.APIError {
+	nowUnix := time.Now().Unix()
+
+	tokenList, err := t.dao.FindRevokedToken(nowUnix)
+	if err != nil {
+		return err
+	}
+	userList, err := t.dao.FindRevokedUser(nowUnix - maxTokenLifetime)
+	if err != nil {
+		return err
+	}
+	familyList, err := t.dao.FindRevokedFamily(nowUnix)
+	if err != nil {
+		return err
+	}
+
+	revokedTokens := make(map[string]int64, len(tokenList))
+	for _, token := range tokenList {
+		revokedTokens[token.ID] = token.Exp
+	}
+	revokedUsers := make(map[string]int64, len(userList))
+	for _, user := range userList {
+		revokedUsers[user.ID] = user.NotBefore
+	}
+
+	revokedFamilies := make(map[string]int64, len(familyList))
+	for _, family := range familyList {
+		revokedFamilies[family.ID] = family.Exp
+	}
+
+	t.mu.Lock()
+	t.revokedTokens = revokedTokens
+	t.revokedUsers = revokedUsers
+	t.revokedFamilies = revokedFamilies
+	t.mu.Unlock()
+
+	return nil
+}
+
+// CleanupExpired menghapus data pencabutan untuk token yang pasti sudah kadaluarsa
+func (t *TokenService) CleanupExpired() (int64, resterr.APIError) {
+	nowUnix := time.Now().Unix()
+	return t.dao.DeleteExpired(nowUnix, nowUnix-maxTokenLifetime)
+}
+
+// StartFamily membuat token family baru pada saat login dengan refreshTokenID sebagai token aktif
+func (t *TokenService) StartFamily(userID string, refreshTokenID string) (string, resterr.APIError) {
+	familyID, errID := mjwt.GenerateTokenID()
+	if errID != nil {
+		logger.Error("gagal membuat token family id", errID)
+		return "", resterr.NewInternalServerError("gagal membuat sesi login", errID)
+	}
+
+	timeNow := time.Now()
+	err := t.dao.InsertFamily(dto.TokenFamily{
+		ID:             familyID,
+		Identity:       userID,
+		CurrentTokenID: refreshTokenID,
+		CreatedAt:      timeNow.Unix(),
+		UpdatedAt:      timeNow.Unix(),
+		Exp:            timeNow.Add(time.Minute * refreshTokenMinute).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return familyID, nil
+}
+
+// RotateFamily menjadikan newRefreshTokenID sebagai satu-satunya refresh token yang berlaku pada family.
+// jika refresh token yang dikirim sudah pernah dirotasi (reuse), seluruh family dicabut
+// karena kemungkinan token telah dicuri
+func (t *TokenService) RotateFamily(claims mjwt.CustomClaim, newRefreshTokenID string) resterr.APIError {
+	exp := time.Now().Add(time.Minute * refreshTokenMinute).Unix()
+
+	_, err := t.dao.RotateFamily(claims.FamilyID, claims.TokenID, newRefreshTokenID, exp)
+	if err == nil {
+		return nil
+	}
+	if err.Status() != http.StatusNotFound {
+		return err
+	}
+
+	family, errGet := t.dao.GetFamilyByID(claims.FamilyID)
+	if errGet != nil {
+		return resterr.NewUnauthorizedError("Sesi login tidak ditemukan, silahkan login ulang")
+	}
+
+	if !family.Revoked {
+		logger.Info(fmt.Sprintf("u: %s | refresh | refresh token family %s digunakan ulang, family dicabut", claims.Identity, claims.FamilyID))
+		if errRevoke := t.RevokeFamily(claims.FamilyID); errRevoke != nil {
+			return errRevoke
+		}
+	}
+
+	return resterr.NewUnauthorizedError("Refresh token sudah tidak berlaku, silahkan login ulang")
+}
+
+// RevokeFamily mencabut seluruh access dan refresh token yang berasal dari satu kali login
+func (t *TokenService) RevokeFamily(familyID string) resterr.APIError {
+	if familyID == "" {
+		return nil
+	}
+
+	family, err := t.dao.RevokeFamily(familyID)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.revokedFamilies[family.ID] = family.Exp
+	t.mu.Unlock()
+
+	return nil
+}
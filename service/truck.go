@@ -1,22 +1,127 @@
 package service
 
 import (
+	"fmt"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"tilank/clients/fcm"
+	"tilank/config"
+	"tilank/dao/companydao"
+	"tilank/dao/jptdao"
 	"tilank/dao/truckdao"
+	"tilank/dao/userdao"
+	"tilank/dao/violationdao"
 	"tilank/dto"
+	"tilank/stream"
 	"tilank/utils/mjwt"
 	"tilank/utils/rest_err"
+	"tilank/utils/sfunc"
 	"time"
 )
 
-func NewTruckService(truckDao truckdao.TruckDaoAssumer) *TruckService {
+// TruckServiceAssumer dipenuhi oleh *TruckService, digunakan handler agar dapat diganti saat pengujian
+type TruckServiceAssumer interface {
+	InsertTruck(user mjwt.CustomClaim, input dto.TruckRequest) (*string, resterr.APIError)
+	EditTruck(user mjwt.CustomClaim, truckID string, input dto.TruckEditRequest) (*dto.Truck, resterr.APIError)
+	DeleteTruck(user mjwt.CustomClaim, id string) resterr.APIError
+	ActivateTruck(user mjwt.CustomClaim, id string) resterr.APIError
+	GetTruckByID(truckID string, branchIfSpecific string) (*dto.Truck, resterr.APIError)
+	GetTimeline(truck dto.Truck) (*dto.TruckTimelineResponse, resterr.APIError)
+	BlockTruck(user mjwt.CustomClaim, truckID string, input dto.TruckBlockRequest) (*dto.Truck, resterr.APIError)
+	UnblockTruck(user mjwt.CustomClaim, truckID string, input dto.TruckUnblockRequest) (*dto.Truck, resterr.APIError)
+	GetTruckByNoLambung(truckID string, branch string) (*dto.Truck, resterr.APIError)
+	FindTruck(filter dto.FilterTruck) (dto.TruckResponseMinList, resterr.APIError)
+}
+
+func NewTruckService(truckDao truckdao.TruckDaoAssumer,
+	companyDao companydao.CompanyDaoAssumer,
+	jptDao jptdao.JptDaoAssumer,
+	violationDao violationdao.ViolationDaoAssumer,
+	userDao userdao.UserDaoAssumer,
+	fcmClient fcm.ClientAssumer,
+	auditService *AuditService,
+	webhookService *WebhookService,
+	eventHub stream.HubAssumer) *TruckService {
 	return &TruckService{
-		daoC: truckDao,
+		daoC:    truckDao,
+		daoCo:   companyDao,
+		daoJ:    jptDao,
+		vDao:    violationDao,
+		userDao: userDao,
+		fcm:     fcmClient,
+		audit:   auditService,
+		webhook: webhookService,
+		hub:     eventHub,
 	}
 }
 
 type TruckService struct {
-	daoC truckdao.TruckDaoAssumer
+	daoC    truckdao.TruckDaoAssumer
+	daoCo   companydao.CompanyDaoAssumer
+	daoJ    jptdao.JptDaoAssumer
+	vDao    violationdao.ViolationDaoAssumer
+	userDao userdao.UserDaoAssumer
+	fcm     fcm.ClientAssumer
+	audit   *AuditService
+	webhook *WebhookService
+	hub     stream.HubAssumer
+}
+
+// getOwner mendapatkan perusahaan pemilik truck yang aktif pada cabang user.
+// jika ownerID kosong owner teks bebas dihubungkan ke perusahaan dengan nama yang sama,
+// owner yang belum terdaftar tetap disimpan tanpa owner_id dan dihubungkan melalui migrasi owner
+func (j *TruckService) getOwner(ownerID string, owner string, branch string) (*dto.Company, resterr.APIError) {
+	if ownerID == "" {
+		company, _ := j.daoCo.GetCompanyByNormalizedName(sfunc.NormalizeCompanyName(owner), branch)
+		if company != nil && !company.Deleted {
+			return company, nil
+		}
+		return &dto.Company{Name: strings.ToUpper(strings.TrimSpace(owner))}, nil
+	}
+
+	oid, errT := primitive.ObjectIDFromHex(ownerID)
+	if errT != nil {
+		return nil, resterr.NewBadRequestError("owner_id yang dimasukkan salah")
+	}
+	company, err := j.daoCo.GetCompanyByID(oid, branch)
+	if err != nil {
+		return nil, err
+	}
+	if company.Deleted {
+		return nil, resterr.NewBadRequestError("Perusahaan pemilik sudah dinonaktifkan")
+	}
+	return company, nil
+}
+
+// companyID id perusahaan pemilik, kosong untuk owner teks bebas yang belum terhubung
+func companyID(company *dto.Company) string {
+	if company.ID.IsZero() {
+		return ""
+	}
+	return company.ID.Hex()
+}
+
+// getJpt mendapatkan JPT yang aktif pada cabang user, jptID kosong berarti truck tidak terhubung ke JPT.
+// JPT yang disuspend hanya ditolak jika baru dihubungkan, currentJptID berisi JPT truck sebelum diubah
+func (j *TruckService) getJpt(jptID string, currentJptID string, branch string) (*dto.Jpt, resterr.APIError) {
+	if jptID == "" {
+		return &dto.Jpt{}, nil
+	}
+	oid, errT := primitive.ObjectIDFromHex(jptID)
+	if errT != nil {
+		return nil, resterr.NewBadRequestError("jpt_id yang dimasukkan salah")
+	}
+	jpt, err := j.daoJ.GetJptByID(oid, branch)
+	if err != nil {
+		return nil, err
+	}
+	if jpt.Deleted {
+		return nil, resterr.NewBadRequestError("JPT sudah dinonaktifkan")
+	}
+	if jpt.Status == config.JptStatusSuspended && jptID != currentJptID {
+		return nil, resterr.NewBadRequestError(fmt.Sprintf("JPT %s sedang disuspend dan tidak dapat dihubungkan ke truck", jpt.Name))
+	}
+	return jpt, nil
 }
 
 func (j *TruckService) InsertTruck(user mjwt.CustomClaim, input dto.TruckRequest) (*string, resterr.APIError) {
@@ -27,6 +132,15 @@ func (j *TruckService) InsertTruck(user mjwt.CustomClaim, input dto.TruckRequest
 		return nil, resterr.NewBadRequestError("Nomor lambung tidak tersedia! ")
 	}
 
+	company, err := j.getOwner(input.OwnerID, input.Owner, user.Branch)
+	if err != nil {
+		return nil, err
+	}
+	jpt, err := j.getJpt(input.JptID, "", user.Branch)
+	if err != nil {
+		return nil, err
+	}
+
 	// Filling data
 	timeNow := time.Now().Unix()
 	data := dto.Truck{
@@ -41,7 +155,10 @@ func (j *TruckService) InsertTruck(user mjwt.CustomClaim, input dto.TruckRequest
 		NoIdentity:     input.NoIdentity,
 		NoPol:          input.NoPol,
 		Mark:           input.Mark,
-		Owner:          input.Owner,
+		OwnerID:        companyID(company),
+		Owner:          company.Name,
+		JptID:          input.JptID,
+		JptName:        jpt.Name,
 		Email:          input.Email,
 		Hp:             input.Hp,
 		Deleted:        false,
@@ -58,6 +175,16 @@ func (j *TruckService) InsertTruck(user mjwt.CustomClaim, input dto.TruckRequest
 		return nil, resterr.NewBadRequestError(err.Message())
 	}
 
+	recordTruckHistory(j.daoC, dto.TruckHistory{
+		TruckID:    *insertedID,
+		Branch:     user.Branch,
+		NoIdentity: strings.ReplaceAll(strings.ToUpper(input.NoIdentity), " ", ""),
+		Event:      config.TruckHistoryCreate,
+		Time:       timeNow,
+		Actor:      user.Name,
+		ActorID:    user.Identity,
+	})
+
 	return insertedID, nil
 }
 
@@ -66,6 +193,20 @@ func (j *TruckService) EditTruck(user mjwt.CustomClaim, truckID string, input dt
 	if errT != nil {
 		return nil, resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
 	}
+
+	truckBefore, err := j.daoC.GetTruckByID(oid, user.Branch)
+	if err != nil {
+		return nil, err
+	}
+	company, err := j.getOwner(input.OwnerID, input.Owner, user.Branch)
+	if err != nil {
+		return nil, err
+	}
+	jpt, err := j.getJpt(input.JptID, truckBefore.JptID, user.Branch)
+	if err != nil {
+		return nil, err
+	}
+
 	// Filling data
 	timeNow := time.Now().Unix()
 	data := dto.TruckEdit{
@@ -78,7 +219,10 @@ func (j *TruckService) EditTruck(user mjwt.CustomClaim, truckID string, input dt
 		NoIdentity:      input.NoIdentity,
 		NoPol:           input.NoPol,
 		Mark:            input.Mark,
-		Owner:           input.Owner,
+		OwnerID:         companyID(company),
+		Owner:           company.Name,
+		JptID:           input.JptID,
+		JptName:         jpt.Name,
 		Email:           input.Email,
 		Hp:              input.Hp,
 	}
@@ -89,6 +233,24 @@ func (j *TruckService) EditTruck(user mjwt.CustomClaim, truckID string, input dt
 		return nil, err
 	}
 
+	if detail := truckEditDetail(truckBefore, truckEdited); detail != "" {
+		history := dto.TruckHistory{
+			TruckID:    truckEdited.ID.Hex(),
+			Branch:     truckEdited.Branch,
+			NoIdentity: truckEdited.NoIdentity,
+			Event:      config.TruckHistoryEdit,
+			Time:       timeNow,
+			Actor:      user.Name,
+			ActorID:    user.Identity,
+			Detail:     detail,
+		}
+		// nomor lambung lama disimpan agar pelanggaran sebelum perubahan tetap muncul di timeline
+		if truckBefore.NoIdentity != truckEdited.NoIdentity {
+			history.NoIdentityBefore = truckBefore.NoIdentity
+		}
+		recordTruckHistory(j.daoC, history)
+	}
+
 	return truckEdited, nil
 }
 
@@ -98,7 +260,7 @@ func (j *TruckService) DeleteTruck(user mjwt.CustomClaim, id string) resterr.API
 		return resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
 	}
 	// DB
-	_, err := j.daoC.DeleteTruck(dto.FilterIDBranch{
+	truck, err := j.daoC.DeleteTruck(dto.FilterIDBranch{
 		FilterID:     oid,
 		FilterBranch: user.Branch,
 	}, true)
@@ -106,6 +268,15 @@ func (j *TruckService) DeleteTruck(user mjwt.CustomClaim, id string) resterr.API
 		return err
 	}
 
+	recordTruckHistory(j.daoC, dto.TruckHistory{
+		TruckID:    truck.ID.Hex(),
+		Branch:     truck.Branch,
+		NoIdentity: truck.NoIdentity,
+		Event:      config.TruckHistoryDelete,
+		Actor:      user.Name,
+		ActorID:    user.Identity,
+	})
+
 	return nil
 }
 
@@ -115,7 +286,7 @@ func (j *TruckService) ActivateTruck(user mjwt.CustomClaim, id string) resterr.A
 		return resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
 	}
 	// DB
-	_, err := j.daoC.DeleteTruck(dto.FilterIDBranch{
+	truck, err := j.daoC.DeleteTruck(dto.FilterIDBranch{
 		FilterID:     oid,
 		FilterBranch: user.Branch,
 	}, false)
@@ -123,6 +294,15 @@ func (j *TruckService) ActivateTruck(user mjwt.CustomClaim, id string) resterr.A
 		return err
 	}
 
+	recordTruckHistory(j.daoC, dto.TruckHistory{
+		TruckID:    truck.ID.Hex(),
+		Branch:     truck.Branch,
+		NoIdentity: truck.NoIdentity,
+		Event:      config.TruckHistoryActivate,
+		Actor:      user.Name,
+		ActorID:    user.Identity,
+	})
+
 	return nil
 }
 
@@ -172,11 +352,17 @@ func (j *TruckService) ResetBlockedTruck() (int64, resterr.APIError) {
 
 	// list id truck yang direset
 	var truckIDMustReset []primitive.ObjectID
+	var truckReset dto.TruckResponseMinList
 
 	for _, truck := range truckList {
+		// truck daftar hitam hanya dapat dibuka melalui UnblockTruck
+		if truck.BlockPermanent {
+			continue
+		}
 		if truck.BlockEnd <= nowUnix {
 			// reset status block truck
 			truckIDMustReset = append(truckIDMustReset, truck.ID)
+			truckReset = append(truckReset, truck)
 		}
 	}
 
@@ -186,6 +372,32 @@ func (j *TruckService) ResetBlockedTruck() (int64, resterr.APIError) {
 		if err != nil {
 			return 0, nil
 		}
+
+		for _, truck := range truckReset {
+			recordTruckHistory(j.daoC, dto.TruckHistory{
+				TruckID:     truck.ID.Hex(),
+				Branch:      truck.Branch,
+				NoIdentity:  truck.NoIdentity,
+				Event:       config.TruckHistoryBlockEnd,
+				Time:        nowUnix,
+				Detail:      "masa blokir berakhir",
+				ScoreBefore: truck.Score,
+				ScoreAfter:  truck.Score,
+				BlockStart:  truck.BlockStart,
+				BlockEnd:    truck.BlockEnd,
+			})
+
+			truck.Blocked = false
+			truck.BlockStart = 0
+			truck.BlockEnd = 0
+			truck.BlockReason = ""
+			j.webhook.Emit(config.EventTruckUnblocked, truck.Branch, truck)
+			j.hub.Publish(config.EventTruckUnblocked, truck.Branch, truck)
+			j.notifyBlock(truck,
+				fmt.Sprintf("Blokir truck %s berakhir", truck.NoIdentity),
+				fmt.Sprintf("Masa blokir truck dengan no lambung %s (%s) telah berakhir, truck dapat beroperasi kembali", truck.NoIdentity, truck.NoPol),
+			)
+		}
 	}
 
 	return updated, nil
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"tilank/config"
+	"tilank/dto"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+	"time"
+)
+
+// BlockTruck memblokir truck secara manual selama BlockTime detik atau permanen (daftar hitam).
+// blokir manual menggantikan blokir yang sedang berjalan, kecuali truck sudah masuk daftar hitam
+// atau blokir yang berjalan berakhir lebih lama sebagaimana truckScorePayload
+func (j *TruckService) BlockTruck(user mjwt.CustomClaim, truckID string, input dto.TruckBlockRequest) (*dto.Truck, resterr.APIError) {
+	oid, errT := primitive.ObjectIDFromHex(truckID)
+	if errT != nil {
+		return nil, resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
+	}
+
+	truck, err := j.daoC.GetTruckByID(oid, user.Branch)
+	if err != nil {
+		return nil, err
+	}
+	if truck.Deleted {
+		return nil, resterr.NewBadRequestError("Truck sudah dihapus")
+	}
+	if truck.BlockPermanent {
+		return nil, resterr.NewBadRequestError("Truck sudah masuk daftar hitam, cabut blokir terlebih dahulu")
+	}
+
+	reason := strings.TrimSpace(input.Reason)
+	timeNow := time.Now().Unix()
+	data := dto.TruckBlockEdit{
+		ID:             oid,
+		FilterBranch:   user.Branch,
+		Blocked:        true,
+		BlockStart:     timeNow,
+		BlockPermanent: input.Permanent,
+		BlockReason:    reason,
+	}
+	if !input.Permanent {
+		data.BlockEnd = timeNow + input.BlockTime
+		if truck.Blocked && truck.BlockEnd > data.BlockEnd {
+			return nil, resterr.NewBadRequestError(fmt.Sprintf("Truck sudah diblokir sampai %s, blokir manual tidak dapat memperpendek blokir yang sedang berjalan",
+				time.Unix(truck.BlockEnd, 0).In(blockLocation).Format("02-01-2006 15:04 MST")))
+		}
+	}
+
+	truckBlocked, err := j.daoC.ChangeBlock(data)
+	if err != nil {
+		return nil, err
+	}
+
+	historyEvent, auditAction := config.TruckHistoryBlockStart, AuditTruckBlock
+	detail := fmt.Sprintf("%s cabang %s sampai %s: %s", truckBlocked.NoIdentity, truckBlocked.Branch,
+		time.Unix(truckBlocked.BlockEnd, 0).Format("2006-01-02 15:04"), reason)
+	if input.Permanent {
+		historyEvent, auditAction = config.TruckHistoryBlacklist, AuditTruckBlacklist
+		detail = fmt.Sprintf("%s cabang %s permanen: %s", truckBlocked.NoIdentity, truckBlocked.Branch, reason)
+	}
+
+	recordTruckHistory(j.daoC, dto.TruckHistory{
+		TruckID:     truckBlocked.ID.Hex(),
+		Branch:      truckBlocked.Branch,
+		NoIdentity:  truckBlocked.NoIdentity,
+		Event:       historyEvent,
+		Time:        timeNow,
+		Actor:       user.Name,
+		ActorID:     user.Identity,
+		Detail:      reason,
+		ScoreBefore: truckBlocked.Score,
+		ScoreAfter:  truckBlocked.Score,
+		BlockStart:  truckBlocked.BlockStart,
+		BlockEnd:    truckBlocked.BlockEnd,
+	})
+	j.audit.Record(dto.AuditLog{
+		Action:    auditAction,
+		Actor:     user.Identity,
+		Target:    truckBlocked.ID.Hex(),
+		IP:        input.IP,
+		UserAgent: input.UserAgent,
+		Outcome:   AuditSuccess,
+		Detail:    detail,
+	})
+
+	j.webhook.Emit(config.EventTruckBlocked, truckBlocked.Branch, truckBlocked)
+	j.hub.Publish(config.EventTruckBlocked, truckBlocked.Branch, truckBlocked)
+
+	return truckBlocked, nil
+}
+
+// UnblockTruck mencabut blokir truck sebelum waktunya, termasuk truck daftar hitam
+func (j *TruckService) UnblockTruck(user mjwt.CustomClaim, truckID string, input dto.TruckUnblockRequest) (*dto.Truck, resterr.APIError) {
+	oid, errT := primitive.ObjectIDFromHex(truckID)
+	if errT != nil {
+		return nil, resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
+	}
+
+	truck, err := j.daoC.GetTruckByID(oid, user.Branch)
+	if err != nil {
+		return nil, err
+	}
+	if !truck.Blocked {
+		return nil, resterr.NewBadRequestError("Truck tidak sedang diblokir")
+	}
+
+	reason := strings.TrimSpace(input.Reason)
+	truckUnblocked, err := j.daoC.ChangeBlock(dto.TruckBlockEdit{
+		ID:           oid,
+		FilterBranch: user.Branch,
+		Blocked:      false,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	timeNow := time.Now().Unix()
+	recordTruckHistory(j.daoC, dto.TruckHistory{
+		TruckID:     truckUnblocked.ID.Hex(),
+		Branch:      truckUnblocked.Branch,
+		NoIdentity:  truckUnblocked.NoIdentity,
+		Event:       config.TruckHistoryBlockEnd,
+		Time:        timeNow,
+		Actor:       user.Name,
+		ActorID:     user.Identity,
+		Detail:      reason,
+		ScoreBefore: truck.Score,
+		ScoreAfter:  truckUnblocked.Score,
+		BlockStart:  truck.BlockStart,
+		BlockEnd:    timeNow,
+	})
+	j.audit.Record(dto.AuditLog{
+		Action:    AuditTruckUnblock,
+		Actor:     user.Identity,
+		Target:    truckUnblocked.ID.Hex(),
+		IP:        input.IP,
+		UserAgent: input.UserAgent,
+		Outcome:   AuditSuccess,
+		Detail:    fmt.Sprintf("%s cabang %s: %s", truckUnblocked.NoIdentity, truckUnblocked.Branch, reason),
+	})
+
+	j.webhook.Emit(config.EventTruckUnblocked, truckUnblocked.Branch, truckUnblocked)
+	j.hub.Publish(config.EventTruckUnblocked, truckUnblocked.Branch, truckUnblocked)
+	j.notifyBlock(truckMin(truckUnblocked),
+		fmt.Sprintf("Blokir truck %s dicabut", truckUnblocked.NoIdentity),
+		fmt.Sprintf("Blokir truck dengan no lambung %s (%s) telah dicabut, truck dapat beroperasi kembali", truckUnblocked.NoIdentity, truckUnblocked.NoPol),
+	)
+
+	return truckUnblocked, nil
+}
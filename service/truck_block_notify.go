@@ -0,0 +1,164 @@
+package service
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"html"
+	"strings"
+	"tilank/clients/fcm"
+	"tilank/config"
+	"tilank/dto"
+	"tilank/utils/logger"
+	"tilank/utils/rest_err"
+	"tilank/worker"
+	"time"
+	// data zona waktu disertakan pada binary agar blockTimezone dapat dimuat pada host tanpa tzdata
+	_ "time/tzdata"
+)
+
+const (
+	envBlockReminderHour = "BLOCK_REMINDER_HOURS"
+
+	// blockTimezone zona waktu cabang yang digunakan pada pesan pemberitahuan blokir
+	blockTimezone = "Asia/Makassar"
+)
+
+var (
+	// blockReminderHour jumlah jam sebelum blokir truck berakhir untuk mengirim pengingat, 0 berarti tidak aktif
+	blockReminderHour = 24
+	// blockLocation zona waktu blockTimezone yang dimuat oleh LoadBlockReminder
+	blockLocation = time.Local
+)
+
+// LoadBlockReminder memuat jarak waktu pengingat blokir berakhir dari environment dan zona waktu pesan
+func LoadBlockReminder() error {
+	hour, err := config.EnvInt(envBlockReminderHour, blockReminderHour, 0, 720)
+	if err != nil {
+		return err
+	}
+	location, err := time.LoadLocation(blockTimezone)
+	if err != nil {
+		return fmt.Errorf("gagal memuat zona waktu %s: %w", blockTimezone, err)
+	}
+
+	blockReminderHour = hour
+	blockLocation = location
+	return nil
+}
+
+// blockReminderDue truck diblokir sementara yang akan berakhir dalam window detik dan belum
+// mendapat pengingat untuk periode blokir saat ini
+func blockReminderDue(truck dto.TruckResponseMin, now int64, window int64) bool {
+	if !truck.Blocked || truck.BlockPermanent || truck.BlockEnd <= now {
+		return false
+	}
+	return truck.BlockEnd-now <= window && truck.BlockReminderAt < truck.BlockStart
+}
+
+// RemindBlockExpiring mengirim pengingat ke pemilik truck dan security cabang
+// untuk truck yang masa blokirnya akan berakhir, dijalankan oleh scheduler
+func (j *TruckService) RemindBlockExpiring() (int64, resterr.APIError) {
+	if blockReminderHour == 0 {
+		return 0, nil
+	}
+
+	truckList, err := j.daoC.FindTruck(dto.FilterTruck{
+		Blocked: true,
+		Active:  true,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	nowUnix := time.Now().Unix()
+	window := int64(blockReminderHour) * 3600
+
+	var reminded int64
+	for _, truck := range truckList {
+		if !blockReminderDue(truck, nowUnix, window) {
+			continue
+		}
+		if err := j.daoC.SetBlockReminder(truck.ID, nowUnix); err != nil {
+			continue
+		}
+		truck.BlockReminderAt = nowUnix
+		reminded++
+
+		blockEnd := time.Unix(truck.BlockEnd, 0).In(blockLocation).Format("02-01-2006 15:04 MST")
+		j.notifyBlock(truck,
+			fmt.Sprintf("Blokir truck %s akan berakhir", truck.NoIdentity),
+			fmt.Sprintf("Masa blokir truck dengan no lambung %s (%s) akan berakhir pada %s", truck.NoIdentity, truck.NoPol, blockEnd),
+		)
+		j.webhook.Emit(config.EventTruckBlockExpiring, truck.Branch, truck)
+		j.hub.Publish(config.EventTruckBlockExpiring, truck.Branch, truck)
+	}
+
+	return reminded, nil
+}
+
+// truckMin data truck yang digunakan pada pemberitahuan blokir
+func truckMin(truck *dto.Truck) dto.TruckResponseMin {
+	return dto.TruckResponseMin{
+		ID:              truck.ID,
+		Branch:          truck.Branch,
+		NoIdentity:      truck.NoIdentity,
+		NoPol:           truck.NoPol,
+		Mark:            truck.Mark,
+		OwnerID:         truck.OwnerID,
+		Owner:           truck.Owner,
+		JptID:           truck.JptID,
+		JptName:         truck.JptName,
+		Email:           truck.Email,
+		Hp:              truck.Hp,
+		Deleted:         truck.Deleted,
+		Score:           truck.Score,
+		ResetScoreDate:  truck.ResetScoreDate,
+		Blocked:         truck.Blocked,
+		BlockStart:      truck.BlockStart,
+		BlockEnd:        truck.BlockEnd,
+		BlockPermanent:  truck.BlockPermanent,
+		BlockReason:     truck.BlockReason,
+		BlockReminderAt: truck.BlockReminderAt,
+	}
+}
+
+// notifyBlock mengirim pemberitahuan blokir ke email truck dan perusahaan pemilik serta fcm security cabang.
+// pengiriman SMS atau WhatsApp ke nomor hp truck berada di luar cakupan aplikasi ini, sistem eksternal
+// berlangganan webhook truck.blocked, truck.block_expiring dan truck.unblocked yang payloadnya memuat hp truck
+func (j *TruckService) notifyBlock(truck dto.TruckResponseMin, title string, message string) {
+	emails := map[string]bool{}
+	if truck.Email != "" {
+		emails[strings.ToLower(truck.Email)] = true
+	}
+	if ownerID, errT := primitive.ObjectIDFromHex(truck.OwnerID); errT == nil {
+		if company, err := j.daoCo.GetCompanyByID(ownerID, truck.Branch); err == nil && company.Email != "" {
+			emails[strings.ToLower(company.Email)] = true
+		}
+	}
+	for email := range emails {
+		worker.RegSendEmail(&worker.MailInfo{
+			ToEmail: email,
+			Subject: fmt.Sprintf("%s - TILANK", title),
+			Body:    fmt.Sprintf("Yth. %s,<br>%s.<br>Terimakasih.", html.EscapeString(truck.Owner), html.EscapeString(message)),
+		})
+	}
+
+	users, err := j.userDao.FindUserByRole(truck.Branch, config.RoleSEC)
+	if err != nil {
+		logger.Error("Gagal mendapatkan user security untuk pemberitahuan blokir (notifyBlock)", err)
+		return
+	}
+	var tokens []string
+	for _, user := range users {
+		if user.FcmToken != "" {
+			tokens = append(tokens, user.FcmToken)
+		}
+	}
+	if len(tokens) != 0 {
+		j.fcm.SendMessage(fcm.Payload{
+			Title:          title,
+			Message:        message,
+			ReceiverTokens: tokens,
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"os"
+	"testing"
+	"tilank/dto"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockReminderDue(t *testing.T) {
+	now := time.Now().Unix()
+	window := int64(24 * 3600)
+
+	cases := []struct {
+		name  string
+		truck dto.TruckResponseMin
+		want  bool
+	}{
+		{"berakhir dalam window", dto.TruckResponseMin{Blocked: true, BlockStart: now - 3600, BlockEnd: now + 3600}, true},
+		{"berakhir di luar window", dto.TruckResponseMin{Blocked: true, BlockStart: now - 3600, BlockEnd: now + window + 60}, false},
+		{"tepat di batas window", dto.TruckResponseMin{Blocked: true, BlockStart: now - 3600, BlockEnd: now + window}, true},
+		{"blokir lebih pendek dari window", dto.TruckResponseMin{Blocked: true, BlockStart: now, BlockEnd: now + 600}, true},
+		{"blokir permanen", dto.TruckResponseMin{Blocked: true, BlockPermanent: true, BlockStart: now - 3600}, false},
+		{"sudah berakhir", dto.TruckResponseMin{Blocked: true, BlockStart: now - 7200, BlockEnd: now}, false},
+		{"tidak diblokir", dto.TruckResponseMin{BlockEnd: now + 3600}, false},
+		{"sudah diingatkan pada periode ini",
+			dto.TruckResponseMin{Blocked: true, BlockStart: now - 3600, BlockEnd: now + 3600, BlockReminderAt: now - 60}, false},
+		{"diingatkan pada periode sebelumnya",
+			dto.TruckResponseMin{Blocked: true, BlockStart: now - 3600, BlockEnd: now + 3600, BlockReminderAt: now - 7200}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, blockReminderDue(c.truck, now, window))
+		})
+	}
+}
+
+func TestLoadBlockReminder(t *testing.T) {
+	defer func() {
+		_ = os.Setenv(envBlockReminderHour, "")
+		blockReminderHour = 24
+	}()
+
+	_ = os.Setenv(envBlockReminderHour, "6")
+	assert.Nil(t, LoadBlockReminder())
+	assert.Equal(t, 6, blockReminderHour)
+	assert.Equal(t, blockTimezone, blockLocation.String())
+	assert.Equal(t, "01-01-2026 08:00 WITA", time.Unix(1767225600, 0).In(blockLocation).Format("02-01-2006 15:04 MST"))
+
+	for _, value := range []string{"-1", "721", "abc"} {
+		_ = os.Setenv(envBlockReminderHour, value)
+		assert.NotNil(t, LoadBlockReminder(), value)
+		assert.Equal(t, 6, blockReminderHour)
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+	"tilank/clients/fcm"
+	"tilank/config"
+	"tilank/dao/userdao"
+	"tilank/dto"
+	"tilank/utils/rest_err"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeUserDao user berdasarkan role
+type fakeUserDao struct {
+	userdao.UserDaoAssumer
+	users map[string]dto.UserResponseList
+}
+
+func (f *fakeUserDao) FindUserByRole(_ string, role string) (dto.UserResponseList, resterr.APIError) {
+	return f.users[role], nil
+}
+
+func (f *fakeUserDao) FindUserCoveringBranch(_ string, role string) (dto.UserResponseList, resterr.APIError) {
+	return f.users[role], nil
+}
+
+// fakeFcm mencatat pesan yang dikirim
+type fakeFcm struct {
+	payloads []fcm.Payload
+}
+
+func (f *fakeFcm) SendMessage(payload fcm.Payload) {
+	f.payloads = append(f.payloads, payload)
+}
+
+func newTruckBlockService(truckDao *fakeTruckDao) (*TruckService, *fakeAuditDao, *fakeHub) {
+	auditDao := &fakeAuditDao{}
+	hub := &fakeHub{}
+	return &TruckService{
+		daoC:    truckDao,
+		daoCo:   &fakeCompanyDao{},
+		userDao: &fakeUserDao{},
+		fcm:     &fakeFcm{},
+		audit:   NewAuditService(auditDao),
+		webhook: NewWebhookService(&fakeWebhookDao{}, nil),
+		hub:     hub,
+	}, auditDao, hub
+}
+
+func TestBlockTruck(t *testing.T) {
+	cases := []struct {
+		name      string
+		input     dto.TruckBlockRequest
+		wantEvent string
+		wantAudit string
+	}{
+		{"blokir sementara", dto.TruckBlockRequest{Reason: "melanggar aturan", BlockTime: 3600},
+			config.TruckHistoryBlockStart, AuditTruckBlock},
+		{"daftar hitam", dto.TruckBlockRequest{Reason: "melanggar aturan", BlockTime: 3600, Permanent: true},
+			config.TruckHistoryBlacklist, AuditTruckBlacklist},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			truckDao := &fakeTruckDao{truck: dto.Truck{ID: primitive.NewObjectID(), NoIdentity: "T01"}}
+			j, auditDao, hub := newTruckBlockService(truckDao)
+
+			before := time.Now().Unix()
+			truck, apiErr := j.BlockTruck(hsseClaims, truckDao.truck.ID.Hex(), c.input)
+			assert.Nil(t, apiErr)
+			assert.True(t, truck.Blocked)
+			assert.Equal(t, c.input.Permanent, truck.BlockPermanent)
+			assert.GreaterOrEqual(t, truck.BlockStart, before)
+			if c.input.Permanent {
+				assert.Zero(t, truck.BlockEnd)
+			} else {
+				assert.Equal(t, truck.BlockStart+c.input.BlockTime, truck.BlockEnd)
+			}
+
+			assert.Len(t, truckDao.history, 1)
+			assert.Equal(t, c.wantEvent, truckDao.history[0].Event)
+			assert.Len(t, auditDao.inserted, 1)
+			assert.Equal(t, c.wantAudit, auditDao.inserted[0].Action)
+			assert.Equal(t, []string{config.EventTruckBlocked}, hub.events)
+		})
+	}
+}
+
+func TestBlockTruckRejected(t *testing.T) {
+	cases := []struct {
+		name  string
+		truck dto.Truck
+	}{
+		{"truck daftar hitam", dto.Truck{Blocked: true, BlockPermanent: true}},
+		{"truck dihapus", dto.Truck{Deleted: true}},
+		{"blokir berjalan lebih lama", dto.Truck{Blocked: true, BlockStart: time.Now().Unix() - 60, BlockEnd: time.Now().Unix() + 7200}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.truck.ID = primitive.NewObjectID()
+			truckDao := &fakeTruckDao{truck: c.truck}
+			j, auditDao, _ := newTruckBlockService(truckDao)
+
+			_, apiErr := j.BlockTruck(hsseClaims, c.truck.ID.Hex(), dto.TruckBlockRequest{Reason: "melanggar aturan", BlockTime: 3600})
+			assert.NotNil(t, apiErr)
+			assert.Equal(t, http.StatusBadRequest, apiErr.Status())
+			assert.Nil(t, truckDao.blockEdit)
+			assert.Empty(t, auditDao.inserted)
+		})
+	}
+}
+
+func TestBlockTruckReplaceShorterBlock(t *testing.T) {
+	now := time.Now().Unix()
+	truckDao := &fakeTruckDao{truck: dto.Truck{ID: primitive.NewObjectID(), Blocked: true, BlockStart: now - 60, BlockEnd: now + 60}}
+	j, _, _ := newTruckBlockService(truckDao)
+
+	truck, apiErr := j.BlockTruck(hsseClaims, truckDao.truck.ID.Hex(), dto.TruckBlockRequest{Reason: "melanggar aturan", BlockTime: 3600})
+	assert.Nil(t, apiErr)
+	assert.Equal(t, truck.BlockStart+3600, truck.BlockEnd)
+}
+
+func TestUnblockTruck(t *testing.T) {
+	now := time.Now().Unix()
+	truckDao := &fakeTruckDao{truck: dto.Truck{ID: primitive.NewObjectID(), NoIdentity: "T01", Blocked: true, BlockPermanent: true, BlockStart: now - 60}}
+	j, auditDao, hub := newTruckBlockService(truckDao)
+	fcmClient := &fakeFcm{}
+	j.fcm = fcmClient
+	j.userDao = &fakeUserDao{users: map[string]dto.UserResponseList{
+		config.RoleSEC: {{FcmToken: "token-security"}},
+	}}
+
+	truck, apiErr := j.UnblockTruck(hsseClaims, truckDao.truck.ID.Hex(), dto.TruckUnblockRequest{Reason: "banding diterima"})
+	assert.Nil(t, apiErr)
+	assert.False(t, truck.Blocked)
+	assert.False(t, truck.BlockPermanent)
+	assert.Len(t, truckDao.history, 1)
+	assert.Equal(t, config.TruckHistoryBlockEnd, truckDao.history[0].Event)
+	assert.Equal(t, now-60, truckDao.history[0].BlockStart)
+	assert.Equal(t, AuditTruckUnblock, auditDao.inserted[0].Action)
+	assert.Equal(t, []string{config.EventTruckUnblocked}, hub.events)
+	// security cabang mendapat pemberitahuan yang sama dengan pencabutan blokir otomatis
+	assert.Len(t, fcmClient.payloads, 1)
+	assert.Equal(t, "Blokir truck T01 dicabut", fcmClient.payloads[0].Title)
+	assert.Equal(t, []string{"token-security"}, fcmClient.payloads[0].ReceiverTokens)
+
+	// truck yang tidak diblokir tidak dapat dicabut blokirnya
+	truckDao = &fakeTruckDao{truck: dto.Truck{ID: primitive.NewObjectID()}}
+	j, _, _ = newTruckBlockService(truckDao)
+	_, apiErr = j.UnblockTruck(hsseClaims, truckDao.truck.ID.Hex(), dto.TruckUnblockRequest{Reason: "banding diterima"})
+	assert.NotNil(t, apiErr)
+	assert.Nil(t, truckDao.blockEdit)
+}
+
+func TestResetBlockedTruckSkipPermanent(t *testing.T) {
+	now := time.Now().Unix()
+	expired := primitive.NewObjectID()
+	truckDao := &fakeTruckDao{trucks: dto.TruckResponseMinList{
+		{ID: expired, Blocked: true, BlockStart: now - 7200, BlockEnd: now - 1},
+		{ID: primitive.NewObjectID(), Blocked: true, BlockPermanent: true, BlockStart: now - 7200},
+		{ID: primitive.NewObjectID(), Blocked: true, BlockStart: now - 60, BlockEnd: now + 3600},
+	}}
+	j, _, _ := newTruckBlockService(truckDao)
+
+	updated, apiErr := j.ResetBlockedTruck()
+	assert.Nil(t, apiErr)
+	assert.Equal(t, int64(1), updated)
+	assert.Equal(t, []primitive.ObjectID{expired}, truckDao.resetIDs)
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"tilank/config"
+	"tilank/dao/truckdao"
+	"tilank/dto"
+	"tilank/utils/logger"
+	"tilank/utils/rest_err"
+	"time"
+)
+
+const (
+	// truckTimelineLimit jumlah maksimal riwayat dan pelanggaran yang digabungkan pada timeline
+	truckTimelineLimit = 500
+)
+
+// recordTruckHistory menyimpan riwayat truck, kegagalan hanya dicatat di log
+// agar tidak membatalkan perubahan truck yang sudah tersimpan
+func recordTruckHistory(truckDao truckdao.TruckDaoAssumer, entry dto.TruckHistory) {
+	if entry.Time == 0 {
+		entry.Time = time.Now().Unix()
+	}
+	if err := truckDao.InsertHistory(entry); err != nil {
+		logger.Error(fmt.Sprintf("gagal menyimpan riwayat %s truck %s (recordTruckHistory)", entry.Event, entry.TruckID), err)
+	}
+}
+
+// truckEditDetail menjelaskan field yang berubah pada saat truck diedit
+func truckEditDetail(before *dto.Truck, after *dto.Truck) string {
+	fields := []struct {
+		name   string
+		before string
+		after  string
+	}{
+		{"no_identity", before.NoIdentity, after.NoIdentity},
+		{"no_pol", before.NoPol, after.NoPol},
+		{"mark", before.Mark, after.Mark},
+		{"owner", before.Owner, after.Owner},
+		{"jpt", before.JptName, after.JptName},
+		{"email", before.Email, after.Email},
+		{"hp", before.Hp, after.Hp},
+	}
+
+	var changes []string
+	for _, field := range fields {
+		if field.before != field.after {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", field.name, field.before, field.after))
+		}
+	}
+	return strings.Join(changes, ", ")
+}
+
+// timelineNoIdentityPeriods nomor lambung yang pernah digunakan truck beserta periode penggunaannya berdasarkan
+// riwayat perubahan. pelanggaran hanya menyimpan nomor lambung, periode mencegah pelanggaran truck lain
+// yang kemudian menggunakan nomor lambung yang sama ikut ditampilkan
+func timelineNoIdentityPeriods(truck dto.Truck, historyList dto.TruckHistoryList) []dto.NoIdentityPeriod {
+	var changes dto.TruckHistoryList
+	for _, history := range historyList {
+		if history.NoIdentityBefore != "" && history.NoIdentityBefore != history.NoIdentity {
+			changes = append(changes, history)
+		}
+	}
+	sort.SliceStable(changes, func(a, b int) bool {
+		return changes[a].Time < changes[b].Time
+	})
+
+	current := dto.NoIdentityPeriod{NoIdentity: truck.NoIdentity, Start: truck.CreatedAt}
+	if len(changes) != 0 {
+		current.NoIdentity = changes[0].NoIdentityBefore
+	}
+	periods := make([]dto.NoIdentityPeriod, 0, len(changes)+1)
+	for _, change := range changes {
+		current.End = change.Time
+		periods = append(periods, current)
+		current = dto.NoIdentityPeriod{NoIdentity: change.NoIdentity, Start: change.Time}
+	}
+	return append(periods, current)
+}
+
+// GetTimeline menggabungkan pelanggaran yang sudah diajukan dan riwayat truck (skor, blokir, perubahan data)
+// secara kronologis
+func (j *TruckService) GetTimeline(truck dto.Truck) (*dto.TruckTimelineResponse, resterr.APIError) {
+	historyList, err := j.daoC.FindHistory(truck.ID.Hex(), truckTimelineLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	violationList, err := j.vDao.FindViolation(dto.FilterViolation{
+		FilterBranch:            truck.Branch,
+		FilterNoIdentityPeriods: timelineNoIdentityPeriods(truck, historyList),
+		Submitted:               true,
+		Limit:                   truckTimelineLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.TruckTimelineItem, 0, len(historyList)+len(violationList))
+	for i := range historyList {
+		items = append(items, dto.TruckTimelineItem{
+			Time:    historyList[i].Time,
+			Event:   historyList[i].Event,
+			History: &historyList[i],
+		})
+	}
+	for i := range violationList {
+		items = append(items, dto.TruckTimelineItem{
+			Time:      violationList[i].TimeViolation,
+			Event:     config.TruckTimelineViolation,
+			Violation: &violationList[i],
+		})
+	}
+
+	sort.SliceStable(items, func(a, b int) bool {
+		return items[a].Time < items[b].Time
+	})
+
+	return &dto.TruckTimelineResponse{
+		Truck: truck,
+		Items: items,
+	}, nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"testing"
+	"tilank/config"
+	"tilank/dto"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestTruckEditDetail(t *testing.T) {
+	before := &dto.Truck{NoIdentity: "T01", NoPol: "DA1234AB", Owner: "PT ABC", JptName: "JPT A", Email: "a@example.com"}
+
+	cases := []struct {
+		name  string
+		after dto.Truck
+		want  string
+	}{
+		{"tidak ada perubahan", *before, ""},
+		{"satu field", dto.Truck{NoIdentity: "T01", NoPol: "DA1234AB", Owner: "PT ABC", JptName: "JPT B", Email: "a@example.com"},
+			"jpt: JPT A -> JPT B"},
+		{"beberapa field sesuai urutan", dto.Truck{NoIdentity: "T02", NoPol: "DA1234AB", Owner: "PT XYZ", JptName: "JPT A", Email: "a@example.com", Hp: "0811"},
+			"no_identity: T01 -> T02, owner: PT ABC -> PT XYZ, hp:  -> 0811"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, truckEditDetail(before, &c.after))
+		})
+	}
+}
+
+func TestGetTimeline(t *testing.T) {
+	truck := dto.Truck{ID: primitive.NewObjectID(), Branch: "BANJARMASIN", NoIdentity: "T02", CreatedAt: 100}
+	truckDao := &fakeTruckDao{history: dto.TruckHistoryList{
+		{NoIdentity: "T00", Event: config.TruckHistoryCreate, Time: 100},
+		{NoIdentity: "T01", NoIdentityBefore: "T00", Event: config.TruckHistoryEdit, Time: 300},
+		{NoIdentity: "T02", NoIdentityBefore: "T01", Event: config.TruckHistoryEdit, Time: 500},
+		{NoIdentity: "T02", Event: config.TruckHistoryScore, Time: 600},
+	}}
+	violationDao := &fakeViolationDao{violations: dto.ViolationResponseMinList{
+		{NoIdentity: "T02", TimeViolation: 550},
+		{NoIdentity: "T00", TimeViolation: 200},
+		{NoIdentity: "T01", TimeViolation: 300},
+	}}
+	j := &TruckService{daoC: truckDao, vDao: violationDao}
+
+	timeline, apiErr := j.GetTimeline(truck)
+	assert.Nil(t, apiErr)
+
+	// pelanggaran dicari dengan seluruh nomor lambung yang pernah digunakan truck pada periodenya
+	// dan hanya pelanggaran yang sudah diajukan
+	assert.Equal(t, "BANJARMASIN", violationDao.filter.FilterBranch)
+	assert.Equal(t, []dto.NoIdentityPeriod{
+		{NoIdentity: "T00", Start: 100, End: 300},
+		{NoIdentity: "T01", Start: 300, End: 500},
+		{NoIdentity: "T02", Start: 500},
+	}, violationDao.filter.FilterNoIdentityPeriods)
+	assert.Empty(t, violationDao.filter.FilterNoIdentity)
+	assert.True(t, violationDao.filter.Submitted)
+
+	var times []int64
+	var events []string
+	for _, item := range timeline.Items {
+		times = append(times, item.Time)
+		events = append(events, item.Event)
+	}
+	assert.Equal(t, []int64{100, 200, 300, 300, 500, 550, 600}, times)
+	// kejadian dengan waktu sama tetap berurutan riwayat lalu pelanggaran
+	assert.Equal(t, []string{
+		config.TruckHistoryCreate,
+		config.TruckTimelineViolation,
+		config.TruckHistoryEdit,
+		config.TruckTimelineViolation,
+		config.TruckHistoryEdit,
+		config.TruckTimelineViolation,
+		config.TruckHistoryScore,
+	}, events)
+}
+
+func TestTimelineNoIdentityPeriods(t *testing.T) {
+	cases := []struct {
+		name    string
+		truck   dto.Truck
+		history dto.TruckHistoryList
+		want    []dto.NoIdentityPeriod
+	}{
+		{"nomor lambung tidak pernah diubah",
+			dto.Truck{NoIdentity: "T01", CreatedAt: 100},
+			dto.TruckHistoryList{{NoIdentity: "T01", Event: config.TruckHistoryEdit, Time: 200}},
+			[]dto.NoIdentityPeriod{{NoIdentity: "T01", Start: 100}}},
+		// riwayat terurut dari yang terbaru
+		{"nomor lambung kembali ke nomor lama",
+			dto.Truck{NoIdentity: "T01", CreatedAt: 100},
+			dto.TruckHistoryList{
+				{NoIdentity: "T01", NoIdentityBefore: "T02", Event: config.TruckHistoryEdit, Time: 400},
+				{NoIdentity: "T02", NoIdentityBefore: "T01", Event: config.TruckHistoryEdit, Time: 200},
+			},
+			[]dto.NoIdentityPeriod{
+				{NoIdentity: "T01", Start: 100, End: 200},
+				{NoIdentity: "T02", Start: 200, End: 400},
+				{NoIdentity: "T01", Start: 400},
+			}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, timelineNoIdentityPeriods(c.truck, c.history))
+		})
+	}
+}
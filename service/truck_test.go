@@ -0,0 +1,121 @@
+package service
+
+import (
+	"testing"
+	"tilank/config"
+	"tilank/dao/companydao"
+	"tilank/dao/jptdao"
+	"tilank/dto"
+	"tilank/utils/rest_err"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeCompanyDao perusahaan berdasarkan nama yang dinormalisasi
+type fakeCompanyDao struct {
+	companydao.CompanyDaoAssumer
+	companies map[string]dto.Company
+}
+
+func (f *fakeCompanyDao) GetCompanyByNormalizedName(normalizedName string, _ string) (*dto.Company, resterr.APIError) {
+	company, ok := f.companies[normalizedName]
+	if !ok {
+		return nil, resterr.NewNotFoundError("perusahaan tidak ditemukan")
+	}
+	return &company, nil
+}
+
+// fakeJptDao JPT berdasarkan id hex
+type fakeJptDao struct {
+	jptdao.JptDaoAssumer
+	jpts map[string]dto.Jpt
+}
+
+func (f *fakeJptDao) GetJptByID(jptID primitive.ObjectID, _ string) (*dto.Jpt, resterr.APIError) {
+	jpt, ok := f.jpts[jptID.Hex()]
+	if !ok {
+		return nil, resterr.NewNotFoundError("jpt tidak ditemukan")
+	}
+	return &jpt, nil
+}
+
+func TestEditTruckSuspendedJpt(t *testing.T) {
+	suspended := primitive.NewObjectID()
+	normal := primitive.NewObjectID()
+	jptDao := &fakeJptDao{jpts: map[string]dto.Jpt{
+		suspended.Hex(): {ID: suspended, Name: "JPT SUSPEND", Status: config.JptStatusSuspended},
+		normal.Hex():    {ID: normal, Name: "JPT NORMAL", Status: config.JptStatusNormal},
+	}}
+
+	cases := []struct {
+		name     string
+		current  string
+		input    string
+		rejected bool
+	}{
+		{"tetap pada jpt yang disuspend", suspended.Hex(), suspended.Hex(), false},
+		{"pindah ke jpt yang disuspend", normal.Hex(), suspended.Hex(), true},
+		{"menghubungkan jpt yang disuspend", "", suspended.Hex(), true},
+		{"pindah dari jpt yang disuspend", suspended.Hex(), normal.Hex(), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			truckDao := &fakeTruckDao{truck: dto.Truck{ID: primitive.NewObjectID(), NoIdentity: "T01", JptID: c.current}}
+			j := &TruckService{daoC: truckDao, daoCo: &fakeCompanyDao{}, daoJ: jptDao}
+
+			_, apiErr := j.EditTruck(hsseClaims, truckDao.truck.ID.Hex(), dto.TruckEditRequest{
+				NoIdentity: "T01",
+				Owner:      "PT ABC",
+				JptID:      c.input,
+			})
+			if c.rejected {
+				assert.NotNil(t, apiErr)
+				assert.Nil(t, truckDao.edited)
+				return
+			}
+			assert.Nil(t, apiErr)
+			assert.Equal(t, c.input, truckDao.edited.JptID)
+		})
+	}
+}
+
+func TestGetOwnerFreeText(t *testing.T) {
+	registered := primitive.NewObjectID()
+	j := &TruckService{daoCo: &fakeCompanyDao{companies: map[string]dto.Company{
+		"PT ABC":  {ID: registered, Name: "PT ABC"},
+		"CV LAMA": {ID: primitive.NewObjectID(), Name: "CV LAMA", Deleted: true},
+	}}}
+
+	cases := []struct {
+		name      string
+		owner     string
+		wantID    string
+		wantOwner string
+	}{
+		{"terhubung ke perusahaan terdaftar", "P.T. A.B.C.", registered.Hex(), "PT ABC"},
+		{"perusahaan belum terdaftar", " pt baru ", "", "PT BARU"},
+		{"perusahaan nonaktif tidak dihubungkan", "CV. Lama", "", "CV. LAMA"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			company, apiErr := j.getOwner("", c.owner, "BANJARMASIN")
+			assert.Nil(t, apiErr)
+			assert.Equal(t, c.wantID, companyID(company))
+			assert.Equal(t, c.wantOwner, company.Name)
+		})
+	}
+}
+
+func TestTruckRequestOwnerValidation(t *testing.T) {
+	base := dto.TruckRequest{NoIdentity: "T01", NoPol: "DA1234AB", Email: "truck@example.com"}
+	assert.NotNil(t, base.Validate())
+
+	withOwnerID := base
+	withOwnerID.OwnerID = primitive.NewObjectID().Hex()
+	assert.Nil(t, withOwnerID.Validate())
+
+	withOwner := base
+	withOwner.Owner = "PT ABC"
+	assert.Nil(t, withOwner.Validate())
+}
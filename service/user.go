@@ -1,7 +1,12 @@
 package service
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
+	"tilank/clients/oidc"
+	"tilank/dao/passworddao"
+	"tilank/dao/ssodao"
 	userdao "tilank/dao/userdao"
 	"tilank/dto"
 	"tilank/utils/crypt"
@@ -10,33 +15,80 @@ import (
 	"time"
 )
 
-func NewUserService(dao userdao.UserDaoAssumer, crypto crypt.BcryptAssumer, jwt mjwt.JWTAssumer) UserServiceAssumer {
+const (
+	// loginDelayAfter jumlah kegagalan login sebelum penundaan bertahap diberlakukan
+	loginDelayAfter = 3
+	// loginMaxDelaySecond batas atas penundaan antar percobaan login
+	loginMaxDelaySecond = 60
+	// loginLockThreshold jumlah kegagalan login berturut-turut sebelum user dikunci
+	loginLockThreshold = 10
+	// loginLockMinute lama user dikunci
+	loginLockMinute = 15
+	// userPageMaxLimit jumlah maksimal user per halaman
+	userPageMaxLimit = 100
+)
+
+func NewUserService(dao userdao.UserDaoAssumer,
+	passwordDao passworddao.PasswordDaoAssumer,
+	ssoDao ssodao.SsoDaoAssumer,
+	sso oidc.ClientAssumer,
+	crypto crypt.HasherAssumer,
+	jwt mjwt.JWTAssumer,
+	token *TokenService,
+	audit *AuditService) UserServiceAssumer {
 	return &userService{
-		dao:    dao,
-		crypto: crypto,
-		jwt:    jwt,
+		dao:         dao,
+		passwordDao: passwordDao,
+		ssoDao:      ssoDao,
+		sso:         sso,
+		crypto:      crypto,
+		jwt:         jwt,
+		token:       token,
+		audit:       audit,
 	}
 }
 
 type userService struct {
-	dao    userdao.UserDaoAssumer
-	crypto crypt.BcryptAssumer
-	jwt    mjwt.JWTAssumer
+	dao         userdao.UserDaoAssumer
+	passwordDao passworddao.PasswordDaoAssumer
+	ssoDao      ssodao.SsoDaoAssumer
+	sso         oidc.ClientAssumer
+	crypto      crypt.HasherAssumer
+	jwt         mjwt.JWTAssumer
+	token       *TokenService
+	audit       *AuditService
 }
 
 type UserServiceAssumer interface {
 	GetUser(userID string) (*dto.UserResponse, resterr.APIError)
 	GetUserByID(email string) (*dto.UserResponse, resterr.APIError)
-	InsertUser(dto.UserRequest) (*string, resterr.APIError)
-	FindUsers() (dto.UserResponseList, resterr.APIError)
-	EditUser(userID string, userEdit dto.UserEditRequest) (*dto.UserResponse, resterr.APIError)
+	InsertUser(actor string, user dto.UserRequest) (*string, resterr.APIError)
+	FindUsers(filterA dto.FilterUser) (*dto.UserPageResponse, resterr.APIError)
+	EditUser(actor string, userID string, userEdit dto.UserEditRequest) (*dto.UserResponse, resterr.APIError)
 	EditFcm(userID string, fcmToken string) (*dto.UserResponse, resterr.APIError)
-	DeleteUser(userID string) resterr.APIError
+	DeactivateUser(actor string, userID string) (*dto.UserResponse, resterr.APIError)
+	ReactivateUser(actor string, userID string) (*dto.UserResponse, resterr.APIError)
 	Login(dto.UserLoginRequest) (*dto.UserLoginResponse, resterr.APIError)
 	Refresh(login dto.UserRefreshTokenRequest) (*dto.UserRefreshTokenResponse, resterr.APIError)
+	Logout(claims mjwt.CustomClaim, refreshToken string) resterr.APIError
+	RevokeAllTokens(actor string, userID string) resterr.APIError
+	UnlockUser(actor string, userID string) (*dto.UserResponse, resterr.APIError)
+	VerifyTwoFactor(input dto.TwoFactorVerifyRequest) (*dto.UserLoginResponse, resterr.APIError)
+	SetupTwoFactor(userID string) (*dto.TwoFactorSetupResponse, resterr.APIError)
+	ActivateTwoFactor(userID string, code string) ([]string, resterr.APIError)
+	LoginSetupTwoFactor(input dto.TwoFactorTokenRequest) (*dto.TwoFactorSetupResponse, resterr.APIError)
+	LoginActivateTwoFactor(input dto.TwoFactorActivateRequest) (*dto.TwoFactorActivateResponse, resterr.APIError)
+	ResetTwoFactor(actor string, userID string) resterr.APIError
 	PutAvatar(userID string, fileLocation string) (*dto.UserResponse, resterr.APIError)
 	ChangePassword(data dto.UserChangePasswordRequest) resterr.APIError
-	ResetPassword(data dto.UserChangePasswordRequest) resterr.APIError
+	ResetPassword(actor string, userID string) (*dto.UserResetPasswordResponse, resterr.APIError)
+	LoginChangePassword(input dto.LoginChangePasswordRequest) (*dto.UserLoginResponse, resterr.APIError)
+	SsoLogin(limit int) (*dto.SsoLoginResponse, resterr.APIError)
+	SsoCallback(input dto.SsoCallbackRequest) (*dto.UserLoginResponse, resterr.APIError)
+	CleanupSsoState() (int64, resterr.APIError)
+	ForgotPassword(input dto.ForgotPasswordRequest) resterr.APIError
+	ResetPasswordWithToken(input dto.ResetPasswordRequest) resterr.APIError
+	StreamTicket(claims mjwt.CustomClaim) (*dto.StreamTicketResponse, resterr.APIError)
 }
 
 // GetUser mendapatkan user dari database
@@ -57,17 +109,29 @@ func (u *userService) GetUserByID(userID string) (*dto.UserResponse, resterr.API
 	return user, nil
 }
 
-// FindUsers
-func (u *userService) FindUsers() (dto.UserResponseList, resterr.APIError) {
-	userList, err := u.dao.FindUser()
+// FindUsers menampilkan daftar user per halaman, limit dibatasi maksimal userPageMaxLimit
+func (u *userService) FindUsers(filterA dto.FilterUser) (*dto.UserPageResponse, resterr.APIError) {
+	if filterA.Page < 1 {
+		filterA.Page = 1
+	}
+	if filterA.Limit < 1 || filterA.Limit > userPageMaxLimit {
+		filterA.Limit = userPageMaxLimit
+	}
+
+	userList, total, err := u.dao.FindUser(filterA)
 	if err != nil {
 		return nil, err
 	}
-	return userList, nil
+	return &dto.UserPageResponse{
+		Users: userList,
+		Total: total,
+		Page:  filterA.Page,
+		Limit: filterA.Limit,
+	}, nil
 }
 
 // InsertUser melakukan register user
-func (u *userService) InsertUser(user dto.UserRequest) (*string, resterr.APIError) {
+func (u *userService) InsertUser(actor string, user dto.UserRequest) (*string, resterr.APIError) {
 	// cek ketersediaan id
 	_, err := u.dao.CheckIDAvailable(user.ID)
 	if err != nil {
@@ -75,6 +139,10 @@ func (u *userService) InsertUser(user dto.UserRequest) (*string, resterr.APIErro
 	}
 	// END cek ketersediaan id
 
+	if err := passwordPolicy.Validate(user.Password); err != nil {
+		return nil, err
+	}
+
 	hashPassword, err := u.crypto.GenerateHash(user.Password)
 	if err != nil {
 		return nil, err
@@ -87,15 +155,33 @@ func (u *userService) InsertUser(user dto.UserRequest) (*string, resterr.APIErro
 	if err != nil {
 		return nil, err
 	}
+
+	u.audit.Record(dto.AuditLog{
+		Action:  AuditUserCreate,
+		Actor:   actor,
+		Target:  *insertedID,
+		Outcome: AuditSuccess,
+		Detail:  fmt.Sprintf("roles=%s branch=%s", strings.Join(user.Roles, ","), user.Branch),
+	})
+
 	return insertedID, nil
 }
 
-// EditUser
-func (u *userService) EditUser(userID string, request dto.UserEditRequest) (*dto.UserResponse, resterr.APIError) {
+// EditUser mengubah user oleh admin, perubahan role dan cabang dicatat ke audit log
+func (u *userService) EditUser(actor string, userID string, request dto.UserEditRequest) (*dto.UserResponse, resterr.APIError) {
 	result, err := u.dao.EditUser(userID, request)
 	if err != nil {
 		return nil, err
 	}
+
+	u.audit.Record(dto.AuditLog{
+		Action:  AuditUserEdit,
+		Actor:   actor,
+		Target:  result.ID,
+		Outcome: AuditSuccess,
+		Detail:  fmt.Sprintf("roles=%s branch=%s branches=%s", strings.Join(result.Roles, ","), result.Branch, strings.Join(result.Branches, ",")),
+	})
+
 	return result, nil
 }
 
@@ -107,47 +193,156 @@ func (u *userService) EditFcm(userID string, fcmToken string) (*dto.UserResponse
 	return result, nil
 }
 
-// DeleteUser
-func (u *userService) DeleteUser(userID string) resterr.APIError {
-	err := u.dao.DeleteUser(userID)
+// DeactivateUser menonaktifkan user sekaligus mencabut seluruh token yang masih aktif.
+// dokumen user tetap disimpan agar riwayat dan referensi pada data lain tetap utuh
+func (u *userService) DeactivateUser(actor string, userID string) (*dto.UserResponse, resterr.APIError) {
+	if strings.EqualFold(actor, userID) {
+		return nil, resterr.NewBadRequestError("Tidak dapat menonaktifkan akun terkait (diri sendiri)!")
+	}
+
+	user, err := u.dao.SetUserActive(userID, false, actor)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err := u.token.RevokeUser(user.ID); err != nil {
+		return nil, err
 	}
 
-	return nil
+	u.audit.Record(dto.AuditLog{
+		Action:  AuditUserDeactivate,
+		Actor:   actor,
+		Target:  user.ID,
+		Outcome: AuditSuccess,
+	})
+
+	return user, nil
 }
 
-// Login
+// ReactivateUser mengaktifkan kembali user yang dinonaktifkan
+func (u *userService) ReactivateUser(actor string, userID string) (*dto.UserResponse, resterr.APIError) {
+	user, err := u.dao.SetUserActive(userID, true, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	u.audit.Record(dto.AuditLog{
+		Action:  AuditUserReactivate,
+		Actor:   actor,
+		Target:  user.ID,
+		Outcome: AuditSuccess,
+	})
+
+	return user, nil
+}
+
+// Login memverifikasi password dengan perlindungan brute force per akun:
+// penundaan bertahap setelah loginDelayAfter kegagalan dan kunci sementara setelah loginLockThreshold kegagalan
 func (u *userService) Login(login dto.UserLoginRequest) (*dto.UserLoginResponse, resterr.APIError) {
+	auditEntry := dto.AuditLog{
+		Actor:     login.ID,
+		Target:    login.ID,
+		IP:        login.IP,
+		UserAgent: login.UserAgent,
+	}
+
 	user, err := u.dao.GetUserByIDWithPassword(login.ID)
 	if err != nil {
+		if err.Status() == http.StatusUnauthorized {
+			auditEntry.Action = AuditLoginFailed
+			auditEntry.Outcome = AuditFailure
+			auditEntry.Detail = "user tidak ditemukan"
+			u.audit.Record(auditEntry)
+		}
 		return nil, err
 	}
 
+	if user.Deactivated {
+		auditEntry.Action = AuditLoginFailed
+		auditEntry.Outcome = AuditDenied
+		auditEntry.Detail = "user dinonaktifkan"
+		u.audit.Record(auditEntry)
+		return nil, resterr.NewAPIError("Akun telah dinonaktifkan, hubungi admin", http.StatusForbidden, "account_deactivated", nil)
+	}
+
+	timeNow := time.Now().Unix()
+	if apiErr := u.checkLoginThrottle(user, timeNow, auditEntry); apiErr != nil {
+		return nil, apiErr
+	}
+
 	if !u.crypto.IsPWAndHashPWMatch(login.Password, user.HashPw) {
+		u.recordFailedLogin(user.ID, timeNow, auditEntry)
 		return nil, resterr.NewUnauthorizedError("Username atau password tidak valid")
 	}
 
-	if login.Limit == 0 || login.Limit > 60*24*30 { // 30 days
-		login.Limit = 60 * 24 * 30
+	if user.FailedLogin != 0 || user.LockedUntil != 0 {
+		if _, err := u.dao.ResetLoginAttempt(user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	// hash lama (bcrypt atau parameter argon2id lama) diganti selagi password plaintext tersedia
+	if u.crypto.NeedsRehash(user.HashPw) {
+		u.rehashPassword(user, login.Password)
+	}
+
+	auditEntry.Detail = "password"
+	return u.completeLogin(user, false, login.Limit, auditEntry)
+}
+
+// completeLogin melanjutkan login setelah faktor pertama (password atau SSO) berhasil.
+// user dengan two factor aktif atau role yang mewajibkan two factor harus melalui langkah kedua lebih dulu,
+// baru kemudian password hasil reset admin atau yang sudah kadaluarsa wajib diganti sebelum mendapatkan token.
+// twoFactorPassed true jika kode two factor sudah diverifikasi
+func (u *userService) completeLogin(user *dto.User, twoFactorPassed bool, limit int, auditEntry dto.AuditLog) (*dto.UserLoginResponse, resterr.APIError) {
+	if !twoFactorPassed && (user.TwoFactorEnabled || requiresTwoFactor(user.Roles)) {
+		return u.twoFactorChallenge(user)
+	}
+
+	if user.MustChangePassword || passwordPolicy.IsExpired(user, time.Now().Unix()) {
+		return u.passwordChangeChallenge(user)
+	}
+
+	return u.issueLoginToken(user, limit, auditEntry)
+}
+
+// issueLoginToken menerbitkan access token dan refresh token dengan token family baru
+// lalu mencatat login yang berhasil ke audit log, Action kosong dicatat sebagai LOGIN_SUCCESS
+func (u *userService) issueLoginToken(user *dto.User, limit int, auditEntry dto.AuditLog) (*dto.UserLoginResponse, resterr.APIError) {
+	if limit == 0 || limit > 60*24*30 { // 30 days
+		limit = 60 * 24 * 30
+	}
+
+	// setiap login membuat token family baru untuk rotasi refresh token
+	refreshTokenID, errID := mjwt.GenerateTokenID()
+	if errID != nil {
+		return nil, resterr.NewInternalServerError("gagal membuat token id", errID)
+	}
+	familyID, err := u.token.StartFamily(user.ID, refreshTokenID)
+	if err != nil {
+		return nil, err
 	}
 
 	AccessClaims := mjwt.CustomClaim{
+		FamilyID:    familyID,
 		Identity:    user.ID,
 		Name:        user.Name,
 		Roles:       user.Roles,
 		Branch:      user.Branch,
-		ExtraMinute: time.Duration(login.Limit),
+		Branches:    user.Branches,
+		ExtraMinute: time.Duration(limit),
 		Type:        mjwt.Access,
 		Fresh:       true,
 	}
 
 	RefreshClaims := mjwt.CustomClaim{
+		TokenID:     refreshTokenID,
+		FamilyID:    familyID,
 		Identity:    user.ID,
 		Name:        user.Name,
 		Roles:       user.Roles,
 		Branch:      user.Branch,
-		ExtraMinute: 60 * 24 * 90, // 90 days
+		Branches:    user.Branches,
+		ExtraMinute: refreshTokenMinute,
 		Type:        mjwt.Refresh,
 	}
 
@@ -164,19 +359,127 @@ func (u *userService) Login(login dto.UserLoginRequest) (*dto.UserLoginResponse,
 		ID:           user.ID,
 		Name:         user.Name,
 		Branch:       user.Branch,
+		Branches:     user.Branches,
 		Email:        user.Email,
 		Roles:        user.Roles,
 		Avatar:       user.Avatar,
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
-		Expired:      time.Now().Add(time.Minute * time.Duration(login.Limit)).Unix(),
+		Expired:      time.Now().Add(time.Minute * time.Duration(limit)).Unix(),
 	}
 
+	if auditEntry.Action == "" {
+		auditEntry.Action = AuditLoginSuccess
+	}
+	auditEntry.Actor = user.ID
+	auditEntry.Target = user.ID
+	auditEntry.Outcome = AuditSuccess
+	u.audit.Record(auditEntry)
+
 	return &userResponse, nil
 }
 
-// Refresh token
+// rehashPassword menyimpan ulang hash password dengan format terbaru, kegagalan tidak menggagalkan login
+func (u *userService) rehashPassword(user *dto.User, password string) {
+	newHash, err := u.crypto.GenerateHash(password)
+	if err != nil {
+		return
+	}
+	if err := u.dao.UpdatePasswordHash(user.ID, user.HashPw, newHash); err != nil {
+		return
+	}
+	user.HashPw = newHash
+}
+
+// recordFailedLogin menambah jumlah kegagalan login dan mengunci user jika melewati batas
+func (u *userService) recordFailedLogin(userID string, timeNow int64, auditEntry dto.AuditLog) {
+	auditEntry.Action = AuditLoginFailed
+	auditEntry.Outcome = AuditFailure
+	auditEntry.Detail = "password salah"
+
+	user, err := u.dao.IncrementFailedLogin(userID, timeNow)
+	if err != nil {
+		u.audit.Record(auditEntry)
+		return
+	}
+	auditEntry.Detail = fmt.Sprintf("password salah, percobaan ke %d", user.FailedLogin)
+	u.audit.Record(auditEntry)
+
+	if user.FailedLogin >= loginLockThreshold {
+		lockedUntil := time.Now().Add(loginLockMinute * time.Minute).Unix()
+		if err := u.dao.LockUser(userID, lockedUntil); err != nil {
+			return
+		}
+		auditEntry.Action = AuditLoginLocked
+		auditEntry.Outcome = AuditSuccess
+		auditEntry.Detail = fmt.Sprintf("dikunci hingga %d setelah %d kegagalan login", lockedUntil, user.FailedLogin)
+		u.audit.Record(auditEntry)
+	}
+}
+
+// checkLoginThrottle menolak percobaan login (password maupun kode two factor) selama user dikunci
+// atau belum melewati penundaan bertahap sejak kegagalan terakhir
+func (u *userService) checkLoginThrottle(user *dto.User, timeNow int64, auditEntry dto.AuditLog) resterr.APIError {
+	if user.LockedUntil > timeNow {
+		auditEntry.Action = AuditLoginFailed
+		auditEntry.Outcome = AuditDenied
+		auditEntry.Detail = "user sedang dikunci"
+		u.audit.Record(auditEntry)
+		return resterr.NewAPIError(
+			fmt.Sprintf("Akun dikunci karena terlalu banyak percobaan login, coba lagi dalam %d menit", (user.LockedUntil-timeNow)/60+1),
+			http.StatusTooManyRequests, "account_locked", nil)
+	}
+
+	if wait := user.LastFailedLogin + loginDelaySecond(user.FailedLogin) - timeNow; wait > 0 {
+		auditEntry.Action = AuditLoginFailed
+		auditEntry.Outcome = AuditDenied
+		auditEntry.Detail = "percobaan login terlalu cepat"
+		u.audit.Record(auditEntry)
+		return resterr.NewAPIError(
+			fmt.Sprintf("Terlalu banyak percobaan login, coba lagi dalam %d detik", wait),
+			http.StatusTooManyRequests, "too_many_attempts", nil)
+	}
+	return nil
+}
+
+// loginDelaySecond lama penundaan (detik) berdasarkan jumlah kegagalan login, berlipat dua setiap kegagalan
+func loginDelaySecond(failedLogin int) int64 {
+	if failedLogin < loginDelayAfter {
+		return 0
+	}
+	delay := int64(1) << uint(failedLogin-loginDelayAfter)
+	if delay > loginMaxDelaySecond {
+		return loginMaxDelaySecond
+	}
+	return delay
+}
+
+// UnlockUser membuka kunci user dan mereset jumlah kegagalan login oleh admin
+func (u *userService) UnlockUser(actor string, userID string) (*dto.UserResponse, resterr.APIError) {
+	user, err := u.dao.ResetLoginAttempt(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	u.audit.Record(dto.AuditLog{
+		Action:  AuditUserUnlock,
+		Actor:   actor,
+		Target:  userID,
+		Outcome: AuditSuccess,
+	})
+
+	return user, nil
+}
+
+// Refresh menerbitkan access token dan refresh token baru. refresh token lama langsung
+// tidak berlaku, penggunaan ulang refresh token lama akan mencabut seluruh token family
 func (u *userService) Refresh(payload dto.UserRefreshTokenRequest) (*dto.UserRefreshTokenResponse, resterr.APIError) {
+	auditEntry := dto.AuditLog{
+		Action:    AuditTokenRefresh,
+		IP:        payload.IP,
+		UserAgent: payload.UserAgent,
+	}
+
 	token, apiErr := u.jwt.ValidateToken(payload.RefreshToken)
 	if apiErr != nil {
 		return nil, apiErr
@@ -191,6 +494,16 @@ func (u *userService) Refresh(payload dto.UserRefreshTokenRequest) (*dto.UserRef
 		return nil, resterr.NewAPIError("Token tidak valid", http.StatusUnprocessableEntity, "jwt_error", []interface{}{"not a refresh token"})
 	}
 
+	// cek apakah token sudah dicabut melalui logout, token tanpa jti tidak dapat dirotasi
+	auditEntry.Actor = claims.Identity
+	auditEntry.Target = claims.Identity
+	if claims.TokenID == "" || u.token.IsRevoked(*claims) {
+		auditEntry.Outcome = AuditDenied
+		auditEntry.Detail = "refresh token sudah dicabut"
+		u.audit.Record(auditEntry)
+		return nil, resterr.NewUnauthorizedError("Token sudah tidak berlaku, silahkan login ulang")
+	}
+
 	// mendapatkan data terbaru dari user
 	user, apiErr := u.dao.GetUserByID(claims.Identity)
 	if apiErr != nil {
@@ -201,29 +514,141 @@ func (u *userService) Refresh(payload dto.UserRefreshTokenRequest) (*dto.UserRef
 		payload.Limit = 60 * 24 * 30
 	}
 
+	// rotasi refresh token
+	newRefreshTokenID, errID := mjwt.GenerateTokenID()
+	if errID != nil {
+		return nil, resterr.NewInternalServerError("gagal membuat token id", errID)
+	}
+	familyID := claims.FamilyID
+	if familyID == "" {
+		// refresh token yang diterbitkan sebelum rotasi diterapkan, dipindahkan ke family baru
+		familyID, apiErr = u.token.StartFamily(user.ID, newRefreshTokenID)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		if apiErr = u.token.RevokeToken(*claims); apiErr != nil {
+			return nil, apiErr
+		}
+	} else {
+		if apiErr = u.token.RotateFamily(*claims, newRefreshTokenID); apiErr != nil {
+			auditEntry.Outcome = AuditDenied
+			auditEntry.Detail = apiErr.Message()
+			u.audit.Record(auditEntry)
+			return nil, apiErr
+		}
+	}
+
 	AccessClaims := mjwt.CustomClaim{
+		FamilyID:    familyID,
 		Identity:    user.ID,
 		Name:        user.Name,
 		Roles:       user.Roles,
 		Branch:      user.Branch,
+		Branches:    user.Branches,
 		ExtraMinute: time.Duration(payload.Limit),
 		Type:        mjwt.Access,
 		Fresh:       false,
 	}
 
+	RefreshClaims := mjwt.CustomClaim{
+		TokenID:     newRefreshTokenID,
+		FamilyID:    familyID,
+		Identity:    user.ID,
+		Name:        user.Name,
+		Roles:       user.Roles,
+		Branch:      user.Branch,
+		Branches:    user.Branches,
+		ExtraMinute: refreshTokenMinute,
+		Type:        mjwt.Refresh,
+	}
+
 	accessToken, err := u.jwt.GenerateToken(AccessClaims)
 	if err != nil {
 		return nil, err
 	}
+	refreshToken, err := u.jwt.GenerateToken(RefreshClaims)
+	if err != nil {
+		return nil, err
+	}
 
 	userRefreshTokenResponse := dto.UserRefreshTokenResponse{
-		AccessToken: accessToken,
-		Expired:     time.Now().Add(time.Minute * time.Duration(payload.Limit)).Unix(),
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		Expired:      time.Now().Add(time.Minute * time.Duration(payload.Limit)).Unix(),
 	}
 
+	auditEntry.Outcome = AuditSuccess
+	u.audit.Record(auditEntry)
+
 	return &userRefreshTokenResponse, nil
 }
 
+// Logout mencabut access token yang sedang digunakan beserta token family-nya dan refresh token
+// jika dikirimkan, serta menghapus fcm token agar perangkat tidak lagi menerima notifikasi
+func (u *userService) Logout(claims mjwt.CustomClaim, refreshToken string) resterr.APIError {
+	if err := u.token.RevokeToken(claims); err != nil {
+		return err
+	}
+	if err := u.token.RevokeFamily(claims.FamilyID); err != nil {
+		return err
+	}
+
+	if refreshToken != "" {
+		token, apiErr := u.jwt.ValidateToken(refreshToken)
+		if apiErr != nil {
+			return apiErr
+		}
+		refreshClaims, apiErr := u.jwt.ReadToken(token)
+		if apiErr != nil {
+			return apiErr
+		}
+		if refreshClaims.Identity != claims.Identity {
+			return resterr.NewBadRequestError("Refresh token bukan milik user yang sedang login")
+		}
+		if err := u.token.RevokeToken(*refreshClaims); err != nil {
+			return err
+		}
+		if refreshClaims.FamilyID != claims.FamilyID {
+			if err := u.token.RevokeFamily(refreshClaims.FamilyID); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := u.dao.EditFcm(claims.Identity, ""); err != nil {
+		return err
+	}
+
+	u.audit.Record(dto.AuditLog{
+		Action:  AuditLogout,
+		Actor:   claims.Identity,
+		Target:  claims.Identity,
+		Outcome: AuditSuccess,
+	})
+
+	return nil
+}
+
+// RevokeAllTokens mencabut seluruh sesi user (access dan refresh token) dan menghapus fcm token
+func (u *userService) RevokeAllTokens(actor string, userID string) resterr.APIError {
+	if err := u.token.RevokeUser(userID); err != nil {
+		return err
+	}
+
+	if _, err := u.dao.EditFcm(userID, ""); err != nil {
+		return err
+	}
+
+	u.audit.Record(dto.AuditLog{
+		Action:  AuditTokenRevokeAll,
+		Actor:   actor,
+		Target:  userID,
+		Outcome: AuditSuccess,
+	})
+
+	return nil
+}
+
 // PutAvatar memasukkan lokasi file (path) ke dalam database user
 func (u *userService) PutAvatar(userID string, fileLocation string) (*dto.UserResponse, resterr.APIError) {
 	user, err := u.dao.PutAvatar(userID, fileLocation)
@@ -249,30 +674,49 @@ func (u *userService) ChangePassword(data dto.UserChangePasswordRequest) resterr
 		return resterr.NewBadRequestError("Gagal mengganti password, password salah!")
 	}
 
-	newPasswordHash, err := u.crypto.GenerateHash(data.NewPassword)
-	if err != nil {
+	if err := u.setPassword(userResult, data.NewPassword, false); err != nil {
 		return err
 	}
-	data.NewPassword = newPasswordHash
 
-	_ = u.dao.ChangePassword(data)
+	u.audit.Record(dto.AuditLog{
+		Action:  AuditPasswordChange,
+		Actor:   userResult.ID,
+		Target:  userResult.ID,
+		Outcome: AuditSuccess,
+	})
 
 	return nil
 }
 
-// ResetPassword . inputan password berada di level handler
-// hanya memproses field newPassword, mengabaikan field password
-func (u *userService) ResetPassword(data dto.UserChangePasswordRequest) resterr.APIError {
-	newPasswordHash, err := u.crypto.GenerateHash(data.NewPassword)
+// ResetPassword mengganti password user dengan password sementara oleh admin.
+// user wajib mengganti password tersebut pada login berikutnya dan seluruh sesinya dicabut
+func (u *userService) ResetPassword(actor string, userID string) (*dto.UserResetPasswordResponse, resterr.APIError) {
+	user, err := u.dao.GetUserByIDWithPassword(userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	data.NewPassword = newPasswordHash
 
-	err = u.dao.ChangePassword(data)
-	if err != nil {
-		return err
+	temporaryPassword, errGen := generateTemporaryPassword()
+	if errGen != nil {
+		return nil, resterr.NewInternalServerError("gagal membuat password sementara", errGen)
 	}
 
-	return nil
+	if err := u.setPassword(user, temporaryPassword, true); err != nil {
+		return nil, err
+	}
+	if err := u.token.RevokeUser(user.ID); err != nil {
+		return nil, err
+	}
+
+	u.audit.Record(dto.AuditLog{
+		Action:  AuditPasswordAdminReset,
+		Actor:   actor,
+		Target:  user.ID,
+		Outcome: AuditSuccess,
+	})
+
+	return &dto.UserResetPasswordResponse{
+		ID:                user.ID,
+		TemporaryPassword: temporaryPassword,
+	}, nil
 }
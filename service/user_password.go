@@ -0,0 +1,242 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"tilank/config"
+	"tilank/dto"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+	"tilank/worker"
+	"time"
+)
+
+const (
+	// passwordResetMinute masa berlaku token reset password
+	passwordResetMinute = 30
+	// passwordResetInterval jarak minimum (detik) antar permintaan reset password untuk user yang sama
+	passwordResetInterval = 120
+	// envPasswordResetURL alamat halaman reset password pada aplikasi client, token ditambahkan sebagai query
+	envPasswordResetURL = "PASSWORD_RESET_URL"
+	// passwordChangeTokenMinute masa berlaku token sementara untuk penggantian password wajib pada saat login
+	passwordChangeTokenMinute = 10
+)
+
+// ForgotPassword mengirim token reset password ke email user. hasil selalu sukses meskipun user
+// tidak ditemukan agar endpoint tidak dapat digunakan untuk mencari ID user yang terdaftar
+func (u *userService) ForgotPassword(input dto.ForgotPasswordRequest) resterr.APIError {
+	auditEntry := dto.AuditLog{
+		Action:    AuditPasswordResetRequest,
+		Actor:     input.ID,
+		Target:    input.ID,
+		IP:        input.IP,
+		UserAgent: input.UserAgent,
+	}
+
+	user, apiErr := u.dao.GetUserByID(input.ID)
+	if apiErr != nil {
+		if apiErr.Status() == http.StatusNotFound {
+			auditEntry.Outcome = AuditFailure
+			auditEntry.Detail = "user tidak ditemukan"
+			u.audit.Record(auditEntry)
+			return nil
+		}
+		return apiErr
+	}
+	if user.Deactivated {
+		auditEntry.Outcome = AuditDenied
+		auditEntry.Detail = "user dinonaktifkan"
+		u.audit.Record(auditEntry)
+		return nil
+	}
+	if user.Email == "" {
+		auditEntry.Outcome = AuditFailure
+		auditEntry.Detail = "user tidak memiliki email"
+		u.audit.Record(auditEntry)
+		return nil
+	}
+
+	timeNow := time.Now().Unix()
+	lastToken, apiErr := u.passwordDao.GetLastResetToken(user.ID)
+	if apiErr != nil {
+		return apiErr
+	}
+	if lastToken != nil && timeNow-lastToken.CreatedAt < passwordResetInterval {
+		auditEntry.Outcome = AuditDenied
+		auditEntry.Detail = "permintaan terlalu cepat"
+		u.audit.Record(auditEntry)
+		return nil
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return resterr.NewInternalServerError("gagal membuat token reset password", err)
+	}
+	token := hex.EncodeToString(b)
+
+	// hanya token terbaru yang berlaku
+	if apiErr := u.passwordDao.DeleteResetTokenByUser(user.ID); apiErr != nil {
+		return apiErr
+	}
+	if apiErr := u.passwordDao.InsertResetToken(dto.PasswordResetToken{
+		ID:        sha256Hex(token),
+		UserID:    user.ID,
+		CreatedAt: timeNow,
+		Exp:       timeNow + passwordResetMinute*60,
+	}); apiErr != nil {
+		return apiErr
+	}
+
+	worker.RegSendEmail(&worker.MailInfo{
+		ToEmail: user.Email,
+		Subject: "Reset Password TILANK",
+		Body:    passwordResetEmailBody(user.Name, token),
+	})
+
+	auditEntry.Outcome = AuditSuccess
+	u.audit.Record(auditEntry)
+
+	return nil
+}
+
+// passwordResetEmailBody menyusun isi email reset password, nama dan alamat reset di-escape sebelum masuk ke html
+func passwordResetEmailBody(name string, token string) string {
+	action := fmt.Sprintf("Token reset password anda: <b>%s</b>", html.EscapeString(token))
+	if resetURL := config.EnvString(envPasswordResetURL); resetURL != "" {
+		link := fmt.Sprintf("%s?token=%s", resetURL, url.QueryEscape(token))
+		action = fmt.Sprintf("Silahkan buka tautan berikut untuk mengganti password: <a href=\"%s\">Reset Password</a>", html.EscapeString(link))
+	}
+
+	return fmt.Sprintf("Halo %s,<br>kami menerima permintaan reset password untuk akun anda. %s<br>"+
+		"Token berlaku selama %d menit dan hanya dapat digunakan sekali. "+
+		"Abaikan email ini jika anda tidak merasa melakukan permintaan.<br>Terimakasih.", html.EscapeString(name), action, passwordResetMinute)
+}
+
+// ResetPasswordWithToken mengganti password menggunakan token dari email, seluruh sesi user dicabut.
+// password baru divalidasi sebelum token ditandai terpakai agar password yang ditolak tidak menghanguskan token
+func (u *userService) ResetPasswordWithToken(input dto.ResetPasswordRequest) resterr.APIError {
+	if apiErr := passwordPolicy.Validate(input.NewPassword); apiErr != nil {
+		return apiErr
+	}
+
+	tokenHash := sha256Hex(input.Token)
+	failedAudit := dto.AuditLog{
+		Action:    AuditPasswordReset,
+		IP:        input.IP,
+		UserAgent: input.UserAgent,
+		Outcome:   AuditFailure,
+		Detail:    "token tidak valid",
+	}
+	resetToken, apiErr := u.passwordDao.GetResetToken(tokenHash, time.Now().Unix())
+	if apiErr != nil {
+		u.audit.Record(failedAudit)
+		return apiErr
+	}
+
+	user, apiErr := u.dao.GetUserByIDWithPassword(resetToken.UserID)
+	if apiErr != nil {
+		return apiErr
+	}
+	if apiErr := u.checkNewPassword(user, input.NewPassword, false); apiErr != nil {
+		return apiErr
+	}
+
+	// token ditandai terpakai secara atomik, request paralel dengan token yang sama hanya berhasil sekali
+	if _, apiErr := u.passwordDao.UseResetToken(tokenHash, time.Now().Unix()); apiErr != nil {
+		u.audit.Record(failedAudit)
+		return apiErr
+	}
+	if apiErr := u.storePassword(user, input.NewPassword, false); apiErr != nil {
+		return apiErr
+	}
+
+	if apiErr := u.passwordDao.DeleteResetTokenByUser(resetToken.UserID); apiErr != nil {
+		return apiErr
+	}
+	if _, apiErr := u.dao.ResetLoginAttempt(resetToken.UserID); apiErr != nil {
+		return apiErr
+	}
+	if apiErr := u.token.RevokeUser(resetToken.UserID); apiErr != nil {
+		return apiErr
+	}
+
+	u.audit.Record(dto.AuditLog{
+		Action:    AuditPasswordReset,
+		Actor:     resetToken.UserID,
+		Target:    resetToken.UserID,
+		IP:        input.IP,
+		UserAgent: input.UserAgent,
+		Outcome:   AuditSuccess,
+	})
+
+	return nil
+}
+
+// passwordChangeChallenge menerbitkan token sementara untuk mengganti password sebagai pengganti access token
+func (u *userService) passwordChangeChallenge(user *dto.User) (*dto.UserLoginResponse, resterr.APIError) {
+	passwordChangeToken, err := u.jwt.GenerateToken(mjwt.CustomClaim{
+		Identity:    user.ID,
+		Name:        user.Name,
+		Roles:       []string{},
+		Branch:      user.Branch,
+		ExtraMinute: passwordChangeTokenMinute,
+		Type:        mjwt.PasswordChange,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.UserLoginResponse{
+		ID:                     user.ID,
+		Name:                   user.Name,
+		Branch:                 user.Branch,
+		Email:                  user.Email,
+		Roles:                  user.Roles,
+		Avatar:                 user.Avatar,
+		PasswordChangeRequired: true,
+		PasswordChangeToken:    passwordChangeToken,
+		Expired:                time.Now().Add(passwordChangeTokenMinute * time.Minute).Unix(),
+	}, nil
+}
+
+// LoginChangePassword mengganti password yang wajib diganti pada saat login lalu menerbitkan token,
+// token ganti password hanya diterbitkan completeLogin setelah langkah two factor terlewati
+func (u *userService) LoginChangePassword(input dto.LoginChangePasswordRequest) (*dto.UserLoginResponse, resterr.APIError) {
+	claims, apiErr := u.readChallengeToken(input.PasswordChangeToken, mjwt.PasswordChange)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	user, apiErr := u.dao.GetUserByIDWithPassword(claims.Identity)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := u.setPassword(user, input.NewPassword, false); apiErr != nil {
+		return nil, apiErr
+	}
+
+	// token sementara hanya dapat digunakan sekali
+	if apiErr := u.token.RevokeToken(*claims); apiErr != nil {
+		return nil, apiErr
+	}
+
+	u.audit.Record(dto.AuditLog{
+		Action:    AuditPasswordChange,
+		Actor:     user.ID,
+		Target:    user.ID,
+		IP:        input.IP,
+		UserAgent: input.UserAgent,
+		Outcome:   AuditSuccess,
+		Detail:    "penggantian password wajib pada saat login",
+	})
+
+	return u.issueLoginToken(user, input.Limit, dto.AuditLog{
+		IP:        input.IP,
+		UserAgent: input.UserAgent,
+		Detail:    "penggantian password wajib",
+	})
+}
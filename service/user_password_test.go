@@ -0,0 +1,21 @@
+package service
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordResetEmailBodyEscape(t *testing.T) {
+	defer os.Unsetenv(envPasswordResetURL)
+
+	body := passwordResetEmailBody("<i>Budi</i>", "abc123")
+	assert.Contains(t, body, "<b>abc123</b>")
+	assert.Contains(t, body, "&lt;i&gt;Budi&lt;/i&gt;")
+
+	os.Setenv(envPasswordResetURL, " https://tilank.example/reset\"><script>x</script> ")
+	body = passwordResetEmailBody("Budi", "abc123")
+	assert.Contains(t, body, `href="https://tilank.example/reset&#34;&gt;&lt;script&gt;x&lt;/script&gt;?token=abc123"`)
+	assert.NotContains(t, body, "<script>")
+}
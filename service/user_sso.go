@@ -0,0 +1,159 @@
+package service
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"tilank/clients/oidc"
+	"tilank/config"
+	"tilank/dto"
+	"tilank/utils/logger"
+	"tilank/utils/rest_err"
+	"tilank/utils/sfunc"
+	"time"
+)
+
+const (
+	// ssoStateMinute batas waktu user menyelesaikan login pada halaman identity provider
+	ssoStateMinute = 10
+)
+
+// SsoLogin memulai login OpenID Connect authorization code dengan PKCE.
+// state, nonce dan code verifier disimpan di server, client hanya menerima url login identity provider.
+// Binding dikirim handler sebagai cookie HttpOnly sehingga state hanya dapat diselesaikan browser yang memulai login
+func (u *userService) SsoLogin(limit int) (*dto.SsoLoginResponse, resterr.APIError) {
+	if !u.sso.Enabled() {
+		return nil, resterr.NewNotFoundError("Login sso tidak diaktifkan")
+	}
+
+	var values [4]string
+	for i := range values {
+		value, err := oidc.GenerateRandom()
+		if err != nil {
+			return nil, resterr.NewInternalServerError("gagal membuat state sso", err)
+		}
+		values[i] = value
+	}
+	state, nonce, codeVerifier, binding := values[0], values[1], values[2], values[3]
+
+	authURL, err := u.sso.AuthCodeURL(state, nonce, oidc.CodeChallenge(codeVerifier))
+	if err != nil {
+		logger.Error("gagal membuat url login sso (SsoLogin)", err)
+		return nil, resterr.NewAPIError("Identity provider tidak dapat dihubungi", http.StatusBadGateway, "sso_error", nil)
+	}
+
+	timeNow := time.Now().Unix()
+	exp := timeNow + ssoStateMinute*60
+	if apiErr := u.ssoDao.InsertState(dto.SsoState{
+		ID:           sha256Hex(state),
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		BindingHash:  sha256Hex(binding),
+		Limit:        limit,
+		CreatedAt:    timeNow,
+		Exp:          exp,
+	}); apiErr != nil {
+		return nil, apiErr
+	}
+
+	return &dto.SsoLoginResponse{
+		AuthorizationURL: authURL,
+		State:            state,
+		Expired:          exp,
+		Binding:          binding,
+	}, nil
+}
+
+// SsoCallback menyelesaikan login sso: menukar code dengan id token, memetakan user berdasarkan email,
+// menyinkronkan role dan cabang dari claim lalu melanjutkan langkah login yang sama dengan Login
+// (two factor dan wajib ganti password) sebelum menerbitkan token aplikasi
+func (u *userService) SsoCallback(input dto.SsoCallbackRequest) (*dto.UserLoginResponse, resterr.APIError) {
+	if !u.sso.Enabled() {
+		return nil, resterr.NewNotFoundError("Login sso tidak diaktifkan")
+	}
+
+	auditEntry := dto.AuditLog{
+		Action:    AuditLoginSso,
+		IP:        input.IP,
+		UserAgent: input.UserAgent,
+	}
+	deny := func(actor string, detail string, apiErr resterr.APIError) (*dto.UserLoginResponse, resterr.APIError) {
+		auditEntry.Actor = actor
+		auditEntry.Target = actor
+		auditEntry.Outcome = AuditDenied
+		auditEntry.Detail = detail
+		u.audit.Record(auditEntry)
+		return nil, apiErr
+	}
+
+	state, apiErr := u.ssoDao.UseState(sha256Hex(input.State), time.Now().Unix())
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	// state yang diselesaikan dari browser lain (login csrf) ditolak, state tetap dianggap terpakai
+	if subtle.ConstantTimeCompare([]byte(state.BindingHash), []byte(sha256Hex(input.Binding))) != 1 {
+		return deny("", "state sso tidak terikat dengan browser",
+			resterr.NewUnauthorizedError("Login sso gagal, silahkan ulangi"))
+	}
+
+	identity, err := u.sso.Exchange(input.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		logger.Info(fmt.Sprintf("login sso gagal: %s", err.Error()))
+		return deny("", err.Error(), resterr.NewUnauthorizedError("Login sso gagal, silahkan ulangi"))
+	}
+	if identity.Email == "" || !identity.EmailVerified {
+		return deny(identity.Email, "email tidak tersedia atau belum diverifikasi",
+			resterr.NewUnauthorizedError("Email akun sso belum diverifikasi"))
+	}
+
+	user, apiErr := u.dao.GetUserByEmail(identity.Email)
+	if apiErr != nil {
+		return deny(identity.Email, "email tidak terdaftar", apiErr)
+	}
+	if user.Deactivated {
+		return deny(user.ID, "user dinonaktifkan",
+			resterr.NewAPIError("Akun telah dinonaktifkan, hubungi admin", http.StatusForbidden, "account_deactivated", nil))
+	}
+	// subject yang berbeda berarti email telah dipindahkan ke akun identity provider lain
+	if user.SsoSubject != "" && user.SsoSubject != identity.Subject {
+		return deny(user.ID, "subject identity provider berbeda",
+			resterr.NewAPIError("Akun sso tidak sesuai dengan user, hubungi admin", http.StatusForbidden, "sso_subject_mismatch", nil))
+	}
+
+	sync := dto.UserSsoSync{Subject: identity.Subject}
+	if identity.HasRoles {
+		roles := []string{}
+		for _, role := range identity.Roles {
+			role = strings.ToUpper(role)
+			if sfunc.InSlice(role, config.GetRolesAvailable()) && !sfunc.InSlice(role, roles) {
+				roles = append(roles, role)
+			}
+		}
+		if len(roles) == 0 {
+			return deny(user.ID, "tidak memiliki role aplikasi",
+				resterr.NewAPIError("Akun sso tidak memiliki role aplikasi", http.StatusForbidden, "no_role", nil))
+		}
+		sync.Roles = roles
+	}
+	if identity.HasBranch {
+		branch := identity.Branch
+		sync.Branch = &branch
+	}
+	if identity.HasBranches {
+		sync.Branches = append([]string{}, identity.Branches...)
+	}
+
+	user, apiErr = u.dao.SyncSsoUser(user.ID, sync)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	auditEntry.Detail = ""
+	return u.completeLogin(user, false, state.Limit, auditEntry)
+}
+
+// CleanupSsoState menghapus state login sso yang tidak diselesaikan
+func (u *userService) CleanupSsoState() (int64, resterr.APIError) {
+	return u.ssoDao.DeleteExpiredState(time.Now().Unix())
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"tilank/dto"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+	"time"
+)
+
+// streamTicketMinute masa berlaku tiket untuk membuka koneksi server-sent events
+const streamTicketMinute = 1
+
+// StreamTicket menerbitkan tiket singkat dari access token untuk membuka koneksi server-sent events.
+// tiket membawa jti dan family access token sehingga logout dan pencabutan token ikut menutup koneksi
+func (u *userService) StreamTicket(claims mjwt.CustomClaim) (*dto.StreamTicketResponse, resterr.APIError) {
+	ticket, err := u.jwt.GenerateToken(mjwt.CustomClaim{
+		TokenID:     claims.TokenID,
+		FamilyID:    claims.FamilyID,
+		Identity:    claims.Identity,
+		Name:        claims.Name,
+		Roles:       claims.Roles,
+		Branch:      claims.Branch,
+		Branches:    claims.Branches,
+		ExtraMinute: streamTicketMinute,
+		Type:        mjwt.StreamTicket,
+		AccessExp:   claims.Exp,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.StreamTicketResponse{
+		Ticket:  ticket,
+		Expired: time.Now().Add(streamTicketMinute * time.Minute).Unix(),
+	}, nil
+}
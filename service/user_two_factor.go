@@ -0,0 +1,307 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"tilank/config"
+	"tilank/dto"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+	"tilank/utils/sfunc"
+	"tilank/utils/totp"
+	"time"
+)
+
+const (
+	// twoFactorIssuer nama yang tampil pada aplikasi authenticator
+	twoFactorIssuer = "TILANK"
+	// twoFactorTokenMinute masa berlaku token sementara antara langkah password dan kode TOTP
+	twoFactorTokenMinute = 5
+	// recoveryCodeCount jumlah recovery code yang dibuat ketika two factor diaktifkan
+	recoveryCodeCount = 10
+)
+
+// requiresTwoFactor return true jika salah satu role user mewajibkan two factor
+func requiresTwoFactor(roles []string) bool {
+	for _, role := range config.GetRolesRequireTwoFactor() {
+		if sfunc.InSlice(role, roles) {
+			return true
+		}
+	}
+	return false
+}
+
+// twoFactorChallenge menerbitkan token sementara sebagai pengganti access token.
+// user yang belum mendaftarkan authenticator diarahkan ke setup two factor dengan token bertipe
+// TwoFactorSetup, token TwoFactor tidak dapat digunakan untuk setup sehingga secret tidak dapat diganti
+// hanya dengan password
+func (u *userService) twoFactorChallenge(user *dto.User) (*dto.UserLoginResponse, resterr.APIError) {
+	tokenType := mjwt.TwoFactor
+	if !user.TwoFactorEnabled {
+		tokenType = mjwt.TwoFactorSetup
+	}
+	twoFactorToken, err := u.jwt.GenerateToken(mjwt.CustomClaim{
+		Identity:    user.ID,
+		Name:        user.Name,
+		Roles:       []string{},
+		Branch:      user.Branch,
+		ExtraMinute: twoFactorTokenMinute,
+		Type:        tokenType,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.UserLoginResponse{
+		ID:                     user.ID,
+		Name:                   user.Name,
+		Branch:                 user.Branch,
+		Email:                  user.Email,
+		Roles:                  user.Roles,
+		Avatar:                 user.Avatar,
+		TwoFactorRequired:      user.TwoFactorEnabled,
+		TwoFactorSetupRequired: !user.TwoFactorEnabled,
+		TwoFactorToken:         twoFactorToken,
+		Expired:                time.Now().Add(twoFactorTokenMinute * time.Minute).Unix(),
+	}, nil
+}
+
+// readChallengeToken memvalidasi token sementara hasil login (two factor atau ganti password)
+func (u *userService) readChallengeToken(challengeToken string, tokenType int) (*mjwt.CustomClaim, resterr.APIError) {
+	token, apiErr := u.jwt.ValidateToken(challengeToken)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	claims, apiErr := u.jwt.ReadToken(token)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if claims.Type != tokenType {
+		return nil, resterr.NewAPIError("Token tidak valid", http.StatusUnprocessableEntity, "jwt_error", []interface{}{"wrong token type"})
+	}
+	if u.token.IsRevoked(*claims) {
+		return nil, resterr.NewUnauthorizedError("Token sudah tidak berlaku, silahkan login ulang")
+	}
+
+	return claims, nil
+}
+
+// VerifyTwoFactor langkah kedua login, menukar token sementara dan kode TOTP (atau recovery code)
+// dengan access token dan refresh token. kegagalan dihitung dan ditunda bertahap bersama kegagalan password
+func (u *userService) VerifyTwoFactor(input dto.TwoFactorVerifyRequest) (*dto.UserLoginResponse, resterr.APIError) {
+	claims, apiErr := u.readChallengeToken(input.TwoFactorToken, mjwt.TwoFactor)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	user, apiErr := u.dao.GetUserByIDWithPassword(claims.Identity)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if !user.TwoFactorEnabled {
+		return nil, resterr.NewBadRequestError("Two factor belum diaktifkan, lakukan setup terlebih dahulu")
+	}
+
+	auditEntry := dto.AuditLog{
+		Actor:     user.ID,
+		Target:    user.ID,
+		IP:        input.IP,
+		UserAgent: input.UserAgent,
+	}
+
+	timeNow := time.Now()
+	if apiErr := u.checkLoginThrottle(user, timeNow.Unix(), auditEntry); apiErr != nil {
+		return nil, apiErr
+	}
+
+	code := strings.TrimSpace(input.Code)
+	if step, ok := totp.Validate(user.TwoFactorSecret, code, timeNow); ok {
+		if apiErr := u.dao.UseTwoFactorStep(user.ID, step); apiErr != nil {
+			u.recordFailedLogin(user.ID, timeNow.Unix(), auditEntry)
+			return nil, apiErr
+		}
+	} else {
+		if apiErr := u.dao.UseRecoveryCode(user.ID, hashRecoveryCode(code)); apiErr != nil {
+			u.recordFailedLogin(user.ID, timeNow.Unix(), auditEntry)
+			return nil, resterr.NewUnauthorizedError("Kode two factor tidak valid")
+		}
+		auditEntry.Action = AuditRecoveryCodeUsed
+		auditEntry.Outcome = AuditSuccess
+		auditEntry.Detail = "login menggunakan recovery code"
+		u.audit.Record(auditEntry)
+	}
+
+	// token sementara hanya dapat digunakan sekali
+	if apiErr := u.token.RevokeToken(*claims); apiErr != nil {
+		return nil, apiErr
+	}
+	if user.FailedLogin != 0 {
+		if _, apiErr := u.dao.ResetLoginAttempt(user.ID); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+
+	auditEntry.Action = ""
+	auditEntry.Detail = "two factor"
+	return u.completeLogin(user, true, input.Limit, auditEntry)
+}
+
+// SetupTwoFactor membuat secret baru yang belum aktif hingga ActivateTwoFactor berhasil
+func (u *userService) SetupTwoFactor(userID string) (*dto.TwoFactorSetupResponse, resterr.APIError) {
+	user, apiErr := u.dao.GetUserByID(userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if user.TwoFactorEnabled {
+		return nil, resterr.NewBadRequestError("Two factor sudah aktif, hubungi admin untuk reset two factor")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, resterr.NewInternalServerError("gagal membuat secret two factor", err)
+	}
+	if apiErr := u.dao.SetTwoFactorPending(user.ID, secret); apiErr != nil {
+		return nil, apiErr
+	}
+
+	uri := totp.ProvisioningURI(twoFactorIssuer, user.ID, secret)
+	qrCode, err := totp.QRCode(uri)
+	if err != nil {
+		return nil, resterr.NewInternalServerError("gagal membuat qr code two factor", err)
+	}
+
+	return &dto.TwoFactorSetupResponse{
+		Secret: secret,
+		URI:    uri,
+		QRCode: qrCode,
+	}, nil
+}
+
+// ActivateTwoFactor memverifikasi kode dari secret pending, mengaktifkan two factor
+// dan mengembalikan recovery code yang hanya ditampilkan sekali
+func (u *userService) ActivateTwoFactor(userID string, code string) ([]string, resterr.APIError) {
+	user, apiErr := u.dao.GetUserByIDWithPassword(userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if user.TwoFactorEnabled {
+		return nil, resterr.NewBadRequestError("Two factor sudah aktif, hubungi admin untuk reset two factor")
+	}
+	if user.TwoFactorPendingSecret == "" {
+		return nil, resterr.NewBadRequestError("Lakukan setup two factor terlebih dahulu")
+	}
+
+	step, ok := totp.Validate(user.TwoFactorPendingSecret, code, time.Now())
+	if !ok {
+		return nil, resterr.NewBadRequestError("Kode two factor tidak valid")
+	}
+
+	recoveryCodes, recoveryHashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, resterr.NewInternalServerError("gagal membuat recovery code", err)
+	}
+
+	if apiErr := u.dao.EnableTwoFactor(user.ID, user.TwoFactorPendingSecret, recoveryHashes, step); apiErr != nil {
+		return nil, apiErr
+	}
+
+	u.audit.Record(dto.AuditLog{
+		Action:  AuditTwoFactorEnabled,
+		Actor:   user.ID,
+		Target:  user.ID,
+		Outcome: AuditSuccess,
+	})
+
+	return recoveryCodes, nil
+}
+
+// LoginSetupTwoFactor setup two factor menggunakan token sementara hasil login,
+// hanya untuk token yang diterbitkan dengan TwoFactorSetupRequired
+func (u *userService) LoginSetupTwoFactor(input dto.TwoFactorTokenRequest) (*dto.TwoFactorSetupResponse, resterr.APIError) {
+	claims, apiErr := u.readChallengeToken(input.TwoFactorToken, mjwt.TwoFactorSetup)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	return u.SetupTwoFactor(claims.Identity)
+}
+
+// LoginActivateTwoFactor aktivasi two factor menggunakan token sementara hasil login,
+// jika berhasil sekaligus menerbitkan access token dan refresh token
+func (u *userService) LoginActivateTwoFactor(input dto.TwoFactorActivateRequest) (*dto.TwoFactorActivateResponse, resterr.APIError) {
+	claims, apiErr := u.readChallengeToken(input.TwoFactorToken, mjwt.TwoFactorSetup)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	recoveryCodes, apiErr := u.ActivateTwoFactor(claims.Identity, input.Code)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := u.token.RevokeToken(*claims); apiErr != nil {
+		return nil, apiErr
+	}
+
+	user, apiErr := u.dao.GetUserByIDWithPassword(claims.Identity)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	login, apiErr := u.completeLogin(user, true, input.Limit, dto.AuditLog{
+		IP:        input.IP,
+		UserAgent: input.UserAgent,
+		Detail:    "aktivasi two factor",
+	})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	return &dto.TwoFactorActivateResponse{
+		RecoveryCodes: recoveryCodes,
+		Login:         login,
+	}, nil
+}
+
+// ResetTwoFactor menghapus two factor user oleh admin (misal perangkat hilang),
+// user dengan role wajib two factor akan diminta setup ulang pada login berikutnya
+func (u *userService) ResetTwoFactor(actor string, userID string) resterr.APIError {
+	if apiErr := u.dao.DisableTwoFactor(userID); apiErr != nil {
+		return apiErr
+	}
+	if apiErr := u.token.RevokeUser(userID); apiErr != nil {
+		return apiErr
+	}
+
+	u.audit.Record(dto.AuditLog{
+		Action:  AuditTwoFactorReset,
+		Actor:   actor,
+		Target:  userID,
+		Outcome: AuditSuccess,
+	})
+
+	return nil
+}
+
+// generateRecoveryCodes membuat recovery code dengan format xxxxx-xxxxx beserta hash-nya
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := hex.EncodeToString(b)
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode recovery code bernilai acak sehingga cukup menggunakan sha256
+func hashRecoveryCode(code string) string {
+	return sha256Hex(strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", "")))
+}
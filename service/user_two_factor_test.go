@@ -0,0 +1,81 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+	"tilank/dao/userdao"
+	"tilank/dto"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTwoFactorUserDao user two factor di memori, codeChecked menandai kode sempat diperiksa
+type fakeTwoFactorUserDao struct {
+	userdao.UserDaoAssumer
+	user        dto.User
+	codeChecked bool
+}
+
+func (f *fakeTwoFactorUserDao) GetUserByIDWithPassword(_ string) (*dto.User, resterr.APIError) {
+	user := f.user
+	return &user, nil
+}
+
+func (f *fakeTwoFactorUserDao) UseTwoFactorStep(_ string, _ int64) resterr.APIError {
+	f.codeChecked = true
+	return nil
+}
+
+func (f *fakeTwoFactorUserDao) UseRecoveryCode(_ string, _ string) resterr.APIError {
+	f.codeChecked = true
+	return resterr.NewUnauthorizedError("recovery code tidak valid")
+}
+
+// fakeChallengeJwt mengembalikan claims yang sama untuk token apapun
+type fakeChallengeJwt struct {
+	mjwt.JWTAssumer
+	claims mjwt.CustomClaim
+}
+
+func (f *fakeChallengeJwt) ValidateToken(_ string) (*jwt.Token, resterr.APIError) {
+	return &jwt.Token{}, nil
+}
+
+func (f *fakeChallengeJwt) ReadToken(_ *jwt.Token) (*mjwt.CustomClaim, resterr.APIError) {
+	claims := f.claims
+	return &claims, nil
+}
+
+func TestVerifyTwoFactorThrottle(t *testing.T) {
+	timeNow := time.Now().Unix()
+	cases := []struct {
+		name   string
+		user   dto.User
+		status string
+	}{
+		{"dikunci", dto.User{ID: "USER", TwoFactorEnabled: true, LockedUntil: timeNow + 600}, "account_locked"},
+		{"penundaan bertahap", dto.User{ID: "USER", TwoFactorEnabled: true, FailedLogin: loginDelayAfter + 2, LastFailedLogin: timeNow}, "too_many_attempts"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			userDao := &fakeTwoFactorUserDao{user: c.user}
+			u := &userService{
+				dao:   userDao,
+				jwt:   &fakeChallengeJwt{claims: mjwt.CustomClaim{Identity: "USER", Type: mjwt.TwoFactor}},
+				token: &TokenService{},
+				audit: NewAuditService(&fakeAuditDao{}),
+			}
+
+			response, apiErr := u.VerifyTwoFactor(dto.TwoFactorVerifyRequest{TwoFactorToken: "token", Code: "123456"})
+			assert.Nil(t, response)
+			assert.NotNil(t, apiErr)
+			assert.Equal(t, http.StatusTooManyRequests, apiErr.Status())
+			assert.Contains(t, apiErr.Error(), c.status)
+			assert.False(t, userDao.codeChecked)
+		})
+	}
+}
@@ -3,13 +3,18 @@ package service
 import (
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
 	"tilank/clients/fcm"
+	"tilank/config"
+	"tilank/dao/driverdao"
+	"tilank/dao/jptdao"
 	"tilank/dao/rulesdao"
 	"tilank/dao/truckdao"
 	"tilank/dao/userdao"
 	"tilank/dao/violationdao"
 	"tilank/dto"
 	"tilank/enum"
+	"tilank/stream"
 	"tilank/utils/logger"
 	"tilank/utils/mjwt"
 	"tilank/utils/pdfgen"
@@ -18,26 +23,59 @@ import (
 	"time"
 )
 
+// ViolationServiceAssumer dipenuhi oleh *ViolationService, digunakan handler agar dapat diganti saat pengujian
+type ViolationServiceAssumer interface {
+	InsertViolation(user mjwt.CustomClaim, input dto.ViolationRequest) (*string, resterr.APIError)
+	EditViolation(user mjwt.CustomClaim, violationID string, input dto.ViolationEditRequest) (*dto.Violation, resterr.APIError)
+	SendToDraftViolation(user mjwt.CustomClaim, violationID string) (*dto.Violation, resterr.APIError)
+	SendToConfirmationViolation(user mjwt.CustomClaim, violationID string) (*dto.Violation, resterr.APIError)
+	ApproveViolation(user mjwt.CustomClaim, violationID string) (*dto.Violation, resterr.APIError)
+	DeleteViolation(user mjwt.CustomClaim, id string) resterr.APIError
+	PutImage(user mjwt.CustomClaim, id string, imagePath string) (*dto.Violation, resterr.APIError)
+	DeleteImage(user mjwt.CustomClaim, id string, imagePath string) (*dto.Violation, resterr.APIError)
+	GetViolationByID(violationID string, branchIfSpecific string) (*dto.Violation, resterr.APIError)
+	FindViolation(filter dto.FilterViolation) (dto.ViolationResponseMinList, resterr.APIError)
+	GeneratePDFViolation(violationID string) (*dto.Violation, resterr.APIError)
+	RestoreViolation(user mjwt.CustomClaim, violationID string) (*dto.Violation, resterr.APIError)
+	GetDraftRetention(branch string) (*dto.DraftRetention, resterr.APIError)
+	EditDraftRetention(user mjwt.CustomClaim, input dto.DraftRetentionRequest) (*dto.DraftRetention, resterr.APIError)
+}
+
 func NewViolationService(violationDao violationdao.ViolationDaoAssumer,
 	truckDao truckdao.TruckDaoAssumer,
+	driverDao driverdao.DriverDaoAssumer,
+	jptDao jptdao.JptDaoAssumer,
 	rulesDao rulesdao.RulesDaoAssumer,
 	userDao userdao.UserDaoAssumer,
-	fcmClient fcm.ClientAssumer) *ViolationService {
+	fcmClient fcm.ClientAssumer,
+	policyService *PolicyService,
+	webhookService *WebhookService,
+	eventHub stream.HubAssumer) *ViolationService {
 	return &ViolationService{
-		vDao: violationDao,
-		tDao: truckDao,
-		rDao: rulesDao,
-		uDao: userDao,
-		fcm:  fcmClient,
+		vDao:    violationDao,
+		tDao:    truckDao,
+		dDao:    driverDao,
+		jDao:    jptDao,
+		rDao:    rulesDao,
+		uDao:    userDao,
+		fcm:     fcmClient,
+		policy:  policyService,
+		webhook: webhookService,
+		hub:     eventHub,
 	}
 }
 
 type ViolationService struct {
-	vDao violationdao.ViolationDaoAssumer
-	tDao truckdao.TruckDaoAssumer
-	rDao rulesdao.RulesDaoAssumer
-	uDao userdao.UserDaoAssumer
-	fcm  fcm.ClientAssumer
+	vDao    violationdao.ViolationDaoAssumer
+	tDao    truckdao.TruckDaoAssumer
+	dDao    driverdao.DriverDaoAssumer
+	jDao    jptdao.JptDaoAssumer
+	rDao    rulesdao.RulesDaoAssumer
+	uDao    userdao.UserDaoAssumer
+	fcm     fcm.ClientAssumer
+	policy  *PolicyService
+	webhook *WebhookService
+	hub     stream.HubAssumer
 }
 
 func (v *ViolationService) InsertViolation(user mjwt.CustomClaim, input dto.ViolationRequest) (*string, resterr.APIError) {
@@ -60,6 +98,12 @@ func (v *ViolationService) InsertViolation(user mjwt.CustomClaim, input dto.Viol
 		return nil, err
 	}
 
+	// mendapatkan sopir jika dicantumkan
+	driver, err := v.getDriver(input.NoLicense, user.Branch)
+	if err != nil {
+		return nil, err
+	}
+
 	// Filling data
 	timeNow := time.Now().Unix()
 	data := dto.Violation{
@@ -79,12 +123,20 @@ func (v *ViolationService) InsertViolation(user mjwt.CustomClaim, input dto.Viol
 		NoIdentity:      truck.NoIdentity,
 		NoPol:           truck.NoPol,
 		Mark:            truck.Mark,
+		OwnerID:         truck.OwnerID,
 		Owner:           truck.Owner,
 		TypeViolation:   input.TypeViolation,
 		DetailViolation: input.DetailViolation,
 		TimeViolation:   input.TimeViolation,
 		Location:        input.Location,
 		Images:          []string{},
+		DriverID:        driver.ID,
+		NoLicense:       driver.NoLicense,
+		DriverName:      driver.Name,
+		DriverNViol:     driver.Score,
+	}
+	if state == enum.StNeedApprove {
+		data.SubmittedAt = timeNow
 	}
 
 	// DB
@@ -93,9 +145,41 @@ func (v *ViolationService) InsertViolation(user mjwt.CustomClaim, input dto.Viol
 		return nil, resterr.NewBadRequestError(err.Message())
 	}
 
+	v.hub.Publish(config.EventViolationCreated, data.Branch, data)
+
 	return insertedID, nil
 }
 
+// violationDriver data sopir yang disalin ke dokumen pelanggaran, kosong jika sopir tidak dicantumkan
+type violationDriver struct {
+	ID        string
+	NoLicense string
+	Name      string
+	Score     int
+}
+
+// getDriver mendapatkan sopir aktif berdasarkan nomor SIM pada cabang pelanggaran
+func (v *ViolationService) getDriver(noLicense string, branch string) (violationDriver, resterr.APIError) {
+	if noLicense == "" {
+		return violationDriver{}, nil
+	}
+
+	driver, err := v.dDao.GetDriverByLicense(noLicense, branch)
+	if err != nil {
+		return violationDriver{}, err
+	}
+	if driver.Deleted {
+		return violationDriver{}, resterr.NewBadRequestError(fmt.Sprintf("Sopir dengan nomor SIM %s sudah tidak aktif", driver.NoLicense))
+	}
+
+	return violationDriver{
+		ID:        driver.ID.Hex(),
+		NoLicense: driver.NoLicense,
+		Name:      driver.Name,
+		Score:     driver.Score,
+	}, nil
+}
+
 func (v *ViolationService) EditViolation(user mjwt.CustomClaim, violationID string, input dto.ViolationEditRequest) (*dto.Violation, resterr.APIError) {
 	oid, errT := primitive.ObjectIDFromHex(violationID)
 	if errT != nil {
@@ -107,6 +191,11 @@ func (v *ViolationService) EditViolation(user mjwt.CustomClaim, violationID stri
 		return nil, err
 	}
 
+	driver, err := v.getDriver(input.NoLicense, user.Branch)
+	if err != nil {
+		return nil, err
+	}
+
 	if input.TimeViolation == 0 {
 		input.TimeViolation = time.Now().Unix()
 	}
@@ -126,11 +215,15 @@ func (v *ViolationService) EditViolation(user mjwt.CustomClaim, violationID stri
 		NoIdentity:      truck.NoIdentity,
 		NoPol:           truck.NoPol,
 		Mark:            truck.Mark,
+		OwnerID:         truck.OwnerID,
 		Owner:           truck.Owner,
 		TypeViolation:   input.TypeViolation,
 		DetailViolation: input.DetailViolation,
 		TimeViolation:   input.TimeViolation,
 		Location:        input.Location,
+		DriverID:        driver.ID,
+		NoLicense:       driver.NoLicense,
+		DriverName:      driver.Name,
 	}
 
 	// DB
@@ -173,6 +266,7 @@ func (v *ViolationService) SendToDraftViolation(user mjwt.CustomClaim, violation
 		ApprovedByID: "",
 		State:        enum.StDraft,
 		NViol:        violation.NViol,
+		DriverNViol:  violation.DriverNViol,
 	}
 
 	// DB
@@ -181,6 +275,8 @@ func (v *ViolationService) SendToDraftViolation(user mjwt.CustomClaim, violation
 		return nil, err
 	}
 
+	v.hub.Publish(config.EventViolationStateChanged, violationDrafted.Branch, violationDrafted)
+
 	return violationDrafted, nil
 }
 
@@ -200,6 +296,9 @@ func (v *ViolationService) SendToConfirmationViolation(user mjwt.CustomClaim, vi
 		apiErr := resterr.NewBadRequestError("status dokumen tidak dapat diubah ke NeedConfirm")
 		return nil, apiErr
 	}
+	if violation.Archived {
+		return nil, resterr.NewBadRequestError("draft diarsipkan, pulihkan draft terlebih dahulu")
+	}
 
 	// Filling data
 	timeNow := time.Now().Unix()
@@ -214,6 +313,7 @@ func (v *ViolationService) SendToConfirmationViolation(user mjwt.CustomClaim, vi
 		ApprovedByID: "",
 		State:        enum.StNeedApprove,
 		NViol:        violation.NViol,
+		DriverNViol:  violation.DriverNViol,
 	}
 
 	// DB
@@ -222,6 +322,8 @@ func (v *ViolationService) SendToConfirmationViolation(user mjwt.CustomClaim, vi
 		return nil, err
 	}
 
+	v.hub.Publish(config.EventViolationStateChanged, violationReady.Branch, violationReady)
+
 	go func() {
 		users, err := v.uDao.FindUserHSSE(user.Branch)
 		if err != nil {
@@ -275,6 +377,19 @@ func (v *ViolationService) ApproveViolation(user mjwt.CustomClaim, violationID s
 		return nil, err
 	}
 
+	// 3b mendapatkan data sopir jika dicantumkan, skor sopir terpisah dari truck
+	var driver *dto.Driver
+	if violation.DriverID != "" {
+		driverOid, errT := primitive.ObjectIDFromHex(violation.DriverID)
+		if errT != nil {
+			return nil, resterr.NewBadRequestError("ID sopir pada dokumen tidak valid")
+		}
+		driver, err = v.dDao.GetDriverByID(driverOid, violation.Branch)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// 4 Filling data
 	timeNow := time.Now().Unix()
 	data := dto.ViolationConfirm{
@@ -288,6 +403,11 @@ func (v *ViolationService) ApproveViolation(user mjwt.CustomClaim, violationID s
 		ApprovedByID: user.Identity,
 		State:        enum.StApproved,
 		NViol:        truck.Score + 1,
+		JptID:        truck.JptID,
+		JptName:      truck.JptName,
+	}
+	if driver != nil {
+		data.DriverNViol = driver.Score + 1
 	}
 
 	// 5 DB
@@ -296,26 +416,12 @@ func (v *ViolationService) ApproveViolation(user mjwt.CustomClaim, violationID s
 		return nil, err
 	}
 
-	payloadTruck := dto.TruckScoreEdit{
-		ID:             truck.ID,
-		Score:          truck.Score + 1,
-		ResetScoreDate: 0,
-		Blocked:        false,
-		BlockStart:     0,
-		BlockEnd:       0,
-	}
-
 	// 6 mendapatkan rules block truck
-	rules, _ := v.rDao.GetRulesByScore(payloadTruck.Score, truck.Branch)
+	rules, _ := v.rDao.GetRulesByScore(truck.Score+1, truck.Branch, config.RulesSubjectTruck)
 	if rules != nil {
 		timeNow = time.Now().Unix()
-		// jika ditemukan role pemblokiran
-		if rules.BlockTime != 0 {
-			payloadTruck.Blocked = true
-			payloadTruck.BlockStart = timeNow
-			payloadTruck.BlockEnd = timeNow + rules.BlockTime
-		}
 	}
+	payloadTruck := truckScorePayload(truck, rules, timeNow)
 
 	// 7 menambahkan status di truck
 	truckUpdated, err := v.tDao.ChangeScore(payloadTruck)
@@ -328,16 +434,86 @@ func (v *ViolationService) ApproveViolation(user mjwt.CustomClaim, violationID s
 		return nil, err
 	}
 
-	// 8 membuat pdf
+	recordTruckHistory(v.tDao, dto.TruckHistory{
+		TruckID:     truckUpdated.ID.Hex(),
+		Branch:      truckUpdated.Branch,
+		NoIdentity:  truckUpdated.NoIdentity,
+		Event:       config.TruckHistoryScore,
+		Time:        timeNow,
+		Actor:       user.Name,
+		ActorID:     user.Identity,
+		Detail:      violationApproved.TypeViolation,
+		ScoreBefore: truck.Score,
+		ScoreAfter:  truckUpdated.Score,
+		ViolationID: violationID,
+	})
+	// blokir baru dimulai jika truck sebelumnya tidak diblokir atau periode blokir diganti oleh rules
+	blockStarted := truckUpdated.Blocked && !(truck.Blocked && truck.BlockStart == truckUpdated.BlockStart)
+	if blockStarted {
+		recordTruckHistory(v.tDao, dto.TruckHistory{
+			TruckID:     truckUpdated.ID.Hex(),
+			Branch:      truckUpdated.Branch,
+			NoIdentity:  truckUpdated.NoIdentity,
+			Event:       config.TruckHistoryBlockStart,
+			Time:        truckUpdated.BlockStart,
+			Actor:       user.Name,
+			ActorID:     user.Identity,
+			Detail:      truckUpdated.BlockReason,
+			ScoreBefore: truck.Score,
+			ScoreAfter:  truckUpdated.Score,
+			BlockStart:  truckUpdated.BlockStart,
+			BlockEnd:    truckUpdated.BlockEnd,
+			ViolationID: violationID,
+		})
+	}
+
+	// 7b menambahkan status di sopir, kegagalan tidak membatalkan approval yang sudah tersimpan
+	var driverUpdated *dto.Driver
+	if driver != nil {
+		driverUpdated, err = v.addDriverScore(driver)
+		if err != nil {
+			logger.
+				Error(fmt.Sprintf(
+					"error di ApproveViolation 7b menambahkan status di sopir, need roleback. id : %s",
+					violationID),
+					err)
+		}
+	}
+
+	// 8 webhook dan stream dashboard
+	v.webhook.Emit(config.EventViolationApproved, violationApproved.Branch, violationApproved)
+	v.hub.Publish(config.EventViolationStateChanged, violationApproved.Branch, violationApproved)
+	if blockStarted {
+		v.webhook.Emit(config.EventTruckBlocked, truckUpdated.Branch, truckUpdated)
+		v.hub.Publish(config.EventTruckBlocked, truckUpdated.Branch, truckUpdated)
+	}
+	// blokir sopir yang sedang berjalan dan tidak diganti rules tidak dikirim ulang
+	if driverUpdated != nil && driverUpdated.Blocked && !(driver.Blocked && driver.BlockStart == driverUpdated.BlockStart) {
+		v.webhook.Emit(config.EventDriverBlocked, driverUpdated.Branch, driverUpdated)
+		v.hub.Publish(config.EventDriverBlocked, driverUpdated.Branch, driverUpdated)
+	}
+
+	// 9 membuat pdf
 	errPdf := pdfgen.GeneratePDF(violationApproved, truckUpdated, rules)
 	if errPdf != nil {
 		logger.Error(fmt.Sprintf("membuat pdf gagal. id : %s", violationID), errPdf)
 	} else {
-		if truckUpdated.Email != "" {
+		// JPT yang bertanggung jawab mendapatkan tembusan, atau menjadi penerima jika email truck kosong
+		toEmail := truckUpdated.Email
+		var ccEmails []string
+		if jptEmail := v.getJptEmail(violationApproved.JptID, violationApproved.Branch); jptEmail != "" {
+			if toEmail == "" {
+				toEmail = jptEmail
+			} else if jptEmail != toEmail {
+				ccEmails = append(ccEmails, jptEmail)
+			}
+		}
+		if toEmail != "" {
 			worker.RegSendEmail(&worker.MailInfo{
 				ViolID:        violationID,
 				TruckIdentity: fmt.Sprintf("%s (%s)", truckUpdated.NoIdentity, truckUpdated.NoPol),
-				ToEmail:       truckUpdated.Email,
+				ToEmail:       toEmail,
+				CcEmails:      ccEmails,
 			})
 		}
 	}
@@ -345,6 +521,77 @@ func (v *ViolationService) ApproveViolation(user mjwt.CustomClaim, violationID s
 	return violationApproved, nil
 }
 
+// truckScorePayload menambah skor truck dan menerapkan rules pemblokiran truck.
+// blokir yang sedang berjalan (daftar hitam atau berakhir lebih lama) tidak diperpendek oleh rules
+func truckScorePayload(truck *dto.Truck, rules *dto.Rules, now int64) dto.TruckScoreEdit {
+	payloadTruck := dto.TruckScoreEdit{
+		ID:             truck.ID,
+		Score:          truck.Score + 1,
+		ResetScoreDate: 0,
+		Blocked:        false,
+		BlockStart:     0,
+		BlockEnd:       0,
+	}
+
+	// jika ditemukan role pemblokiran
+	if rules != nil && rules.BlockTime != 0 {
+		payloadTruck.Blocked = true
+		payloadTruck.BlockStart = now
+		payloadTruck.BlockEnd = now + rules.BlockTime
+		payloadTruck.BlockReason = rules.Description
+	}
+	if truck.Blocked && (truck.BlockPermanent || truck.BlockEnd > payloadTruck.BlockEnd) {
+		payloadTruck.Blocked = true
+		payloadTruck.BlockStart = truck.BlockStart
+		payloadTruck.BlockEnd = truck.BlockEnd
+		payloadTruck.BlockReason = truck.BlockReason
+	}
+	return payloadTruck
+}
+
+// getJptEmail mendapatkan email JPT yang masih aktif, kosong jika pelanggaran tidak terhubung ke JPT
+func (v *ViolationService) getJptEmail(jptID string, branch string) string {
+	if jptID == "" {
+		return ""
+	}
+	oid, errT := primitive.ObjectIDFromHex(jptID)
+	if errT != nil {
+		return ""
+	}
+	jpt, err := v.jDao.GetJptByID(oid, branch)
+	if err != nil || jpt.Deleted {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(jpt.Email))
+}
+
+// driverScorePayload menambah skor sopir dan menerapkan rules pemblokiran sopir.
+// blokir yang sedang berjalan dan berakhir lebih lama tidak diperpendek oleh rules
+func driverScorePayload(driver *dto.Driver, rules *dto.Rules, now int64) dto.DriverScoreEdit {
+	payloadDriver := dto.DriverScoreEdit{
+		ID:    driver.ID,
+		Score: driver.Score + 1,
+	}
+
+	if rules != nil && rules.BlockTime != 0 {
+		payloadDriver.Blocked = true
+		payloadDriver.BlockStart = now
+		payloadDriver.BlockEnd = now + rules.BlockTime
+	}
+	if driver.Blocked && driver.BlockEnd > payloadDriver.BlockEnd {
+		payloadDriver.Blocked = true
+		payloadDriver.BlockStart = driver.BlockStart
+		payloadDriver.BlockEnd = driver.BlockEnd
+	}
+	return payloadDriver
+}
+
+// addDriverScore menambah skor sopir dan menerapkan rules pemblokiran sopir
+func (v *ViolationService) addDriverScore(driver *dto.Driver) (*dto.Driver, resterr.APIError) {
+	rules, _ := v.rDao.GetRulesByScore(driver.Score+1, driver.Branch, config.RulesSubjectDriver)
+	return v.dDao.ChangeScore(driverScorePayload(driver, rules, time.Now().Unix()))
+}
+
 func (v *ViolationService) DeleteViolation(user mjwt.CustomClaim, id string) resterr.APIError {
 	oid, errT := primitive.ObjectIDFromHex(id)
 	if errT != nil {
@@ -452,7 +699,7 @@ func (v *ViolationService) GeneratePDFViolation(violationID string) (*dto.Violat
 		}
 	}
 	// 3 mendapatkan rules block truck
-	rules, _ := v.rDao.GetRulesByScore(violationScore, violation.Branch)
+	rules, _ := v.rDao.GetRulesByScore(violationScore, violation.Branch, config.RulesSubjectTruck)
 
 	// 4 dummy truck, pdf hanya melihat score saja
 	truck := &dto.Truck{
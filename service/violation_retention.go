@@ -0,0 +1,256 @@
+package service
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"html"
+	"strings"
+	"tilank/clients/fcm"
+	"tilank/config"
+	"tilank/dto"
+	"tilank/utils/logger"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+	"tilank/worker"
+	"time"
+)
+
+const (
+	envDraftRetentionDay = "VIOLATION_DRAFT_RETENTION_DAYS"
+	envDraftWarningDay   = "VIOLATION_DRAFT_WARNING_DAYS"
+
+	secondPerDay = 24 * 3600
+)
+
+// draftRetentionDefault aturan pengarsipan draft untuk cabang yang belum memiliki aturan tersimpan
+var draftRetentionDefault = dto.DraftRetention{
+	RetentionDay: 30,
+	WarningDay:   3,
+	Default:      true,
+}
+
+// LoadDraftRetention memuat aturan pengarsipan draft default dari environment
+func LoadDraftRetention() error {
+	input := dto.DraftRetentionRequest{
+		RetentionDay: draftRetentionDefault.RetentionDay,
+		WarningDay:   draftRetentionDefault.WarningDay,
+	}
+
+	var err error
+	if input.RetentionDay, err = config.EnvInt(envDraftRetentionDay, input.RetentionDay, 0, 365); err != nil {
+		return err
+	}
+	if input.WarningDay, err = config.EnvInt(envDraftWarningDay, input.WarningDay, 0, 364); err != nil {
+		return err
+	}
+	if err := input.Validate(); err != nil {
+		return fmt.Errorf("%s / %s tidak valid: %s", envDraftRetentionDay, envDraftWarningDay, err.Error())
+	}
+
+	draftRetentionDefault.RetentionDay = input.RetentionDay
+	draftRetentionDefault.WarningDay = input.WarningDay
+	return nil
+}
+
+// findDraftRetention mendapatkan aturan pengarsipan draft yang tersimpan per cabang
+func (v *ViolationService) findDraftRetention() (map[string]dto.DraftRetention, resterr.APIError) {
+	retentionList, err := v.vDao.FindDraftRetention()
+	if err != nil {
+		return nil, err
+	}
+	retentions := make(map[string]dto.DraftRetention, len(retentionList))
+	for _, retention := range retentionList {
+		retentions[retention.Branch] = retention
+	}
+	return retentions, nil
+}
+
+// draftRetentionFor aturan pengarsipan draft cabang, menggunakan default jika belum tersimpan
+func draftRetentionFor(branch string, retentions map[string]dto.DraftRetention) dto.DraftRetention {
+	if retention, ok := retentions[branch]; ok {
+		return retention
+	}
+	retention := draftRetentionDefault
+	retention.Branch = branch
+	return retention
+}
+
+// GetDraftRetention mendapatkan aturan pengarsipan draft yang berlaku pada cabang
+func (v *ViolationService) GetDraftRetention(branch string) (*dto.DraftRetention, resterr.APIError) {
+	retentions, err := v.findDraftRetention()
+	if err != nil {
+		return nil, err
+	}
+	retention := draftRetentionFor(strings.ToUpper(branch), retentions)
+	return &retention, nil
+}
+
+// EditDraftRetention mengganti aturan pengarsipan draft cabang user
+func (v *ViolationService) EditDraftRetention(user mjwt.CustomClaim, input dto.DraftRetentionRequest) (*dto.DraftRetention, resterr.APIError) {
+	return v.vDao.UpsertDraftRetention(dto.DraftRetention{
+		Branch:       user.Branch,
+		RetentionDay: input.RetentionDay,
+		WarningDay:   input.WarningDay,
+		UpdatedAt:    time.Now().Unix(),
+		UpdatedBy:    user.Name,
+	})
+}
+
+// ArchiveStaleDraft memperingatkan pembuat draft yang mendekati masa retensi dan mengarsipkan draft
+// yang melewati masa retensi setelah diperingatkan, dijalankan oleh scheduler
+func (v *ViolationService) ArchiveStaleDraft() (*dto.DraftArchiveResult, resterr.APIError) {
+	retentions, err := v.findDraftRetention()
+	if err != nil {
+		return nil, err
+	}
+
+	// batas waktu pencarian mengikuti masa peringatan tercepat dari seluruh aturan yang aktif
+	nowUnix := time.Now().Unix()
+	earliest := int64(-1)
+	for _, retention := range append(mapRetentionValues(retentions), draftRetentionDefault) {
+		if retention.RetentionDay == 0 {
+			continue
+		}
+		warnAfter := int64(retention.RetentionDay-retention.WarningDay) * secondPerDay
+		if earliest == -1 || warnAfter < earliest {
+			earliest = warnAfter
+		}
+	}
+	result := dto.DraftArchiveResult{}
+	if earliest == -1 {
+		return &result, nil
+	}
+
+	draftList, err := v.vDao.FindDraftUntouched(nowUnix - earliest)
+	if err != nil {
+		return nil, err
+	}
+
+	warnedByCreator := map[string]dto.ViolationResponseMinList{}
+	for _, draft := range draftList {
+		retention := draftRetentionFor(draft.Branch, retentions)
+		if retention.RetentionDay == 0 {
+			continue
+		}
+		archiveAt := draft.UpdatedAt + int64(retention.RetentionDay)*secondPerDay
+		warningSecond := int64(retention.WarningDay) * secondPerDay
+		warned := draft.DraftWarnedAt >= draft.UpdatedAt && draft.DraftWarnedAt != 0
+
+		switch {
+		case warned && nowUnix >= archiveAt && nowUnix-draft.DraftWarnedAt >= warningSecond:
+			archived, err := v.vDao.ArchiveDraft(draft.ID, draft.UpdatedAt, nowUnix)
+			if err != nil || archived == 0 {
+				continue
+			}
+			result.Archived++
+			draft.Archived = true
+			draft.ArchivedAt = nowUnix
+			v.hub.Publish(config.EventViolationArchived, draft.Branch, draft)
+		case !warned && nowUnix >= archiveAt-warningSecond:
+			marked, err := v.vDao.WarnDraft(draft.ID, draft.UpdatedAt, nowUnix)
+			if err != nil || marked == 0 {
+				continue
+			}
+			result.Warned++
+			// draft diarsipkan paling cepat setelah masa peringatan penuh
+			if archiveAt < nowUnix+warningSecond {
+				archiveAt = nowUnix + warningSecond
+			}
+			draft.DraftWarnedAt = nowUnix
+			draft.ArchivedAt = archiveAt
+			warnedByCreator[draft.CreatedByID] = append(warnedByCreator[draft.CreatedByID], draft)
+		}
+	}
+
+	for creatorID, warnedList := range warnedByCreator {
+		v.notifyDraftCreator(creatorID, warnedList)
+	}
+
+	return &result, nil
+}
+
+func mapRetentionValues(retentions map[string]dto.DraftRetention) []dto.DraftRetention {
+	values := make([]dto.DraftRetention, 0, len(retentions))
+	for _, retention := range retentions {
+		values = append(values, retention)
+	}
+	return values
+}
+
+// notifyDraftCreator memperingatkan pembuat draft melalui email dan fcm, ArchivedAt pada draftList
+// berisi perkiraan waktu pengarsipan
+func (v *ViolationService) notifyDraftCreator(creatorID string, draftList dto.ViolationResponseMinList) {
+	if creatorID == "" {
+		return
+	}
+	creator, err := v.uDao.GetUserByID(creatorID)
+	if err != nil {
+		logger.Error("Gagal mendapatkan pembuat draft untuk peringatan pengarsipan (notifyDraftCreator)", err)
+		return
+	}
+
+	title := "Draft pelanggaran akan diarsipkan"
+	message := fmt.Sprintf("%d draft pelanggaran anda tidak diubah dan akan diarsipkan, "+
+		"ubah atau ajukan draft untuk mencegah pengarsipan", len(draftList))
+
+	if creator.Email != "" {
+		var rows strings.Builder
+		for _, draft := range draftList {
+			rows.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>",
+				html.EscapeString(draft.NoIdentity),
+				html.EscapeString(draft.TypeViolation),
+				time.Unix(draft.UpdatedAt, 0).Format("02-01-2006 15:04"),
+				time.Unix(draft.ArchivedAt, 0).Format("02-01-2006"),
+			))
+		}
+		worker.RegSendEmail(&worker.MailInfo{
+			ToEmail: creator.Email,
+			Subject: fmt.Sprintf("%s - TILANK", title),
+			Body: fmt.Sprintf("Halo %s,<br>%s.<br><br>"+
+				"<table border=\"1\" cellpadding=\"4\" cellspacing=\"0\">"+
+				"<tr><th>No Lambung</th><th>Pelanggaran</th><th>Terakhir Diubah</th><th>Diarsipkan</th></tr>"+
+				"%s</table><br>Draft yang diarsipkan dapat dipulihkan kembali.<br>Terimakasih.",
+				creator.Name, message, rows.String()),
+		})
+	}
+	if creator.FcmToken != "" {
+		v.fcm.SendMessage(fcm.Payload{
+			Title:          title,
+			Message:        message,
+			ReceiverTokens: []string{creator.FcmToken},
+		})
+	}
+}
+
+// RestoreViolation memulihkan draft yang diarsipkan pada cabang user, masa retensi dihitung ulang.
+// draft hanya dapat dipulihkan oleh pembuatnya atau user dengan permission violation:approve
+func (v *ViolationService) RestoreViolation(user mjwt.CustomClaim, violationID string) (*dto.Violation, resterr.APIError) {
+	oid, errT := primitive.ObjectIDFromHex(violationID)
+	if errT != nil {
+		return nil, resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
+	}
+
+	violation, err := v.vDao.GetViolationByID(oid, user.Branch)
+	if err != nil {
+		return nil, err
+	}
+	if violation.CreatedByID != user.Identity && !v.policy.HasAnyPermission(user.Roles, []string{config.PermViolationApprove}) {
+		return nil, resterr.NewUnauthorizedError(fmt.Sprintf("Unauthorized, draft hanya dapat dipulihkan oleh pembuat atau pemilik permission %s",
+			config.PermViolationApprove))
+	}
+
+	violationRestored, err := v.vDao.RestoreDraft(dto.ViolationRestore{
+		ID:           oid,
+		FilterBranch: user.Branch,
+		UpdatedAt:    time.Now().Unix(),
+		UpdatedBy:    user.Name,
+		UpdatedByID:  user.Identity,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	v.hub.Publish(config.EventViolationRestored, violationRestored.Branch, violationRestored)
+
+	return violationRestored, nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"os"
+	"testing"
+	"tilank/config"
+	"tilank/dto"
+	"tilank/utils/mjwt"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRestoreViolationPermission(t *testing.T) {
+	cases := []struct {
+		name    string
+		user    mjwt.CustomClaim
+		allowed bool
+	}{
+		{"pembuat draft", mjwt.CustomClaim{Identity: "creator", Name: "Creator", Branch: "BANJARMASIN"}, true},
+		{"pemilik permission approve", mjwt.CustomClaim{Identity: "hsse", Name: "HSSE", Branch: "BANJARMASIN", Roles: []string{config.RoleHSSE}}, true},
+		{"user lain", mjwt.CustomClaim{Identity: "security", Name: "Security", Branch: "BANJARMASIN", Roles: []string{config.RoleSEC}}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			vDao := &fakeViolationDao{violation: dto.Violation{
+				ID:          primitive.NewObjectID(),
+				Branch:      "BANJARMASIN",
+				CreatedByID: "creator",
+				Archived:    true,
+			}}
+			hub := &fakeHub{}
+			v := &ViolationService{
+				vDao:   vDao,
+				policy: NewPolicyService(&fakePolicyDao{}, NewAuditService(&fakeAuditDao{})),
+				hub:    hub,
+			}
+
+			violation, apiErr := v.RestoreViolation(c.user, vDao.violation.ID.Hex())
+			if !c.allowed {
+				assert.NotNil(t, apiErr)
+				assert.Equal(t, 401, apiErr.Status())
+				assert.Nil(t, vDao.restored)
+				assert.Empty(t, hub.events)
+				return
+			}
+			assert.Nil(t, apiErr)
+			assert.False(t, violation.Archived)
+			assert.Equal(t, c.user.Identity, vDao.restored.UpdatedByID)
+			assert.Equal(t, []string{config.EventViolationRestored}, hub.events)
+		})
+	}
+}
+
+func TestLoadDraftRetention(t *testing.T) {
+	defer func(retention dto.DraftRetention) { draftRetentionDefault = retention }(draftRetentionDefault)
+	defer os.Unsetenv(envDraftRetentionDay)
+	defer os.Unsetenv(envDraftWarningDay)
+
+	os.Setenv(envDraftRetentionDay, "14")
+	os.Setenv(envDraftWarningDay, "2")
+	assert.Nil(t, LoadDraftRetention())
+	assert.Equal(t, 14, draftRetentionDefault.RetentionDay)
+	assert.Equal(t, 2, draftRetentionDefault.WarningDay)
+
+	cases := []struct {
+		name      string
+		retention string
+		warning   string
+	}{
+		{"bukan angka", "satu", "2"},
+		{"negatif", "-1", "2"},
+		{"melebihi batas", "400", "2"},
+		{"peringatan tidak kurang dari retensi", "3", "3"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			os.Setenv(envDraftRetentionDay, c.retention)
+			os.Setenv(envDraftWarningDay, c.warning)
+			assert.NotNil(t, LoadDraftRetention())
+			assert.Equal(t, 14, draftRetentionDefault.RetentionDay)
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"tilank/clients/fcm"
+	"tilank/config"
+	"tilank/dto"
+	"tilank/enum"
+	"tilank/utils/logger"
+	"tilank/utils/rest_err"
+	"tilank/utils/sfunc"
+	"tilank/worker"
+	"time"
+)
+
+const (
+	envViolationSlaHour        = "VIOLATION_APPROVAL_SLA_HOURS"
+	envViolationEscalationRole = "VIOLATION_ESCALATION_ROLE"
+
+	// violationEscalationMaxLevel tingkat eskalasi tertinggi, pelanggaran tidak dieskalasi lagi setelahnya
+	violationEscalationMaxLevel = 3
+)
+
+// ViolationSlaPolicy aturan SLA persetujuan pelanggaran.
+// pelanggaran yang menunggu persetujuan lebih lama dari SlaHour dieskalasi ke user dengan EscalationRole
+// pada cabang tersebut, tingkat eskalasi naik setiap kelipatan SlaHour hingga violationEscalationMaxLevel
+type ViolationSlaPolicy struct {
+	SlaHour        int
+	EscalationRole string
+}
+
+var violationSlaPolicy = defaultViolationSlaPolicy()
+
+func defaultViolationSlaPolicy() ViolationSlaPolicy {
+	return ViolationSlaPolicy{
+		SlaHour:        24,
+		EscalationRole: config.RoleRegional,
+	}
+}
+
+// LoadViolationSlaPolicy memuat SLA persetujuan pelanggaran dari environment
+func LoadViolationSlaPolicy() error {
+	policy := defaultViolationSlaPolicy()
+
+	var err error
+	if policy.SlaHour, err = config.EnvInt(envViolationSlaHour, policy.SlaHour, 1, 720); err != nil {
+		return err
+	}
+
+	if role := strings.ToUpper(config.EnvString(envViolationEscalationRole)); role != "" {
+		if !sfunc.InSlice(role, config.GetRolesAvailable()) {
+			return fmt.Errorf("%s harus salah satu dari %s", envViolationEscalationRole,
+				strings.Join(config.GetRolesAvailable(), ", "))
+		}
+		policy.EscalationRole = role
+	}
+
+	violationSlaPolicy = policy
+	return nil
+}
+
+// pendingSince waktu mulai menunggu persetujuan, dokumen lama yang belum memiliki submitted_at
+// dihitung sejak dibuat
+func pendingSince(violation dto.ViolationResponseMin) int64 {
+	if violation.SubmittedAt != 0 {
+		return violation.SubmittedAt
+	}
+	return violation.CreatedAt
+}
+
+// Level tingkat eskalasi pelanggaran yang sudah menunggu persetujuan sejak since
+func (p ViolationSlaPolicy) Level(since int64, now int64) int {
+	slaSecond := int64(p.SlaHour) * 3600
+	if since <= 0 || slaSecond <= 0 || now <= since {
+		return 0
+	}
+	level := int((now - since) / slaSecond)
+	if level > violationEscalationMaxLevel {
+		level = violationEscalationMaxLevel
+	}
+	return level
+}
+
+// findPendingViolation mendapatkan seluruh pelanggaran yang menunggu persetujuan dikelompokkan per cabang
+func (v *ViolationService) findPendingViolation() (map[string]dto.ViolationResponseMinList, resterr.APIError) {
+	violationList, err := v.vDao.FindViolation(dto.FilterViolation{
+		FilterState: enum.StNeedApprove,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pending := map[string]dto.ViolationResponseMinList{}
+	for _, violation := range violationList {
+		pending[violation.Branch] = append(pending[violation.Branch], violation)
+	}
+	for branch := range pending {
+		list := pending[branch]
+		sort.SliceStable(list, func(i, j int) bool {
+			return pendingSince(list[i]) < pendingSince(list[j])
+		})
+	}
+	return pending, nil
+}
+
+// EscalatePendingViolation mengeskalasi pelanggaran yang melewati SLA persetujuan ke user supervisor cabang,
+// dijalankan oleh scheduler. mengembalikan jumlah pelanggaran yang dieskalasi
+func (v *ViolationService) EscalatePendingViolation() (int64, resterr.APIError) {
+	pending, err := v.findPendingViolation()
+	if err != nil {
+		return 0, err
+	}
+
+	nowUnix := time.Now().Unix()
+	var escalated int64
+	for branch, violationList := range pending {
+		var branchEscalated dto.ViolationResponseMinList
+		for _, violation := range violationList {
+			level := violationSlaPolicy.Level(pendingSince(violation), nowUnix)
+			if level <= violation.EscalationLevel {
+				continue
+			}
+			// pelanggaran yang sudah disetujui atau dieskalasi proses lain tidak dihitung ulang
+			matched, err := v.vDao.EscalateViolation(violation.ID, level, nowUnix)
+			if err != nil || matched == 0 {
+				continue
+			}
+			violation.EscalationLevel = level
+			violation.EscalatedAt = nowUnix
+			branchEscalated = append(branchEscalated, violation)
+
+			v.webhook.Emit(config.EventViolationEscalated, branch, violation)
+			v.hub.Publish(config.EventViolationEscalated, branch, violation)
+		}
+		if len(branchEscalated) == 0 {
+			continue
+		}
+		escalated += int64(len(branchEscalated))
+
+		users, err := v.uDao.FindUserCoveringBranch(branch, violationSlaPolicy.EscalationRole)
+		if err != nil {
+			logger.Error("Gagal mendapatkan user supervisor untuk eskalasi pelanggaran (EscalatePendingViolation)", err)
+			continue
+		}
+		v.notifyPending(users,
+			fmt.Sprintf("Eskalasi persetujuan pelanggaran %s", branch),
+			fmt.Sprintf("%d pelanggaran di cabang %s melewati SLA persetujuan %d jam", len(branchEscalated), branch, violationSlaPolicy.SlaHour),
+			branchEscalated, nowUnix)
+	}
+
+	return escalated, nil
+}
+
+// SendPendingDigest mengirim ringkasan harian pelanggaran yang menunggu persetujuan ke user HSSE
+// masing masing cabang, dijalankan oleh scheduler. mengembalikan jumlah cabang yang dikirim ringkasan
+func (v *ViolationService) SendPendingDigest() (int64, resterr.APIError) {
+	pending, err := v.findPendingViolation()
+	if err != nil {
+		return 0, err
+	}
+
+	nowUnix := time.Now().Unix()
+	var branchSent int64
+	for branch, violationList := range pending {
+		users, err := v.uDao.FindUserHSSE(branch)
+		if err != nil {
+			logger.Error("Gagal mendapatkan user hsse untuk ringkasan pelanggaran (SendPendingDigest)", err)
+			continue
+		}
+		if len(users) == 0 {
+			continue
+		}
+		branchSent++
+
+		var overSla int
+		for _, violation := range violationList {
+			if violationSlaPolicy.Level(pendingSince(violation), nowUnix) > 0 {
+				overSla++
+			}
+		}
+		v.notifyPending(users,
+			fmt.Sprintf("Ringkasan pelanggaran menunggu persetujuan %s", branch),
+			fmt.Sprintf("%d pelanggaran di cabang %s menunggu persetujuan, %d melewati SLA %d jam", len(violationList), branch, overSla, violationSlaPolicy.SlaHour),
+			violationList, nowUnix)
+	}
+
+	return branchSent, nil
+}
+
+// notifyPending mengirim daftar pelanggaran melalui email dan ringkasan melalui fcm ke user
+func (v *ViolationService) notifyPending(users dto.UserResponseList, title string, message string,
+	violationList dto.ViolationResponseMinList, now int64) {
+	body := pendingViolationEmailBody(message, violationList, now)
+
+	var tokens []string
+	for _, user := range users {
+		if user.Email != "" {
+			worker.RegSendEmail(&worker.MailInfo{
+				ToEmail: user.Email,
+				Subject: fmt.Sprintf("%s - TILANK", title),
+				Body:    body,
+			})
+		}
+		if user.FcmToken != "" {
+			tokens = append(tokens, user.FcmToken)
+		}
+	}
+	if len(tokens) != 0 {
+		v.fcm.SendMessage(fcm.Payload{
+			Title:          title,
+			Message:        message,
+			ReceiverTokens: tokens,
+		})
+	}
+}
+
+func pendingViolationEmailBody(message string, violationList dto.ViolationResponseMinList, now int64) string {
+	var rows strings.Builder
+	for _, violation := range violationList {
+		since := pendingSince(violation)
+		rows.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%d jam</td><td>%d</td></tr>",
+			html.EscapeString(violation.NoIdentity),
+			html.EscapeString(violation.Owner),
+			html.EscapeString(violation.TypeViolation),
+			time.Unix(since, 0).Format("02-01-2006 15:04"),
+			(now-since)/3600,
+			violation.EscalationLevel,
+		))
+	}
+
+	return fmt.Sprintf("Halo,<br>%s.<br><br>"+
+		"<table border=\"1\" cellpadding=\"4\" cellspacing=\"0\">"+
+		"<tr><th>No Lambung</th><th>Pemilik</th><th>Pelanggaran</th><th>Diajukan</th><th>Menunggu</th><th>Eskalasi</th></tr>"+
+		"%s</table><br>Terimakasih.", message, rows.String())
+}
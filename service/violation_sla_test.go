@@ -0,0 +1,99 @@
+package service
+
+import (
+	"os"
+	"testing"
+	"tilank/config"
+	"tilank/dto"
+	"tilank/enum"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestViolationSlaPolicyLevel(t *testing.T) {
+	policy := ViolationSlaPolicy{SlaHour: 24}
+	since := int64(1000000)
+	cases := []struct {
+		name  string
+		since int64
+		now   int64
+		want  int
+	}{
+		{"belum melewati sla", since, since + 24*3600 - 1, 0},
+		{"tepat sla", since, since + 24*3600, 1},
+		{"dua kali sla", since, since + 48*3600, 2},
+		{"dibatasi tingkat tertinggi", since, since + 240*3600, violationEscalationMaxLevel},
+		{"waktu mulai kosong", 0, since, 0},
+		{"waktu sekarang sebelum mulai", since, since - 60, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, policy.Level(c.since, c.now))
+		})
+	}
+}
+
+func TestEscalatePendingViolationSkipUnmatched(t *testing.T) {
+	since := time.Now().Unix() - 25*3600
+	escalated := primitive.NewObjectID()
+	approved := primitive.NewObjectID()
+	vDao := &fakeViolationDao{
+		violations: dto.ViolationResponseMinList{
+			{ID: escalated, Branch: "BANJARMASIN", State: enum.StNeedApprove, SubmittedAt: since},
+			// sudah disetujui proses lain sehingga tidak cocok dengan filter eskalasi
+			{ID: approved, Branch: "BANJARMASIN", State: enum.StNeedApprove, SubmittedAt: since},
+			// belum melewati sla
+			{ID: primitive.NewObjectID(), Branch: "BANJARMASIN", State: enum.StNeedApprove, SubmittedAt: time.Now().Unix()},
+		},
+		escalateMatched: map[primitive.ObjectID]int64{approved: 0},
+	}
+	fcmClient := &fakeFcm{}
+	hub := &fakeHub{}
+	v := &ViolationService{
+		vDao: vDao,
+		uDao: &fakeUserDao{users: map[string]dto.UserResponseList{
+			violationSlaPolicy.EscalationRole: {{FcmToken: "token-regional"}},
+		}},
+		fcm:     fcmClient,
+		webhook: NewWebhookService(&fakeWebhookDao{}, nil),
+		hub:     hub,
+	}
+
+	count, apiErr := v.EscalatePendingViolation()
+	assert.Nil(t, apiErr)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, []primitive.ObjectID{escalated}, vDao.escalated)
+	assert.Equal(t, []string{config.EventViolationEscalated}, hub.events)
+	assert.Len(t, fcmClient.payloads, 1)
+	assert.Contains(t, fcmClient.payloads[0].Message, "1 pelanggaran")
+
+	// seluruh eskalasi tidak cocok, tidak ada pemberitahuan
+	vDao.escalateMatched = map[primitive.ObjectID]int64{escalated: 0, approved: 0}
+	vDao.escalated = nil
+	fcmClient.payloads = nil
+	count, apiErr = v.EscalatePendingViolation()
+	assert.Nil(t, apiErr)
+	assert.Zero(t, count)
+	assert.Empty(t, fcmClient.payloads)
+}
+
+func TestLoadViolationSlaPolicy(t *testing.T) {
+	defer func() {
+		_ = os.Setenv(envViolationSlaHour, "")
+		_ = os.Setenv(envViolationEscalationRole, "")
+		violationSlaPolicy = defaultViolationSlaPolicy()
+	}()
+
+	_ = os.Setenv(envViolationSlaHour, "12")
+	_ = os.Setenv(envViolationEscalationRole, "hsse")
+	assert.Nil(t, LoadViolationSlaPolicy())
+	assert.Equal(t, ViolationSlaPolicy{SlaHour: 12, EscalationRole: config.RoleHSSE}, violationSlaPolicy)
+
+	for _, values := range [][2]string{{"0", ""}, {"721", ""}, {"abc", ""}, {"", "SUPERVISOR"}} {
+		_ = os.Setenv(envViolationSlaHour, values[0])
+		_ = os.Setenv(envViolationEscalationRole, values[1])
+		assert.NotNil(t, LoadViolationSlaPolicy(), values)
+	}
+}
@@ -0,0 +1,364 @@
+package service
+
+import (
+	"testing"
+	"tilank/config"
+	"tilank/dao/driverdao"
+	"tilank/dao/rulesdao"
+	"tilank/dao/truckdao"
+	"tilank/dao/violationdao"
+	"tilank/dao/webhookdao"
+	"tilank/dto"
+	"tilank/stream"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fake dao berikut hanya mengimplementasikan method yang digunakan pada pengujian service
+
+type fakeViolationDao struct {
+	violationdao.ViolationDaoAssumer
+	violation  dto.Violation
+	violations dto.ViolationResponseMinList
+	filter     dto.FilterViolation
+	inserted   *dto.Violation
+	confirmed  *dto.ViolationConfirm
+	// escalateMatched hasil EscalateViolation per id, id yang tidak terdaftar dianggap cocok
+	escalateMatched map[primitive.ObjectID]int64
+	escalated       []primitive.ObjectID
+	restored        *dto.ViolationRestore
+}
+
+func (f *fakeViolationDao) EscalateViolation(violationID primitive.ObjectID, _ int, _ int64) (int64, resterr.APIError) {
+	matched, ok := f.escalateMatched[violationID]
+	if !ok {
+		matched = 1
+	}
+	if matched != 0 {
+		f.escalated = append(f.escalated, violationID)
+	}
+	return matched, nil
+}
+
+func (f *fakeViolationDao) RestoreDraft(input dto.ViolationRestore) (*dto.Violation, resterr.APIError) {
+	f.restored = &input
+	violation := f.violation
+	violation.Archived = false
+	return &violation, nil
+}
+
+func (f *fakeViolationDao) FindViolation(filter dto.FilterViolation) (dto.ViolationResponseMinList, resterr.APIError) {
+	f.filter = filter
+	return f.violations, nil
+}
+
+func (f *fakeViolationDao) InsertViolation(input dto.Violation) (*string, resterr.APIError) {
+	f.inserted = &input
+	id := input.ID.Hex()
+	return &id, nil
+}
+
+func (f *fakeViolationDao) GetViolationByID(_ primitive.ObjectID, _ string) (*dto.Violation, resterr.APIError) {
+	violation := f.violation
+	return &violation, nil
+}
+
+func (f *fakeViolationDao) ChangeStateViolation(input dto.ViolationConfirm) (*dto.Violation, resterr.APIError) {
+	f.confirmed = &input
+	violation := f.violation
+	violation.State = input.State
+	return &violation, nil
+}
+
+type fakeTruckDao struct {
+	truckdao.TruckDaoAssumer
+	truck     dto.Truck
+	scoreEdit *dto.TruckScoreEdit
+	trucks    dto.TruckResponseMinList
+	edited    *dto.TruckEdit
+	blockEdit *dto.TruckBlockEdit
+	resetIDs  []primitive.ObjectID
+	history   []dto.TruckHistory
+}
+
+func (f *fakeTruckDao) ChangeBlock(input dto.TruckBlockEdit) (*dto.Truck, resterr.APIError) {
+	f.blockEdit = &input
+	truck := f.truck
+	truck.Blocked = input.Blocked
+	truck.BlockStart = input.BlockStart
+	truck.BlockEnd = input.BlockEnd
+	truck.BlockPermanent = input.BlockPermanent
+	truck.BlockReason = input.BlockReason
+	return &truck, nil
+}
+
+func (f *fakeTruckDao) FindTruck(_ dto.FilterTruck) (dto.TruckResponseMinList, resterr.APIError) {
+	return f.trucks, nil
+}
+
+func (f *fakeTruckDao) ResetTruckBlock(trucksID []primitive.ObjectID) (int64, resterr.APIError) {
+	f.resetIDs = trucksID
+	return int64(len(trucksID)), nil
+}
+
+func (f *fakeTruckDao) FindHistory(_ string, _ int64) (dto.TruckHistoryList, resterr.APIError) {
+	return f.history, nil
+}
+
+func (f *fakeTruckDao) GetTruckByID(_ primitive.ObjectID, _ string) (*dto.Truck, resterr.APIError) {
+	truck := f.truck
+	return &truck, nil
+}
+
+func (f *fakeTruckDao) EditTruck(input dto.TruckEdit) (*dto.Truck, resterr.APIError) {
+	f.edited = &input
+	truck := f.truck
+	truck.JptID = input.JptID
+	truck.JptName = input.JptName
+	return &truck, nil
+}
+
+func (f *fakeTruckDao) InsertHistory(input dto.TruckHistory) resterr.APIError {
+	f.history = append(f.history, input)
+	return nil
+}
+
+func (f *fakeTruckDao) GetTruckByIdentity(_ string, _ string) (*dto.Truck, resterr.APIError) {
+	truck := f.truck
+	return &truck, nil
+}
+
+func (f *fakeTruckDao) ChangeScore(input dto.TruckScoreEdit) (*dto.Truck, resterr.APIError) {
+	f.scoreEdit = &input
+	truck := f.truck
+	truck.Score = input.Score
+	truck.Blocked = input.Blocked
+	truck.BlockStart = input.BlockStart
+	truck.BlockEnd = input.BlockEnd
+	truck.BlockReason = input.BlockReason
+	return &truck, nil
+}
+
+type fakeDriverDao struct {
+	driverdao.DriverDaoAssumer
+	driver    dto.Driver
+	drivers   dto.DriverResponseMinList
+	scoreEdit *dto.DriverScoreEdit
+	resetIDs  []primitive.ObjectID
+}
+
+func (f *fakeDriverDao) GetDriverByID(_ primitive.ObjectID, _ string) (*dto.Driver, resterr.APIError) {
+	driver := f.driver
+	return &driver, nil
+}
+
+func (f *fakeDriverDao) GetDriverByLicense(_ string, _ string) (*dto.Driver, resterr.APIError) {
+	driver := f.driver
+	return &driver, nil
+}
+
+func (f *fakeDriverDao) ChangeScore(input dto.DriverScoreEdit) (*dto.Driver, resterr.APIError) {
+	f.scoreEdit = &input
+	driver := f.driver
+	driver.Score = input.Score
+	driver.Blocked = input.Blocked
+	driver.BlockStart = input.BlockStart
+	driver.BlockEnd = input.BlockEnd
+	return &driver, nil
+}
+
+func (f *fakeDriverDao) FindDriver(_ dto.FilterDriver) (dto.DriverResponseMinList, resterr.APIError) {
+	return f.drivers, nil
+}
+
+func (f *fakeDriverDao) ResetDriverBlock(driversID []primitive.ObjectID) (int64, resterr.APIError) {
+	f.resetIDs = driversID
+	return int64(len(driversID)), nil
+}
+
+// fakeRulesDao rules berdasarkan subject lalu skor
+type fakeRulesDao struct {
+	rulesdao.RulesDaoAssumer
+	rules map[string]map[int]dto.Rules
+}
+
+func (f *fakeRulesDao) GetRulesByScore(score int, _ string, subject string) (*dto.Rules, resterr.APIError) {
+	rules, ok := f.rules[subject][score]
+	if !ok {
+		return nil, resterr.NewNotFoundError("rules tidak ditemukan")
+	}
+	return &rules, nil
+}
+
+type fakeWebhookDao struct {
+	webhookdao.WebhookDaoAssumer
+}
+
+func (f *fakeWebhookDao) FindWebhookByEvent(_ string, _ string) ([]dto.Webhook, resterr.APIError) {
+	return nil, nil
+}
+
+// fakeHub mencatat nama event yang dipublikasikan
+type fakeHub struct {
+	stream.HubAssumer
+	events []string
+}
+
+func (f *fakeHub) Publish(name string, _ string, _ interface{}) {
+	f.events = append(f.events, name)
+}
+
+var hsseClaims = mjwt.CustomClaim{
+	Identity: "hsse",
+	Name:     "HSSE",
+	Branch:   "BANJARMASIN",
+}
+
+func TestInsertViolationBlockedDriver(t *testing.T) {
+	now := time.Now().Unix()
+	vDao := &fakeViolationDao{}
+	v := &ViolationService{
+		vDao: vDao,
+		tDao: &fakeTruckDao{truck: dto.Truck{NoIdentity: "T01"}},
+		dDao: &fakeDriverDao{driver: dto.Driver{NoLicense: "SIM1", Score: 3, Blocked: true, BlockStart: now - 60, BlockEnd: now + 3600}},
+		hub:  &fakeHub{},
+	}
+
+	// sopir yang sedang diblokir tetap dapat dicatat melakukan pelanggaran baru
+	_, apiErr := v.InsertViolation(hsseClaims, dto.ViolationRequest{NoIdentity: "T01", NoLicense: "SIM1"})
+	assert.Nil(t, apiErr)
+	assert.Equal(t, "SIM1", vDao.inserted.NoLicense)
+	assert.Equal(t, 3, vDao.inserted.DriverNViol)
+}
+
+func TestInsertViolationRejectDeletedDriver(t *testing.T) {
+	vDao := &fakeViolationDao{}
+	v := &ViolationService{
+		vDao: vDao,
+		tDao: &fakeTruckDao{truck: dto.Truck{NoIdentity: "T01"}},
+		dDao: &fakeDriverDao{driver: dto.Driver{NoLicense: "SIM1", Deleted: true}},
+		hub:  &fakeHub{},
+	}
+
+	_, apiErr := v.InsertViolation(hsseClaims, dto.ViolationRequest{NoIdentity: "T01", NoLicense: "SIM1"})
+	assert.NotNil(t, apiErr)
+	assert.Nil(t, vDao.inserted)
+}
+
+func TestDriverScorePayload(t *testing.T) {
+	now := time.Now().Unix()
+	rules := &dto.Rules{Score: 3, BlockTime: 3600}
+
+	cases := []struct {
+		name      string
+		driver    dto.Driver
+		rules     *dto.Rules
+		wantStart int64
+		wantEnd   int64
+	}{
+		{"tanpa rules dan tidak diblokir", dto.Driver{Score: 0}, nil, 0, 0},
+		{"rules memblokir sopir", dto.Driver{Score: 2}, rules, now, now + 3600},
+		{"blokir berjalan lebih lama dipertahankan",
+			dto.Driver{Score: 2, Blocked: true, BlockStart: now - 60, BlockEnd: now + 7200}, rules, now - 60, now + 7200},
+		{"blokir berjalan lebih pendek diperpanjang rules",
+			dto.Driver{Score: 2, Blocked: true, BlockStart: now - 60, BlockEnd: now + 60}, rules, now, now + 3600},
+		{"blokir berjalan tanpa rules dipertahankan",
+			dto.Driver{Score: 5, Blocked: true, BlockStart: now - 60, BlockEnd: now + 60}, nil, now - 60, now + 60},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			payload := driverScorePayload(&c.driver, c.rules, now)
+			assert.Equal(t, c.driver.Score+1, payload.Score)
+			assert.Equal(t, c.wantStart != 0, payload.Blocked)
+			assert.Equal(t, c.wantStart, payload.BlockStart)
+			assert.Equal(t, c.wantEnd, payload.BlockEnd)
+		})
+	}
+}
+
+func TestTruckScorePayload(t *testing.T) {
+	now := time.Now().Unix()
+	rules := &dto.Rules{Score: 3, BlockTime: 3600, Description: "blokir 1 jam"}
+
+	cases := []struct {
+		name      string
+		truck     dto.Truck
+		rules     *dto.Rules
+		wantStart int64
+		wantEnd   int64
+	}{
+		{"tanpa rules dan tidak diblokir", dto.Truck{Score: 0}, nil, 0, 0},
+		{"rules memblokir truck", dto.Truck{Score: 2}, rules, now, now + 3600},
+		{"blokir berjalan lebih lama dipertahankan",
+			dto.Truck{Score: 2, Blocked: true, BlockStart: now - 60, BlockEnd: now + 7200, BlockReason: "manual"}, rules, now - 60, now + 7200},
+		{"blokir berjalan lebih pendek diganti rules",
+			dto.Truck{Score: 2, Blocked: true, BlockStart: now - 60, BlockEnd: now + 60, BlockReason: "manual"}, rules, now, now + 3600},
+		{"blokir berjalan tanpa rules dipertahankan",
+			dto.Truck{Score: 5, Blocked: true, BlockStart: now - 60, BlockEnd: now + 60, BlockReason: "manual"}, nil, now - 60, now + 60},
+		{"daftar hitam tidak diganti rules",
+			dto.Truck{Score: 2, Blocked: true, BlockPermanent: true, BlockStart: now - 60, BlockReason: "manual"}, rules, now - 60, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			payload := truckScorePayload(&c.truck, c.rules, now)
+			assert.Equal(t, c.truck.Score+1, payload.Score)
+			assert.Equal(t, c.wantStart != 0, payload.Blocked)
+			assert.Equal(t, c.wantStart, payload.BlockStart)
+			assert.Equal(t, c.wantEnd, payload.BlockEnd)
+		})
+	}
+}
+
+func TestAddDriverScore(t *testing.T) {
+	rDao := &fakeRulesDao{rules: map[string]map[int]dto.Rules{
+		config.RulesSubjectDriver: {3: {Score: 3, BlockTime: 3600}},
+	}}
+	cases := []struct {
+		name    string
+		score   int
+		blocked bool
+	}{
+		{"skor tanpa rules", 0, false},
+		{"skor mencapai rules blokir", 2, true},
+		{"skor melewati rules", 3, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dDao := &fakeDriverDao{driver: dto.Driver{Score: c.score}}
+			v := &ViolationService{dDao: dDao, rDao: rDao}
+
+			before := time.Now().Unix()
+			driver, apiErr := v.addDriverScore(&dDao.driver)
+			assert.Nil(t, apiErr)
+			assert.Equal(t, c.score+1, driver.Score)
+			assert.Equal(t, c.blocked, driver.Blocked)
+			if c.blocked {
+				assert.GreaterOrEqual(t, driver.BlockStart, before)
+				assert.Equal(t, driver.BlockStart+3600, driver.BlockEnd)
+			} else {
+				assert.Zero(t, driver.BlockEnd)
+			}
+		})
+	}
+}
+
+func TestResetBlockedDriver(t *testing.T) {
+	now := time.Now().Unix()
+	expired := primitive.NewObjectID()
+	dDao := &fakeDriverDao{drivers: dto.DriverResponseMinList{
+		{ID: expired, Blocked: true, BlockStart: now - 7200, BlockEnd: now - 1},
+		{ID: primitive.NewObjectID(), Blocked: true, BlockStart: now - 60, BlockEnd: now + 3600},
+	}}
+	hub := &fakeHub{}
+	d := NewDriverService(dDao, NewWebhookService(&fakeWebhookDao{}, nil), hub)
+
+	updated, apiErr := d.ResetBlockedDriver()
+	assert.Nil(t, apiErr)
+	assert.Equal(t, int64(1), updated)
+	assert.Equal(t, []primitive.ObjectID{expired}, dDao.resetIDs)
+	assert.Len(t, hub.events, 1)
+}
@@ -0,0 +1,340 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"tilank/clients/webhook"
+	"tilank/dao/webhookdao"
+	"tilank/dto"
+	"tilank/utils/logger"
+	"tilank/utils/mjwt"
+	"tilank/utils/rest_err"
+	"time"
+)
+
+const (
+	// webhookMaxAttempts jumlah percobaan pengiriman sebelum log ditandai failed
+	webhookMaxAttempts = 6
+	// webhookBaseBackoff jeda percobaan pertama dalam detik, berlipat dua setiap percobaan gagal
+	webhookBaseBackoff = 30
+	// webhookClaimSecond masa klaim log yang sedang dikirim dalam detik, lebih lama dari timeout client webhook
+	// sehingga scheduler tidak mengambil log yang masih dalam proses pengiriman
+	webhookClaimSecond = 60
+)
+
+func NewWebhookService(webhookDao webhookdao.WebhookDaoAssumer, webhookClient webhook.ClientAssumer) *WebhookService {
+	return &WebhookService{
+		daoW:   webhookDao,
+		client: webhookClient,
+	}
+}
+
+type WebhookService struct {
+	daoW   webhookdao.WebhookDaoAssumer
+	client webhook.ClientAssumer
+}
+
+func (w *WebhookService) InsertWebhook(user mjwt.CustomClaim, input dto.WebhookRequest) (*dto.Webhook, resterr.APIError) {
+	if input.Secret == "" {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			logger.Error("gagal membuat secret webhook", err)
+			return nil, resterr.NewInternalServerError("gagal membuat secret webhook", err)
+		}
+		input.Secret = secret
+	}
+
+	// Filling data
+	timeNow := time.Now().Unix()
+	data := dto.Webhook{
+		ID:          primitive.NewObjectID(),
+		CreatedAt:   timeNow,
+		CreatedBy:   user.Name,
+		CreatedByID: user.Identity,
+		UpdatedAt:   timeNow,
+		UpdatedBy:   user.Name,
+		UpdatedByID: user.Identity,
+		Name:        input.Name,
+		URL:         input.URL,
+		Secret:      input.Secret,
+		Events:      input.Events,
+		Branch:      input.Branch,
+		Active:      true,
+	}
+
+	// DB
+	_, err := w.daoW.InsertWebhook(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+func (w *WebhookService) EditWebhook(user mjwt.CustomClaim, webhookID string, input dto.WebhookEditRequest) (*dto.WebhookResponse, resterr.APIError) {
+	oid, errT := primitive.ObjectIDFromHex(webhookID)
+	if errT != nil {
+		return nil, resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
+	}
+
+	data := dto.WebhookEdit{
+		ID:              oid,
+		FilterTimestamp: input.FilterTimestamp,
+		UpdatedAt:       time.Now().Unix(),
+		UpdatedBy:       user.Name,
+		UpdatedByID:     user.Identity,
+		Name:            input.Name,
+		URL:             input.URL,
+		Events:          input.Events,
+		Branch:          input.Branch,
+		Active:          input.Active,
+	}
+
+	// DB
+	webhookEdited, err := w.daoW.EditWebhook(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhookResponse(webhookEdited), nil
+}
+
+func (w *WebhookService) DeleteWebhook(webhookID string) resterr.APIError {
+	oid, errT := primitive.ObjectIDFromHex(webhookID)
+	if errT != nil {
+		return resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
+	}
+
+	return w.daoW.DeleteWebhook(oid)
+}
+
+// GetWebhookByID mendapatkan detail webhook tanpa secret
+func (w *WebhookService) GetWebhookByID(webhookID string) (*dto.WebhookResponse, resterr.APIError) {
+	oid, errT := primitive.ObjectIDFromHex(webhookID)
+	if errT != nil {
+		return nil, resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
+	}
+
+	webhookSubs, err := w.daoW.GetWebhookByID(oid)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhookResponse(webhookSubs), nil
+}
+
+// webhookResponse menghilangkan secret dari webhook yang dikembalikan ke user
+func webhookResponse(webhookSubs *dto.Webhook) *dto.WebhookResponse {
+	return &dto.WebhookResponse{
+		ID:          webhookSubs.ID,
+		CreatedAt:   webhookSubs.CreatedAt,
+		CreatedBy:   webhookSubs.CreatedBy,
+		CreatedByID: webhookSubs.CreatedByID,
+		UpdatedAt:   webhookSubs.UpdatedAt,
+		UpdatedBy:   webhookSubs.UpdatedBy,
+		UpdatedByID: webhookSubs.UpdatedByID,
+		Name:        webhookSubs.Name,
+		URL:         webhookSubs.URL,
+		Events:      webhookSubs.Events,
+		Branch:      webhookSubs.Branch,
+		Active:      webhookSubs.Active,
+	}
+}
+
+func (w *WebhookService) FindWebhook() (dto.WebhookResponseMinList, resterr.APIError) {
+	return w.daoW.FindWebhook()
+}
+
+func (w *WebhookService) FindDelivery(filter dto.FilterWebhookDelivery) (dto.WebhookDeliveryList, resterr.APIError) {
+	if filter.Limit == 0 {
+		filter.Limit = 100
+	}
+	return w.daoW.FindDelivery(filter)
+}
+
+// ReplayDelivery mengirim ulang payload dari log yang sudah ada sebagai log baru,
+// log lama tetap disimpan apa adanya
+func (w *WebhookService) ReplayDelivery(deliveryID string) (*dto.WebhookDelivery, resterr.APIError) {
+	oid, errT := primitive.ObjectIDFromHex(deliveryID)
+	if errT != nil {
+		return nil, resterr.NewBadRequestError("ObjectID yang dimasukkan salah")
+	}
+
+	delivery, err := w.daoW.GetDeliveryByID(oid)
+	if err != nil {
+		return nil, err
+	}
+
+	webhookSubs, err := w.daoW.GetWebhookByID(delivery.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	timeNow := time.Now().Unix()
+	replay := dto.WebhookDelivery{
+		ID:            primitive.NewObjectID(),
+		CreatedAt:     timeNow,
+		UpdatedAt:     timeNow,
+		WebhookID:     webhookSubs.ID,
+		Event:         delivery.Event,
+		Branch:        delivery.Branch,
+		URL:           webhookSubs.URL,
+		Payload:       delivery.Payload,
+		Status:        webhookdao.StatusDeliveryPending,
+		NextAttemptAt: timeNow + webhookClaimSecond,
+	}
+
+	_, err = w.daoW.InsertDelivery(replay)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.attemptDelivery(replay, webhookSubs.Secret), nil
+}
+
+// Emit mengirimkan event ke semua webhook aktif yang berlangganan event dan cabang terkait.
+// berjalan di goroutine sehingga tidak memperlambat request pemanggil
+func (w *WebhookService) Emit(event string, branch string, data interface{}) {
+	go func() {
+		webhookList, err := w.daoW.FindWebhookByEvent(event, branch)
+		if err != nil {
+			logger.Error(fmt.Sprintf("mendapatkan webhook gagal saat emit event %s", event), err)
+			return
+		}
+
+		for _, webhookSubs := range webhookList {
+			timeNow := time.Now().Unix()
+			delivery := dto.WebhookDelivery{
+				ID:            primitive.NewObjectID(),
+				CreatedAt:     timeNow,
+				UpdatedAt:     timeNow,
+				WebhookID:     webhookSubs.ID,
+				Event:         event,
+				Branch:        branch,
+				URL:           webhookSubs.URL,
+				Status:        webhookdao.StatusDeliveryPending,
+				NextAttemptAt: timeNow + webhookClaimSecond,
+			}
+
+			body, errJSON := json.Marshal(dto.WebhookEventPayload{
+				ID:        delivery.ID.Hex(),
+				Event:     event,
+				Branch:    branch,
+				Timestamp: timeNow,
+				Data:      data,
+			})
+			if errJSON != nil {
+				logger.Error(fmt.Sprintf("gagal membuat payload webhook event %s", event), errJSON)
+				continue
+			}
+			delivery.Payload = string(body)
+
+			if _, err := w.daoW.InsertDelivery(delivery); err != nil {
+				continue
+			}
+			w.attemptDelivery(delivery, webhookSubs.Secret)
+		}
+	}()
+}
+
+// RetryDueDeliveries dijalankan oleh scheduler untuk mengirim ulang log pending
+// yang sudah melewati waktu backoff. setiap log diklaim terlebih dahulu sehingga log yang sama
+// tidak dikirim dua kali oleh proses lain
+func (w *WebhookService) RetryDueDeliveries() (int, resterr.APIError) {
+	timeNow := time.Now().Unix()
+	deliveryList, err := w.daoW.FindDeliveryDue(timeNow)
+	if err != nil {
+		return 0, err
+	}
+
+	secrets := map[primitive.ObjectID]string{}
+	retried := 0
+	for _, delivery := range deliveryList {
+		claimed, err := w.daoW.ClaimDelivery(delivery.ID, delivery.NextAttemptAt, timeNow+webhookClaimSecond)
+		if err != nil || claimed == 0 {
+			continue
+		}
+
+		secret, ok := secrets[delivery.WebhookID]
+		if !ok {
+			webhookSubs, err := w.daoW.GetWebhookByID(delivery.WebhookID)
+			if err != nil {
+				// webhook sudah dihapus, log tidak dapat dikirim lagi
+				w.finishDelivery(delivery, webhookdao.StatusDeliveryFailed, 0, "webhook tidak ditemukan")
+				continue
+			}
+			secret = webhookSubs.Secret
+			secrets[delivery.WebhookID] = secret
+		}
+		w.attemptDelivery(delivery, secret)
+		retried++
+	}
+
+	return retried, nil
+}
+
+// attemptDelivery melakukan satu kali percobaan pengiriman dan mencatat hasilnya.
+// jika gagal dan masih ada sisa percobaan, waktu percobaan berikutnya dihitung dengan exponential backoff
+func (w *WebhookService) attemptDelivery(delivery dto.WebhookDelivery, secret string) *dto.WebhookDelivery {
+	statusCode, errSend := w.client.Send(webhook.Payload{
+		DeliveryID: delivery.ID.Hex(),
+		Event:      delivery.Event,
+		URL:        delivery.URL,
+		Secret:     secret,
+		Body:       []byte(delivery.Payload),
+	})
+
+	attempts := delivery.Attempts + 1
+	if errSend == nil {
+		delivery.Attempts = attempts
+		return w.finishDelivery(delivery, webhookdao.StatusDeliverySuccess, statusCode, "")
+	}
+
+	if attempts >= webhookMaxAttempts {
+		delivery.Attempts = attempts
+		return w.finishDelivery(delivery, webhookdao.StatusDeliveryFailed, statusCode, errSend.Error())
+	}
+
+	timeNow := time.Now().Unix()
+	backoff := int64(webhookBaseBackoff) << (attempts - 1)
+	updated, err := w.daoW.UpdateDeliveryAttempt(dto.WebhookDeliveryAttempt{
+		ID:             delivery.ID,
+		UpdatedAt:      timeNow,
+		Status:         webhookdao.StatusDeliveryPending,
+		Attempts:       attempts,
+		NextAttemptAt:  timeNow + backoff,
+		LastStatusCode: statusCode,
+		LastError:      errSend.Error(),
+	})
+	if err != nil {
+		return &delivery
+	}
+	return updated
+}
+
+func (w *WebhookService) finishDelivery(delivery dto.WebhookDelivery, status string, statusCode int, errMessage string) *dto.WebhookDelivery {
+	updated, err := w.daoW.UpdateDeliveryAttempt(dto.WebhookDeliveryAttempt{
+		ID:             delivery.ID,
+		UpdatedAt:      time.Now().Unix(),
+		Status:         status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  0,
+		LastStatusCode: statusCode,
+		LastError:      errMessage,
+	})
+	if err != nil {
+		return &delivery
+	}
+	return updated
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"tilank/clients/webhook"
+	"tilank/dao/webhookdao"
+	"tilank/dto"
+	"tilank/utils/rest_err"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeDeliveryDao log pengiriman webhook di memori, claimed berisi log yang sudah diklaim proses lain
+type fakeDeliveryDao struct {
+	fakeWebhookDao
+	webhook    dto.Webhook
+	deliveries dto.WebhookDeliveryList
+	claimed    map[primitive.ObjectID]bool
+	attempts   []dto.WebhookDeliveryAttempt
+}
+
+func (f *fakeDeliveryDao) GetWebhookByID(_ primitive.ObjectID) (*dto.Webhook, resterr.APIError) {
+	webhookSubs := f.webhook
+	return &webhookSubs, nil
+}
+
+func (f *fakeDeliveryDao) FindDeliveryDue(_ int64) (dto.WebhookDeliveryList, resterr.APIError) {
+	return f.deliveries, nil
+}
+
+func (f *fakeDeliveryDao) ClaimDelivery(deliveryID primitive.ObjectID, _ int64, _ int64) (int64, resterr.APIError) {
+	if f.claimed[deliveryID] {
+		return 0, nil
+	}
+	f.claimed[deliveryID] = true
+	return 1, nil
+}
+
+func (f *fakeDeliveryDao) UpdateDeliveryAttempt(input dto.WebhookDeliveryAttempt) (*dto.WebhookDelivery, resterr.APIError) {
+	f.attempts = append(f.attempts, input)
+	return &dto.WebhookDelivery{ID: input.ID, Status: input.Status, Attempts: input.Attempts}, nil
+}
+
+func (f *fakeDeliveryDao) EditWebhook(_ dto.WebhookEdit) (*dto.Webhook, resterr.APIError) {
+	webhookSubs := f.webhook
+	return &webhookSubs, nil
+}
+
+// fakeWebhookClient mencatat payload yang dikirim, err dikembalikan untuk setiap pengiriman
+type fakeWebhookClient struct {
+	sent []webhook.Payload
+	err  error
+}
+
+func (f *fakeWebhookClient) Send(payload webhook.Payload) (int, error) {
+	f.sent = append(f.sent, payload)
+	if f.err != nil {
+		return 500, f.err
+	}
+	return 200, nil
+}
+
+func TestRetryDueDeliveriesSkipClaimed(t *testing.T) {
+	free := primitive.NewObjectID()
+	inFlight := primitive.NewObjectID()
+	daoW := &fakeDeliveryDao{
+		webhook: dto.Webhook{ID: primitive.NewObjectID(), Secret: "secret"},
+		deliveries: dto.WebhookDeliveryList{
+			{ID: free, Status: webhookdao.StatusDeliveryPending, Attempts: 1},
+			// sedang dikirim oleh Emit atau proses scheduler lain
+			{ID: inFlight, Status: webhookdao.StatusDeliveryPending},
+		},
+		claimed: map[primitive.ObjectID]bool{inFlight: true},
+	}
+	client := &fakeWebhookClient{}
+	w := NewWebhookService(daoW, client)
+
+	retried, apiErr := w.RetryDueDeliveries()
+	assert.Nil(t, apiErr)
+	assert.Equal(t, 1, retried)
+	assert.Len(t, client.sent, 1)
+	assert.Equal(t, free.Hex(), client.sent[0].DeliveryID)
+	assert.Equal(t, "secret", client.sent[0].Secret)
+	assert.Len(t, daoW.attempts, 1)
+	assert.Equal(t, webhookdao.StatusDeliverySuccess, daoW.attempts[0].Status)
+	assert.Equal(t, 2, daoW.attempts[0].Attempts)
+}
+
+func TestAttemptDeliveryBackoff(t *testing.T) {
+	daoW := &fakeDeliveryDao{}
+	w := NewWebhookService(daoW, &fakeWebhookClient{err: errors.New("status 500")})
+
+	delivery := w.attemptDelivery(dto.WebhookDelivery{ID: primitive.NewObjectID(), Attempts: 2}, "secret")
+	assert.Equal(t, webhookdao.StatusDeliveryPending, delivery.Status)
+	assert.Equal(t, 3, daoW.attempts[0].Attempts)
+	assert.Equal(t, daoW.attempts[0].UpdatedAt+webhookBaseBackoff*4, daoW.attempts[0].NextAttemptAt)
+
+	delivery = w.attemptDelivery(dto.WebhookDelivery{ID: primitive.NewObjectID(), Attempts: webhookMaxAttempts - 1}, "secret")
+	assert.Equal(t, webhookdao.StatusDeliveryFailed, delivery.Status)
+}
+
+func TestWebhookResponseWithoutSecret(t *testing.T) {
+	webhookID := primitive.NewObjectID()
+	daoW := &fakeDeliveryDao{webhook: dto.Webhook{ID: webhookID, Name: "erp", Secret: "secret"}}
+	w := NewWebhookService(daoW, nil)
+
+	webhookSubs, apiErr := w.GetWebhookByID(webhookID.Hex())
+	assert.Nil(t, apiErr)
+	assert.Equal(t, "erp", webhookSubs.Name)
+	body, _ := json.Marshal(webhookSubs)
+	assert.NotContains(t, string(body), "secret")
+
+	webhookEdited, apiErr := w.EditWebhook(hsseClaims, webhookID.Hex(), dto.WebhookEditRequest{Name: "erp"})
+	assert.Nil(t, apiErr)
+	assert.Equal(t, webhookID, webhookEdited.ID)
+	body, _ = json.Marshal(webhookEdited)
+	assert.NotContains(t, string(body), "secret")
+}
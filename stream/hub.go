@@ -0,0 +1,94 @@
+package stream
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"tilank/utils/logger"
+	"time"
+)
+
+// subscriberBuffer jumlah event yang ditampung per koneksi sebelum event dibuang
+const subscriberBuffer = 20
+
+// Event dikirim ke client dashboard melalui server-sent events
+type Event struct {
+	Name      string      `json:"event"`
+	Branch    string      `json:"branch"`
+	Timestamp int64       `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+func NewHub() HubAssumer {
+	return &hub{
+		subscribers: map[*subscriber]struct{}{},
+	}
+}
+
+type HubAssumer interface {
+	Publish(name string, branch string, data interface{})
+	Subscribe(branches ...string) (<-chan Event, func())
+}
+
+type subscriber struct {
+	branches map[string]bool
+	ch       chan Event
+}
+
+type hub struct {
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+}
+
+// Publish meneruskan event ke semua subscriber yang berlangganan cabang event.
+// tidak pernah memblokir pemanggil, subscriber yang lambat akan kehilangan event
+func (h *hub) Publish(name string, branch string, data interface{}) {
+	event := Event{
+		Name:      name,
+		Branch:    strings.ToUpper(branch),
+		Timestamp: time.Now().Unix(),
+		Data:      data,
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.subscribers {
+		if !sub.branches[event.Branch] {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			logger.Info(fmt.Sprintf("stream | subscriber %s penuh, event %s dibuang", event.Branch, event.Name))
+		}
+	}
+}
+
+// Subscribe mendaftarkan subscriber untuk satu atau beberapa cabang.
+// fungsi kembalian wajib dipanggil ketika koneksi client ditutup
+func (h *hub) Subscribe(branches ...string) (<-chan Event, func()) {
+	sub := &subscriber{
+		branches: make(map[string]bool, len(branches)),
+		ch:       make(chan Event, subscriberBuffer),
+	}
+	for _, branch := range branches {
+		sub.branches[strings.ToUpper(branch)] = true
+	}
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers, sub)
+			h.mu.Unlock()
+			close(sub.ch)
+		})
+	}
+
+	return sub.ch, unsubscribe
+}
@@ -0,0 +1,60 @@
+package stream
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPublishOnlySameBranch(t *testing.T) {
+	h := NewHub()
+	eventsA, unsubscribeA := h.Subscribe("banjarmasin")
+	defer unsubscribeA()
+	eventsB, unsubscribeB := h.Subscribe("SAMPIT")
+	defer unsubscribeB()
+
+	h.Publish("truck.blocked", "BANJARMASIN", "data")
+
+	event := <-eventsA
+	assert.Equal(t, "truck.blocked", event.Name)
+	assert.Equal(t, "BANJARMASIN", event.Branch)
+	assert.Equal(t, "data", event.Data)
+	assert.Len(t, eventsB, 0)
+}
+
+func TestSubscribeMultipleBranches(t *testing.T) {
+	h := NewHub()
+	events, unsubscribe := h.Subscribe("BANJARMASIN", "sampit")
+	defer unsubscribe()
+
+	h.Publish("truck.blocked", "BANJARMASIN", "a")
+	h.Publish("truck.blocked", "SAMPIT", "b")
+	h.Publish("truck.blocked", "PALANGKARAYA", "c")
+
+	assert.Len(t, events, 2)
+	assert.Equal(t, "BANJARMASIN", (<-events).Branch)
+	assert.Equal(t, "SAMPIT", (<-events).Branch)
+}
+
+func TestUnsubscribeClosesChannel(t *testing.T) {
+	h := NewHub()
+	events, unsubscribe := h.Subscribe("BANJARMASIN")
+
+	unsubscribe()
+	unsubscribe()
+	h.Publish("truck.blocked", "BANJARMASIN", nil)
+
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestPublishDoesNotBlockWhenBufferFull(t *testing.T) {
+	h := NewHub()
+	events, unsubscribe := h.Subscribe("BANJARMASIN")
+	defer unsubscribe()
+
+	for i := 0; i < subscriberBuffer+5; i++ {
+		h.Publish("violation.created", "BANJARMASIN", i)
+	}
+
+	assert.Len(t, events, subscriberBuffer)
+}
@@ -0,0 +1,90 @@
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"tilank/config"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const envTrustedProxies = "TRUSTED_PROXIES"
+
+// trustedProxies daftar reverse proxy yang header X-Forwarded-For nya dipercaya,
+// kosong berarti header tersebut diabaikan dan ip koneksi digunakan apa adanya
+var trustedProxies []*net.IPNet
+
+// Init memuat daftar ip atau CIDR reverse proxy terpercaya dari TRUSTED_PROXIES (dipisah koma)
+func Init() error {
+	proxies, err := parseTrustedProxies(config.EnvString(envTrustedProxies))
+	if err != nil {
+		return err
+	}
+	trustedProxies = proxies
+	return nil
+}
+
+func parseTrustedProxies(raw string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("%s berisi ip tidak valid: %s", envTrustedProxies, entry)
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			entry = fmt.Sprintf("%s/%d", entry, bits)
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s berisi CIDR tidak valid: %s", envTrustedProxies, entry)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+func isTrusted(proxies []*net.IPNet, ip net.IP) bool {
+	for _, network := range proxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// FromCtx mengembalikan ip client asli. X-Forwarded-For hanya dibaca jika koneksi berasal dari
+// proxy terpercaya, header ditelusuri dari kanan dan hop pertama yang bukan proxy terpercaya
+// adalah client karena entri di sebelah kirinya dapat diisi bebas oleh client
+func FromCtx(c *fiber.Ctx) string {
+	return resolve(trustedProxies, c.IP(), c.IPs())
+}
+
+func resolve(proxies []*net.IPNet, remoteIP string, forwarded []string) string {
+	remote := net.ParseIP(remoteIP)
+	if remote == nil || !isTrusted(proxies, remote) {
+		return remoteIP
+	}
+
+	client := remoteIP
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if hop == nil {
+			break
+		}
+		// salin karena string dari header mengacu ke buffer request yang digunakan ulang
+		client = hop.String()
+		if !isTrusted(proxies, hop) {
+			break
+		}
+	}
+	return client
+}
@@ -0,0 +1,44 @@
+package clientip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := parseTrustedProxies("127.0.0.1, 10.0.0.0/8,::1")
+	assert.Nil(t, err)
+	assert.Len(t, proxies, 3)
+
+	_, err = parseTrustedProxies("localhost")
+	assert.NotNil(t, err)
+
+	_, err = parseTrustedProxies("10.0.0.0/33")
+	assert.NotNil(t, err)
+}
+
+func TestResolve(t *testing.T) {
+	proxies, _ := parseTrustedProxies("127.0.0.1,10.0.0.0/8")
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		expected  string
+	}{
+		{"tanpa proxy", "203.0.113.7", nil, "203.0.113.7"},
+		{"header dari client langsung diabaikan", "203.0.113.7", []string{"1.2.3.4"}, "203.0.113.7"},
+		{"proxy terpercaya", "127.0.0.1", []string{"203.0.113.7"}, "203.0.113.7"},
+		{"entri palsu di kiri diabaikan", "127.0.0.1", []string{"1.2.3.4", "203.0.113.7"}, "203.0.113.7"},
+		{"beberapa proxy terpercaya", "127.0.0.1", []string{"203.0.113.7", "10.1.2.3"}, "203.0.113.7"},
+		{"entri tidak valid", "127.0.0.1", []string{"bukan-ip"}, "127.0.0.1"},
+		{"proxy tanpa header", "127.0.0.1", nil, "127.0.0.1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, resolve(proxies, tc.remote, tc.forwarded))
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package crypt
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"tilank/config"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	envArgonMemory  = "ARGON2_MEMORY_KB"
+	envArgonTime    = "ARGON2_TIME"
+	envArgonThreads = "ARGON2_THREADS"
+
+	argon2idPrefix = "$argon2id$"
+	argonSaltLen   = 16
+	argonKeyLen    = 32
+)
+
+// Argon2Params parameter argon2id yang disimpan di dalam hash sehingga dapat diubah tanpa
+// membuat hash lama tidak valid
+type Argon2Params struct {
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+}
+
+var (
+	defaultArgonParams = Argon2Params{
+		Memory:  64 * 1024,
+		Time:    3,
+		Threads: 2,
+	}
+	argonParams = defaultArgonParams
+
+	errInvalidHash = errors.New("format hash argon2id tidak valid")
+)
+
+// Init memuat parameter argon2id dari environment, nilai kosong menggunakan parameter default
+func Init() error {
+	params := defaultArgonParams
+
+	memory, err := config.EnvInt(envArgonMemory, int(params.Memory), 8*1024, 1024*1024)
+	if err != nil {
+		return err
+	}
+	timeCost, err := config.EnvInt(envArgonTime, int(params.Time), 1, 20)
+	if err != nil {
+		return err
+	}
+	threads, err := config.EnvInt(envArgonThreads, int(params.Threads), 1, 64)
+	if err != nil {
+		return err
+	}
+	params.Memory = uint32(memory)
+	params.Time = uint32(timeCost)
+	params.Threads = uint8(threads)
+
+	argonParams = params
+	return nil
+}
+
+// argon2Hash membuat hash dengan format $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+func argon2Hash(password string, params Argon2Params) (string, error) {
+	salt := make([]byte, argonSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, argonKeyLen)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		params.Memory,
+		params.Time,
+		params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// argon2Match memverifikasi password menggunakan parameter yang tersimpan di hash
+func argon2Match(password string, hashPass string) bool {
+	params, salt, key, err := decodeArgon2(hashPass)
+	if err != nil {
+		return false
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, otherKey) == 1
+}
+
+func decodeArgon2(hashPass string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(hashPass, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return params, nil, nil, errInvalidHash
+	}
+	if params.Memory == 0 || params.Time == 0 || params.Threads == 0 {
+		return params, nil, nil, errInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, errInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errInvalidHash
+	}
+
+	return params, salt, key, nil
+}
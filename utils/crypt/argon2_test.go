@@ -0,0 +1,50 @@
+package crypt
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGenerateHashArgon2id(t *testing.T) {
+	passwordHash, err := Obj.GenerateHash("password")
+
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(passwordHash, "$argon2id$v=19$m=65536,t=3,p=2$"))
+	assert.True(t, Obj.IsPWAndHashPWMatch("password", passwordHash))
+	assert.False(t, Obj.IsPWAndHashPWMatch("Password", passwordHash))
+	assert.False(t, Obj.NeedsRehash(passwordHash))
+}
+
+func TestNeedsRehashLegacyBcrypt(t *testing.T) {
+	hashPass := "$2a$04$Whst1LZo5bt9XaE/nCqJRehzQRcSG7nTP/sf3LVfNEEpWeLCJHlE6"
+
+	assert.True(t, Obj.NeedsRehash(hashPass))
+}
+
+func TestNeedsRehashParamsChanged(t *testing.T) {
+	oldHash, err := argon2Hash("password", Argon2Params{Memory: 8 * 1024, Time: 1, Threads: 1})
+	assert.Nil(t, err)
+
+	// hash dengan parameter lama tetap valid namun perlu di-hash ulang
+	assert.True(t, Obj.IsPWAndHashPWMatch("password", oldHash))
+	assert.True(t, Obj.NeedsRehash(oldHash))
+}
+
+func TestInitArgon2Params(t *testing.T) {
+	defer func() {
+		argonParams = defaultArgonParams
+		_ = os.Unsetenv(envArgonMemory)
+		_ = os.Unsetenv(envArgonTime)
+		_ = os.Unsetenv(envArgonThreads)
+	}()
+
+	_ = os.Setenv(envArgonMemory, "32768")
+	_ = os.Setenv(envArgonTime, "2")
+	assert.Nil(t, Init())
+	assert.Equal(t, Argon2Params{Memory: 32768, Time: 2, Threads: 2}, argonParams)
+
+	_ = os.Setenv(envArgonThreads, "0")
+	assert.NotNil(t, Init())
+}
@@ -0,0 +1,51 @@
+package crypt
+
+import (
+	"strings"
+	"tilank/utils/logger"
+	"tilank/utils/rest_err"
+)
+
+func NewCrypto() HasherAssumer {
+	return &cryptoObj{}
+}
+
+// HasherAssumer hashing password dengan format berversi. hash baru selalu menggunakan argon2id,
+// hash lama (bcrypt) tetap dapat diverifikasi dan ditandai NeedsRehash agar diganti ketika user login
+type HasherAssumer interface {
+	GenerateHash(password string) (string, resterr.APIError)
+	IsPWAndHashPWMatch(password string, hashPass string) bool
+	NeedsRehash(hashPass string) bool
+}
+
+type cryptoObj struct {
+}
+
+// GenerateHash membuat hashpassword argon2id, hash password 1 dengan yang lainnya akan berbeda meskipun
+// inputannya sama, sehingga untuk membandingkan hashpassword memerlukan method lain IsPWAndHashPWMatch
+func (c *cryptoObj) GenerateHash(password string) (string, resterr.APIError) {
+	passwordHash, err := argon2Hash(password, argonParams)
+	if err != nil {
+		logger.Error("Error pada kriptograpi (GenerateHash)", err)
+		restErr := resterr.NewInternalServerError("Crypto error", err)
+		return "", restErr
+	}
+	return passwordHash, nil
+}
+
+// IsPWAndHashPWMatch return true jika inputan password dan hashpassword sesuai, format hash dikenali dari prefix
+func (c *cryptoObj) IsPWAndHashPWMatch(password string, hashPass string) bool {
+	if strings.HasPrefix(hashPass, argon2idPrefix) {
+		return argon2Match(password, hashPass)
+	}
+	return bcryptMatch(password, hashPass)
+}
+
+// NeedsRehash return true jika hash bukan argon2id atau parameter argon2id berbeda dengan konfigurasi saat ini
+func (c *cryptoObj) NeedsRehash(hashPass string) bool {
+	params, _, _, err := decodeArgon2(hashPass)
+	if err != nil {
+		return true
+	}
+	return params != argonParams
+}
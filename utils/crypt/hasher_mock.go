@@ -5,11 +5,11 @@ import (
 	"tilank/utils/rest_err"
 )
 
-type MockBcrypt struct {
+type MockHasher struct {
 	mock.Mock
 }
 
-func (m *MockBcrypt) GenerateHash(password string) (string, resterr.APIError) {
+func (m *MockHasher) GenerateHash(password string) (string, resterr.APIError) {
 	args := m.Called(password)
 	var err resterr.APIError
 	if args.Get(1) != nil {
@@ -19,7 +19,12 @@ func (m *MockBcrypt) GenerateHash(password string) (string, resterr.APIError) {
 	return args.Get(0).(string), err
 }
 
-func (m *MockBcrypt) IsPWAndHashPWMatch(password string, hashPass string) bool {
+func (m *MockHasher) IsPWAndHashPWMatch(password string, hashPass string) bool {
 	args := m.Called(password, hashPass)
 	return args.Bool(0)
 }
+
+func (m *MockHasher) NeedsRehash(hashPass string) bool {
+	args := m.Called(hashPass)
+	return args.Bool(0)
+}
@@ -8,9 +8,24 @@ import (
 const (
 	Access int = iota
 	Refresh
+	// TwoFactor token sementara setelah password benar, hanya dapat ditukar dengan token access dan refresh
+	// setelah kode TOTP diverifikasi
+	TwoFactor
+	// PasswordChange token sementara untuk user yang wajib mengganti password (kadaluarsa atau hasil reset admin),
+	// hanya dapat digunakan untuk mengganti password pada saat login
+	PasswordChange
+	// TwoFactorSetup token sementara untuk user yang diwajibkan two factor tetapi belum mendaftarkan authenticator,
+	// hanya dapat digunakan untuk setup dan aktivasi two factor pada saat login
+	TwoFactorSetup
+	// StreamTicket token singkat untuk membuka koneksi server-sent events melalui query, dibuat dari access token
+	// karena EventSource pada browser tidak dapat mengirim header Authorization
+	StreamTicket
 )
 
 type CustomClaim struct {
+	TokenID     string
+	FamilyID    string
+	IssuedAt    int64
 	Identity    string
 	Name        string
 	Exp         int64
@@ -19,4 +34,7 @@ type CustomClaim struct {
 	Fresh       bool
 	Roles       []string
 	Branch      string
-}
\ No newline at end of file
+	Branches    []string
+	// AccessExp masa berlaku access token asal, hanya diisi pada token StreamTicket
+	AccessExp int64
+}
@@ -0,0 +1,243 @@
+package mjwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"sort"
+	"strings"
+	"tilank/config"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	envAlgorithm  = "JWT_ALGORITHM"
+	envKeyDir     = "JWT_KEY_DIR"
+	envSigningKID = "JWT_SIGNING_KID"
+	envAppEnv     = "APP_ENV"
+
+	defaultSecret    = "rahasia"
+	privateKeySuffix = ".pem"
+	publicKeySuffix  = ".pub.pem"
+)
+
+// keyConfig menyimpan kunci penandatanganan aktif dan seluruh kunci verifikasi berdasarkan kid.
+// kunci lama cukup disimpan sebagai public key (<kid>.pub.pem) agar token lama tetap valid selama rotasi
+type keyConfig struct {
+	method     jwt.SigningMethod
+	signingKID string
+	signingKey interface{}
+	verifyKeys map[string]interface{}
+}
+
+// JWK representasi public key sesuai RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Init memuat konfigurasi kunci dari environment variable.
+// di luar mode dev (APP_ENV=dev) aplikasi menolak berjalan dengan secret default atau kosong
+func Init() error {
+	keys, err := loadKeyConfig(
+		config.EnvString(envAlgorithm),
+		config.EnvString(secretKey),
+		config.EnvString(envKeyDir),
+		config.EnvString(envSigningKID),
+		isDevMode(config.EnvString(envAppEnv)),
+	)
+	if err != nil {
+		return err
+	}
+
+	JwtObj.(*jwtUtils).keys = keys
+	return nil
+}
+
+func isDevMode(appEnv string) bool {
+	appEnv = strings.ToLower(strings.TrimSpace(appEnv))
+	return appEnv == "dev" || appEnv == "development"
+}
+
+func loadKeyConfig(algorithm string, secretValue string, keyDir string, signingKID string, devMode bool) (*keyConfig, error) {
+	algorithm = strings.ToUpper(strings.TrimSpace(algorithm))
+	if algorithm == "" {
+		algorithm = jwt.SigningMethodHS256.Alg()
+	}
+
+	switch algorithm {
+	case jwt.SigningMethodHS256.Alg():
+		if secretValue == "" || secretValue == defaultSecret {
+			if !devMode {
+				return nil, fmt.Errorf("%s kosong atau menggunakan nilai default, set %s=dev untuk pengembangan", secretKey, envAppEnv)
+			}
+			secretValue = defaultSecret
+		}
+		return &keyConfig{
+			method:     jwt.SigningMethodHS256,
+			signingKey: []byte(secretValue),
+			verifyKeys: map[string]interface{}{},
+		}, nil
+	case jwt.SigningMethodRS256.Alg():
+		return loadAsymmetricKeys(jwt.SigningMethodRS256, keyDir, signingKID)
+	case jwt.SigningMethodES256.Alg():
+		return loadAsymmetricKeys(jwt.SigningMethodES256, keyDir, signingKID)
+	default:
+		return nil, fmt.Errorf("%s %s tidak didukung, gunakan HS256, RS256 atau ES256", envAlgorithm, algorithm)
+	}
+}
+
+// loadAsymmetricKeys membaca <kid>.pem (private key) dan <kid>.pub.pem (public key) dari keyDir
+func loadAsymmetricKeys(method jwt.SigningMethod, keyDir string, signingKID string) (*keyConfig, error) {
+	if keyDir == "" {
+		return nil, fmt.Errorf("%s wajib diisi untuk algoritma %s", envKeyDir, method.Alg())
+	}
+
+	files, err := ioutil.ReadDir(keyDir)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca %s: %w", envKeyDir, err)
+	}
+
+	config := &keyConfig{
+		method:     method,
+		verifyKeys: map[string]interface{}{},
+	}
+	privateKeys := map[string]interface{}{}
+
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, privateKeySuffix) {
+			continue
+		}
+		pemBytes, err := ioutil.ReadFile(filepath.Join(keyDir, name))
+		if err != nil {
+			return nil, fmt.Errorf("gagal membaca kunci %s: %w", name, err)
+		}
+
+		if strings.HasSuffix(name, publicKeySuffix) {
+			kid := strings.TrimSuffix(name, publicKeySuffix)
+			publicKey, err := parsePublicKey(method, pemBytes)
+			if err != nil {
+				return nil, fmt.Errorf("kunci %s tidak valid: %w", name, err)
+			}
+			config.verifyKeys[kid] = publicKey
+			continue
+		}
+
+		kid := strings.TrimSuffix(name, privateKeySuffix)
+		privateKey, publicKey, err := parsePrivateKey(method, pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("kunci %s tidak valid: %w", name, err)
+		}
+		privateKeys[kid] = privateKey
+		config.verifyKeys[kid] = publicKey
+	}
+
+	if signingKID == "" {
+		if len(privateKeys) != 1 {
+			return nil, fmt.Errorf("%s wajib diisi jika jumlah private key bukan satu (ditemukan %d)", envSigningKID, len(privateKeys))
+		}
+		for kid := range privateKeys {
+			signingKID = kid
+		}
+	}
+
+	signingKey, ok := privateKeys[signingKID]
+	if !ok {
+		return nil, fmt.Errorf("private key dengan kid %s tidak ditemukan di %s", signingKID, keyDir)
+	}
+	config.signingKID = signingKID
+	config.signingKey = signingKey
+
+	return config, nil
+}
+
+func parsePrivateKey(method jwt.SigningMethod, pemBytes []byte) (interface{}, interface{}, error) {
+	if method == jwt.SigningMethodRS256 {
+		key, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, &key.PublicKey, nil
+	}
+
+	key, err := jwt.ParseECPrivateKeyFromPEM(pemBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if key.Curve.Params().Name != "P-256" {
+		return nil, nil, errors.New("ES256 memerlukan kurva P-256")
+	}
+	return key, &key.PublicKey, nil
+}
+
+func parsePublicKey(method jwt.SigningMethod, pemBytes []byte) (interface{}, error) {
+	if method == jwt.SigningMethodRS256 {
+		return jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+	}
+	return jwt.ParseECPublicKeyFromPEM(pemBytes)
+}
+
+// jwks mengembalikan seluruh public key verifikasi, kosong untuk HS256 karena secret tidak boleh dipublikasikan
+func (k *keyConfig) jwks() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+
+	kids := make([]string, 0, len(k.verifyKeys))
+	for kid := range k.verifyKeys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	for _, kid := range kids {
+		switch key := k.verifyKeys[kid].(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				Alg: k.method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		case *ecdsa.PublicKey:
+			size := (key.Curve.Params().BitSize + 7) / 8
+			set.Keys = append(set.Keys, JWK{
+				Kty: "EC",
+				Kid: kid,
+				Use: "sig",
+				Alg: k.method.Alg(),
+				Crv: key.Curve.Params().Name,
+				X:   base64.RawURLEncoding.EncodeToString(padBytes(key.X.Bytes(), size)),
+				Y:   base64.RawURLEncoding.EncodeToString(padBytes(key.Y.Bytes(), size)),
+			})
+		}
+	}
+
+	return set
+}
+
+func padBytes(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
@@ -0,0 +1,110 @@
+package mjwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeRSAKey(t *testing.T, dir string, kid string, publicOnly bool) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	if publicOnly {
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		assert.Nil(t, err)
+		pemBytes := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, kid+publicKeySuffix), pemBytes, 0600))
+		return
+	}
+
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, kid+privateKeySuffix), pemBytes, 0600))
+}
+
+func writeECKey(t *testing.T, dir string, kid string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	der, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, kid+privateKeySuffix), pemBytes, 0600))
+}
+
+func TestLoadKeyConfig_RefuseDefaultSecret(t *testing.T) {
+	_, err := loadKeyConfig("", "", "", "", false)
+	assert.NotNil(t, err)
+
+	_, err = loadKeyConfig("HS256", defaultSecret, "", "", false)
+	assert.NotNil(t, err)
+
+	config, err := loadKeyConfig("HS256", "", "", "", true)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(defaultSecret), config.signingKey)
+	assert.Empty(t, config.jwks().Keys)
+}
+
+func TestLoadKeyConfig_RS256Rotation(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "2024-01", false)
+	writeRSAKey(t, dir, "2024-02", false)
+	writeRSAKey(t, dir, "2023-12", true)
+
+	_, err := loadKeyConfig("RS256", "", dir, "", false)
+	assert.NotNil(t, err, "kid wajib dipilih jika private key lebih dari satu")
+
+	oldConfig, err := loadKeyConfig("RS256", "", dir, "2024-01", false)
+	assert.Nil(t, err)
+	newConfig, err := loadKeyConfig("RS256", "", dir, "2024-02", false)
+	assert.Nil(t, err)
+
+	oldJwt := &jwtUtils{keys: oldConfig}
+	newJwt := &jwtUtils{keys: newConfig}
+
+	// token yang ditandatangani kunci lama tetap valid setelah rotasi
+	signedToken, apiErr := oldJwt.GenerateToken(CustomClaim{Identity: "muchlis", Roles: []string{"ADMIN"}, ExtraMinute: 1})
+	assert.Nil(t, apiErr)
+	token, apiErr := newJwt.ValidateToken(signedToken)
+	assert.Nil(t, apiErr)
+	assert.Equal(t, "2024-01", token.Header["kid"])
+
+	jwks := newJwt.JWKS()
+	assert.Len(t, jwks.Keys, 3)
+	assert.Equal(t, "2023-12", jwks.Keys[0].Kid)
+	assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+	assert.Equal(t, "AQAB", jwks.Keys[0].E)
+}
+
+func TestLoadKeyConfig_ES256(t *testing.T) {
+	dir := t.TempDir()
+	writeECKey(t, dir, "ec-1")
+
+	config, err := loadKeyConfig("es256", "", dir, "", false)
+	assert.Nil(t, err)
+	j := &jwtUtils{keys: config}
+
+	signedToken, apiErr := j.GenerateToken(CustomClaim{Identity: "muchlis", Roles: []string{"ADMIN"}, ExtraMinute: 1})
+	assert.Nil(t, apiErr)
+	_, apiErr = j.ValidateToken(signedToken)
+	assert.Nil(t, apiErr)
+
+	jwks := j.JWKS()
+	assert.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "P-256", jwks.Keys[0].Crv)
+	assert.Len(t, jwks.Keys[0].X, 43)
+
+	// token HS256 tidak boleh diterima ketika server menggunakan ES256
+	hmacToken, apiErr := JwtObj.GenerateToken(CustomClaim{Identity: "muchlis", Roles: []string{"ADMIN"}, ExtraMinute: 1})
+	assert.Nil(t, apiErr)
+	_, apiErr = j.ValidateToken(hmacToken)
+	assert.NotNil(t, apiErr)
+}
@@ -1,9 +1,10 @@
 package mjwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"github.com/dgrijalva/jwt-go"
 	"net/http"
-	"os"
 	"tilank/utils/logger"
 	"tilank/utils/rest_err"
 	"time"
@@ -11,61 +12,94 @@ import (
 
 var (
 	JwtObj JWTAssumer
-	secret []byte
 )
 
 func NewJwt() JWTAssumer {
 	return JwtObj
 }
 
+// init menyiapkan konfigurasi HS256 sementara agar package dapat digunakan (misal dalam test)
+// sebelum Init dipanggil. init berjalan sebelum .env dimuat sehingga konfigurasi sebenarnya dimuat oleh Init
 func init() {
-	secret = []byte(os.Getenv(secretKey))
-	if string(secret) == "" {
-		secret = []byte("rahasia")
+	JwtObj = &jwtUtils{
+		keys: &keyConfig{
+			method:     jwt.SigningMethodHS256,
+			signingKey: []byte(defaultSecret),
+			verifyKeys: map[string]interface{}{},
+		},
 	}
-
-	JwtObj = &jwtUtils{}
 }
 
 type JWTAssumer interface {
 	GenerateToken(claims CustomClaim) (string, resterr.APIError)
 	ValidateToken(tokenString string) (*jwt.Token, resterr.APIError)
 	ReadToken(token *jwt.Token) (*CustomClaim, resterr.APIError)
+	JWKS() JWKSet
 }
 
 type jwtUtils struct {
+	keys *keyConfig
 }
 
 const (
 	CLAIMS    = "claims"
 	secretKey = "SECRET_KEY"
 
+	tokenIDKey   = "jti"
+	familyIDKey  = "fam"
+	issuedAtKey  = "iat"
 	identityKey  = "identity"
 	nameKey      = "name"
 	rolesKey     = "roles"
 	branchKey    = "branch"
+	branchesKey  = "branches"
 	tokenTypeKey = "type"
 	expKey       = "exp"
 	freshKey     = "fresh"
+	accessExpKey = "aexp"
 )
 
 // GenerateToken membuat token jwt untuk login header, untuk menguji nilai payloadnya
-// dapat menggunakan situs jwt.io
+// dapat menggunakan situs jwt.io. TokenID (jti) dibuat otomatis jika kosong
 func (j *jwtUtils) GenerateToken(claims CustomClaim) (string, resterr.APIError) {
-	expired := time.Now().Add(time.Minute * claims.ExtraMinute).Unix()
+	timeNow := time.Now()
+	expired := timeNow.Add(time.Minute * claims.ExtraMinute).Unix()
+
+	if claims.TokenID == "" {
+		tokenID, err := GenerateTokenID()
+		if err != nil {
+			logger.Error("gagal membuat token id", err)
+			return "", resterr.NewInternalServerError("gagal membuat token id", err)
+		}
+		claims.TokenID = tokenID
+	}
 
 	jwtClaim := jwt.MapClaims{}
+	jwtClaim[tokenIDKey] = claims.TokenID
+	if claims.FamilyID != "" {
+		jwtClaim[familyIDKey] = claims.FamilyID
+	}
+	jwtClaim[issuedAtKey] = timeNow.Unix()
 	jwtClaim[identityKey] = claims.Identity
 	jwtClaim[nameKey] = claims.Name
 	jwtClaim[rolesKey] = claims.Roles
 	jwtClaim[branchKey] = claims.Branch
+	if len(claims.Branches) != 0 {
+		jwtClaim[branchesKey] = claims.Branches
+	}
 	jwtClaim[expKey] = expired
 	jwtClaim[tokenTypeKey] = claims.Type
 	jwtClaim[freshKey] = claims.Fresh
+	if claims.AccessExp != 0 {
+		jwtClaim[accessExpKey] = claims.AccessExp
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwtClaim)
+	token := jwt.NewWithClaims(j.keys.method, jwtClaim)
+	if j.keys.signingKID != "" {
+		token.Header["kid"] = j.keys.signingKID
+	}
 
-	signedToken, err := token.SignedString(secret)
+	signedToken, err := token.SignedString(j.keys.signingKey)
 	if err != nil {
 		logger.Error("gagal menandatangani token", err)
 		return "", resterr.NewInternalServerError("gagal menandatangani token", err)
@@ -84,18 +118,59 @@ func (j *jwtUtils) ReadToken(token *jwt.Token) (*CustomClaim, resterr.APIError)
 	}
 
 	customClaim := CustomClaim{
-		Identity: claims[identityKey].(string),
-		Name:     claims[nameKey].(string),
-		Exp:      int64(claims[expKey].(float64)),
-		Roles:    iToSliceString(claims[rolesKey]),
-		Branch:   claims[branchKey].(string),
-		Type:     int(claims[tokenTypeKey].(float64)),
-		Fresh:    claims[freshKey].(bool),
+		TokenID:   optionalString(claims[tokenIDKey]),
+		FamilyID:  optionalString(claims[familyIDKey]),
+		IssuedAt:  optionalInt64(claims[issuedAtKey]),
+		Identity:  claims[identityKey].(string),
+		Name:      claims[nameKey].(string),
+		Exp:       int64(claims[expKey].(float64)),
+		Roles:     iToSliceString(claims[rolesKey]),
+		Branch:    claims[branchKey].(string),
+		Branches:  optionalSliceString(claims[branchesKey]),
+		Type:      int(claims[tokenTypeKey].(float64)),
+		Fresh:     claims[freshKey].(bool),
+		AccessExp: optionalInt64(claims[accessExpKey]),
 	}
 
 	return &customClaim, nil
 }
 
+// JWKS mengembalikan public key yang digunakan untuk memverifikasi token
+func (j *jwtUtils) JWKS() JWKSet {
+	return j.keys.jwks()
+}
+
+// GenerateTokenID membuat id acak untuk claim jti
+func GenerateTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// optionalString dan optionalInt64 digunakan untuk claim yang tidak dimiliki token lama
+func optionalString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return ""
+}
+
+func optionalSliceString(value interface{}) []string {
+	if _, ok := value.([]interface{}); ok {
+		return iToSliceString(value)
+	}
+	return nil
+}
+
+func optionalInt64(value interface{}) int64 {
+	if f, ok := value.(float64); ok {
+		return int64(f)
+	}
+	return 0
+}
+
 func iToSliceString(assumedSliceInterface interface{}) []string {
 	sliceInterface := assumedSliceInterface.([]interface{})
 	sliceString := make([]string, len(sliceInterface))
@@ -109,11 +184,20 @@ func iToSliceString(assumedSliceInterface interface{}) []string {
 // ValidateToken memvalidasi apakah token string masukan valid, termasuk memvalidasi apabila field exp nya kadaluarsa
 func (j *jwtUtils) ValidateToken(tokenString string) (*jwt.Token, resterr.APIError) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		_, ok := token.Method.(*jwt.SigningMethodHMAC)
-		if !ok {
+		// algoritma wajib sama dengan konfigurasi untuk mencegah serangan pertukaran algoritma
+		if token.Method.Alg() != j.keys.method.Alg() {
 			return nil, resterr.NewAPIError("Token signing method salah", http.StatusUnprocessableEntity, "jwt_error", nil)
 		}
-		return secret, nil
+		if j.keys.signingKID == "" {
+			return j.keys.signingKey, nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, ok := j.keys.verifyKeys[kid]
+		if !ok {
+			return nil, resterr.NewAPIError("Token kid tidak dikenali", http.StatusUnprocessableEntity, "jwt_error", nil)
+		}
+		return key, nil
 	})
 
 	// Jika expired akan muncul disini asalkan ada claims exp
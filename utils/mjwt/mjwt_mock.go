@@ -49,3 +49,8 @@ func (m *MockJwt) ReadToken(token *jwt.Token) (*CustomClaim, resterr.APIError) {
 
 	return res, err
 }
+
+func (m *MockJwt) JWKS() JWKSet {
+	args := m.Called()
+	return args.Get(0).(JWKSet)
+}
@@ -80,4 +80,55 @@ func TestJwtUtils_ReadToken(t *testing.T) {
 	assert.Equal(t, "muchlis@gmail.com", claims.Identity)
 	assert.Equal(t, []string{"ADMIN"}, claims.Roles)
 	assert.Equal(t, time.Now().Unix(), claims.Exp)
-}
\ No newline at end of file
+}
+
+func TestJwtUtils_ReadTokenID(t *testing.T) {
+	c := CustomClaim{
+		Identity:    "muchlis@gmail.com",
+		Roles:       []string{"ADMIN"},
+		ExtraMinute: 12,
+	}
+	signedToken, err := JwtObj.GenerateToken(c)
+	assert.Nil(t, err)
+	otherToken, err := JwtObj.GenerateToken(c)
+	assert.Nil(t, err)
+
+	tokenValid, err := JwtObj.ValidateToken(signedToken)
+	assert.Nil(t, err)
+	claims, err := JwtObj.ReadToken(tokenValid)
+	assert.Nil(t, err)
+
+	otherValid, err := JwtObj.ValidateToken(otherToken)
+	assert.Nil(t, err)
+	otherClaims, err := JwtObj.ReadToken(otherValid)
+	assert.Nil(t, err)
+
+	assert.NotEmpty(t, claims.TokenID)
+	assert.NotEqual(t, claims.TokenID, otherClaims.TokenID)
+	assert.Equal(t, time.Now().Unix(), claims.IssuedAt)
+}
+
+func TestJwtUtils_ReadTokenBranches(t *testing.T) {
+	c := CustomClaim{
+		Identity:    "muchlis",
+		Roles:       []string{"REGIONAL"},
+		Branch:      "BANJARMASIN",
+		Branches:    []string{"SAMPIT"},
+		ExtraMinute: 1,
+	}
+
+	signedToken, err := JwtObj.GenerateToken(c)
+	assert.Nil(t, err)
+	token, err := JwtObj.ValidateToken(signedToken)
+	assert.Nil(t, err)
+	claims, err := JwtObj.ReadToken(token)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"SAMPIT"}, claims.Branches)
+
+	c.Branches = nil
+	signedToken, _ = JwtObj.GenerateToken(c)
+	token, _ = JwtObj.ValidateToken(signedToken)
+	claims, err = JwtObj.ReadToken(token)
+	assert.Nil(t, err)
+	assert.Nil(t, claims.Branches)
+}
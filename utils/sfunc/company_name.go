@@ -0,0 +1,101 @@
+package sfunc
+
+import (
+	"strings"
+	"unicode"
+)
+
+// legalEntityForms bentuk badan usaha yang dipindahkan ke depan nama perusahaan
+var legalEntityForms = []string{"PT", "CV", "UD", "PD", "FA", "KOPERASI"}
+
+// NormalizeCompanyName membuat kunci pembanding nama perusahaan sehingga
+// "PT. ABC", "PT ABC", "P.T. ABC", "P.T. A.B.C." dan "ABC, PT" menghasilkan "PT ABC".
+// tanda baca dihapus, bentuk badan usaha di awal atau akhir nama dipisahkan dan diletakkan di depan,
+// lalu huruf tunggal berurutan pada sisa nama digabung
+func NormalizeCompanyName(name string) string {
+	raw := strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	form, consumed := legalFormPrefix(raw)
+	rest := raw[consumed:]
+	if form == "" {
+		form, consumed = legalFormSuffix(raw)
+		rest = raw[:len(raw)-consumed]
+	}
+
+	tokens := joinSingleLetter(rest)
+	if form != "" {
+		tokens = append([]string{form}, tokens...)
+	}
+	return strings.Join(tokens, " ")
+}
+
+// legalFormPrefix bentuk badan usaha di awal nama beserta jumlah token yang digunakan,
+// bentuk badan usaha dapat ditulis utuh ("PT") maupun per huruf ("P.T.")
+func legalFormPrefix(tokens []string) (string, int) {
+	if len(tokens) == 0 {
+		return "", 0
+	}
+	if InSlice(tokens[0], legalEntityForms) {
+		return tokens[0], 1
+	}
+	joined := ""
+	for i, token := range tokens {
+		if !isSingleLetter(token) {
+			break
+		}
+		joined += token
+		if i > 0 && InSlice(joined, legalEntityForms) {
+			return joined, i + 1
+		}
+	}
+	return "", 0
+}
+
+// legalFormSuffix bentuk badan usaha di akhir nama ("ABC, PT"), hanya jika masih tersisa nama perusahaan
+func legalFormSuffix(tokens []string) (string, int) {
+	if len(tokens) < 2 {
+		return "", 0
+	}
+	if InSlice(tokens[len(tokens)-1], legalEntityForms) {
+		return tokens[len(tokens)-1], 1
+	}
+	joined := ""
+	for i := len(tokens) - 1; i > 0; i-- {
+		if !isSingleLetter(tokens[i]) {
+			break
+		}
+		joined = tokens[i] + joined
+		if len(tokens)-i > 1 && InSlice(joined, legalEntityForms) {
+			return joined, len(tokens) - i
+		}
+	}
+	return "", 0
+}
+
+// joinSingleLetter menggabungkan huruf tunggal berurutan, "A B C" menjadi "ABC"
+func joinSingleLetter(raw []string) []string {
+	var tokens []string
+	single := ""
+	for _, token := range raw {
+		if isSingleLetter(token) {
+			single += token
+			continue
+		}
+		if single != "" {
+			tokens = append(tokens, single)
+			single = ""
+		}
+		tokens = append(tokens, token)
+	}
+	if single != "" {
+		tokens = append(tokens, single)
+	}
+	return tokens
+}
+
+func isSingleLetter(token string) bool {
+	runes := []rune(token)
+	return len(runes) == 1 && unicode.IsLetter(runes[0])
+}
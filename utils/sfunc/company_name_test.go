@@ -0,0 +1,23 @@
+package sfunc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeCompanyName(t *testing.T) {
+	for _, name := range []string{"PT ABC", "PT. ABC", "pt.abc", "P.T. ABC", "  PT  ABC ", "ABC, PT", "ABC PT.",
+		"P.T. A.B.C.", "PT A.B.C", "P T A B C", "A.B.C., P.T.", "ABC P.T."} {
+		assert.Equal(t, "PT ABC", NormalizeCompanyName(name), name)
+	}
+
+	assert.Equal(t, "CV MAJU JAYA 2", NormalizeCompanyName("C.V. Maju-Jaya 2"))
+	assert.Equal(t, "CV MAJU JAYA", NormalizeCompanyName("Maju Jaya, C.V."))
+	assert.Equal(t, "KOPERASI SEJAHTERA", NormalizeCompanyName("Koperasi Sejahtera"))
+	assert.Equal(t, "PT", NormalizeCompanyName("P.T."))
+	assert.Equal(t, "BUDI", NormalizeCompanyName("Budi"))
+	assert.Equal(t, "", NormalizeCompanyName(" - "))
+	assert.NotEqual(t, NormalizeCompanyName("PT ABC"), NormalizeCompanyName("PT ABD"))
+	assert.NotEqual(t, NormalizeCompanyName("PT ABC"), NormalizeCompanyName("CV ABC"))
+}
@@ -0,0 +1,60 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// secret RFC 6238 lampiran B untuk SHA1
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_RFC6238(t *testing.T) {
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for unix, expected := range cases {
+		code, err := Code(rfcSecret, Step(time.Unix(unix, 0)))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.Nil(t, err)
+
+	now := time.Unix(1700000000, 0)
+	code, _ := Code(secret, Step(now))
+
+	step, ok := Validate(secret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	_, ok = Validate(secret, code, now.Add(period*time.Second))
+	assert.True(t, ok, "kode periode sebelumnya masih diterima")
+
+	_, ok = Validate(secret, code, now.Add(3*period*time.Second))
+	assert.False(t, ok)
+
+	_, ok = Validate(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestProvisioningURIAndQRCode(t *testing.T) {
+	uri := ProvisioningURI("TILANK", "MUCHLIS", rfcSecret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/TILANK:MUCHLIS?"))
+	assert.Contains(t, uri, "secret="+rfcSecret)
+
+	image, err := QRCode(uri)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(image, "data:image/png;base64,"))
+}
@@ -21,6 +21,12 @@ type MailInfo struct {
 	ViolID        string
 	TruckIdentity string
 	ToEmail       string
+	// CcEmails tambahan penerima tembusan pemberitahuan pelanggaran selain HSSE, misal email JPT
+	CcEmails []string
+	// Subject dan Body digunakan untuk email selain pemberitahuan pelanggaran (misal reset password),
+	// email jenis ini tidak memiliki lampiran dan tidak di-CC ke HSSE
+	Subject string
+	Body    string
 }
 
 var mailInfoCh = make(chan *MailInfo, 30)
@@ -28,7 +34,11 @@ var mailInfoCh = make(chan *MailInfo, 30)
 func init() {
 	go func() {
 		for info := range mailInfoCh {
-			sendEmailGmail(info.ViolID, info.TruckIdentity, info.ToEmail)
+			if info.Body != "" {
+				sendPlainEmailGmail(info.Subject, info.Body, info.ToEmail)
+				continue
+			}
+			sendEmailGmail(info.ViolID, info.TruckIdentity, info.ToEmail, info.CcEmails)
 		}
 	}()
 	logger.Info("email worker dijalankan")
@@ -38,7 +48,7 @@ func RegSendEmail(data *MailInfo) {
 	mailInfoCh <- data
 }
 
-func sendEmailGmail(violID string, violIdentity string, toEmail string) {
+func sendEmailGmail(violID string, violIdentity string, toEmail string, ccEmails []string) {
 	email := strings.TrimSpace(os.Getenv(envGmailAccount))
 	emailCC := strings.TrimSpace(os.Getenv(envGmailCCAccount))
 	password := strings.TrimSpace(os.Getenv(envGmailPassword))
@@ -52,7 +62,7 @@ func sendEmailGmail(violID string, violIdentity string, toEmail string) {
 	mailer := gomail.NewMessage()
 	mailer.SetHeader("From", senderName)
 	mailer.SetHeader("To", toEmail)
-	mailer.SetAddressHeader("Cc", emailCC, "HSSE TPKB")
+	mailer.SetHeader("Cc", append([]string{mailer.FormatAddress(emailCC, "HSSE TPKB")}, ccEmails...)...)
 	mailer.SetHeader("Subject", "Pemberitahuan ETI TPKB")
 	mailer.SetBody("text/html", fmt.Sprintf("Pemberitahuan, truck anda dengan Nomor Lambung <b>%s</b> telah melakukan pelanggaran di area TPKB. terlampir surat Elektronik tilang TPKB.<br>Terimakasih.", violIdentity))
 	mailer.Attach(fmt.Sprintf("static/pdf/%s.pdf", violID))
@@ -70,3 +80,34 @@ func sendEmailGmail(violID string, violIdentity string, toEmail string) {
 	}
 	logger.Info(fmt.Sprintf("email dikirim ke %s", toEmail))
 }
+
+// sendPlainEmailGmail mengirim email tanpa lampiran dan tanpa CC
+func sendPlainEmailGmail(subject string, body string, toEmail string) {
+	email := strings.TrimSpace(os.Getenv(envGmailAccount))
+	password := strings.TrimSpace(os.Getenv(envGmailPassword))
+
+	if email == "" || password == "" {
+		logger.Error("konfigurasi email salah", errors.New("environment variable not set"))
+		return
+	}
+	senderName := fmt.Sprintf("PT. Pelabuhan Indonesia III TPKB <%s>", email)
+
+	mailer := gomail.NewMessage()
+	mailer.SetHeader("From", senderName)
+	mailer.SetHeader("To", toEmail)
+	mailer.SetHeader("Subject", subject)
+	mailer.SetBody("text/html", body)
+
+	dialer := gomail.NewDialer(
+		configSMTPHost,
+		configSMTPPort,
+		email,
+		password,
+	)
+
+	if err := dialer.DialAndSend(mailer); err != nil {
+		logger.Error("email gagal dikirim", err)
+		return
+	}
+	logger.Info(fmt.Sprintf("email %s dikirim ke %s", subject, toEmail))
+}